@@ -1,10 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"example.com/gin-playground/pkg/metrics"
 	"github.com/gin-gonic/gin"
 )
 
@@ -38,9 +51,48 @@ type ErrorResponse struct {
 	Detail  string `json:"detail,omitempty"`
 }
 
+// ========================================
+// v1 지원 종료 설정
+// ========================================
+
+// v1 API 종료 예정일
+var v1SunsetDate = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+// v1 라우트 템플릿 → v2 대체 경로 (대체 경로가 없는 라우트는 Link 헤더 생략)
+var v1SuccessorRoutes = map[string]string{
+	"/api/v1/health":       "/api/v2/health",
+	"/api/v1/users":        "/api/v2/users",
+	"/api/v1/users/:id":    "/api/v2/users/:id",
+	"/api/v1/products":     "/api/v2/products",
+	"/api/v1/products/:id": "/api/v2/products/:id",
+	"/api/users":           "/api/v2/users", // 헤더 기반 버저닝 (API-Version: 1.0)
+}
+
 func main() {
+	// METRICS_ENABLED=true 이면 /metrics 엔드포인트 활성화
+	r := setupRouter(os.Getenv("METRICS_ENABLED") == "true")
+
+	// 서버 시작
+	fmt.Println("Server is running on :8080")
+	fmt.Println("Available API versions: v1, v2")
+	fmt.Println("Admin panel: /admin")
+	fmt.Println("Public API: /public")
+	if err := r.Run(":8080"); err != nil {
+		panic("Failed to start server: " + err.Error())
+	}
+}
+
+func setupRouter(enableMetrics bool) *gin.Engine {
 	r := gin.Default()
 
+	// 요청 수/지연 시간 메트릭스 (라우트 등록 전에 설치)
+	if enableMetrics {
+		metrics.NewRegistry().Mount(r)
+	}
+
+	// 클라이언트 IP별 요청 제한 (그룹마다 인증 미들웨어 뒤에 설치해야 내부 호출 플래그를 볼 수 있음)
+	rateLimit := rateLimitMiddleware(newRateLimiter(rateLimitRequests, rateLimitWindow))
+
 	// 루트 엔드포인트
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -54,8 +106,9 @@ func main() {
 	// 1. API 버저닝 - URL Path 방식
 	// ========================================
 
-	// API v1 그룹
+	// API v1 그룹 (deprecated - v1SunsetDate 이후 종료 예정)
 	v1 := r.Group("/api/v1")
+	v1.Use(deprecationMiddleware(), rateLimit)
 	{
 		// 헬스체크
 		v1.GET("/health", func(c *gin.Context) {
@@ -101,7 +154,7 @@ func main() {
 	// API v2 그룹 (개선된 버전)
 	v2 := r.Group("/api/v2")
 	// v2 전용 미들웨어
-	v2.Use(v2Middleware())
+	v2.Use(v2Middleware(), rateLimit)
 	{
 		// 헬스체크
 		v2.GET("/health", func(c *gin.Context) {
@@ -174,6 +227,7 @@ func main() {
 	// 3. Public API (인증 불필요)
 	// ========================================
 	public := r.Group("/public")
+	public.Use(rateLimit)
 	{
 		public.GET("/status", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
@@ -195,7 +249,7 @@ func main() {
 	// 4. Internal API (내부 서비스용)
 	// ========================================
 	internal := r.Group("/internal")
-	internal.Use(internalAuthMiddleware())
+	internal.Use(internalAuthMiddleware(), rateLimit) // 유효한 키면 제한을 건너뜀
 	{
 		internal.GET("/health/detailed", detailedHealthCheck)
 		internal.POST("/cache/clear", clearCache)
@@ -208,9 +262,10 @@ func main() {
 	webhooks := r.Group("/webhooks")
 	{
 		// 각 서비스별 webhook
-		webhooks.POST("/github", handleGithubWebhook)
-		webhooks.POST("/stripe", handleStripeWebhook)
-		webhooks.POST("/slack", handleSlackWebhook)
+		// 서명 검증을 통과해야 핸들러가 실행됨 (실패 시 401)
+		webhooks.POST("/github", webhookSignatureMiddleware(verifyGithubSignature(webhookSecrets.GitHub)), handleGithubWebhook)
+		webhooks.POST("/stripe", webhookSignatureMiddleware(verifyStripeSignature(webhookSecrets.Stripe)), handleStripeWebhook)
+		webhooks.POST("/slack", webhookSignatureMiddleware(verifySlackSignature(webhookSecrets.Slack)), handleSlackWebhook)
 	}
 
 	// ========================================
@@ -218,14 +273,7 @@ func main() {
 	// ========================================
 	r.GET("/api/users", versionedHandler())
 
-	// 서버 시작
-	fmt.Println("Server is running on :8080")
-	fmt.Println("Available API versions: v1, v2")
-	fmt.Println("Admin panel: /admin")
-	fmt.Println("Public API: /public")
-	if err := r.Run(":8080"); err != nil {
-		panic("Failed to start server: " + err.Error())
-	}
+	return r
 }
 
 // ========================================
@@ -569,7 +617,6 @@ func unbanUser(c *gin.Context) {
 }
 
 func deleteUser(c *gin.Context) {
-	id := c.Param("id")
 	c.JSON(http.StatusNoContent, nil)
 }
 
@@ -653,23 +700,53 @@ func triggerJob(c *gin.Context) {
 	})
 }
 
+// 서명 검증 미들웨어를 통과한 요청만 도달하므로 rawBody를 그대로 파싱
 func handleGithubWebhook(c *gin.Context) {
-	event := c.GetHeader("X-GitHub-Event")
+	var payload struct {
+		Ref string `json:"ref"`
+	}
+	if !parseWebhookBody(c, &payload) {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "GitHub webhook received",
-		"event":   event,
+		"event":   c.GetHeader("X-GitHub-Event"),
+		"ref":     payload.Ref,
 	})
 }
 
 func handleStripeWebhook(c *gin.Context) {
+	var payload struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if !parseWebhookBody(c, &payload) {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Stripe webhook received",
+		"id":      payload.ID,
+		"type":    payload.Type,
 	})
 }
 
 func handleSlackWebhook(c *gin.Context) {
+	var payload struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Text      string `json:"text"`
+	}
+	if !parseWebhookBody(c, &payload) {
+		return
+	}
+	// Events API URL 등록 시 challenge 값을 그대로 돌려줘야 함
+	if payload.Type == "url_verification" {
+		c.JSON(http.StatusOK, gin.H{"challenge": payload.Challenge})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Slack webhook received",
+		"text":    payload.Text,
 	})
 }
 
@@ -684,6 +761,34 @@ func v2Middleware() gin.HandlerFunc {
 	}
 }
 
+// v1 API 지원 종료 안내 (RFC 8594 Sunset, Deprecation 헤더)
+func deprecationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setDeprecationHeaders(c)
+		c.Next()
+	}
+}
+
+func setDeprecationHeaders(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	c.Header("Sunset", v1SunsetDate.Format(http.TimeFormat))
+	if successor, ok := successorPath(c); ok {
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+	}
+}
+
+// 매칭된 라우트 템플릿을 v2 경로로 바꾸고 경로 파라미터를 실제 값으로 채운다
+func successorPath(c *gin.Context) (string, bool) {
+	successor, ok := v1SuccessorRoutes[c.FullPath()]
+	if !ok {
+		return "", false
+	}
+	for _, p := range c.Params {
+		successor = strings.ReplaceAll(successor, ":"+p.Key, p.Value)
+	}
+	return successor, true
+}
+
 func adminAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("X-Admin-Token")
@@ -706,17 +811,274 @@ func internalAuthMiddleware() gin.HandlerFunc {
 			})
 			return
 		}
+		// 내부 서비스 간 호출은 요청 제한 대상이 아님
+		c.Set(rateLimitExemptKey, true)
+		c.Next()
+	}
+}
+
+// ========================================
+// Webhook 서명 검증 (HMAC-SHA256)
+// ========================================
+
+// 검증에 사용한 원본 바디를 핸들러에 넘길 때 쓰는 컨텍스트 키
+const webhookBodyKey = "webhook_raw_body"
+
+// 서비스별 서명 비밀키 (환경 변수가 없으면 예제용 기본값)
+var webhookSecrets = struct {
+	GitHub string
+	Stripe string
+	Slack  string
+}{
+	GitHub: envOrDefault("GITHUB_WEBHOOK_SECRET", "github-webhook-secret"),
+	Stripe: envOrDefault("STRIPE_WEBHOOK_SECRET", "stripe-webhook-secret"),
+	Slack:  envOrDefault("SLACK_SIGNING_SECRET", "slack-signing-secret"),
+}
+
+const (
+	// 재전송(replay) 공격 방지 - 서명 시각이 이 범위를 벗어나면 거절
+	webhookTimestampTolerance = 5 * time.Minute
+	maxWebhookBodyBytes       = 1 << 20
+)
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// 헤더와 원본 바디로 서명이 유효한지 판단
+type webhookVerifier func(header http.Header, body []byte, now time.Time) bool
+
+// 바디를 한 번만 읽어 서명을 검증하고, 같은 바이트를 핸들러의 파싱에 재사용
+func webhookSignatureMiddleware(verify webhookVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error": "Webhook payload too large",
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to read webhook payload",
+			})
+			return
+		}
+
+		if !verify(c.Request.Header, body, time.Now()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid webhook signature",
+			})
+			return
+		}
+
+		c.Set(webhookBodyKey, body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// GitHub: X-Hub-Signature-256: sha256=hex(HMAC(secret, body))
+func verifyGithubSignature(secret string) webhookVerifier {
+	return func(header http.Header, body []byte, _ time.Time) bool {
+		sig, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		return ok && validHMAC(secret, body, sig)
+	}
+}
+
+// Stripe: Stripe-Signature: t=<unix>,v1=hex(HMAC(secret, "<t>.<body>"))
+// 비밀키 교체 중에는 v1 값이 여러 개 올 수 있으므로 하나라도 맞으면 통과
+func verifyStripeSignature(secret string) webhookVerifier {
+	return func(header http.Header, body []byte, now time.Time) bool {
+		var timestamp string
+		var signatures []string
+		for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signatures = append(signatures, value)
+			}
+		}
+		if !freshTimestamp(timestamp, now) {
+			return false
+		}
+
+		signed := append([]byte(timestamp+"."), body...)
+		for _, sig := range signatures {
+			if validHMAC(secret, signed, sig) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Slack: X-Slack-Signature: v0=hex(HMAC(secret, "v0:<timestamp>:<body>"))
+func verifySlackSignature(secret string) webhookVerifier {
+	return func(header http.Header, body []byte, now time.Time) bool {
+		timestamp := header.Get("X-Slack-Request-Timestamp")
+		if !freshTimestamp(timestamp, now) {
+			return false
+		}
+		sig, ok := strings.CutPrefix(header.Get("X-Slack-Signature"), "v0=")
+		return ok && validHMAC(secret, append([]byte("v0:"+timestamp+":"), body...), sig)
+	}
+}
+
+// 타이밍 공격을 막기 위해 hmac.Equal로 비교
+func validHMAC(secret string, message []byte, signatureHex string) bool {
+	if secret == "" {
+		return false
+	}
+	sig, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+func freshTimestamp(timestamp string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(sec, 0))
+	return age <= webhookTimestampTolerance && age >= -webhookTimestampTolerance
+}
+
+// 미들웨어가 저장한 원본 바디를 JSON으로 파싱 - 실패하면 400으로 응답하고 false
+func parseWebhookBody(c *gin.Context, v interface{}) bool {
+	body, _ := c.Get(webhookBodyKey)
+	raw, _ := body.([]byte)
+	if err := json.Unmarshal(raw, v); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook payload",
+		})
+		return false
+	}
+	return true
+}
+
+// ========================================
+// 요청 제한 (Rate Limiting)
+// ========================================
+
+// 이 키가 true로 설정된 요청은 rateLimitMiddleware가 제한하지 않음
+const rateLimitExemptKey = "rate_limit_exempt"
+
+// 클라이언트 IP별 허용 요청 수와 윈도우 길이
+var (
+	rateLimitRequests = 60
+	rateLimitWindow   = time.Minute
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// 고정 윈도우 카운터 - 키별로 window 동안 limit개까지 허용
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+	swept   time.Time
+	now     func() time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+		now:     time.Now,
+	}
+}
+
+// allow - 요청 허용 여부와, 거절 시 윈도우가 끝날 때까지 남은 시간
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// 끝난 윈도우는 새로 만든 것과 같으므로 윈도우마다 한 번씩 제거
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	l.swept = now
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+}
+
+func rateLimitMiddleware(limiter *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool(rateLimitExemptKey) {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := limiter.allow(c.ClientIP())
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Too many requests",
+			})
+			return
+		}
 		c.Next()
 	}
 }
 
 // 헤더 기반 버저닝
+// 우선순위: Accept 벤더 미디어 타입 > API-Version 헤더 > 최신 버전
 func versionedHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		c.Header("Vary", "Accept, API-Version")
+
 		version := c.GetHeader("API-Version")
+		if major, ok := parseVendorVersion(c.GetHeader("Accept")); ok {
+			v, supported := mediaTypeVersions[major]
+			if !supported {
+				c.JSON(http.StatusNotAcceptable, ErrorResponse{
+					Code:    http.StatusNotAcceptable,
+					Message: "Unsupported API version",
+					Detail:  fmt.Sprintf("%s%s%s is not supported", vendorMediaTypePrefix, major, vendorMediaTypeSuffix),
+				})
+				return
+			}
+			version = v
+		}
 
 		switch version {
 		case "1.0":
+			setDeprecationHeaders(c)
 			getUsersV1(c)
 		case "2.0":
 			getUsersV2(c)
@@ -725,4 +1087,39 @@ func versionedHandler() gin.HandlerFunc {
 			getUsersV2(c)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// ========================================
+// 미디어 타입 버저닝 (Accept: application/vnd.myapi.v2+json)
+// ========================================
+
+const (
+	vendorMediaTypePrefix = "application/vnd.myapi.v"
+	vendorMediaTypeSuffix = "+json"
+)
+
+// 미디어 타입의 메이저 버전 → API-Version 헤더 값
+var mediaTypeVersions = map[string]string{
+	"1": "1.0",
+	"2": "2.0",
+}
+
+// Accept 헤더에서 첫 번째 벤더 미디어 타입의 버전 숫자를 꺼낸다.
+// 벤더 타입이 없거나 버전 형식이 잘못되면 ok=false (최신 버전으로 처리)
+func parseVendorVersion(accept string) (major string, ok bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if !strings.HasPrefix(mediaType, vendorMediaTypePrefix) || !strings.HasSuffix(mediaType, vendorMediaTypeSuffix) {
+			continue
+		}
+		major = strings.TrimSuffix(strings.TrimPrefix(mediaType, vendorMediaTypePrefix), vendorMediaTypeSuffix)
+		if major == "" || strings.Trim(major, "0123456789") != "" {
+			continue
+		}
+		return major, true
+	}
+	return "", false
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return setupRouter(false)
+}
+
+func perform(r http.Handler, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestV1ResponsesCarryDeprecationHeaders(t *testing.T) {
+	r := newTestRouter()
+	sunset := v1SunsetDate.Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		wantLink string
+	}{
+		{"list", "/api/v1/users", nil, `</api/v2/users>; rel="successor-version"`},
+		{"path params", "/api/v1/users/42", nil, `</api/v2/users/42>; rel="successor-version"`},
+		{"no successor", "/api/v1/users/42/settings", nil, ""},
+		{"header versioning", "/api/users", map[string]string{"API-Version": "1.0"}, `</api/v2/users>; rel="successor-version"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := perform(r, "GET", tt.path, tt.headers)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("Deprecation"); got != "true" {
+				t.Errorf("expected Deprecation: true, got %q", got)
+			}
+			if got := w.Header().Get("Sunset"); got != sunset {
+				t.Errorf("expected Sunset %q, got %q", sunset, got)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("expected Link %q, got %q", tt.wantLink, got)
+			}
+		})
+	}
+}
+
+func TestV2ResponsesAreNotDeprecated(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+	}{
+		{"path versioning", "/api/v2/users", nil},
+		{"header versioning", "/api/users", map[string]string{"API-Version": "2.0"}},
+		{"default version", "/api/users", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := perform(r, "GET", tt.path, tt.headers)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+			for _, h := range []string{"Deprecation", "Sunset", "Link"} {
+				if got := w.Header().Get(h); got != "" {
+					t.Errorf("unexpected %s header %q", h, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAcceptMediaTypeVersioning(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantStatus  int
+		wantVersion string
+	}{
+		{"v1", map[string]string{"Accept": "application/vnd.myapi.v1+json"}, http.StatusOK, "v1"},
+		{"v2", map[string]string{"Accept": "application/vnd.myapi.v2+json"}, http.StatusOK, "v2"},
+		{"v1 among other types", map[string]string{"Accept": "text/html, application/vnd.myapi.v1+json; q=0.9"}, http.StatusOK, "v1"},
+		{"accept overrides header", map[string]string{"Accept": "application/vnd.myapi.v1+json", "API-Version": "2.0"}, http.StatusOK, "v1"},
+		{"malformed version", map[string]string{"Accept": "application/vnd.myapi.vX+json"}, http.StatusOK, "v2"},
+		{"malformed media type", map[string]string{"Accept": "application/vnd.myapi.v1"}, http.StatusOK, "v2"},
+		{"missing accept", nil, http.StatusOK, "v2"},
+		{"unsupported version", map[string]string{"Accept": "application/vnd.myapi.v3+json"}, http.StatusNotAcceptable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := perform(r, "GET", "/api/users", tt.headers)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Version string `json:"version"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Version != tt.wantVersion {
+				t.Errorf("expected %s handler, got %s", tt.wantVersion, resp.Version)
+			}
+			if vary := w.Header().Get("Vary"); !strings.Contains(vary, "Accept") {
+				t.Errorf("expected Vary to include Accept, got %q", vary)
+			}
+		})
+	}
+}
+
+func TestMetricsAreOptIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	if w := perform(setupRouter(false), "GET", "/metrics", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected /metrics to be disabled by default, got %d", w.Code)
+	}
+
+	r := setupRouter(true)
+	perform(r, "GET", "/api/v1/users/1", nil)
+	perform(r, "GET", "/api/v1/users/2", nil)
+
+	w := perform(r, "GET", "/metrics", nil)
+	want := `http_requests_total{method="GET",route="/api/v1/users/:id",status="2xx"} 2`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected %q in metrics output:\n%s", want, w.Body.String())
+	}
+}
+
+func TestInternalKeyBypassesRateLimit(t *testing.T) {
+	original := rateLimitRequests
+	rateLimitRequests = 3
+	defer func() { rateLimitRequests = original }()
+
+	r := newTestRouter()
+
+	// 익명 호출자는 한도를 넘으면 429
+	for i := 0; i < 3; i++ {
+		if w := perform(r, "GET", "/public/status", nil); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+	w := perform(r, "GET", "/public/status", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on 429")
+	}
+
+	// 같은 IP라도 유효한 내부 키가 있으면 제한하지 않음
+	internal := map[string]string{"X-Internal-API-Key": "internal-api-key-123"}
+	for i := 0; i < 10; i++ {
+		if w := perform(r, "GET", "/internal/health/detailed", internal); w.Code != http.StatusOK {
+			t.Fatalf("internal request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	// 잘못된 키는 인증 단계에서 거절
+	if w := perform(r, "GET", "/internal/health/detailed", map[string]string{"X-Internal-API-Key": "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for invalid key, got %d", w.Code)
+	}
+}
+
+func TestRateLimiterWindowResets(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(1, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	if ok, _ := limiter.allow("1.2.3.4"); !ok {
+		t.Fatal("expected first request to be allowed")
+	}
+	ok, retryAfter := limiter.allow("1.2.3.4")
+	if ok || retryAfter != time.Minute {
+		t.Errorf("expected rejection with 1m retry, got %v %v", ok, retryAfter)
+	}
+	if ok, _ := limiter.allow("5.6.7.8"); !ok {
+		t.Error("expected other clients to have their own window")
+	}
+
+	now = now.Add(time.Minute)
+	if ok, _ := limiter.allow("1.2.3.4"); !ok {
+		t.Error("expected request to be allowed in the next window")
+	}
+}
+
+func sign(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func postWebhook(r http.Handler, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookSignatures(t *testing.T) {
+	r := newTestRouter()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	githubBody := `{"ref":"refs/heads/main"}`
+	stripeBody := `{"id":"evt_1","type":"payment_intent.succeeded"}`
+	slackBody := `{"type":"event_callback","text":"hello"}`
+	tampered := func(body string) string { return strings.Replace(body, "}", `,"x":1}`, 1) }
+
+	githubHeaders := func(sig string) map[string]string {
+		return map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sig}
+	}
+	stripeHeaders := func(t, sig string) map[string]string {
+		return map[string]string{"Stripe-Signature": "t=" + t + ",v1=" + sig}
+	}
+	slackHeaders := func(t, sig string) map[string]string {
+		return map[string]string{"X-Slack-Request-Timestamp": t, "X-Slack-Signature": "v0=" + sig}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		headers map[string]string
+		want    int
+	}{
+		{"github valid", "/webhooks/github", githubBody,
+			githubHeaders(sign(webhookSecrets.GitHub, githubBody)), http.StatusOK},
+		{"github tampered body", "/webhooks/github", tampered(githubBody),
+			githubHeaders(sign(webhookSecrets.GitHub, githubBody)), http.StatusUnauthorized},
+		{"github wrong secret", "/webhooks/github", githubBody,
+			githubHeaders(sign("other-secret", githubBody)), http.StatusUnauthorized},
+		{"github missing header", "/webhooks/github", githubBody, nil, http.StatusUnauthorized},
+
+		{"stripe valid", "/webhooks/stripe", stripeBody,
+			stripeHeaders(ts, sign(webhookSecrets.Stripe, ts+"."+stripeBody)), http.StatusOK},
+		{"stripe tampered body", "/webhooks/stripe", tampered(stripeBody),
+			stripeHeaders(ts, sign(webhookSecrets.Stripe, ts+"."+stripeBody)), http.StatusUnauthorized},
+		{"stripe stale timestamp", "/webhooks/stripe", stripeBody,
+			stripeHeaders(stale, sign(webhookSecrets.Stripe, stale+"."+stripeBody)), http.StatusUnauthorized},
+
+		{"slack valid", "/webhooks/slack", slackBody,
+			slackHeaders(ts, sign(webhookSecrets.Slack, "v0:"+ts+":"+slackBody)), http.StatusOK},
+		{"slack tampered body", "/webhooks/slack", tampered(slackBody),
+			slackHeaders(ts, sign(webhookSecrets.Slack, "v0:"+ts+":"+slackBody)), http.StatusUnauthorized},
+		{"slack stale timestamp", "/webhooks/slack", slackBody,
+			slackHeaders(stale, sign(webhookSecrets.Slack, "v0:"+stale+":"+slackBody)), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postWebhook(r, tt.path, tt.body, tt.headers)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebhookHandlersParseVerifiedBody(t *testing.T) {
+	r := newTestRouter()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// 검증에 쓴 바디가 그대로 핸들러에서 파싱되어야 함
+	body := `{"ref":"refs/heads/release"}`
+	w := postWebhook(r, "/webhooks/github", body, map[string]string{
+		"X-GitHub-Event":      "push",
+		"X-Hub-Signature-256": "sha256=" + sign(webhookSecrets.GitHub, body),
+	})
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp["ref"] != "refs/heads/release" || resp["event"] != "push" {
+		t.Errorf("expected parsed push event, got %d %v", w.Code, resp)
+	}
+
+	// 비밀키 교체 중 여러 v1 서명 중 하나만 맞아도 통과
+	body = `{"id":"evt_2","type":"charge.refunded"}`
+	w = postWebhook(r, "/webhooks/stripe", body, map[string]string{
+		"Stripe-Signature": "t=" + ts + ",v1=" + sign("old-secret", ts+"."+body) + ",v1=" + sign(webhookSecrets.Stripe, ts+"."+body),
+	})
+	resp = nil
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp["type"] != "charge.refunded" {
+		t.Errorf("expected parsed stripe event, got %d %v", w.Code, resp)
+	}
+
+	body = `{"type":"url_verification","challenge":"abc123"}`
+	w = postWebhook(r, "/webhooks/slack", body, map[string]string{
+		"X-Slack-Request-Timestamp": ts,
+		"X-Slack-Signature":         "v0=" + sign(webhookSecrets.Slack, "v0:"+ts+":"+body),
+	})
+	resp = nil
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp["challenge"] != "abc123" {
+		t.Errorf("expected slack challenge echo, got %d %v", w.Code, resp)
+	}
+
+	// 서명은 맞지만 JSON이 아니면 400
+	body = `not json`
+	w = postWebhook(r, "/webhooks/github", body, map[string]string{
+		"X-Hub-Signature-256": "sha256=" + sign(webhookSecrets.GitHub, body),
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for signed non-JSON body, got %d", w.Code)
+	}
+}
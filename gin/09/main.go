@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"runtime/debug"
+	"strings"
 	"time"
 
+	"example.com/gin-playground/pkg/acceptlang"
+	"example.com/gin-playground/pkg/timeout"
 	"github.com/gin-gonic/gin"
 )
 
@@ -36,6 +42,20 @@ type ErrorResponse struct {
 	Error   *StandardError `json:"error"`
 }
 
+// ProblemDetails - RFC 7807 Problem Details (application/problem+json)
+type ProblemDetails struct {
+	Type     string `json:"type"`     // 에러 종류를 식별하는 URI
+	Title    string `json:"title"`    // 상태 코드의 짧은 설명
+	Status   int    `json:"status"`   // HTTP 상태 코드
+	Detail   string `json:"detail"`   // 이번 발생에 대한 설명
+	Instance string `json:"instance"` // 문제가 발생한 요청 경로
+
+	// 확장 멤버
+	ErrorCode string      `json:"error_code"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
 // SuccessResponse - 성공 응답 래퍼
 type SuccessResponse struct {
 	Success bool        `json:"success"`
@@ -48,13 +68,18 @@ type SuccessResponse struct {
 // ========================================
 
 // BusinessError - 비즈니스 로직 에러
+// 핸들러에서 c.Error(BusinessError{...})로 첨부하면 ErrorHandlerMiddleware가 응답을 만듦
 type BusinessError struct {
 	Code    string
 	Message string
 	Status  int
+	Details interface{}
 }
 
 func (e BusinessError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
 	return e.Message
 }
 
@@ -75,16 +100,169 @@ const (
 	ErrServiceUnavailable  = "SERVICE_UNAVAILABLE"
 	ErrDatabaseConnection  = "DATABASE_ERROR"
 	ErrExternalService     = "EXTERNAL_SERVICE_ERROR"
+	ErrGatewayTimeout      = "GATEWAY_TIMEOUT"
+
+	// 도메인 에러
+	ErrInvalidAmount        = "INVALID_AMOUNT"
+	ErrAmountLimitExceeded  = "AMOUNT_LIMIT_EXCEEDED"
+	ErrInsufficientFunds    = "INSUFFICIENT_FUNDS"
+	ErrFileTooLarge         = "FILE_TOO_LARGE"
+	ErrInvalidFileType      = "INVALID_FILE_TYPE"
+	ErrPageOutOfRange       = "PAGE_OUT_OF_RANGE"
+	ErrAPIVersionDeprecated = "API_VERSION_DEPRECATED"
 )
 
+// ========================================
+// 에러 레지스트리
+// ========================================
+
+// ErrorDefinition - 에러 코드의 표준 HTTP 상태와 기본 메시지
+type ErrorDefinition struct {
+	Status  int
+	Message string
+}
+
+// errorRegistry - ErrorCode별 HTTP 상태/기본 메시지의 단일 출처
+var errorRegistry = map[string]ErrorDefinition{
+	ErrBadRequest:       {http.StatusBadRequest, "Bad request"},
+	ErrUnauthorized:     {http.StatusUnauthorized, "Authentication required"},
+	ErrForbidden:        {http.StatusForbidden, "Access denied"},
+	ErrNotFound:         {http.StatusNotFound, "Resource not found"},
+	ErrMethodNotAllowed: {http.StatusMethodNotAllowed, "Method not allowed"},
+	ErrConflict:         {http.StatusConflict, "Resource already exists"},
+	ErrValidation:       {http.StatusUnprocessableEntity, "Validation failed"},
+	ErrTooManyRequests:  {http.StatusTooManyRequests, "Rate limit exceeded"},
+
+	ErrInternalServer:     {http.StatusInternalServerError, "An unexpected error occurred"},
+	ErrServiceUnavailable: {http.StatusServiceUnavailable, "Service is temporarily unavailable"},
+	ErrDatabaseConnection: {http.StatusInternalServerError, "Database connection failed"},
+	ErrExternalService:    {http.StatusBadGateway, "External service is not responding"},
+	ErrGatewayTimeout:     {http.StatusGatewayTimeout, "The request took too long to process"},
+
+	ErrInvalidAmount:        {http.StatusBadRequest, "Transfer amount must be positive"},
+	ErrAmountLimitExceeded:  {http.StatusBadRequest, "Transfer amount exceeds daily limit"},
+	ErrInsufficientFunds:    {http.StatusBadRequest, "Insufficient funds in source account"},
+	ErrFileTooLarge:         {http.StatusRequestEntityTooLarge, "File size exceeds maximum allowed size"},
+	ErrInvalidFileType:      {http.StatusUnsupportedMediaType, "File type not supported"},
+	ErrPageOutOfRange:       {http.StatusBadRequest, "Page number exceeds total pages"},
+	ErrAPIVersionDeprecated: {http.StatusGone, "This API version is no longer supported"},
+}
+
+// lookupError - 등록되지 않은 코드는 내부 서버 에러로 취급
+func lookupError(code string) (string, ErrorDefinition) {
+	if def, ok := errorRegistry[code]; ok {
+		return code, def
+	}
+	log.Printf("unregistered error code: %s", code)
+	return ErrInternalServer, errorRegistry[ErrInternalServer]
+}
+
+// ========================================
+// 에러 메시지 다국어 처리
+// ========================================
+
+// defaultLocale - 카탈로그에 없는 언어를 요청하면 영어 메시지 사용
+const defaultLocale = "en"
+
+// supportedLocales - 에러 메시지를 제공하는 언어
+var supportedLocales = []string{"en", "ko"}
+
+// messageCatalog - ErrorCode -> locale -> 메시지
+// 영어는 핸들러가 넘긴 구체적인 메시지를 그대로 사용하므로 번역만 등록
+var messageCatalog = map[string]map[string]string{
+	ErrBadRequest:         {"ko": "잘못된 요청입니다"},
+	ErrUnauthorized:       {"ko": "인증이 필요합니다"},
+	ErrForbidden:          {"ko": "접근 권한이 없습니다"},
+	ErrNotFound:           {"ko": "요청한 리소스를 찾을 수 없습니다"},
+	ErrMethodNotAllowed:   {"ko": "허용되지 않은 메서드입니다"},
+	ErrConflict:           {"ko": "이미 존재하는 리소스입니다"},
+	ErrValidation:         {"ko": "입력값 검증에 실패했습니다"},
+	ErrTooManyRequests:    {"ko": "요청 한도를 초과했습니다"},
+	ErrInternalServer:     {"ko": "예기치 않은 오류가 발생했습니다"},
+	ErrServiceUnavailable: {"ko": "서비스를 일시적으로 사용할 수 없습니다"},
+	ErrDatabaseConnection: {"ko": "데이터베이스 연결에 실패했습니다"},
+	ErrExternalService:    {"ko": "외부 서비스가 응답하지 않습니다"},
+	ErrGatewayTimeout:     {"ko": "요청 처리 시간이 초과되었습니다"},
+
+	ErrInvalidAmount:        {"ko": "이체 금액은 0보다 커야 합니다"},
+	ErrAmountLimitExceeded:  {"ko": "일일 이체 한도를 초과했습니다"},
+	ErrInsufficientFunds:    {"ko": "출금 계좌의 잔액이 부족합니다"},
+	ErrFileTooLarge:         {"ko": "파일 크기가 허용된 최대 크기를 초과했습니다"},
+	ErrInvalidFileType:      {"ko": "지원하지 않는 파일 형식입니다"},
+	ErrPageOutOfRange:       {"ko": "페이지 번호가 전체 페이지 수를 초과했습니다"},
+	ErrAPIVersionDeprecated: {"ko": "더 이상 지원하지 않는 API 버전입니다"},
+}
+
+// requestLocale - Accept-Language에서 카탈로그가 지원하는 첫 번째 언어 선택
+func requestLocale(c *gin.Context) string {
+	for _, tag := range acceptlang.Parse(c.GetHeader("Accept-Language")) {
+		base := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		for _, locale := range supportedLocales {
+			if base == locale {
+				return locale
+			}
+		}
+	}
+	return defaultLocale
+}
+
+// localizeMessage - 요청 언어의 메시지를 찾고, 없으면 원래(영어) 메시지 사용
+func localizeMessage(locale, code, message string) string {
+	if locale == defaultLocale {
+		return message
+	}
+	if translated, ok := messageCatalog[code][locale]; ok {
+		return translated
+	}
+	return message
+}
+
 // ========================================
 // 에러 응답 헬퍼 함수들
 // ========================================
 
+// ProblemJSONContentType - RFC 7807 응답 Content-Type
+const ProblemJSONContentType = "application/problem+json"
+
+// problemTypeBaseURI - ErrorCode를 Problem Details의 type URI로 만들 때 사용하는 기본 경로
+var problemTypeBaseURI = "https://example.com/problems/"
+
+// problemTypeURI - "FILE_TOO_LARGE" -> "https://example.com/problems/file-too-large"
+func problemTypeURI(code string) string {
+	return problemTypeBaseURI + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+// wantsProblemJSON - 클라이언트가 Accept 헤더로 problem+json을 요청했는지 확인
+func wantsProblemJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ProblemJSONContentType)
+}
+
 // NewErrorResponse - 에러 응답 생성
+// 기본은 StandardError 래퍼, Accept: application/problem+json이면 RFC 7807 형식
 func NewErrorResponse(c *gin.Context, status int, code string, message string, details interface{}) {
 	requestID, _ := c.Get("RequestID")
 
+	// ErrorCode는 그대로 두고 메시지만 요청 언어로 변환
+	locale := requestLocale(c)
+	message = localizeMessage(locale, code, message)
+	c.Header("Content-Language", locale)
+
+	if wantsProblemJSON(c) {
+		problem := ProblemDetails{
+			Type:      problemTypeURI(code),
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    message,
+			Instance:  c.Request.URL.Path,
+			ErrorCode: code,
+			Details:   details,
+			RequestID: fmt.Sprintf("%v", requestID),
+		}
+		c.Header("Content-Type", ProblemJSONContentType)
+		c.JSON(status, problem)
+		return
+	}
+
 	errorResp := ErrorResponse{
 		Success: false,
 		Error: &StandardError{
@@ -112,48 +290,127 @@ func NewSuccessResponse(c *gin.Context, status int, data interface{}, meta inter
 	c.JSON(status, response)
 }
 
+// Fail - 레지스트리의 상태 코드와 기본 메시지로 에러 응답
+func Fail(c *gin.Context, code string, details interface{}) {
+	code, def := lookupError(code)
+	NewErrorResponse(c, def.Status, code, def.Message, details)
+}
+
+// FailWithMessage - 상태 코드는 레지스트리를 따르고 메시지만 지정
+func FailWithMessage(c *gin.Context, code string, message string, details interface{}) {
+	code, def := lookupError(code)
+	if message == "" {
+		message = def.Message
+	}
+	NewErrorResponse(c, def.Status, code, message, details)
+}
+
 // ========================================
 // 상태 코드별 헬퍼 함수들
 // ========================================
 
 // BadRequest - 400
 func BadRequest(c *gin.Context, message string, details interface{}) {
-	NewErrorResponse(c, http.StatusBadRequest, ErrBadRequest, message, details)
+	FailWithMessage(c, ErrBadRequest, message, details)
 }
 
 // Unauthorized - 401
 func Unauthorized(c *gin.Context, message string) {
-	NewErrorResponse(c, http.StatusUnauthorized, ErrUnauthorized, message, nil)
+	FailWithMessage(c, ErrUnauthorized, message, nil)
 }
 
 // Forbidden - 403
 func Forbidden(c *gin.Context, message string) {
-	NewErrorResponse(c, http.StatusForbidden, ErrForbidden, message, nil)
+	FailWithMessage(c, ErrForbidden, message, nil)
 }
 
 // NotFound - 404
 func NotFound(c *gin.Context, resource string) {
 	message := fmt.Sprintf("%s not found", resource)
-	NewErrorResponse(c, http.StatusNotFound, ErrNotFound, message, nil)
+	FailWithMessage(c, ErrNotFound, message, gin.H{"resource": resource})
+}
+
+// MethodNotAllowed - 405, 허용 메서드를 Allow 헤더와 details에 함께 담음
+func MethodNotAllowed(c *gin.Context, allowed []string) {
+	c.Header("Allow", strings.Join(allowed, ", "))
+	message := fmt.Sprintf("Method %s is not allowed", c.Request.Method)
+	FailWithMessage(c, ErrMethodNotAllowed, message, gin.H{
+		"method":          c.Request.Method,
+		"allowed_methods": allowed,
+	})
 }
 
 // Conflict - 409
 func Conflict(c *gin.Context, message string) {
-	NewErrorResponse(c, http.StatusConflict, ErrConflict, message, nil)
+	FailWithMessage(c, ErrConflict, message, nil)
 }
 
 // InternalServerError - 500
 func InternalServerError(c *gin.Context, message string) {
-	NewErrorResponse(c, http.StatusInternalServerError, ErrInternalServer, message, nil)
+	FailWithMessage(c, ErrInternalServer, message, nil)
 }
 
 // ValidationFailed - 422
 func ValidationFailed(c *gin.Context, errors []ValidationError) {
-	NewErrorResponse(c, http.StatusUnprocessableEntity, ErrValidation, "Validation failed", errors)
+	Fail(c, ErrValidation, errors)
 }
 
-func main() {
-	r := gin.Default()
+// ========================================
+// 에러 처리 미들웨어
+// ========================================
+
+// ErrorHandlerMiddleware - 핸들러 실행 후 c.Errors와 panic을 표준 에러 응답으로 변환
+func ErrorHandlerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("panic recovered: %v\n%s", rec, debug.Stack())
+				c.Abort()
+				if !c.Writer.Written() {
+					InternalServerError(c, "An unexpected error occurred")
+				}
+			}
+		}()
+
+		c.Next()
+
+		// 이미 응답을 쓴 핸들러는 건드리지 않음
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var bizErr BusinessError
+		if errors.As(err, &bizErr) {
+			// Status를 지정하지 않으면 레지스트리의 상태 코드 사용
+			if bizErr.Status == 0 {
+				FailWithMessage(c, bizErr.Code, bizErr.Message, bizErr.Details)
+				return
+			}
+			NewErrorResponse(c, bizErr.Status, bizErr.Code, bizErr.Message, bizErr.Details)
+			return
+		}
+
+		if c.Errors.Last().IsType(gin.ErrorTypeBind) {
+			BadRequest(c, "Invalid request", err.Error())
+			return
+		}
+
+		// 알 수 없는 에러는 내부 정보를 노출하지 않음
+		log.Printf("unhandled error: %v", err)
+		InternalServerError(c, "An unexpected error occurred")
+	}
+}
+
+// requestTimeout - 요청 하나에 허용하는 최대 처리 시간
+var requestTimeout = 5 * time.Second
+
+// setupRouter - 라우터 및 예제 엔드포인트 설정
+func setupRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Logger())
+	r.Use(ErrorHandlerMiddleware()) // panic 복구 + c.Errors 처리
 
 	// Request ID 미들웨어
 	r.Use(func(c *gin.Context) {
@@ -161,6 +418,14 @@ func main() {
 		c.Next()
 	})
 
+	// 요청별 타임아웃 - 데드라인을 넘기면 레지스트리 기반 504 응답
+	r.Use(timeout.NewWithConfig(timeout.Config{
+		Timeout: requestTimeout,
+		OnTimeout: func(c *gin.Context) {
+			Fail(c, ErrGatewayTimeout, nil)
+		},
+	}))
+
 	// ========================================
 	// 1. 정상 응답 예제 (2xx)
 	// ========================================
@@ -267,10 +532,7 @@ func main() {
 
 	// 405 Method Not Allowed
 	r.GET("/api/method-not-allowed", func(c *gin.Context) {
-		NewErrorResponse(c, http.StatusMethodNotAllowed, ErrMethodNotAllowed,
-			"Method not allowed", gin.H{
-				"allowed_methods": []string{"POST", "PUT"},
-			})
+		MethodNotAllowed(c, []string{"POST", "PUT"})
 	})
 
 	// 409 Conflict - 충돌
@@ -336,12 +598,11 @@ func main() {
 
 	// 429 Too Many Requests
 	r.GET("/api/rate-limited", func(c *gin.Context) {
-		NewErrorResponse(c, http.StatusTooManyRequests, ErrTooManyRequests,
-			"Rate limit exceeded", gin.H{
-				"limit":       100,
-				"remaining":   0,
-				"reset_after": "60 seconds",
-			})
+		Fail(c, ErrTooManyRequests, gin.H{
+			"limit":       100,
+			"remaining":   0,
+			"reset_after": "60 seconds",
+		})
 	})
 
 	// ========================================
@@ -355,10 +616,9 @@ func main() {
 
 		switch errorType {
 		case "db":
-			NewErrorResponse(c, http.StatusInternalServerError, ErrDatabaseConnection,
-				"Database connection failed", gin.H{
-					"retry_after": "30 seconds",
-				})
+			Fail(c, ErrDatabaseConnection, gin.H{
+				"retry_after": "30 seconds",
+			})
 		case "panic":
 			// 패닉 시뮬레이션 (리커버리 미들웨어가 처리)
 			panic("Something went terribly wrong!")
@@ -369,19 +629,29 @@ func main() {
 
 	// 502 Bad Gateway
 	r.GET("/api/external", func(c *gin.Context) {
-		NewErrorResponse(c, http.StatusBadGateway, ErrExternalService,
-			"External service is not responding", gin.H{
-				"service": "payment-gateway",
-				"timeout": "30s",
-			})
+		Fail(c, ErrExternalService, gin.H{
+			"service": "payment-gateway",
+			"timeout": "30s",
+		})
 	})
 
 	// 503 Service Unavailable
 	r.GET("/api/maintenance", func(c *gin.Context) {
-		NewErrorResponse(c, http.StatusServiceUnavailable, ErrServiceUnavailable,
-			"Service is under maintenance", gin.H{
-				"retry_after": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
-			})
+		FailWithMessage(c, ErrServiceUnavailable, "Service is under maintenance", gin.H{
+			"retry_after": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		})
+	})
+
+	// 504 Gateway Timeout - 요청 컨텍스트의 데드라인을 넘기는 느린 작업
+	r.GET("/api/slow", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		select {
+		case <-time.After(2 * requestTimeout):
+			NewSuccessResponse(c, http.StatusOK, gin.H{"status": "done"}, nil)
+		case <-ctx.Done():
+			// 응답은 타임아웃 미들웨어가 작성
+			c.Error(ctx.Err())
+		}
 	})
 
 	// ========================================
@@ -400,42 +670,28 @@ func main() {
 			return
 		}
 
-		// 비즈니스 규칙 검증
+		// 비즈니스 규칙 검증 (에러를 첨부하고 반환하면 미들웨어가 응답)
 		if transfer.Amount <= 0 {
-			err := BusinessError{
-				Code:    "INVALID_AMOUNT",
-				Message: "Transfer amount must be positive",
-				Status:  http.StatusBadRequest,
-			}
-			NewErrorResponse(c, err.Status, err.Code, err.Message, gin.H{
-				"amount": transfer.Amount,
+			c.Error(BusinessError{
+				Code:    ErrInvalidAmount,
+				Details: gin.H{"amount": transfer.Amount},
 			})
 			return
 		}
 
 		if transfer.Amount > 10000 {
-			err := BusinessError{
-				Code:    "AMOUNT_LIMIT_EXCEEDED",
-				Message: "Transfer amount exceeds daily limit",
-				Status:  http.StatusBadRequest,
-			}
-			NewErrorResponse(c, err.Status, err.Code, err.Message, gin.H{
-				"amount": transfer.Amount,
-				"limit":  10000,
+			c.Error(BusinessError{
+				Code:    ErrAmountLimitExceeded,
+				Details: gin.H{"amount": transfer.Amount, "limit": 10000},
 			})
 			return
 		}
 
 		// 잔액 부족 시뮬레이션
 		if transfer.From == "poor-account" {
-			err := BusinessError{
-				Code:    "INSUFFICIENT_FUNDS",
-				Message: "Insufficient funds in source account",
-				Status:  http.StatusBadRequest,
-			}
-			NewErrorResponse(c, err.Status, err.Code, err.Message, gin.H{
-				"available": 100,
-				"requested": transfer.Amount,
+			c.Error(BusinessError{
+				Code:    ErrInsufficientFunds,
+				Details: gin.H{"available": 100, "requested": transfer.Amount},
 			})
 			return
 		}
@@ -460,11 +716,10 @@ func main() {
 
 		// 파일 크기 체크 (5MB 제한)
 		if file.Size > 5*1024*1024 {
-			NewErrorResponse(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE",
-				"File size exceeds maximum allowed size", gin.H{
-					"max_size":     "5MB",
-					"uploaded_size": fmt.Sprintf("%.2fMB", float64(file.Size)/(1024*1024)),
-				})
+			Fail(c, ErrFileTooLarge, gin.H{
+				"max_size":      "5MB",
+				"uploaded_size": fmt.Sprintf("%.2fMB", float64(file.Size)/(1024*1024)),
+			})
 			return
 		}
 
@@ -476,11 +731,10 @@ func main() {
 		}
 
 		if !allowedTypes[file.Header.Get("Content-Type")] {
-			NewErrorResponse(c, http.StatusUnsupportedMediaType, "INVALID_FILE_TYPE",
-				"File type not supported", gin.H{
-					"allowed_types": []string{"image/jpeg", "image/png", "image/gif"},
-					"uploaded_type": file.Header.Get("Content-Type"),
-				})
+			Fail(c, ErrInvalidFileType, gin.H{
+				"allowed_types": []string{"image/jpeg", "image/png", "image/gif"},
+				"uploaded_type": file.Header.Get("Content-Type"),
+			})
 			return
 		}
 
@@ -520,11 +774,10 @@ func main() {
 		totalItems := 50
 		totalPages := (totalItems + limitNum - 1) / limitNum
 		if pageNum > totalPages {
-			NewErrorResponse(c, http.StatusBadRequest, "PAGE_OUT_OF_RANGE",
-				"Page number exceeds total pages", gin.H{
-					"requested_page": pageNum,
-					"total_pages":    totalPages,
-				})
+			Fail(c, ErrPageOutOfRange, gin.H{
+				"requested_page": pageNum,
+				"total_pages":    totalPages,
+			})
 			return
 		}
 
@@ -542,16 +795,16 @@ func main() {
 	// 7. API 버전 에러
 	// ========================================
 
-	r.Any("/api/*path", func(c *gin.Context) {
+	// 등록되지 않은 경로 (catch-all 라우트는 기존 /api 경로와 충돌하므로 NoRoute 사용)
+	r.NoRoute(func(c *gin.Context) {
 		version := c.GetHeader("API-Version")
 
 		if version != "" && version < "2.0" {
-			NewErrorResponse(c, http.StatusGone, "API_VERSION_DEPRECATED",
-				"This API version is no longer supported", gin.H{
-					"requested_version": version,
-					"minimum_version":   "2.0",
-					"current_version":   "3.0",
-				})
+			Fail(c, ErrAPIVersionDeprecated, gin.H{
+				"requested_version": version,
+				"minimum_version":   "2.0",
+				"current_version":   "3.0",
+			})
 			return
 		}
 
@@ -559,6 +812,19 @@ func main() {
 		NotFound(c, "Endpoint")
 	})
 
+	// 경로는 있지만 메서드가 다른 경우 404 대신 405
+	// gin이 NoMethod 핸들러 실행 전에 허용 메서드로 Allow 헤더를 채워 둠
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(func(c *gin.Context) {
+		MethodNotAllowed(c, strings.Split(c.Writer.Header().Get("Allow"), ", "))
+	})
+
+	return r
+}
+
+func main() {
+	r := setupRouter()
+
 	// 서버 시작
 	fmt.Println("Server is running on :8080")
 	fmt.Println("Test endpoints:")
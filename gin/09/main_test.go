@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return setupRouter()
+}
+
+func perform(r http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) *StandardError {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode error response %q: %v", w.Body.String(), err)
+	}
+	if resp.Success || resp.Error == nil {
+		t.Fatalf("expected error envelope, got %s", w.Body.String())
+	}
+	return resp.Error
+}
+
+func TestErrorMiddlewareRecoversPanic(t *testing.T) {
+	w := perform(newTestRouter(), "GET", "/api/error?type=panic", "", nil)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	e := decodeError(t, w)
+	if e.ErrorCode != ErrInternalServer || e.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected error envelope: %+v", e)
+	}
+	if strings.Contains(w.Body.String(), "terribly wrong") {
+		t.Error("panic value must not leak to the client")
+	}
+	if e.RequestID == "" || e.Path != "/api/error" {
+		t.Errorf("expected request id and path in envelope, got %+v", e)
+	}
+}
+
+func TestErrorMiddlewareRendersAttachedErrors(t *testing.T) {
+	r := newTestRouter()
+	r.GET("/test/wrapped", func(c *gin.Context) {
+		c.Error(errors.Join(errors.New("context"), BusinessError{
+			Code:    "ORDER_LOCKED",
+			Message: "Order is locked",
+			Status:  http.StatusConflict,
+		}))
+	})
+	r.GET("/test/plain", func(c *gin.Context) {
+		c.Error(errors.New("db: connection reset by peer"))
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"business error", "POST", "/api/transfer", `{"from":"a","to":"b","amount":-5}`, http.StatusBadRequest, "INVALID_AMOUNT"},
+		{"wrapped business error", "GET", "/test/wrapped", "", http.StatusConflict, "ORDER_LOCKED"},
+		{"unknown error", "GET", "/test/plain", "", http.StatusInternalServerError, ErrInternalServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := perform(r, tt.method, tt.path, tt.body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if e := decodeError(t, w); e.ErrorCode != tt.wantCode {
+				t.Errorf("expected error_code %s, got %s", tt.wantCode, e.ErrorCode)
+			}
+		})
+	}
+
+	w := perform(r, "GET", "/test/plain", "", nil)
+	if strings.Contains(w.Body.String(), "connection reset") {
+		t.Error("unknown error details must not leak to the client")
+	}
+}
+
+func TestBusinessErrorDetails(t *testing.T) {
+	w := perform(newTestRouter(), "POST", "/api/transfer", `{"from":"poor-account","to":"b","amount":500}`, nil)
+	e := decodeError(t, w)
+	details, _ := e.Details.(map[string]interface{})
+	if e.ErrorCode != "INSUFFICIENT_FUNDS" || details["requested"] != float64(500) {
+		t.Errorf("expected INSUFFICIENT_FUNDS with details, got %+v", e)
+	}
+}
+
+func TestUnknownEndpointReturnsNotFound(t *testing.T) {
+	w := perform(newTestRouter(), "GET", "/api/does-not-exist", "", nil)
+	if w.Code != http.StatusNotFound || decodeError(t, w).ErrorCode != ErrNotFound {
+		t.Errorf("expected 404 NOT_FOUND, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUnknownEndpointEnvelope(t *testing.T) {
+	w := perform(newTestRouter(), "GET", "/no/such/path", "", nil)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	e := decodeError(t, w)
+	if e.ErrorCode != ErrNotFound || e.Code != http.StatusNotFound {
+		t.Errorf("unexpected error envelope: %+v", e)
+	}
+	if e.Path != "/no/such/path" || e.RequestID == "" {
+		t.Errorf("expected request path and id in envelope, got %+v", e)
+	}
+}
+
+func TestWrongMethodReturnsMethodNotAllowed(t *testing.T) {
+	w := perform(newTestRouter(), "DELETE", "/api/conflict", "", nil)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d %s", w.Code, w.Body.String())
+	}
+	if allow := w.Header().Get("Allow"); allow != "POST" {
+		t.Errorf("expected Allow: POST, got %q", allow)
+	}
+	e := decodeError(t, w)
+	if e.ErrorCode != ErrMethodNotAllowed || e.Path != "/api/conflict" || e.RequestID == "" {
+		t.Errorf("unexpected error envelope: %+v", e)
+	}
+	details, _ := e.Details.(map[string]interface{})
+	allowed, _ := details["allowed_methods"].([]interface{})
+	if len(allowed) != 1 || allowed[0] != "POST" || details["method"] != "DELETE" {
+		t.Errorf("expected allowed methods in details, got %v", e.Details)
+	}
+}
+
+func TestErrorFormatNegotiation(t *testing.T) {
+	r := newTestRouter()
+
+	// 기본: StandardError 래퍼
+	w := perform(r, "GET", "/api/users/999", "", nil)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("expected application/json by default, got %q", ct)
+	}
+	standard := decodeError(t, w)
+
+	// problem+json 요청
+	w = perform(r, "GET", "/api/users/999", "", map[string]string{"Accept": "application/problem+json, application/json;q=0.5"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, ProblemJSONContentType) {
+		t.Errorf("expected %s, got %q", ProblemJSONContentType, ct)
+	}
+	var problem ProblemDetails
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+
+	want := ProblemDetails{
+		Type:      "https://example.com/problems/not-found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    standard.Message,
+		Instance:  "/api/users/999",
+		ErrorCode: standard.ErrorCode,
+	}
+	if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status ||
+		problem.Detail != want.Detail || problem.Instance != want.Instance || problem.ErrorCode != want.ErrorCode {
+		t.Errorf("unexpected problem details:\n got %+v\nwant %+v", problem, want)
+	}
+}
+
+func TestProblemJSONForBusinessError(t *testing.T) {
+	w := perform(newTestRouter(), "POST", "/api/transfer", `{"from":"a","to":"b","amount":20000}`,
+		map[string]string{"Accept": ProblemJSONContentType})
+
+	var problem ProblemDetails
+	json.Unmarshal(w.Body.Bytes(), &problem)
+	if problem.Type != "https://example.com/problems/amount-limit-exceeded" || problem.Status != http.StatusBadRequest {
+		t.Errorf("unexpected problem details: %+v", problem)
+	}
+	if details, _ := problem.Details.(map[string]interface{}); details["limit"] != float64(10000) {
+		t.Errorf("expected details as extension member, got %+v", problem.Details)
+	}
+}
+
+func TestErrorMessagesFollowAcceptLanguage(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		acceptLanguage string
+		wantMessage    string
+		wantLanguage   string
+	}{
+		{"", "User not found", "en"},
+		{"en-US,en;q=0.9", "User not found", "en"},
+		{"ko-KR,ko;q=0.9,en;q=0.8", "요청한 리소스를 찾을 수 없습니다", "ko"},
+		{"fr-FR,en;q=0.5,ko;q=0.9", "요청한 리소스를 찾을 수 없습니다", "ko"},
+		{"fr-FR", "User not found", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			w := perform(r, "GET", "/api/users/999", "", map[string]string{"Accept-Language": tt.acceptLanguage})
+			e := decodeError(t, w)
+			if e.Message != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, e.Message)
+			}
+			if e.ErrorCode != ErrNotFound || w.Code != http.StatusNotFound {
+				t.Errorf("error code and status must not change with language, got %s %d", e.ErrorCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("expected Content-Language %q, got %q", tt.wantLanguage, got)
+			}
+		})
+	}
+}
+
+func TestBusinessErrorMessageIsTranslated(t *testing.T) {
+	w := perform(newTestRouter(), "POST", "/api/transfer", `{"from":"a","to":"b","amount":-1}`,
+		map[string]string{"Accept-Language": "ko"})
+	if e := decodeError(t, w); e.ErrorCode != "INVALID_AMOUNT" || e.Message != "이체 금액은 0보다 커야 합니다" {
+		t.Errorf("unexpected localized business error: %+v", e)
+	}
+}
+
+func TestErrorRegistryIsConsistent(t *testing.T) {
+	for code, def := range errorRegistry {
+		if def.Status < 400 || def.Status > 599 || http.StatusText(def.Status) == "" {
+			t.Errorf("%s: invalid status %d", code, def.Status)
+		}
+		if def.Message == "" {
+			t.Errorf("%s: missing default message", code)
+		}
+		for _, locale := range supportedLocales {
+			if locale == defaultLocale {
+				continue
+			}
+			if _, ok := messageCatalog[code][locale]; !ok {
+				t.Errorf("%s: missing %s translation", code, locale)
+			}
+		}
+	}
+}
+
+func TestFailRendersFromRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/fail/:code", func(c *gin.Context) {
+		Fail(c, c.Param("code"), nil)
+	})
+
+	for code, def := range errorRegistry {
+		t.Run(code, func(t *testing.T) {
+			w := perform(r, "GET", "/fail/"+code, "", nil)
+			if w.Code != def.Status {
+				t.Errorf("expected status %d, got %d", def.Status, w.Code)
+			}
+			e := decodeError(t, w)
+			if e.ErrorCode != code || e.Code != def.Status || e.Message != def.Message {
+				t.Errorf("inconsistent envelope: %+v", e)
+			}
+		})
+	}
+
+	t.Run("unregistered code", func(t *testing.T) {
+		w := perform(r, "GET", "/fail/NOT_A_CODE", "", nil)
+		if w.Code != http.StatusInternalServerError || decodeError(t, w).ErrorCode != ErrInternalServer {
+			t.Errorf("expected unregistered code to fall back to 500, got %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestHelpersUseRegistryStatus(t *testing.T) {
+	r := newTestRouter()
+	tests := []struct {
+		method, path, body string
+		code               string
+	}{
+		{"GET", "/api/bad-request", "", ErrBadRequest},
+		{"GET", "/api/protected", "", ErrUnauthorized},
+		{"DELETE", "/api/admin/users", "", ErrForbidden},
+		{"POST", "/api/conflict", "", ErrConflict},
+		{"POST", "/api/validate", `{"email":"","password":"1","age":1}`, ErrValidation},
+		{"GET", "/api/paginated?page=99", "", ErrPageOutOfRange},
+		{"POST", "/api/transfer", `{"from":"a","to":"b","amount":20000}`, ErrAmountLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			w := perform(r, tt.method, tt.path, tt.body, nil)
+			if want := errorRegistry[tt.code].Status; w.Code != want {
+				t.Errorf("expected %d, got %d", want, w.Code)
+			}
+			if e := decodeError(t, w); e.ErrorCode != tt.code {
+				t.Errorf("expected %s, got %s", tt.code, e.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestSlowRequestTimesOut(t *testing.T) {
+	original := requestTimeout
+	requestTimeout = 20 * time.Millisecond
+	defer func() { requestTimeout = original }()
+
+	w := perform(newTestRouter(), "GET", "/api/slow", "", nil)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+	e := decodeError(t, w)
+	if e.ErrorCode != ErrGatewayTimeout || e.Code != http.StatusGatewayTimeout || e.Path != "/api/slow" {
+		t.Errorf("unexpected error envelope: %+v", e)
+	}
+}
@@ -2,16 +2,28 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"example.com/gin-playground/pkg/compress"
 	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 	"net/http"
 )
 
@@ -52,6 +64,9 @@ type LogEntry struct {
 	Request    *RequestLog           `json:"request,omitempty"`
 	Response   *ResponseLog          `json:"response,omitempty"`
 	Extra      map[string]interface{} `json:"extra,omitempty"`
+
+	// ForceLog - 샘플링과 무관하게 반드시 기록 (디버그 헤더가 있는 요청 등)
+	ForceLog bool `json:"-"`
 }
 
 // RequestLog - 요청 로그 정보
@@ -115,40 +130,247 @@ func (l *JSONLogger) Warn(entry LogEntry)  { l.log(WARN, entry) }
 func (l *JSONLogger) Error(entry LogEntry) { l.log(ERROR, entry) }
 func (l *JSONLogger) Fatal(entry LogEntry) { l.log(FATAL, entry) }
 
-// FileLogger - 파일 로거
+// SampledLogger - 성공 응답(2xx/3xx)은 1/N만 기록하고
+// 4xx/5xx 및 ForceLog 엔트리는 항상 기록하는 샘플링 로거
+type SampledLogger struct {
+	next       Logger
+	sampleRate uint64
+	counter    atomic.Uint64
+}
+
+// NewSampledLogger - sampleRate가 1 이하이면 모든 엔트리를 기록
+func NewSampledLogger(next Logger, sampleRate int) *SampledLogger {
+	if sampleRate < 1 {
+		sampleRate = 1
+	}
+	return &SampledLogger{next: next, sampleRate: uint64(sampleRate)}
+}
+
+func (s *SampledLogger) shouldLog(entry LogEntry) bool {
+	if entry.ForceLog || s.sampleRate == 1 {
+		return true
+	}
+	// 요청과 무관한 엔트리(StatusCode 0)와 에러 응답은 샘플링하지 않음
+	if entry.StatusCode == 0 || entry.StatusCode >= 400 {
+		return true
+	}
+	return s.counter.Add(1)%s.sampleRate == 1
+}
+
+func (s *SampledLogger) Debug(entry LogEntry) {
+	if s.shouldLog(entry) {
+		s.next.Debug(entry)
+	}
+}
+
+func (s *SampledLogger) Info(entry LogEntry) {
+	if s.shouldLog(entry) {
+		s.next.Info(entry)
+	}
+}
+
+func (s *SampledLogger) Warn(entry LogEntry) {
+	if s.shouldLog(entry) {
+		s.next.Warn(entry)
+	}
+}
+
+func (s *SampledLogger) Error(entry LogEntry) {
+	if s.shouldLog(entry) {
+		s.next.Error(entry)
+	}
+}
+
+func (s *SampledLogger) Fatal(entry LogEntry) {
+	if s.shouldLog(entry) {
+		s.next.Fatal(entry)
+	}
+}
+
+// OverflowPolicy - 비동기 로거 버퍼가 가득 찼을 때의 동작
+type OverflowPolicy int
+
+const (
+	DropOnFull  OverflowPolicy = iota // 버퍼가 가득 차면 엔트리를 버림 (요청 경로를 막지 않음)
+	BlockOnFull                       // 버퍼에 자리가 날 때까지 대기 (유실 없음)
+)
+
+type asyncRecord struct {
+	level LogLevel
+	entry LogEntry
+}
+
+// AsyncLogger - 버퍼 채널과 백그라운드 고루틴으로 로그를 기록하는 로거
+// 엔트리는 들어온 순서대로 next 로거에 전달됨
+type AsyncLogger struct {
+	next    Logger
+	policy  OverflowPolicy
+	queue   chan asyncRecord
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewAsyncLogger(next Logger, bufferSize int, policy OverflowPolicy) *AsyncLogger {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	a := &AsyncLogger{
+		next:   next,
+		policy: policy,
+		queue:  make(chan asyncRecord, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncLogger) run() {
+	defer close(a.done)
+	for rec := range a.queue {
+		switch rec.level {
+		case DEBUG:
+			a.next.Debug(rec.entry)
+		case INFO:
+			a.next.Info(rec.entry)
+		case WARN:
+			a.next.Warn(rec.entry)
+		case ERROR:
+			a.next.Error(rec.entry)
+		case FATAL:
+			a.next.Fatal(rec.entry)
+		}
+	}
+}
+
+func (a *AsyncLogger) enqueue(level LogLevel, entry LogEntry) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	rec := asyncRecord{level: level, entry: entry}
+	if a.policy == BlockOnFull {
+		a.queue <- rec
+		return
+	}
+	select {
+	case a.queue <- rec:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+func (a *AsyncLogger) Debug(entry LogEntry) { a.enqueue(DEBUG, entry) }
+func (a *AsyncLogger) Info(entry LogEntry)  { a.enqueue(INFO, entry) }
+func (a *AsyncLogger) Warn(entry LogEntry)  { a.enqueue(WARN, entry) }
+func (a *AsyncLogger) Error(entry LogEntry) { a.enqueue(ERROR, entry) }
+func (a *AsyncLogger) Fatal(entry LogEntry) { a.enqueue(FATAL, entry) }
+
+// Dropped - 버퍼 초과 등으로 버려진 엔트리 수
+func (a *AsyncLogger) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close - 새 엔트리를 받지 않고 버퍼에 남은 엔트리를 모두 기록할 때까지 대기
+func (a *AsyncLogger) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
+
+// FileLoggerConfig - 파일 로거 설정 (gin/12 LoggingConfig의 로테이션 필드와 대응)
+type FileLoggerConfig struct {
+	FilePath   string
+	MaxSize    int64 // 바이트, 0이면 크기 기준 로테이션 안 함
+	MaxBackups int   // 보관할 백업 파일 수, 0이면 무제한
+	MaxAge     int   // 백업 보관 일수, 0이면 무제한
+	Level      LogLevel
+}
+
+// FileLogger - 파일 로거 (크기/일 단위 로테이션 + 백업 정리)
 type FileLogger struct {
-	logger   Logger
+	mu       sync.Mutex
+	logger   *JSONLogger
 	file     *os.File
-	maxSize  int64
-	filePath string
+	openedOn string // 현재 파일을 연 날짜 (YYYYMMDD)
+	config   FileLoggerConfig
+	now      func() time.Time
+
+	// 백업 정리는 백그라운드에서 수행해 로깅이 막히지 않도록 함
+	pruneCh   chan struct{}
+	done      sync.WaitGroup
+	closeOnce sync.Once
 }
 
 func NewFileLogger(filePath string, maxSize int64, level LogLevel) (*FileLogger, error) {
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	return NewFileLoggerWithConfig(FileLoggerConfig{
+		FilePath: filePath,
+		MaxSize:  maxSize,
+		Level:    level,
+	})
+}
+
+func NewFileLoggerWithConfig(config FileLoggerConfig) (*FileLogger, error) {
+	file, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonLogger := &JSONLogger{
-		output: log.New(file, "", 0),
-		level:  level,
+	f := &FileLogger{
+		logger: &JSONLogger{
+			output: log.New(file, "", 0),
+			level:  config.Level,
+		},
+		file:    file,
+		config:  config,
+		now:     time.Now,
+		pruneCh: make(chan struct{}, 1),
 	}
+	f.openedOn = f.now().Format("20060102")
 
-	return &FileLogger{
-		logger:   jsonLogger,
-		file:     file,
-		maxSize:  maxSize,
-		filePath: filePath,
-	}, nil
+	f.done.Add(1)
+	go f.pruneLoop()
+
+	return f, nil
+}
+
+// write - 로테이션 확인과 기록을 하나의 임계 구역에서 처리
+func (f *FileLogger) write(fn func(l *JSONLogger)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checkRotation()
+	fn(f.logger)
 }
 
 func (f *FileLogger) checkRotation() {
+	if f.file == nil {
+		return
+	}
+
+	// 날짜가 바뀌면 로테이션
+	if f.now().Format("20060102") != f.openedOn {
+		f.rotate()
+		return
+	}
+
+	if f.config.MaxSize <= 0 {
+		return
+	}
 	info, err := f.file.Stat()
 	if err != nil {
 		return
 	}
-
-	if info.Size() >= f.maxSize {
+	if info.Size() >= f.config.MaxSize {
 		f.rotate()
 	}
 }
@@ -156,53 +378,188 @@ func (f *FileLogger) checkRotation() {
 func (f *FileLogger) rotate() {
 	f.file.Close()
 
-	// 기존 파일 백업
-	backupPath := fmt.Sprintf("%s.%s", f.filePath, time.Now().Format("20060102150405"))
-	os.Rename(f.filePath, backupPath)
+	// 기존 파일 백업 (같은 초에 여러 번 로테이션되면 번호를 붙임)
+	backupPath := fmt.Sprintf("%s.%s", f.config.FilePath, f.now().Format("20060102150405"))
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = fmt.Sprintf("%s.%s-%d", f.config.FilePath, f.now().Format("20060102150405"), i)
+	}
+	os.Rename(f.config.FilePath, backupPath)
 
 	// 새 파일 생성
-	file, err := os.OpenFile(f.filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	file, err := os.OpenFile(f.config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
+		f.file = nil
 		return
 	}
 
 	f.file = file
-	if jsonLogger, ok := f.logger.(*JSONLogger); ok {
-		jsonLogger.output = log.New(file, "", 0)
+	f.openedOn = f.now().Format("20060102")
+	f.logger.output = log.New(file, "", 0)
+
+	// 정리 요청 (이미 대기 중이면 생략)
+	select {
+	case f.pruneCh <- struct{}{}:
+	default:
+	}
+}
+
+func (f *FileLogger) pruneLoop() {
+	defer f.done.Done()
+	for range f.pruneCh {
+		f.pruneBackups()
 	}
 }
 
-func (f *FileLogger) Debug(entry LogEntry) { f.checkRotation(); f.logger.Debug(entry) }
-func (f *FileLogger) Info(entry LogEntry)  { f.checkRotation(); f.logger.Info(entry) }
-func (f *FileLogger) Warn(entry LogEntry)  { f.checkRotation(); f.logger.Warn(entry) }
-func (f *FileLogger) Error(entry LogEntry) { f.checkRotation(); f.logger.Error(entry) }
-func (f *FileLogger) Fatal(entry LogEntry) { f.checkRotation(); f.logger.Fatal(entry) }
+// pruneBackups - MaxBackups/MaxAge 정책에 따라 오래된 백업 삭제
+func (f *FileLogger) pruneBackups() {
+	if f.config.MaxBackups <= 0 && f.config.MaxAge <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(f.config.FilePath + ".*")
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	backups := make([]backup, 0, len(matches))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		backups = append(backups, backup{path: path, modTime: info.ModTime()})
+	}
+
+	// 최신 백업이 앞에 오도록 정렬
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].modTime.Equal(backups[j].modTime) {
+			return backups[i].path > backups[j].path
+		}
+		return backups[i].modTime.After(backups[j].modTime)
+	})
 
+	cutoff := f.now().AddDate(0, 0, -f.config.MaxAge)
+	for i, b := range backups {
+		tooMany := f.config.MaxBackups > 0 && i >= f.config.MaxBackups
+		tooOld := f.config.MaxAge > 0 && b.modTime.Before(cutoff)
+		if tooMany || tooOld {
+			os.Remove(b.path)
+		}
+	}
+}
+
+func (f *FileLogger) Debug(entry LogEntry) { f.write(func(l *JSONLogger) { l.Debug(entry) }) }
+func (f *FileLogger) Info(entry LogEntry)  { f.write(func(l *JSONLogger) { l.Info(entry) }) }
+func (f *FileLogger) Warn(entry LogEntry)  { f.write(func(l *JSONLogger) { l.Warn(entry) }) }
+func (f *FileLogger) Error(entry LogEntry) { f.write(func(l *JSONLogger) { l.Error(entry) }) }
+func (f *FileLogger) Fatal(entry LogEntry) { f.write(func(l *JSONLogger) { l.Fatal(entry) }) }
+
+// Close - 파일을 닫고 진행 중인 백업 정리가 끝날 때까지 대기
 func (f *FileLogger) Close() {
+	f.mu.Lock()
 	if f.file != nil {
 		f.file.Close()
+		f.file = nil
 	}
+	f.mu.Unlock()
+
+	f.closeOnce.Do(func() { close(f.pruneCh) })
+	f.done.Wait()
 }
 
 // ========================================
 // Response Writer 래퍼
 // ========================================
 
+// responseWriter - 응답 바디를 최대 limit 바이트까지만 캡처하는 래퍼
 type responseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body      *bytes.Buffer
+	limit     int
+	truncated bool
 }
 
-func (w responseWriter) Write(b []byte) (int, error) {
+func (w *responseWriter) capture(b []byte) {
+	if remaining := w.limit - w.body.Len(); remaining < len(b) {
+		b = b[:max(remaining, 0)]
+		w.truncated = true
+	}
 	w.body.Write(b)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
 
-func (w responseWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
 	return w.ResponseWriter.WriteString(s)
 }
 
+// readCloser - 일부를 미리 읽은 요청 바디를 원래 Closer와 묶기 위한 타입
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ========================================
+// Request ID
+// ========================================
+
+const (
+	// RequestIDKey - 다른 미들웨어/핸들러가 Request ID를 읽을 때 사용하는 컨텍스트 키
+	RequestIDKey = "RequestID"
+
+	HeaderRequestID     = "X-Request-ID"
+	HeaderCorrelationID = "X-Correlation-ID"
+
+	// HeaderDebugLog - 이 헤더가 있는 요청은 샘플링 없이 항상 기록
+	HeaderDebugLog = "X-Debug-Log"
+
+	maxRequestIDLength = 128
+)
+
+// resolveRequestID - 들어온 X-Request-ID / X-Correlation-ID를 우선 사용하고,
+// 없거나 형식이 올바르지 않으면 새로 생성
+func resolveRequestID(c *gin.Context, now time.Time) string {
+	for _, header := range []string{HeaderRequestID, HeaderCorrelationID} {
+		if id := strings.TrimSpace(c.GetHeader(header)); isValidRequestID(id) {
+			return id
+		}
+	}
+	return fmt.Sprintf("req-%d", now.UnixNano())
+}
+
+// isDebugRequest - 디버그 헤더가 설정된 요청인지 확인
+func isDebugRequest(c *gin.Context) bool {
+	v := strings.ToLower(c.GetHeader(HeaderDebugLog))
+	return v == "1" || v == "true"
+}
+
+// isValidRequestID - 로그/헤더 인젝션을 막기 위해 길이와 문자 집합을 제한
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // ========================================
 // 로깅 미들웨어
 // ========================================
@@ -222,25 +579,53 @@ func BasicLoggingMiddleware() gin.HandlerFunc {
 	})
 }
 
+// DefaultMaxBodyLogSize - 로그에 남길 요청/응답 바디의 기본 최대 크기 (1KB)
+const DefaultMaxBodyLogSize = 1024
+
+// LoggingOptions - 구조화된 로깅 미들웨어 옵션
+type LoggingOptions struct {
+	MaxBodyLogSize int // 이 크기를 넘는 바디는 로깅하지 않음
+}
+
 // StructuredLoggingMiddleware - 구조화된 로깅 미들웨어
 func StructuredLoggingMiddleware(logger Logger) gin.HandlerFunc {
+	return StructuredLoggingMiddlewareWithOptions(logger, LoggingOptions{MaxBodyLogSize: DefaultMaxBodyLogSize})
+}
+
+// StructuredLoggingMiddlewareWithOptions - 바디 캡처 크기를 지정할 수 있는 구조화된 로깅 미들웨어
+func StructuredLoggingMiddlewareWithOptions(logger Logger, opts LoggingOptions) gin.HandlerFunc {
+	maxBody := opts.MaxBodyLogSize
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBodyLogSize
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// Request ID 생성
-		requestID := fmt.Sprintf("req-%d", start.UnixNano())
-		c.Set("RequestID", requestID)
+		// Request ID 결정 (업스트림 전달값 우선) 및 응답 헤더에 설정
+		requestID := resolveRequestID(c, start)
+		c.Set(RequestIDKey, requestID)
+		c.Header(HeaderRequestID, requestID)
+
+		// 바디는 디버그 모드에서만 로깅하므로 그때만 캡처
+		captureBody := gin.Mode() == gin.DebugMode
 
-		// Request body 캡처 (필요한 경우)
+		// Request body 캡처 (최대 maxBody+1 바이트만 읽고 나머지는 그대로 이어 붙임)
 		var requestBody []byte
-		if c.Request.Body != nil && shouldLogBody(c.Request.Header.Get("Content-Type")) {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+		if captureBody && c.Request.Body != nil && shouldLogBody(c.Request.Header.Get("Content-Type")) {
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBody)+1))
+			c.Request.Body = readCloser{
+				Reader: io.MultiReader(bytes.NewReader(requestBody), c.Request.Body),
+				Closer: c.Request.Body,
+			}
 		}
 
 		// Response writer 래핑
-		blw := &responseWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
-		c.Writer = blw
+		var blw *responseWriter
+		if captureBody {
+			blw = &responseWriter{body: &bytes.Buffer{}, limit: maxBody, ResponseWriter: c.Writer}
+			c.Writer = blw
+		}
 
 		// 요청 처리
 		c.Next()
@@ -256,6 +641,7 @@ func StructuredLoggingMiddleware(logger Logger) gin.HandlerFunc {
 			Latency:    latency.String(),
 			ClientIP:   c.ClientIP(),
 			UserAgent:  c.Request.UserAgent(),
+			ForceLog:   isDebugRequest(c),
 		}
 
 		// Request 정보 추가
@@ -265,17 +651,17 @@ func StructuredLoggingMiddleware(logger Logger) gin.HandlerFunc {
 				Query:   c.Request.URL.RawQuery,
 			}
 
-			if len(requestBody) > 0 && len(requestBody) < 1024 { // 1KB 이하만 로깅
+			if len(requestBody) > 0 && len(requestBody) <= maxBody { // maxBody 이하만 로깅
 				entry.Request.Body = string(requestBody)
 			}
 		}
 
 		// Response 정보 추가
-		if gin.Mode() == gin.DebugMode && blw.body.Len() > 0 && blw.body.Len() < 1024 {
+		if blw != nil && blw.body.Len() > 0 && !blw.truncated {
 			entry.Response = &ResponseLog{
 				Headers: getHeaders(c.Writer.Header()),
 				Body:    blw.body.String(),
-				Size:    blw.body.Len(),
+				Size:    c.Writer.Size(),
 			}
 		}
 
@@ -330,7 +716,7 @@ func SlowRequestLoggingMiddleware(threshold time.Duration, logger Logger) gin.Ha
 
 		if latency > threshold {
 			entry := LogEntry{
-				RequestID:  c.GetString("RequestID"),
+				RequestID:  c.GetString(RequestIDKey),
 				Method:     c.Request.Method,
 				Path:       c.Request.URL.Path,
 				StatusCode: c.Writer.Status(),
@@ -354,7 +740,7 @@ func ErrorLoggingMiddleware(logger Logger) gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			for _, err := range c.Errors {
 				entry := LogEntry{
-					RequestID:  c.GetString("RequestID"),
+					RequestID:  c.GetString(RequestIDKey),
 					Method:     c.Request.Method,
 					Path:       c.Request.URL.Path,
 					StatusCode: c.Writer.Status(),
@@ -371,49 +757,387 @@ func ErrorLoggingMiddleware(logger Logger) gin.HandlerFunc {
 	}
 }
 
+// StandardError - 에러 응답 본문 (09 에러 처리 레슨과 같은 형식)
+type StandardError struct {
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	ErrorCode string      `json:"error_code"`
+	Details   interface{} `json:"details,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Path      string      `json:"path"`
+	RequestID string      `json:"request_id"`
+}
+
+// ErrorResponse - 에러 응답 래퍼
+type ErrorResponse struct {
+	Success bool           `json:"success"`
+	Error   *StandardError `json:"error"`
+}
+
+// RecoveryMiddleware - 패닉 복구 미들웨어
+// 패닉을 스택 트레이스와 함께 ERROR 레벨로 기록하고 500 에러 봉투로 응답.
+// 로깅 미들웨어보다 뒤에 등록해야(안쪽에서 실행) 요청 로그에 500이 기록됨
+func RecoveryMiddleware(logger Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// 클라이언트 연결 중단용 패닉은 net/http가 처리하도록 그대로 전달
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			requestID := c.GetString(RequestIDKey)
+			panicErr := fmt.Errorf("panic: %v", rec)
+			logger.Error(LogEntry{
+				Timestamp:  time.Now().Format(time.RFC3339),
+				RequestID:  requestID,
+				Method:     c.Request.Method,
+				Path:       c.Request.URL.Path,
+				StatusCode: http.StatusInternalServerError,
+				ClientIP:   c.ClientIP(),
+				UserAgent:  c.Request.UserAgent(),
+				Error:      panicErr.Error(),
+				Extra: map[string]interface{}{
+					"stack": string(debug.Stack()),
+				},
+				ForceLog: true,
+			})
+			c.Error(panicErr)
+
+			// 이미 응답이 나가기 시작했다면 상태 코드를 바꿀 수 없으므로 중단만 함
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Error: &StandardError{
+					Code:      http.StatusInternalServerError,
+					Message:   "An unexpected error occurred",
+					ErrorCode: "INTERNAL_SERVER_ERROR",
+					Timestamp: time.Now(),
+					Path:      c.Request.URL.Path,
+					RequestID: requestID,
+				},
+			})
+		}()
+		c.Next()
+	}
+}
+
+// maxAuditBodyBytes - 이보다 큰 요청 바디는 감사 로그에 크기만 남김
+const maxAuditBodyBytes = 64 << 10
+
 // AuditLoggingMiddleware - 감사 로그 미들웨어
-func AuditLoggingMiddleware(logger Logger) gin.HandlerFunc {
+// logger에 기록하고, store가 nil이 아니면 조회할 수 있도록 DB에도 저장
+func AuditLoggingMiddleware(logger Logger, store *AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 사용자 정보 (인증 미들웨어에서 설정된다고 가정)
-		userID, _ := c.Get("UserID")
-		userRole, _ := c.Get("UserRole")
+		if !isAuditRequired(c.Request.Method, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		// 핸들러가 바디를 소비하기 전에 읽어 두고 다시 채워 넣음
+		var body []byte
+		if c.Request.Method != "GET" && c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
 
 		start := time.Now()
 		c.Next()
 		latency := time.Since(start)
 
+		// 사용자 정보 (인증 미들웨어에서 설정된다고 가정)
+		// 인증 미들웨어가 뒤에 등록되어 있어도 보이도록 c.Next() 이후에 조회
+		userID := c.GetString("UserID")
+		userRole := c.GetString("UserRole")
+
+		record := AuditRecord{
+			Timestamp:  start.UTC(),
+			RequestID:  c.GetString(RequestIDKey),
+			UserID:     userID,
+			UserRole:   userRole,
+			Action:     getActionType(c.Request.Method),
+			Resource:   getResourceType(c.Request.URL.Path),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: c.Writer.Status(),
+			Latency:    latency.String(),
+			ClientIP:   c.ClientIP(),
+		}
+
+		// Request body 로깅 (민감한 정보 제외)
+		if len(body) > maxAuditBodyBytes {
+			record.RequestBody = omittedBody(body)
+		} else if len(body) > 0 {
+			record.RequestBody = sanitizeBody(c.ContentType(), body)
+		}
+
 		// 중요한 작업에 대한 감사 로그
-		if isAuditRequired(c.Request.Method, c.Request.URL.Path) {
-			entry := LogEntry{
-				Timestamp:  start.Format(time.RFC3339),
-				RequestID:  c.GetString("RequestID"),
-				Method:     c.Request.Method,
-				Path:       c.Request.URL.Path,
-				StatusCode: c.Writer.Status(),
-				Latency:    latency.String(),
-				ClientIP:   c.ClientIP(),
-				Extra: map[string]interface{}{
-					"user_id":   userID,
-					"user_role": userRole,
-					"action":    getActionType(c.Request.Method),
-					"resource":  getResourceType(c.Request.URL.Path),
-				},
+		entry := LogEntry{
+			Timestamp:  start.Format(time.RFC3339),
+			RequestID:  record.RequestID,
+			Method:     record.Method,
+			Path:       record.Path,
+			StatusCode: record.StatusCode,
+			Latency:    record.Latency,
+			ClientIP:   record.ClientIP,
+			Extra: map[string]interface{}{
+				"user_id":   userID,
+				"user_role": userRole,
+				"action":    record.Action,
+				"resource":  record.Resource,
+			},
+		}
+		if record.RequestBody != "" {
+			entry.Extra["request_body"] = record.RequestBody
+		}
+		logger.Info(entry)
+
+		if store != nil {
+			if err := store.Save(c.Request.Context(), &record); err != nil {
+				entry.Error = "failed to persist audit entry: " + err.Error()
+				logger.Error(entry)
 			}
+		}
+	}
+}
 
-			// Request body 로깅 (민감한 정보 제외)
-			if c.Request.Method != "GET" {
-				body, _ := c.GetRawData()
-				if len(body) > 0 {
-					sanitized := sanitizeBody(body)
-					entry.Extra["request_body"] = sanitized
-				}
+// ========================================
+// 감사 로그 저장소 및 조회 API
+// ========================================
+
+// AuditRecord - DB에 저장되는 감사 로그 (요청 바디는 마스킹된 상태로 저장)
+type AuditRecord struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Timestamp   time.Time `gorm:"index" json:"timestamp"`
+	RequestID   string    `json:"request_id"`
+	UserID      string    `gorm:"index" json:"user_id"`
+	UserRole    string    `json:"user_role"`
+	Action      string    `gorm:"index" json:"action"`
+	Resource    string    `gorm:"index" json:"resource"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	StatusCode  int       `json:"status_code"`
+	Latency     string    `json:"latency"`
+	ClientIP    string    `json:"client_ip"`
+	RequestBody string    `gorm:"type:text" json:"request_body,omitempty"`
+}
+
+// AuditFilter - 감사 로그 조회 조건 (빈 값은 조건에서 제외)
+type AuditFilter struct {
+	UserID   string
+	Action   string
+	Resource string
+	From     time.Time // 포함
+	To       time.Time // 제외
+}
+
+// AuditStore - 감사 로그를 DB에 저장하고 조회
+type AuditStore struct {
+	db *gorm.DB
+}
+
+func NewAuditStore(db *gorm.DB) (*AuditStore, error) {
+	if err := db.AutoMigrate(&AuditRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate audit table: %w", err)
+	}
+	return &AuditStore{db: db}, nil
+}
+
+func (s *AuditStore) Save(ctx context.Context, record *AuditRecord) error {
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+// filtered - 조회 조건을 적용한 쿼리 (Query와 Each가 공유)
+func (s *AuditStore) filtered(ctx context.Context, f AuditFilter) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&AuditRecord{})
+	if f.UserID != "" {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", strings.ToUpper(f.Action))
+	}
+	if f.Resource != "" {
+		query = query.Where("resource = ?", f.Resource)
+	}
+	if !f.From.IsZero() {
+		query = query.Where("timestamp >= ?", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		query = query.Where("timestamp < ?", f.To.UTC())
+	}
+	return query
+}
+
+// Query - 조건에 맞는 감사 로그를 발생 순서대로 반환 (재생 가능하도록 시간순 정렬)
+func (s *AuditStore) Query(ctx context.Context, f AuditFilter, offset, limit int) ([]AuditRecord, int64, error) {
+	query := s.filtered(ctx, f)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	records := []AuditRecord{}
+	err := query.Order("timestamp ASC, id ASC").Offset(offset).Limit(limit).Find(&records).Error
+	return records, total, err
+}
+
+// Each - 조건에 맞는 감사 로그를 Query와 같은 순서로 한 건씩 fn에 전달
+// 커서로 한 행씩 읽으므로 결과 전체를 메모리에 올리지 않음
+func (s *AuditStore) Each(ctx context.Context, f AuditFilter, fn func(*AuditRecord) error) error {
+	rows, err := s.filtered(ctx, f).Order("timestamp ASC, id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record AuditRecord
+		if err := s.db.ScanRows(rows, &record); err != nil {
+			return err
+		}
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// bindAuditFilter - user_id, action, resource, from/to(RFC3339) 쿼리 파라미터를 읽음
+// 형식이 잘못되면 400을 응답하고 false를 반환
+func bindAuditFilter(c *gin.Context) (AuditFilter, bool) {
+	filter := AuditFilter{
+		UserID:   c.Query("user_id"),
+		Action:   c.Query("action"),
+		Resource: c.Query("resource"),
+	}
+	for param, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("%s must be an RFC3339 timestamp", param),
+			})
+			return filter, false
+		}
+		*dst = t
+	}
+	return filter, true
+}
+
+// AuditQueryHandler - GET /audit?user_id=&action=&resource=&from=&to=&page=&page_size=
+// from/to는 RFC3339 형식
+func AuditQueryHandler(store *AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindAuditFilter(c)
+		if !ok {
+			return
+		}
+
+		var p, ps int
+		fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &p)
+		fmt.Sscanf(c.DefaultQuery("page_size", "20"), "%d", &ps)
+		if p < 1 {
+			p = 1
+		}
+		if ps < 1 || ps > 100 {
+			ps = 20
+		}
+
+		records, total, err := store.Query(c.Request.Context(), filter, (p-1)*ps, ps)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit log"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"entries":     records,
+			"total":       total,
+			"page":        p,
+			"page_size":   ps,
+			"total_pages": (total + int64(ps) - 1) / int64(ps),
+		})
+	}
+}
+
+// auditCSVHeader - CSV 내보내기 열 순서
+var auditCSVHeader = []string{
+	"id", "timestamp", "request_id", "user_id", "user_role", "action", "resource",
+	"method", "path", "status_code", "latency", "client_ip", "request_body",
+}
+
+// csvFlushRows - 이만큼 쓸 때마다 클라이언트로 내보냄
+const csvFlushRows = 100
+
+// AuditExportHandler - GET /audit/export.csv (필터는 GET /audit와 동일, 페이지 없이 전체)
+// 요청 바디는 저장할 때 이미 마스킹되어 있으므로 원문이 CSV로 새어 나가지 않음
+func AuditExportHandler(store *AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindAuditFilter(c)
+		if !ok {
+			return
+		}
+
+		filename := fmt.Sprintf("audit-%s.csv", time.Now().UTC().Format("20060102-150405"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		w.Write(auditCSVHeader)
+		n := 0
+		err := store.Each(c.Request.Context(), filter, func(r *AuditRecord) error {
+			w.Write([]string{
+				strconv.FormatUint(uint64(r.ID), 10),
+				r.Timestamp.UTC().Format(time.RFC3339Nano),
+				r.RequestID,
+				r.UserID,
+				r.UserRole,
+				r.Action,
+				r.Resource,
+				r.Method,
+				r.Path,
+				strconv.Itoa(r.StatusCode),
+				r.Latency,
+				r.ClientIP,
+				r.RequestBody,
+			})
+			if n++; n%csvFlushRows == 0 {
+				w.Flush()
+				c.Writer.Flush()
 			}
+			return w.Error()
+		})
+		w.Flush()
 
-			logger.Info(entry)
+		// 헤더를 이미 보냈으므로 상태 코드는 바꿀 수 없음 - 에러 로그만 남김
+		if err == nil {
+			err = w.Error()
+		}
+		if err != nil {
+			c.Error(err)
 		}
 	}
 }
 
+// requireAdmin - 감사 로그는 관리자만 조회 가능
+func requireAdmin(c *gin.Context) {
+	if c.GetString("UserRole") != "admin" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin role required"})
+		return
+	}
+	c.Next()
+}
+
 // ========================================
 // 헬퍼 함수들
 // ========================================
@@ -489,46 +1213,134 @@ func getResourceType(path string) string {
 	return "unknown"
 }
 
-func sanitizeBody(body []byte) string {
-	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		return string(body)
-	}
+// sensitiveFields - 감사 로그에서 마스킹할 키 패턴 (대소문자 무시, 모든 깊이에 적용)
+// 키 이름에 포함되기만 해도 마스킹합니다 (refresh_token, new_password, client_secret 등).
+var sensitiveFields = []string{
+	"password", "token", "secret", "credit_card",
+	"authorization", "ssn", "card_number", "cvv",
+}
 
-	// 민감한 필드 마스킹
-	sensitiveFields := []string{"password", "token", "secret", "credit_card"}
+func isSensitiveField(key string) bool {
+	key = strings.ToLower(key)
 	for _, field := range sensitiveFields {
-		if _, exists := data[field]; exists {
-			data[field] = "***MASKED***"
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	// api_key, private_key 같은 "*key" 필드
+	return strings.HasSuffix(key, "key")
+}
+
+// sanitizeBody - JSON과 form 본문은 민감한 필드를 마스킹하고,
+// 그 외 형식은 안전하게 마스킹할 수 없으므로 크기만 남깁니다.
+func sanitizeBody(contentType string, body []byte) string {
+	if contentType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return omittedBody(body)
 		}
+		return maskFormValues(values)
 	}
 
-	sanitized, _ := json.Marshal(data)
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return omittedBody(body)
+	}
+
+	sanitized, _ := json.Marshal(maskSensitive(data))
 	return string(sanitized)
 }
 
+func omittedBody(body []byte) string {
+	return fmt.Sprintf("[%d bytes omitted]", len(body))
+}
+
+// maskFormValues - form 값을 마스킹해 키 순서대로 다시 인코딩
+func maskFormValues(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if isSensitiveField(key) {
+				value = "***MASKED***"
+			} else {
+				value = url.QueryEscape(value)
+			}
+			parts = append(parts, url.QueryEscape(key)+"="+value)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// maskSensitive - 중첩된 객체와 배열을 재귀적으로 돌며 민감한 필드 마스킹
+func maskSensitive(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, child := range val {
+			if isSensitiveField(key) {
+				val[key] = "***MASKED***"
+			} else {
+				val[key] = maskSensitive(child)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = maskSensitive(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
 // ========================================
 // 메인 함수 및 데모 핸들러
 // ========================================
 
 func main() {
-	// 로거 초기화
-	jsonLogger := NewJSONLogger(INFO)
+	// 로거 초기화 (성공 응답은 10건 중 1건만 기록, X-Debug-Log: 1 요청은 항상 기록)
+	// 기록은 백그라운드 고루틴에서 수행하고, 버퍼가 가득 차면 버림
+	jsonLogger := NewAsyncLogger(NewSampledLogger(NewJSONLogger(INFO), 10), 1024, DropOnFull)
+	defer jsonLogger.Close()
 
 	// 파일 로거 초기화
 	logDir := "./logs"
 	os.MkdirAll(logDir, 0755)
 
-	fileLogger, err := NewFileLogger(
-		filepath.Join(logDir, "app.log"),
-		10*1024*1024, // 10MB
-		INFO,
-	)
+	fileLogger, err := NewFileLoggerWithConfig(FileLoggerConfig{
+		FilePath:   filepath.Join(logDir, "app.log"),
+		MaxSize:    10 * 1024 * 1024, // 10MB
+		MaxBackups: 3,
+		MaxAge:     7,
+		Level:      INFO,
+	})
 	if err != nil {
 		panic("Failed to create file logger: " + err.Error())
 	}
 	defer fileLogger.Close()
 
+	// 감사/에러 로그는 유실되면 안 되므로 버퍼가 가득 차면 대기
+	asyncFileLogger := NewAsyncLogger(fileLogger, 1024, BlockOnFull)
+	defer asyncFileLogger.Close()
+
+	// 감사 로그 조회용 DB
+	auditDB, err := gorm.Open(sqlite.Open(filepath.Join(logDir, "audit.db")), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		panic("Failed to open audit database: " + err.Error())
+	}
+	auditStore, err := NewAuditStore(auditDB)
+	if err != nil {
+		panic(err.Error())
+	}
+
 	// Access 로그 파일
 	accessLogFile, _ := os.OpenFile(
 		filepath.Join(logDir, "access.log"),
@@ -542,12 +1354,19 @@ func main() {
 	gin.SetMode(gin.DebugMode)
 	r := gin.New()
 
+	// gzip 압축 (Accept-Encoding: gzip + 1KB 이상 응답만, /api/large 등)
+	// 로거보다 먼저 등록해야 로그에 압축 전 본문이 기록됨
+	r.Use(compress.New())
+
 	// 로깅 미들웨어 적용
 	r.Use(StructuredLoggingMiddleware(jsonLogger))        // 구조화된 로깅
 	r.Use(AccessLoggingMiddleware(accessLogger))          // 접근 로그
-	r.Use(SlowRequestLoggingMiddleware(100*time.Millisecond, fileLogger)) // 느린 요청 로깅
-	r.Use(ErrorLoggingMiddleware(fileLogger))             // 에러 로깅
-	r.Use(AuditLoggingMiddleware(fileLogger))             // 감사 로그
+	r.Use(SlowRequestLoggingMiddleware(100*time.Millisecond, asyncFileLogger)) // 느린 요청 로깅
+	r.Use(ErrorLoggingMiddleware(asyncFileLogger))             // 에러 로깅
+	r.Use(AuditLoggingMiddleware(asyncFileLogger, auditStore)) // 감사 로그 (파일 + DB)
+
+	// 패닉 복구 (로깅 미들웨어 안쪽에서 복구해야 요청 로그에 500이 남음)
+	r.Use(RecoveryMiddleware(jsonLogger))
 
 	// 인증 시뮬레이션 미들웨어
 	r.Use(func(c *gin.Context) {
@@ -685,6 +1504,15 @@ func main() {
 		})
 	})
 
+	// 11. 패닉 발생 (RecoveryMiddleware가 500 에러 봉투로 응답)
+	r.GET("/api/panic", func(c *gin.Context) {
+		panic("something went wrong")
+	})
+
+	// 12. 감사 로그 조회 (관리자 전용)
+	r.GET("/audit", requireAdmin, AuditQueryHandler(auditStore))
+	r.GET("/audit/export.csv", requireAdmin, AuditExportHandler(auditStore))
+
 	// 서버 시작
 	fmt.Println("Server is running on :8080")
 	fmt.Println("Logs are being written to ./logs/")
@@ -695,6 +1523,9 @@ func main() {
 	fmt.Println("  POST /api/users      - Create user (audit log)")
 	fmt.Println("  POST /api/login      - Login (sensitive data masking)")
 	fmt.Println("  GET  /api/status/:code - Various status codes")
+	fmt.Println("  GET  /api/panic      - Panic (recovered into a 500 envelope)")
+	fmt.Println("  GET  /audit          - Query audit log (admin)")
+	fmt.Println("  GET  /audit/export.csv - Download audit log as CSV (admin)")
 
 	if err := r.Run(":8080"); err != nil {
 		panic("Failed to start server: " + err.Error())
@@ -0,0 +1,634 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// captureLogger - 기록된 엔트리를 메모리에 보관하는 테스트용 로거
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func (l *captureLogger) record(level LogLevel, entry LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.Level = level.String()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *captureLogger) Debug(entry LogEntry) { l.record(DEBUG, entry) }
+func (l *captureLogger) Info(entry LogEntry)  { l.record(INFO, entry) }
+func (l *captureLogger) Warn(entry LogEntry)  { l.record(WARN, entry) }
+func (l *captureLogger) Error(entry LogEntry) { l.record(ERROR, entry) }
+func (l *captureLogger) Fatal(entry LogEntry) { l.record(FATAL, entry) }
+
+func (l *captureLogger) Entries() []LogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]LogEntry(nil), l.entries...)
+}
+
+func newTestRouter(logger Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(StructuredLoggingMiddleware(logger))
+	r.GET("/api/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"request_id": c.GetString(RequestIDKey)})
+	})
+	return r
+}
+
+func TestStructuredLoggingPropagatesRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   string
+	}{
+		{name: "X-Request-ID", header: HeaderRequestID, value: "abc-123", want: "abc-123"},
+		{name: "X-Correlation-ID", header: HeaderCorrelationID, value: "corr_42.a:b", want: "corr_42.a:b"},
+		{name: "invalid charset is replaced", header: HeaderRequestID, value: "bad id\r\ninjected"},
+		{name: "too long is replaced", header: HeaderRequestID, value: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "missing is generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &captureLogger{}
+			req := httptest.NewRequest("GET", "/api/health", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			newTestRouter(logger).ServeHTTP(w, req)
+
+			got := w.Header().Get(HeaderRequestID)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("expected response header %q, got %q", tt.want, got)
+			}
+			if tt.want == "" && !strings.HasPrefix(got, "req-") {
+				t.Errorf("expected generated request id, got %q", got)
+			}
+			if !strings.Contains(w.Body.String(), got) {
+				t.Errorf("handler should see request id %q in context, body=%s", got, w.Body.String())
+			}
+
+			entries := logger.Entries()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 log entry, got %d", len(entries))
+			}
+			if entries[0].RequestID != got {
+				t.Errorf("log entry request id %q does not match response %q", entries[0].RequestID, got)
+			}
+		})
+	}
+}
+
+func TestSampledLoggerKeepsErrorsAndSamplesSuccesses(t *testing.T) {
+	const sampleRate = 10
+	capture := &captureLogger{}
+	r := newTestRouter(NewSampledLogger(capture, sampleRate))
+	r.GET("/api/fail", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+
+	const successes, failures = 1000, 5
+	for i := 0; i < successes; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/health", nil))
+	}
+	for i := 0; i < failures; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/fail", nil))
+	}
+	debugReq := httptest.NewRequest("GET", "/api/health", nil)
+	debugReq.Header.Set(HeaderDebugLog, "1")
+	r.ServeHTTP(httptest.NewRecorder(), debugReq)
+
+	var ok, errs, forced int
+	for _, e := range capture.Entries() {
+		switch {
+		case e.StatusCode >= 500:
+			errs++
+		case e.ForceLog:
+			forced++
+		default:
+			ok++
+		}
+	}
+
+	if errs != failures {
+		t.Errorf("expected all %d errors to be logged, got %d", failures, errs)
+	}
+	if forced != 1 {
+		t.Errorf("expected debug request to bypass sampling, got %d", forced)
+	}
+	want := successes / sampleRate
+	if ok < want*8/10 || ok > want*12/10 {
+		t.Errorf("expected about %d sampled successes, got %d", want, ok)
+	}
+}
+
+func TestFileLoggerRotatesAndPrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	logger, err := NewFileLoggerWithConfig(FileLoggerConfig{
+		FilePath:   path,
+		MaxSize:    1, // 매 기록마다 크기 기준 로테이션
+		MaxBackups: 2,
+		Level:      INFO,
+	})
+	if err != nil {
+		t.Fatalf("failed to create file logger: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		logger.Info(LogEntry{Path: fmt.Sprintf("/api/%d", i)})
+	}
+	logger.Close()
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 retained backups, got %d: %v", len(backups), backups)
+	}
+
+	// 가장 최근 백업 두 개만 남아야 함
+	var contents string
+	for _, b := range backups {
+		data, _ := os.ReadFile(b)
+		contents += string(data)
+	}
+	if !strings.Contains(contents, "/api/4") || !strings.Contains(contents, "/api/3") {
+		t.Errorf("expected newest backups to be retained, got %q", contents)
+	}
+}
+
+func TestFileLoggerRotatesDailyAndPrunesByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	old := path + ".20000101000000"
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldTime := time.Now().AddDate(0, 0, -30)
+	os.Chtimes(old, oldTime, oldTime)
+
+	logger, err := NewFileLoggerWithConfig(FileLoggerConfig{FilePath: path, MaxAge: 7, Level: INFO})
+	if err != nil {
+		t.Fatalf("failed to create file logger: %v", err)
+	}
+	now := time.Now()
+	logger.now = func() time.Time { return now }
+
+	logger.Info(LogEntry{Path: "/day1"})
+	logger.Info(LogEntry{Path: "/day1-again"})
+	now = now.Add(24 * time.Hour)
+	logger.Info(LogEntry{Path: "/day2"})
+	logger.Close()
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("expected one daily backup after pruning, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); strings.Count(string(data), "\n") != 2 {
+		t.Errorf("expected day-one entries in backup, got %q", data)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "/day2") {
+		t.Errorf("expected current file to contain day-two entry, got %q", data)
+	}
+}
+
+func TestAsyncLoggerPreservesOrderBelowCapacity(t *testing.T) {
+	capture := &captureLogger{}
+	logger := NewAsyncLogger(capture, 256, DropOnFull)
+
+	const burst = 200
+	for i := 0; i < burst; i++ {
+		logger.Info(LogEntry{Path: fmt.Sprintf("/api/%d", i)})
+	}
+	logger.Close()
+
+	entries := capture.Entries()
+	if len(entries) != burst {
+		t.Fatalf("expected %d entries, got %d (dropped %d)", burst, len(entries), logger.Dropped())
+	}
+	for i, e := range entries {
+		if want := fmt.Sprintf("/api/%d", i); e.Path != want {
+			t.Fatalf("entry %d out of order: got %q want %q", i, e.Path, want)
+		}
+	}
+}
+
+// blockingLogger - release가 닫힐 때까지 기록을 막는 로거
+type blockingLogger struct {
+	captureLogger
+	release chan struct{}
+}
+
+func (l *blockingLogger) Info(entry LogEntry) {
+	<-l.release
+	l.captureLogger.Info(entry)
+}
+
+func TestAsyncLoggerDropsWhenFullWithoutBlocking(t *testing.T) {
+	next := &blockingLogger{release: make(chan struct{})}
+	logger := NewAsyncLogger(next, 1, DropOnFull)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			logger.Info(LogEntry{Path: fmt.Sprintf("/api/%d", i)})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging blocked the caller while the buffer was full")
+	}
+	if logger.Dropped() == 0 {
+		t.Error("expected entries to be dropped when the buffer is full")
+	}
+
+	close(next.release)
+	logger.Close()
+	if got := len(next.Entries()) + int(logger.Dropped()); got != 5 {
+		t.Errorf("expected written+dropped to equal 5, got %d", got)
+	}
+}
+
+func TestStructuredLoggingBodyCap(t *testing.T) {
+	gin.SetMode(gin.DebugMode)
+	defer gin.SetMode(gin.TestMode)
+
+	capture := &captureLogger{}
+	r := gin.New()
+	r.Use(StructuredLoggingMiddlewareWithOptions(capture, LoggingOptions{MaxBodyLogSize: 16}))
+	r.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	send := func(body string) LogEntry {
+		req := httptest.NewRequest("POST", "/echo", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != body {
+			t.Fatalf("handler should see the full body, got %q", w.Body.String())
+		}
+		entries := capture.Entries()
+		return entries[len(entries)-1]
+	}
+
+	small := send(`{"a":1}`)
+	if small.Request.Body != `{"a":1}` || small.Response == nil || small.Response.Body != `{"a":1}` {
+		t.Errorf("expected small bodies to be logged, got %+v / %+v", small.Request, small.Response)
+	}
+
+	large := send(`{"data":"` + strings.Repeat("x", 64) + `"}`)
+	if large.Request.Body != "" || large.Response != nil {
+		t.Errorf("expected bodies over the cap to be omitted, got %+v / %+v", large.Request, large.Response)
+	}
+}
+
+func TestSanitizeBodyMasksNestedFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		leaked      []string
+		retained    []string
+	}{
+		{
+			name:     "top level",
+			body:     `{"username":"admin","password":"hunter2"}`,
+			leaked:   []string{"hunter2"},
+			retained: []string{"admin"},
+		},
+		{
+			name:     "deeply nested",
+			body:     `{"user":{"profile":{"auth":{"Password":"p1","token":"t1"}},"ssn":"123-45-6789"}}`,
+			leaked:   []string{"p1", "t1", "123-45-6789"},
+			retained: []string{"profile"},
+		},
+		{
+			name:     "array of objects",
+			body:     `{"cards":[{"card_number":"4111111111111111","cvv":"123","label":"home"},{"card_number":"5500000000000004","cvv":"456"}]}`,
+			leaked:   []string{"4111111111111111", "5500000000000004", `"123"`, `"456"`},
+			retained: []string{"home"},
+		},
+		{
+			name:   "top level array",
+			body:   `[{"authorization":"Bearer abc"},{"nested":[{"secret":"s3"}]}]`,
+			leaked: []string{"Bearer abc", "s3"},
+		},
+		{
+			name:     "token and secret variants",
+			body:     `{"refresh_token":"rt1","access_token":"at1","new_password":"np1","client_secret":"cs1","api_key":"ak1","keyword":"go"}`,
+			leaked:   []string{"rt1", "at1", "np1", "cs1", "ak1"},
+			retained: []string{`"keyword":"go"`},
+		},
+		{
+			name:        "form body",
+			contentType: "application/x-www-form-urlencoded",
+			body:        `username=admin&password=plain&refresh_token=rt2`,
+			leaked:      []string{"plain", "rt2"},
+			retained:    []string{"username=admin"},
+		},
+		{
+			name:     "unparseable body",
+			body:     `username=admin&password=plain`,
+			leaked:   []string{"plain"},
+			retained: []string{"bytes omitted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			got := sanitizeBody(contentType, []byte(tt.body))
+			for _, s := range tt.leaked {
+				if strings.Contains(got, s) {
+					t.Errorf("sensitive value %q leaked: %s", s, got)
+				}
+			}
+			for _, s := range tt.retained {
+				if !strings.Contains(got, s) {
+					t.Errorf("expected %q to be retained: %s", s, got)
+				}
+			}
+			masked := strings.Count(got, "***MASKED***")
+			if len(tt.leaked) > 0 && !strings.Contains(got, "bytes omitted") && masked < len(tt.leaked) {
+				t.Errorf("expected every occurrence to be masked: %s", got)
+			}
+		})
+	}
+}
+
+func newAuditTestRouter(t *testing.T, logger Logger) (*gin.Engine, *AuditStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store, err := NewAuditStore(db)
+	if err != nil {
+		t.Fatalf("failed to create audit store: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(AuditLoggingMiddleware(logger, store))
+	// main과 같이 인증 미들웨어가 감사 미들웨어 뒤에 등록됨
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("UserID", user)
+			c.Set("UserRole", c.GetHeader("X-Role"))
+		}
+		c.Next()
+	})
+	r.POST("/api/login", func(c *gin.Context) {
+		var credentials struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&credentials); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credentials format"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"username": credentials.Username})
+	})
+	r.DELETE("/api/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/audit", requireAdmin, AuditQueryHandler(store))
+	r.GET("/audit/export.csv", requireAdmin, AuditExportHandler(store))
+	return r, store
+}
+
+func TestAuditEntriesCanBeQueried(t *testing.T) {
+	logger := &captureLogger{}
+	r, _ := newAuditTestRouter(t, logger)
+	before := time.Now().Add(-time.Second)
+
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	alice := map[string]string{"X-User": "alice", "X-Role": "user"}
+	admin := map[string]string{"X-User": "root", "X-Role": "admin"}
+
+	// 핸들러도 바디를 그대로 받아야 함
+	if w := do("POST", "/api/login", `{"username":"alice","password":"hunter2"}`, alice); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "alice") {
+		t.Fatalf("expected login to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	do("DELETE", "/api/users/7", "", alice)
+	do("DELETE", "/api/users/8", "", admin)
+
+	if w := do("GET", "/audit", "", alice); w.Code != http.StatusForbidden {
+		t.Errorf("expected non-admin to get 403, got %d", w.Code)
+	}
+
+	type auditPage struct {
+		Entries []AuditRecord `json:"entries"`
+		Total   int64         `json:"total"`
+	}
+	query := func(params string) auditPage {
+		t.Helper()
+		w := do("GET", "/audit?"+params, "", admin)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /audit?%s: expected 200, got %d: %s", params, w.Code, w.Body.String())
+		}
+		var page auditPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return page
+	}
+
+	login := query("user_id=alice&action=create&resource=login")
+	if login.Total != 1 || len(login.Entries) != 1 {
+		t.Fatalf("expected one login entry, got %+v", login)
+	}
+	entry := login.Entries[0]
+	if entry.Path != "/api/login" || entry.StatusCode != http.StatusOK || entry.UserRole != "user" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if strings.Contains(entry.RequestBody, "hunter2") || !strings.Contains(entry.RequestBody, "***MASKED***") {
+		t.Errorf("expected password to be masked in stored body, got %q", entry.RequestBody)
+	}
+
+	deletes := query("action=DELETE&resource=users")
+	if deletes.Total != 2 || deletes.Entries[0].Path != "/api/users/7" || deletes.Entries[1].Path != "/api/users/8" {
+		t.Errorf("expected both deletes in order, got %+v", deletes)
+	}
+
+	paged := query("user_id=alice&page=2&page_size=1")
+	if paged.Total != 2 || len(paged.Entries) != 1 || paged.Entries[0].Path != "/api/users/7" {
+		t.Errorf("expected second page to hold alice's delete, got %+v", paged)
+	}
+
+	from := url.QueryEscape(before.Format(time.RFC3339))
+	if got := query("from=" + from); got.Total != 3 {
+		t.Errorf("expected 3 entries since %s, got %d", from, got.Total)
+	}
+	if got := query("to=" + from); got.Total != 0 {
+		t.Errorf("expected no entries before %s, got %d", from, got.Total)
+	}
+	if w := do("GET", "/audit?from=yesterday", "", admin); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid from, got %d", w.Code)
+	}
+
+	// 파일 로거에도 같은 내용이 기록됨
+	if n := len(logger.Entries()); n != 3 {
+		t.Errorf("expected 3 audit log entries, got %d", n)
+	}
+}
+
+func TestAuditExportCSV(t *testing.T) {
+	r, _ := newAuditTestRouter(t, &captureLogger{})
+
+	do := func(method, path, body, user, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Role", role)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	do("POST", "/api/login", `{"username":"alice","password":"hunter2"}`, "alice", "user")
+	do("DELETE", "/api/users/7", "", "alice", "user")
+	do("DELETE", "/api/users/8", "", "root", "admin")
+
+	if w := do("GET", "/audit/export.csv", "", "alice", "user"); w.Code != http.StatusForbidden {
+		t.Errorf("expected non-admin to get 403, got %d", w.Code)
+	}
+	if w := do("GET", "/audit/export.csv?from=yesterday", "", "root", "admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid from, got %d", w.Code)
+	}
+
+	export := func(params string) [][]string {
+		t.Helper()
+		w := do("GET", "/audit/export.csv?"+params, "", "root", "admin")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+			t.Errorf("expected text/csv, got %q", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=\"audit-") {
+			t.Errorf("unexpected Content-Disposition %q", cd)
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(auditCSVHeader, ",") {
+			t.Fatalf("unexpected header row: %v", records)
+		}
+		return records[1:]
+	}
+
+	rows := export("user_id=alice")
+	if len(rows) != 2 || rows[0][8] != "/api/login" || rows[1][8] != "/api/users/7" {
+		t.Fatalf("expected alice's two entries in order, got %v", rows)
+	}
+	if body := rows[0][12]; strings.Contains(body, "hunter2") || !strings.Contains(body, "***MASKED***") {
+		t.Errorf("expected masked request body, got %q", body)
+	}
+
+	if rows := export("action=delete&resource=users"); len(rows) != 2 || rows[1][3] != "root" {
+		t.Errorf("expected both deletes, got %v", rows)
+	}
+	if rows := export("user_id=nobody"); len(rows) != 0 {
+		t.Errorf("expected only the header row, got %v", rows)
+	}
+}
+
+func TestRecoveryMiddlewareLogsPanicAndRespondsWithEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := &captureLogger{}
+	r := gin.New()
+	r.Use(StructuredLoggingMiddleware(logger))
+	r.Use(RecoveryMiddleware(logger))
+	r.GET("/api/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/api/panic", nil)
+	req.Header.Set(HeaderRequestID, "panic-req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	if resp.Success || resp.Error == nil {
+		t.Fatalf("expected error envelope, got %s", w.Body.String())
+	}
+	if resp.Error.ErrorCode != "INTERNAL_SERVER_ERROR" || resp.Error.RequestID != "panic-req-1" || resp.Error.Path != "/api/panic" {
+		t.Errorf("unexpected envelope: %+v", resp.Error)
+	}
+	if strings.Contains(w.Body.String(), "boom") {
+		t.Error("panic value leaked into the response")
+	}
+
+	entries := logger.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected panic entry and request entry, got %d", len(entries))
+	}
+	panicEntry, requestEntry := entries[0], entries[1]
+	if panicEntry.Level != "ERROR" || panicEntry.Error != "panic: boom" || panicEntry.RequestID != "panic-req-1" {
+		t.Errorf("unexpected panic entry: %+v", panicEntry)
+	}
+	if stack, _ := panicEntry.Extra["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("expected stack trace in panic entry, got %q", stack)
+	}
+	if requestEntry.StatusCode != http.StatusInternalServerError || requestEntry.Level != "ERROR" {
+		t.Errorf("expected request to be logged as 500 at ERROR, got %d at %s", requestEntry.StatusCode, requestEntry.Level)
+	}
+	if !strings.Contains(requestEntry.Error, "panic: boom") {
+		t.Errorf("expected request entry to carry the panic error, got %q", requestEntry.Error)
+	}
+}
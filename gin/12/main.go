@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"example.com/gin-playground/pkg/timeout"
+	"github.com/fsnotify/fsnotify"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
 )
 
 // ========================================
@@ -29,6 +43,9 @@ type Config struct {
 	Security SecurityConfig `mapstructure:"security"`
 	Features FeatureFlags   `mapstructure:"features"`
 	External ExternalAPIs   `mapstructure:"external"`
+	Routes   []RouteConfig  `mapstructure:"routes"`
+
+	FeatureStore FeatureStoreConfig `mapstructure:"feature_store"`
 }
 
 // ServerConfig - 서버 설정
@@ -98,9 +115,10 @@ type SMTPConfig struct {
 
 // StorageConfig - 스토리지 설정
 type StorageConfig struct {
-	Type      string `mapstructure:"type"` // local, s3, gcs
-	LocalPath string `mapstructure:"local_path"`
-	S3        S3Config `mapstructure:"s3"`
+	Type      string    `mapstructure:"type"` // local, s3, gcs
+	LocalPath string    `mapstructure:"local_path"`
+	S3        S3Config  `mapstructure:"s3"`
+	GCS       GCSConfig `mapstructure:"gcs"`
 }
 
 // S3Config - S3 설정
@@ -112,6 +130,12 @@ type S3Config struct {
 	Endpoint        string `mapstructure:"endpoint"`
 }
 
+// GCSConfig - Google Cloud Storage 설정
+type GCSConfig struct {
+	Bucket          string `mapstructure:"bucket"`
+	CredentialsFile string `mapstructure:"credentials_file"`
+}
+
 // LoggingConfig - 로깅 설정
 type LoggingConfig struct {
 	Level      string `mapstructure:"level"` // debug, info, warn, error
@@ -130,6 +154,9 @@ type SecurityConfig struct {
 	AllowedHosts  []string        `mapstructure:"allowed_hosts"`
 	SSLRedirect   bool            `mapstructure:"ssl_redirect"`
 	CSRFProtection bool           `mapstructure:"csrf_protection"`
+
+	// 관리 API의 Bearer 토큰 (APP_SECURITY_ADMIN_TOKEN, 비어 있으면 관리 API는 항상 401)
+	AdminToken string `mapstructure:"admin_token"`
 }
 
 // CORSConfig - CORS 설정
@@ -160,6 +187,13 @@ type FeatureFlags struct {
 	EnableProfiling  bool `mapstructure:"enable_profiling"`
 }
 
+// FeatureStoreConfig - 런타임 기능 플래그 저장소 설정
+// features 섹션은 테이블이 비어 있을 때의 초기값으로만 쓰이고, 이후에는 테이블이 우선합니다.
+type FeatureStoreConfig struct {
+	Path            string        `mapstructure:"path"`             // SQLite 파일 경로
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 다른 인스턴스의 변경을 반영하는 주기
+}
+
 // ExternalAPIs - 외부 API 설정
 type ExternalAPIs struct {
 	PaymentGateway APIConfig `mapstructure:"payment_gateway"`
@@ -176,6 +210,147 @@ type APIConfig struct {
 	Headers map[string]string `mapstructure:"headers"`
 }
 
+// RouteConfig - 경로 접두사별 요청 타임아웃
+type RouteConfig struct {
+	Prefix  string        `mapstructure:"prefix"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// RouteTimeout - path에 가장 길게 일치하는 routes 항목의 타임아웃
+// 일치하는 항목이 없으면 전역 타임아웃(server.write_timeout)을 사용합니다.
+// 접두사는 경로 구분자 단위로 비교하므로 "/api/batch"는 "/api/batchy"와 일치하지 않습니다.
+func (c *Config) RouteTimeout(path string) time.Duration {
+	timeout, matched := c.Server.WriteTimeout, -1
+	for _, route := range c.Routes {
+		prefix := strings.TrimSuffix(route.Prefix, "/")
+		if len(prefix) <= matched {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, prefix+"/") || prefix == "" {
+			timeout, matched = route.Timeout, len(prefix)
+		}
+	}
+	return timeout
+}
+
+// MaxRequestTimeout - 전역 타임아웃과 routes 중 가장 긴 타임아웃
+func (c *Config) MaxRequestTimeout() time.Duration {
+	longest := c.Server.WriteTimeout
+	for _, route := range c.Routes {
+		longest = max(longest, route.Timeout)
+	}
+	return longest
+}
+
+// ========================================
+// 민감 정보 처리
+// ========================================
+
+const redactedValue = "***"
+
+// 키 이름에 포함되면 민감 정보로 취급하는 패턴
+var secretKeyPatterns = []string{"password", "secret", "key", "token", "authorization"}
+
+// isSecretKey - 설정 키나 환경 변수 이름이 민감 정보인지 판단
+func isSecretKey(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range secretKeyPatterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted - 모든 민감 정보를 "***"로 치환한 깊은 복사본 반환
+// 외부에 설정을 노출할 때는 항상 이 메서드를 거쳐야 합니다.
+func (c *Config) Redacted() *Config {
+	redacted := redactValue(reflect.ValueOf(*c), "").Interface().(Config)
+	return &redacted
+}
+
+func redactValue(v reflect.Value, name string) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			out.Field(i).Set(redactValue(v.Field(i), field.Tag.Get("mapstructure")))
+		}
+		return out
+	case reflect.String:
+		if v.String() != "" && isSecretKey(name) {
+			return reflect.ValueOf(redactedValue).Convert(v.Type())
+		}
+		return v
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactValue(v.Index(i), name))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), redactValue(iter.Value(), fmt.Sprint(iter.Key().Interface())))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// secretConfigKeys - Config에서 민감 정보에 해당하는 키 목록 ("jwt.secret" 등)
+func secretConfigKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := prefix + field.Tag.Get("mapstructure")
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				if field.Type != reflect.TypeOf(time.Duration(0)) {
+					walk(field.Type, key+".")
+				}
+			case reflect.String:
+				if isSecretKey(field.Tag.Get("mapstructure")) {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+// secretEnvName - 설정 키에 대응하는 환경 변수 이름 ("jwt.secret" → "APP_JWT_SECRET")
+func secretEnvName(key string) string {
+	return "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// checkSecretsFromEnv - release 모드에서 민감 정보가 환경 변수가 아닌
+// 커밋된 설정 파일에서 온 경우 에러를 반환합니다.
+func (cl *ViperConfigLoader) checkSecretsFromEnv() error {
+	var errs []error
+	for _, key := range secretConfigKeys() {
+		if cl.viper.GetString(key) == "" {
+			continue
+		}
+		if _, fromEnv := os.LookupEnv(secretEnvName(key)); !fromEnv {
+			errs = append(errs, fmt.Errorf("%s must be provided via %s, not a config file", key, secretEnvName(key)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // ========================================
 // 설정 로더
 // ========================================
@@ -186,27 +361,44 @@ type ConfigLoader interface {
 	Watch(callback func(*Config))
 	Get(key string) interface{}
 	Set(key string, value interface{})
+
+	// 타입 지정 조회 (마지막으로 검증을 통과한 설정 기준)
+	GetConfig() *Config
+	GetSection(name string) (interface{}, bool)
+	GetString(key string) string
+	GetInt(key string) int
+	GetDuration(key string) time.Duration
 }
 
 // ViperConfigLoader - Viper 기반 설정 로더
 type ViperConfigLoader struct {
-	viper *viper.Viper
-	config *Config
+	viper      *viper.Viper
+	configFile string // 사용 중인 설정 파일 (없으면 빈 문자열)
+	mu         sync.RWMutex
+	config     *Config // 마지막으로 검증을 통과한 설정
 }
 
+// 지원하는 설정 파일 형식 (탐색 우선순위 순)
+var supportedConfigTypes = []string{"yaml", "yml", "json", "toml"}
+
+// 기본 설정 파일 탐색 경로
+var configSearchPaths = []string{"./config", "."}
+
 // NewConfigLoader - 새 설정 로더 생성
+// configPath가 주어지면 확장자로 형식을 판단하고, 없으면 탐색 경로에서
+// config.yaml/config.json/config.toml 중 존재하는 파일을 사용합니다.
 func NewConfigLoader(configPath string) ConfigLoader {
 	v := viper.New()
 
 	// 설정 파일 경로 설정
+	if configPath == "" {
+		configPath = findConfigFile(configSearchPaths, "config")
+	}
 	if configPath != "" {
 		v.SetConfigFile(configPath)
-	} else {
-		// 기본 설정 경로
-		v.SetConfigName("config")
-		v.SetConfigType("yaml")
-		v.AddConfigPath("./config")
-		v.AddConfigPath(".")
+		if ext := configTypeOf(configPath); ext != "" {
+			v.SetConfigType(ext)
+		}
 	}
 
 	// 환경 변수 설정
@@ -214,36 +406,57 @@ func NewConfigLoader(configPath string) ConfigLoader {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// 실행 환경은 README대로 APP_ENV에서 읽음 (AutomaticEnv라면 APP_APP_ENV가 됨)
+	v.BindEnv("app_env", "APP_ENV")
+
+	// 민감 정보는 설정 파일에 없어도 환경 변수에서 읽히도록 바인딩
+	for _, key := range secretConfigKeys() {
+		v.BindEnv(key)
+	}
+
 	// 기본값 설정
 	setDefaults(v)
 
 	return &ViperConfigLoader{
-		viper: v,
+		viper:      v,
+		configFile: configPath,
 	}
 }
 
-// Load - 설정 로드
-func (cl *ViperConfigLoader) Load() (*Config, error) {
-	// 설정 파일 읽기
-	if err := cl.viper.ReadInConfig(); err != nil {
-		// 설정 파일이 없어도 환경변수와 기본값으로 동작
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config: %w", err)
+// configTypeOf - 파일 확장자로 설정 형식 판단 (지원하지 않으면 빈 문자열)
+func configTypeOf(path string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	for _, t := range supportedConfigTypes {
+		if ext == t {
+			return ext
 		}
-		log.Println("Config file not found, using defaults and environment variables")
-	} else {
-		log.Printf("Using config file: %s", cl.viper.ConfigFileUsed())
 	}
+	return ""
+}
 
-	// 환경별 설정 오버라이드
-	env := cl.viper.GetString("app_env")
-	if env != "" {
-		envConfigPath := fmt.Sprintf("config.%s", env)
-		cl.viper.SetConfigName(envConfigPath)
-		if err := cl.viper.MergeInConfig(); err == nil {
-			log.Printf("Merged environment config: %s", envConfigPath)
+// findConfigFile - 탐색 경로에서 name.<yaml|yml|json|toml> 파일을 찾음
+func findConfigFile(dirs []string, name string) string {
+	for _, dir := range dirs {
+		for _, ext := range supportedConfigTypes {
+			path := filepath.Join(dir, name+"."+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
 		}
 	}
+	return ""
+}
+
+// Load - 설정 로드
+func (cl *ViperConfigLoader) Load() (*Config, error) {
+	// 설정 파일 읽기 (파일이 없어도 환경변수와 기본값으로 동작)
+	if cl.configFile == "" {
+		log.Println("Config file not found, using defaults and environment variables")
+	} else if err := cl.readConfigFiles(); err != nil {
+		return nil, err
+	} else {
+		log.Printf("Using config file: %s", cl.configFile)
+	}
 
 	// 구조체로 언마샬
 	var config Config
@@ -256,30 +469,142 @@ func (cl *ViperConfigLoader) Load() (*Config, error) {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
+	// release 모드에서는 민감 정보를 환경 변수로만 받음
+	if config.Server.Mode == "release" {
+		if err := cl.checkSecretsFromEnv(); err != nil {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+	}
+
+	cl.mu.Lock()
 	cl.config = &config
+	cl.mu.Unlock()
+
 	return &config, nil
 }
 
+// readConfigFiles - 기본 설정 파일을 읽고 환경별 설정(config.<env>.<ext>)을 병합
+// 환경별 파일의 형식은 기본 파일과 달라도 됩니다. Load와 재로드가 함께 사용합니다.
+func (cl *ViperConfigLoader) readConfigFiles() error {
+	cl.viper.SetConfigFile(cl.configFile)
+	cl.viper.SetConfigType(configTypeOf(cl.configFile))
+	if err := cl.viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	env := cl.viper.GetString("app_env")
+	if env == "" {
+		return nil
+	}
+	envConfigPath := findConfigFile([]string{filepath.Dir(cl.configFile)}, "config."+env)
+	if envConfigPath == "" {
+		return nil
+	}
+
+	cl.viper.SetConfigFile(envConfigPath)
+	cl.viper.SetConfigType(configTypeOf(envConfigPath))
+	err := cl.viper.MergeInConfig()
+
+	// 다음 ReadInConfig가 기본 설정 파일을 읽도록 복원
+	cl.viper.SetConfigFile(cl.configFile)
+	cl.viper.SetConfigType(configTypeOf(cl.configFile))
+
+	if err != nil {
+		return fmt.Errorf("failed to merge %s: %w", envConfigPath, err)
+	}
+	log.Printf("Merged environment config: %s", envConfigPath)
+	return nil
+}
+
+// isConfigFile - 변경된 파일이 기본 설정 또는 환경별 설정 파일인지 확인
+// 환경별 파일은 Load 이후에 새로 생길 수도 있으므로 이름 규칙으로 판단합니다.
+func (cl *ViperConfigLoader) isConfigFile(name string) bool {
+	name = filepath.Clean(name)
+	if name == filepath.Clean(cl.configFile) {
+		return true
+	}
+	if filepath.Dir(name) != filepath.Dir(filepath.Clean(cl.configFile)) {
+		return false
+	}
+	env := cl.viper.GetString("app_env")
+	if env == "" || configTypeOf(name) == "" {
+		return false
+	}
+	base := filepath.Base(name)
+	return strings.TrimSuffix(base, filepath.Ext(base)) == "config."+env
+}
+
 // Watch - 설정 파일 변경 감시
+// 기본 설정 파일과 환경별 설정 파일을 모두 감시하고, 변경 시 둘을 다시 병합합니다.
+// 변경된 설정이 검증을 통과한 경우에만 callback을 호출하며,
+// 잘못된 수정은 무시하고 이전 설정을 유지합니다.
 func (cl *ViperConfigLoader) Watch(callback func(*Config)) {
-	cl.viper.WatchConfig()
-	cl.viper.OnConfigChange(func(e fsnotify.ConfigChangeEvent) {
-		log.Printf("Config file changed: %s", e.Name)
+	if cl.configFile == "" {
+		log.Println("No config file to watch")
+		return
+	}
 
-		var config Config
-		if err := cl.viper.Unmarshal(&config); err != nil {
-			log.Printf("Failed to reload config: %v", err)
-			return
-		}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Failed to create config watcher: %v", err)
+		return
+	}
+	// 에디터의 rename 저장에도 대응하도록 파일이 아닌 디렉토리를 감시
+	if err := watcher.Add(filepath.Dir(cl.configFile)); err != nil {
+		log.Printf("Failed to watch config directory: %v", err)
+		watcher.Close()
+		return
+	}
 
-		if err := validateConfig(&config); err != nil {
-			log.Printf("Config validation failed after reload: %v", err)
-			return
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) {
+					continue
+				}
+				if !cl.isConfigFile(e.Name) {
+					continue
+				}
+				log.Printf("Config file changed: %s", e.Name)
+				cl.reload(callback)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Config watcher error: %v", err)
+			}
 		}
+	}()
+}
 
-		cl.config = &config
-		callback(&config)
-	})
+// reload - 설정 파일을 다시 읽어 검증을 통과하면 교체하고 callback 호출
+func (cl *ViperConfigLoader) reload(callback func(*Config)) {
+	if err := cl.readConfigFiles(); err != nil {
+		log.Printf("Failed to reload config: %v", err)
+		return
+	}
+
+	var config Config
+	if err := cl.viper.Unmarshal(&config); err != nil {
+		log.Printf("Failed to reload config: %v", err)
+		return
+	}
+
+	if err := validateConfig(&config); err != nil {
+		log.Printf("Config validation failed after reload, keeping previous config: %v", err)
+		return
+	}
+
+	cl.mu.Lock()
+	cl.config = &config
+	cl.mu.Unlock()
+
+	callback(&config)
 }
 
 // Get - 설정 값 가져오기
@@ -287,6 +612,125 @@ func (cl *ViperConfigLoader) Get(key string) interface{} {
 	return cl.viper.Get(key)
 }
 
+// GetConfig - 마지막으로 로드/검증에 성공한 설정 반환
+// 재로드가 실패해도 이전 설정을 그대로 반환합니다.
+func (cl *ViperConfigLoader) GetConfig() *Config {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	return cl.config
+}
+
+// GetSection - "database", "security.cors" 같은 섹션의 하위 구조체 복사본 반환
+func (cl *ViperConfigLoader) GetSection(name string) (interface{}, bool) {
+	field, ok := cl.lookup(name)
+	if !ok {
+		return nil, false
+	}
+	return field.Interface(), true
+}
+
+// GetString - 문자열 설정 값 (없으면 빈 문자열)
+func (cl *ViperConfigLoader) GetString(key string) string {
+	field, ok := cl.lookup(key)
+	if !ok {
+		return cl.viper.GetString(key)
+	}
+	if field.Kind() == reflect.String {
+		return field.String()
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+// GetInt - 정수 설정 값 (변환할 수 없으면 0)
+func (cl *ViperConfigLoader) GetInt(key string) int {
+	field, ok := cl.lookup(key)
+	if !ok {
+		return cl.viper.GetInt(key)
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(field.Int())
+	case reflect.String:
+		n, _ := strconv.Atoi(field.String())
+		return n
+	case reflect.Bool:
+		if field.Bool() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// GetDuration - 시간 설정 값 ("15s" 같은 문자열도 변환)
+func (cl *ViperConfigLoader) GetDuration(key string) time.Duration {
+	field, ok := cl.lookup(key)
+	if !ok {
+		return cl.viper.GetDuration(key)
+	}
+	switch v := field.Interface().(type) {
+	case time.Duration:
+		return v
+	case string:
+		d, _ := time.ParseDuration(v)
+		return d
+	}
+	if field.CanInt() {
+		return time.Duration(field.Int())
+	}
+	return 0
+}
+
+// lookup - mapstructure 태그를 따라 "server.port" 같은 키를 마지막 정상 설정에서 찾음
+func (cl *ViperConfigLoader) lookup(key string) (reflect.Value, bool) {
+	config := cl.GetConfig()
+	if config == nil || key == "" {
+		return reflect.Value{}, false
+	}
+
+	current := reflect.ValueOf(*config)
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		switch current.Kind() {
+		case reflect.Struct:
+			found := false
+			for i := 0; i < current.NumField(); i++ {
+				if current.Type().Field(i).Tag.Get("mapstructure") == part {
+					current = current.Field(i)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return reflect.Value{}, false
+			}
+		case reflect.Map:
+			value := current.MapIndex(reflect.ValueOf(part))
+			if !value.IsValid() {
+				return reflect.Value{}, false
+			}
+			current = value
+		default:
+			return reflect.Value{}, false
+		}
+	}
+
+	return current, true
+}
+
+// Section - GetSection의 제네릭 버전
+// 예: db, err := Section[DatabaseConfig](loader, "database")
+func Section[T any](loader ConfigLoader, name string) (T, error) {
+	var zero T
+	section, ok := loader.GetSection(name)
+	if !ok {
+		return zero, fmt.Errorf("config section not found: %s", name)
+	}
+	typed, ok := section.(T)
+	if !ok {
+		return zero, fmt.Errorf("config section %s is %T, not %T", name, section, zero)
+	}
+	return typed, nil
+}
+
 // Set - 설정 값 설정
 func (cl *ViperConfigLoader) Set(key string, value interface{}) {
 	cl.viper.Set(key, value)
@@ -326,7 +770,7 @@ func setDefaults(v *viper.Viper) {
 	// JWT defaults
 	v.SetDefault("jwt.signing_algorithm", "HS256")
 	v.SetDefault("jwt.access_expiry", "15m")
-	v.SetDefault("jwt.refresh_expiry", "7d")
+	v.SetDefault("jwt.refresh_expiry", "168h") // 7일 (time.Duration은 "d" 단위 미지원)
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
@@ -352,37 +796,190 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("features.beta_features", false)
 	v.SetDefault("features.maintenance_mode", false)
 	v.SetDefault("features.enable_metrics", true)
+
+	// Feature store defaults
+	v.SetDefault("feature_store.path", "features.db")
+	v.SetDefault("feature_store.refresh_interval", "30s")
 }
 
 // validateConfig - 설정 검증
+// 첫 번째 오류에서 멈추지 않고 모든 문제를 모아 하나의 에러로 반환합니다.
 func validateConfig(config *Config) error {
-	// 필수 설정 검증
+	var errs []error
+
+	// 서버 설정 검증
 	if config.Server.Port <= 0 || config.Server.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", config.Server.Port)
+		errs = append(errs, fmt.Errorf("invalid server port: %d", config.Server.Port))
 	}
 
-	if config.Database.Driver != "" && config.Database.Host == "" {
-		return fmt.Errorf("database host is required when driver is set")
+	if !contains([]string{"debug", "release", "test"}, config.Server.Mode) {
+		errs = append(errs, fmt.Errorf("invalid server mode: %s", config.Server.Mode))
 	}
 
 	if config.JWT.Secret == "" && config.Server.Mode == "release" {
-		return fmt.Errorf("JWT secret is required in release mode")
+		errs = append(errs, fmt.Errorf("JWT secret is required in release mode"))
+	}
+
+	// 데이터베이스 설정 검증
+	if config.Database.Driver != "" && config.Database.Host == "" {
+		errs = append(errs, fmt.Errorf("database host is required when driver is set"))
+	}
+
+	if config.Database.MaxOpenConns > 0 && config.Database.MaxIdleConns > config.Database.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("database max_idle_conns (%d) must not exceed max_open_conns (%d)",
+			config.Database.MaxIdleConns, config.Database.MaxOpenConns))
+	}
+
+	// Redis 설정 검증
+	if config.Redis.Host != "" && (config.Redis.Port <= 0 || config.Redis.Port > 65535) {
+		errs = append(errs, fmt.Errorf("invalid redis port: %d", config.Redis.Port))
+	}
+
+	// 로깅 설정 검증
+	if !contains([]string{"debug", "info", "warn", "error"}, config.Logging.Level) {
+		errs = append(errs, fmt.Errorf("invalid logging level: %q", config.Logging.Level))
 	}
 
-	// 모드 검증
-	validModes := []string{"debug", "release", "test"}
-	validMode := false
-	for _, mode := range validModes {
-		if config.Server.Mode == mode {
-			validMode = true
-			break
+	// 스토리지 설정 검증 (type에 맞는 하위 설정 필요)
+	switch config.Storage.Type {
+	case "":
+		// 스토리지 미사용
+	case "local":
+		if config.Storage.LocalPath == "" {
+			errs = append(errs, fmt.Errorf("storage.local_path is required when storage type is local"))
 		}
+	case "s3":
+		if config.Storage.S3.Bucket == "" {
+			errs = append(errs, fmt.Errorf("storage.s3.bucket is required when storage type is s3"))
+		}
+		if config.Storage.S3.Region == "" {
+			errs = append(errs, fmt.Errorf("storage.s3.region is required when storage type is s3"))
+		}
+	case "gcs":
+		if config.Storage.GCS.Bucket == "" {
+			errs = append(errs, fmt.Errorf("storage.gcs.bucket is required when storage type is gcs"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid storage type: %q (must be local, s3 or gcs)", config.Storage.Type))
 	}
-	if !validMode {
-		return fmt.Errorf("invalid server mode: %s", config.Server.Mode)
+
+	// CORS 설정 검증 (자격 증명과 와일드카드 출처는 함께 쓸 수 없음)
+	if config.Security.CORS.Enabled && config.Security.CORS.AllowCredentials &&
+		contains(config.Security.CORS.AllowOrigins, "*") {
+		errs = append(errs, fmt.Errorf("security.cors.allow_origins must list explicit origins when allow_credentials is true"))
 	}
 
-	return nil
+	// 기능 플래그 저장소 검증
+	if config.FeatureStore.Path != "" && config.FeatureStore.RefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("feature_store.refresh_interval must be positive: %s", config.FeatureStore.RefreshInterval))
+	}
+
+	// 라우트별 타임아웃 검증
+	for i, route := range config.Routes {
+		if !strings.HasPrefix(route.Prefix, "/") {
+			errs = append(errs, fmt.Errorf("routes[%d].prefix must start with \"/\": %q", i, route.Prefix))
+		}
+		if route.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("routes[%d].timeout must be positive: %s", i, route.Timeout))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// contains - 문자열 슬라이스 포함 여부
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ========================================
+// 라우트별 타임아웃 미들웨어
+// ========================================
+
+// RouteTimeoutMiddleware - 요청 경로에 맞는 타임아웃을 요청 context의 deadline으로 설정
+// 핸들러가 c.Request.Context()를 넘겨 deadline을 지키고, 응답 전에 만료되면 504 응답
+// CORSMiddleware처럼 요청마다 최신 설정을 읽으므로 routes도 핫 리로드됩니다.
+func RouteTimeoutMiddleware(current func() *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout.New(current().RouteTimeout(c.Request.URL.Path))(c)
+	}
+}
+
+// ========================================
+// CORS 미들웨어
+// ========================================
+
+// CORSMiddleware - 요청마다 최신 CORSConfig를 읽어 Access-Control-* 헤더를 설정
+// 설정이 핫 리로드되어도 미들웨어를 다시 등록할 필요가 없도록 getter를 받습니다.
+func CORSMiddleware(current func() CORSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := current()
+		origin := c.GetHeader("Origin")
+		if !cfg.Enabled || origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+
+		wildcard, allowed := corsOriginAllowed(cfg, origin)
+		if !allowed {
+			// 자격 증명을 허용하는 경우 허용되지 않은 출처의 요청은 처리하지 않음
+			if preflight || cfg.AllowCredentials {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "origin not allowed"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if wildcard {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if len(cfg.ExposeHeaders) > 0 {
+			c.Header("Access-Control-Expose-Headers", strings.Join(cfg.ExposeHeaders, ", "))
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", strings.Join(cfg.AllowMethods, ", "))
+			c.Header("Access-Control-Allow-Headers", strings.Join(cfg.AllowHeaders, ", "))
+			if cfg.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// corsOriginAllowed - origin 허용 여부와 "*"로 응답해도 되는지 반환
+// 자격 증명을 허용하면 "*"는 무시하고 명시된 출처만 허용 (브라우저도 이 조합을 거부함)
+func corsOriginAllowed(cfg CORSConfig, origin string) (wildcard, allowed bool) {
+	for _, o := range cfg.AllowOrigins {
+		if o == "*" {
+			if !cfg.AllowCredentials {
+				return true, true
+			}
+			continue
+		}
+		if strings.EqualFold(o, origin) {
+			return false, true
+		}
+	}
+	return false, false
 }
 
 // GetDatabaseDSN - 데이터베이스 연결 문자열 생성
@@ -399,10 +996,295 @@ func GetDatabaseDSN(config *DatabaseConfig) string {
 	}
 }
 
+// ========================================
+// 기능 플래그 저장소
+// ========================================
+
+// 기능 플래그 이름 (FeatureFlags의 mapstructure 태그와 같음)
+const (
+	FeatureNewDashboard    = "new_dashboard"
+	FeatureBetaFeatures    = "beta_features"
+	FeatureMaintenanceMode = "maintenance_mode"
+	FeatureDebugMode       = "debug_mode"
+	FeatureEnableMetrics   = "enable_metrics"
+	FeatureEnableProfiling = "enable_profiling"
+)
+
+// ErrUnknownFeature - FeatureFlags에 없는 플래그 이름
+var ErrUnknownFeature = errors.New("unknown feature flag")
+
+// Map - 플래그 이름별 값 (저장소의 초기값으로 사용)
+func (f FeatureFlags) Map() map[string]bool {
+	return map[string]bool{
+		FeatureNewDashboard:    f.NewDashboard,
+		FeatureBetaFeatures:    f.BetaFeatures,
+		FeatureMaintenanceMode: f.MaintenanceMode,
+		FeatureDebugMode:       f.DebugMode,
+		FeatureEnableMetrics:   f.EnableMetrics,
+		FeatureEnableProfiling: f.EnableProfiling,
+	}
+}
+
+// FeatureFlag - feature_flags 테이블의 한 행
+type FeatureFlag struct {
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FeatureService - DB에 저장된 기능 플래그를 메모리에 캐시
+// 요청 경로에서는 캐시만 읽고, Set은 DB와 캐시를 함께 바꾸며,
+// 다른 인스턴스에서 바꾼 값은 Start의 주기적인 Refresh로 반영됩니다.
+type FeatureService struct {
+	db *gorm.DB
+
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// NewFeatureService - 테이블을 만들고 없는 플래그만 defaults로 채운 뒤 캐시를 로드
+// 이미 저장된 값은 덮어쓰지 않으므로 재배포해도 런타임에 바꾼 값이 유지됩니다.
+func NewFeatureService(db *gorm.DB, defaults FeatureFlags) (*FeatureService, error) {
+	if err := db.AutoMigrate(&FeatureFlag{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate feature_flags: %w", err)
+	}
+
+	var rows []FeatureFlag
+	for name, enabled := range defaults.Map() {
+		rows = append(rows, FeatureFlag{Name: name, Enabled: enabled})
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to seed feature_flags: %w", err)
+	}
+
+	s := &FeatureService{db: db}
+	if err := s.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Enabled - 캐시된 플래그 값 (모르는 플래그는 꺼진 것으로 취급)
+func (s *FeatureService) Enabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.flags[name]
+}
+
+// All - 캐시된 모든 플래그의 복사본
+func (s *FeatureService) All() map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make(map[string]bool, len(s.flags))
+	for name, enabled := range s.flags {
+		flags[name] = enabled
+	}
+	return flags
+}
+
+// Get - DB에 저장된 플래그 (수정 시각 포함)
+func (s *FeatureService) Get(ctx context.Context, name string) (*FeatureFlag, error) {
+	var flag FeatureFlag
+	err := s.db.WithContext(ctx).First(&flag, "name = ?", name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFeature, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List - DB에 저장된 모든 플래그 (이름순)
+func (s *FeatureService) List(ctx context.Context) ([]FeatureFlag, error) {
+	var flags []FeatureFlag
+	err := s.db.WithContext(ctx).Order("name").Find(&flags).Error
+	return flags, err
+}
+
+// Set - 플래그를 저장하고 이 인스턴스의 캐시에 즉시 반영
+func (s *FeatureService) Set(ctx context.Context, name string, enabled bool) (*FeatureFlag, error) {
+	if _, ok := (FeatureFlags{}).Map()[name]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFeature, name)
+	}
+
+	flag := &FeatureFlag{Name: name, Enabled: enabled}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(flag).Error
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.flags[name] = enabled
+	s.mu.Unlock()
+	return flag, nil
+}
+
+// Refresh - DB의 값으로 캐시를 교체
+func (s *FeatureService) Refresh(ctx context.Context) error {
+	var rows []FeatureFlag
+	if err := s.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load feature_flags: %w", err)
+	}
+
+	flags := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		flags[row.Name] = row.Enabled
+	}
+
+	s.mu.Lock()
+	s.flags = flags
+	s.mu.Unlock()
+	return nil
+}
+
+// Start - interval마다 Refresh를 실행하는 고루틴 시작 (ctx가 취소되면 종료)
+// 실패하면 마지막으로 읽은 값을 그대로 사용합니다.
+func (s *FeatureService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("Feature flag refresh failed: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// OpenFeatureStore - 기능 플래그용 SQLite 데이터베이스 열기
+func OpenFeatureStore(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+}
+
+// maintenanceExempt - 유지보수 모드에서도 열어 두는 경로
+// 헬스 체크와, 유지보수 모드를 다시 끌 수 있도록 기능 플래그 관리 API
+var maintenanceExempt = []string{"/api/health", "/api/admin/features"}
+
+// MaintenanceMiddleware - maintenance_mode 플래그가 켜져 있으면 503 응답
+// CORSMiddleware처럼 요청마다 플래그를 읽으므로 재시작 없이 켜고 끌 수 있습니다.
+func MaintenanceMiddleware(features *FeatureService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !features.Enabled(FeatureMaintenanceMode) {
+			c.Next()
+			return
+		}
+		for _, prefix := range maintenanceExempt {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Service is under maintenance",
+			"message": "We'll be back soon!",
+		})
+		c.Abort()
+	}
+}
+
+// DashboardHandler - new_dashboard 플래그가 꺼져 있으면 404
+func DashboardHandler(features *FeatureService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !features.Enabled(FeatureNewDashboard) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "New dashboard is not enabled",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "New dashboard is available",
+			"version": "2.0",
+		})
+	}
+}
+
+// AdminTokenMiddleware - Authorization: Bearer <security.admin_token>을 요구
+// CORSMiddleware처럼 요청마다 최신 토큰을 읽고, 토큰이 설정되지 않았으면 모든 요청을 거절합니다.
+func AdminTokenMiddleware(current func() string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		want := current()
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if want == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RegisterFeatureAdminRoutes - 기능 플래그 조회/토글 API
+// auth에는 AdminTokenMiddleware처럼 관리자만 통과시키는 미들웨어를 넘김
+// (유지보수 모드에서도 열려 있으므로 인증 없이 등록하면 누구나 서비스를 멈출 수 있음)
+func RegisterFeatureAdminRoutes(r gin.IRouter, features *FeatureService, auth gin.HandlerFunc) {
+	admin := r.Group("/api/admin/features", auth)
+
+	admin.GET("", func(c *gin.Context) {
+		flags, err := features.List(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"features": flags})
+	})
+
+	admin.GET("/:name", func(c *gin.Context) {
+		flag, err := features.Get(c.Request.Context(), c.Param("name"))
+		if err != nil {
+			c.JSON(featureErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, flag)
+	})
+
+	admin.PUT("/:name", func(c *gin.Context) {
+		var req struct {
+			Enabled *bool `json:"enabled" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		flag, err := features.Set(c.Request.Context(), c.Param("name"), *req.Enabled)
+		if err != nil {
+			c.JSON(featureErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Feature flag %s set to %t", flag.Name, flag.Enabled)
+		c.JSON(http.StatusOK, flag)
+	})
+}
+
+func featureErrorStatus(err error) int {
+	if errors.Is(err, ErrUnknownFeature) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
 // ========================================
 // 메인 함수 및 데모
 // ========================================
 
+// writeTimeoutMargin - 라우트 타임아웃 이후 504 응답을 쓰는 데 주는 여유 시간
+const writeTimeoutMargin = time.Second
+
 func main() {
 	// 설정 파일 경로 (명령행 인자나 환경변수로 받을 수 있음)
 	configPath := os.Getenv("CONFIG_FILE")
@@ -419,25 +1301,51 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// 핸들러들은 항상 currentConfig에서 최신 설정을 읽음
+	var currentConfig atomic.Pointer[Config]
+	currentConfig.Store(config)
+
 	// 설정 변경 감시 (옵션)
 	configLoader.Watch(func(newConfig *Config) {
+		currentConfig.Store(newConfig)
 		log.Println("Configuration reloaded")
-		// 여기서 필요한 재설정 작업 수행
 	})
 
+	// 기능 플래그 저장소 (features 설정은 비어 있는 테이블의 초기값)
+	featureDB, err := OpenFeatureStore(config.FeatureStore.Path)
+	if err != nil {
+		log.Fatalf("Failed to open feature store: %v", err)
+	}
+	features, err := NewFeatureService(featureDB, config.Features)
+	if err != nil {
+		log.Fatalf("Failed to load feature flags: %v", err)
+	}
+	features.Start(context.Background(), config.FeatureStore.RefreshInterval)
+
 	// Gin 모드 설정
 	gin.SetMode(config.Server.Mode)
 
 	// Gin 라우터 생성
 	r := gin.Default()
 
+	// CORS는 라우트 등록 전에 붙여야 모든 라우트와 OPTIONS preflight에 적용됨
+	// security.cors.enabled가 false면 헤더를 설정하지 않고 통과
+	r.Use(CORSMiddleware(func() CORSConfig {
+		return currentConfig.Load().Security.CORS
+	}))
+
+	// routes 설정의 경로별 타임아웃 (일치하지 않으면 server.write_timeout)
+	r.Use(RouteTimeoutMiddleware(currentConfig.Load))
+
 	// ========================================
 	// 설정 정보 엔드포인트
 	// ========================================
 
 	// 1. 현재 설정 조회 (민감한 정보 제외)
 	r.GET("/api/config", func(c *gin.Context) {
-		// 민감한 정보 제거
+		config := currentConfig.Load().Redacted()
+
+		// 노출할 필드만 골라 응답 형태를 고정
 		safeConfig := map[string]interface{}{
 			"server": map[string]interface{}{
 				"host": config.Server.Host,
@@ -471,11 +1379,9 @@ func main() {
 			if strings.HasPrefix(env, "APP_") {
 				parts := strings.SplitN(env, "=", 2)
 				if len(parts) == 2 {
-					// 민감한 정보 마스킹
-					if strings.Contains(strings.ToLower(parts[0]), "password") ||
-						strings.Contains(strings.ToLower(parts[0]), "secret") ||
-						strings.Contains(strings.ToLower(parts[0]), "key") {
-						envVars[parts[0]] = "***MASKED***"
+					// 민감한 정보 마스킹 (Config.Redacted와 같은 규칙)
+					if isSecretKey(parts[0]) {
+						envVars[parts[0]] = redactedValue
 					} else {
 						envVars[parts[0]] = parts[1]
 					}
@@ -488,31 +1394,19 @@ func main() {
 		})
 	})
 
-	// 3. 기능 플래그 확인
+	// 3. 기능 플래그 확인 (런타임에 토글한 값)
 	r.GET("/api/features", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"features": config.Features,
+			"features": features.All(),
 		})
 	})
 
 	// 4. 기능 플래그별 엔드포인트
-	r.GET("/api/dashboard", func(c *gin.Context) {
-		if !config.Features.NewDashboard {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "New dashboard is not enabled",
-			})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"message": "New dashboard is available",
-			"version": "2.0",
-		})
-	})
+	r.GET("/api/dashboard", DashboardHandler(features))
 
 	// 5. 베타 기능
 	r.GET("/api/beta", func(c *gin.Context) {
-		if !config.Features.BetaFeatures {
+		if !features.Enabled(FeatureBetaFeatures) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"error": "Beta features are not enabled",
 			})
@@ -525,21 +1419,13 @@ func main() {
 		})
 	})
 
-	// 6. 유지보수 모드
-	r.Use(func(c *gin.Context) {
-		if config.Features.MaintenanceMode && !strings.HasPrefix(c.Request.URL.Path, "/api/health") {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":   "Service is under maintenance",
-				"message": "We'll be back soon!",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	})
+	// 6. 유지보수 모드 (maintenance_mode 플래그, 이후에 등록한 라우트에 적용)
+	r.Use(MaintenanceMiddleware(features))
 
 	// 7. 헬스 체크
 	r.GET("/api/health", func(c *gin.Context) {
+		config := currentConfig.Load()
+
 		health := gin.H{
 			"status": "healthy",
 			"server": gin.H{
@@ -579,7 +1465,7 @@ func main() {
 			return
 		}
 
-		config = newConfig
+		currentConfig.Store(newConfig)
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Configuration reloaded successfully",
 		})
@@ -606,8 +1492,15 @@ func main() {
 		})
 	})
 
-	// 10. 환경별 응답
+	// 10. 기능 플래그 런타임 토글
+	RegisterFeatureAdminRoutes(r, features, AdminTokenMiddleware(func() string {
+		return currentConfig.Load().Security.AdminToken
+	}))
+
+	// 11. 환경별 응답
 	r.GET("/api/info", func(c *gin.Context) {
+		config := currentConfig.Load()
+
 		info := gin.H{
 			"environment": os.Getenv("APP_ENV"),
 			"version":     os.Getenv("APP_VERSION"),
@@ -615,7 +1508,7 @@ func main() {
 		}
 
 		// 디버그 모드에서만 상세 정보 표시
-		if config.Server.Mode == "debug" || config.Features.DebugMode {
+		if config.Server.Mode == "debug" || features.Enabled(FeatureDebugMode) {
 			info["detailed"] = gin.H{
 				"go_version":  runtime.Version(),
 				"num_cpu":     runtime.NumCPU(),
@@ -626,6 +1519,17 @@ func main() {
 		c.JSON(http.StatusOK, info)
 	})
 
+	// 12. 오래 걸리는 배치 작업 (routes 설정으로 전역보다 긴 타임아웃 적용)
+	r.POST("/api/batch", func(c *gin.Context) {
+		select {
+		case <-time.After(5 * time.Second):
+			c.JSON(http.StatusOK, gin.H{"message": "Batch completed"})
+		case <-c.Request.Context().Done():
+			// 응답은 타임아웃 미들웨어가 504로 작성
+			c.Error(c.Request.Context().Err())
+		}
+	})
+
 	// 서버 시작
 	addr := fmt.Sprintf("%s:%d", config.Server.Host, config.Server.Port)
 
@@ -640,13 +1544,17 @@ func main() {
 	fmt.Println("  GET /api/features  - View feature flags")
 	fmt.Println("  GET /api/health    - Health check")
 	fmt.Println("  GET /api/info      - Server information")
+	fmt.Println("  POST /api/batch    - Long-running batch (per-route timeout)")
+	fmt.Println("  PUT /api/admin/features/:name - Toggle a feature flag at runtime")
 
 	// 타임아웃 설정이 있는 서버 생성
+	// WriteTimeout은 가장 긴 라우트 타임아웃이 끝난 뒤에도 504 응답을 쓸 수 있도록 여유를 둠
+	// (핫 리로드로 더 긴 route를 추가하면 재시작해야 서버 WriteTimeout에 반영됨)
 	srv := &http.Server{
 		Addr:           addr,
 		Handler:        r,
 		ReadTimeout:    config.Server.ReadTimeout,
-		WriteTimeout:   config.Server.WriteTimeout,
+		WriteTimeout:   config.MaxRequestTimeout() + writeTimeoutMargin,
 		MaxHeaderBytes: config.Server.MaxHeaderBytes,
 	}
 
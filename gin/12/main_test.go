@@ -0,0 +1,788 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// writeConfigFile은 임시 파일에 쓴 뒤 rename하여 watcher가 반쯤 쓰인 파일을 읽지 않도록 합니다.
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to replace config: %v", err)
+	}
+}
+
+func TestWatchReloadsOnlyValidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, "server:\n  port: 8080\n  mode: debug\n")
+
+	loader := NewConfigLoader(path)
+	config, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Server.Port != 8080 {
+		t.Fatalf("expected port 8080, got %d", config.Server.Port)
+	}
+
+	reloaded := make(chan *Config, 10)
+	loader.Watch(func(c *Config) { reloaded <- c })
+
+	// 유효한 변경 → callback 호출
+	writeConfigFile(t, path, "server:\n  port: 9090\n  mode: debug\n")
+	select {
+	case c := <-reloaded:
+		if c.Server.Port != 9090 {
+			t.Errorf("expected reloaded port 9090, got %d", c.Server.Port)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("callback was not invoked after valid edit")
+	}
+
+	// 같은 변경에 대한 중복 이벤트 제거
+	time.Sleep(200 * time.Millisecond)
+	for len(reloaded) > 0 {
+		<-reloaded
+	}
+
+	// 잘못된 변경 → callback 미호출, 이전 설정 유지
+	writeConfigFile(t, path, "server:\n  port: 9090\n  mode: bogus\n")
+	select {
+	case c := <-reloaded:
+		t.Fatalf("callback should not fire for invalid config, got mode %q", c.Server.Mode)
+	case <-time.After(time.Second):
+	}
+
+	vl := loader.(*ViperConfigLoader)
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+	if vl.config.Server.Mode != "debug" || vl.config.Server.Port != 9090 {
+		t.Errorf("expected last valid config to be kept, got %+v", vl.config.Server)
+	}
+}
+
+func TestWatchKeepsEnvironmentOverlayOnReload(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	overlay := filepath.Join(dir, "config.production.yaml")
+	writeConfigFile(t, path, "server:\n  port: 8080\n  mode: debug\n")
+	writeConfigFile(t, overlay, "server:\n  mode: test\n")
+
+	loader := NewConfigLoader(path)
+	config, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Server.Mode != "test" {
+		t.Fatalf("expected overlay mode test, got %q", config.Server.Mode)
+	}
+
+	reloaded := make(chan *Config, 10)
+	loader.Watch(func(c *Config) { reloaded <- c })
+
+	// 기본 파일 변경 → 오버레이가 다시 병합되어야 함
+	writeConfigFile(t, path, "server:\n  port: 9090\n  mode: debug\n")
+	select {
+	case c := <-reloaded:
+		if c.Server.Port != 9090 || c.Server.Mode != "test" {
+			t.Errorf("expected port 9090 with overlay mode test, got %+v", c.Server)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("callback was not invoked after base edit")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	for len(reloaded) > 0 {
+		<-reloaded
+	}
+
+	// 오버레이 파일 변경도 감시됨
+	writeConfigFile(t, overlay, "server:\n  port: 7070\n")
+	select {
+	case c := <-reloaded:
+		if c.Server.Port != 7070 || c.Server.Mode != "debug" {
+			t.Errorf("expected overlay port 7070 with base mode debug, got %+v", c.Server)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("callback was not invoked after overlay edit")
+	}
+}
+
+const jsonConfig = `{
+  "server": {"port": 9000, "mode": "test", "read_timeout": "20s"},
+  "database": {"driver": "postgres", "host": "db.internal", "max_open_conns": 40, "max_idle_conns": 10},
+  "security": {"cors": {"allow_origins": ["https://a.example.com", "https://b.example.com"]}},
+  "external": {"payment_gateway": {"base_url": "https://pay.example.com", "timeout": "45s", "headers": {"x-api-version": "1"}}},
+  "features": {"new_dashboard": true}
+}`
+
+const tomlConfig = `
+[server]
+port = 9000
+mode = "test"
+read_timeout = "20s"
+
+[database]
+driver = "postgres"
+host = "db.internal"
+max_open_conns = 40
+max_idle_conns = 10
+
+[security.cors]
+allow_origins = ["https://a.example.com", "https://b.example.com"]
+
+[external.payment_gateway]
+base_url = "https://pay.example.com"
+timeout = "45s"
+
+[external.payment_gateway.headers]
+x-api-version = "1"
+
+[features]
+new_dashboard = true
+`
+
+func loadConfigFile(t *testing.T, name, content string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	writeConfigFile(t, path, content)
+
+	config, err := NewConfigLoader(path).Load()
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", name, err)
+	}
+	return config
+}
+
+func TestLoadJSONAndTOMLProduceSameConfig(t *testing.T) {
+	fromJSON := loadConfigFile(t, "config.json", jsonConfig)
+	fromTOML := loadConfigFile(t, "config.toml", tomlConfig)
+
+	if !reflect.DeepEqual(fromJSON, fromTOML) {
+		t.Errorf("configs differ:\njson: %+v\ntoml: %+v", fromJSON, fromTOML)
+	}
+	if fromJSON.Server.Port != 9000 || fromJSON.Server.ReadTimeout != 20*time.Second {
+		t.Errorf("unexpected server config: %+v", fromJSON.Server)
+	}
+}
+
+func TestEnvOverridesApplyToAllFormats(t *testing.T) {
+	t.Setenv("APP_SERVER_PORT", "7070")
+
+	for name, content := range map[string]string{"config.json": jsonConfig, "config.toml": tomlConfig} {
+		if port := loadConfigFile(t, name, content).Server.Port; port != 7070 {
+			t.Errorf("%s: expected env override 7070, got %d", name, port)
+		}
+	}
+}
+
+func TestConfigFileAutoDetection(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFile(t, filepath.Join(dir, "config", "config.toml"), tomlConfig)
+	t.Chdir(dir)
+
+	loader := NewConfigLoader("").(*ViperConfigLoader)
+	if filepath.Base(loader.configFile) != "config.toml" {
+		t.Fatalf("expected config.toml to be detected, got %q", loader.configFile)
+	}
+
+	config, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Database.Host != "db.internal" {
+		t.Errorf("expected database host from toml, got %q", config.Database.Host)
+	}
+}
+
+func validTestConfig() Config {
+	return Config{
+		Server:   ServerConfig{Port: 8080, Mode: "debug"},
+		Database: DatabaseConfig{Driver: "postgres", Host: "localhost", MaxOpenConns: 25, MaxIdleConns: 10},
+		Redis:    RedisConfig{Host: "localhost", Port: 6379},
+		Logging:  LoggingConfig{Level: "info"},
+		Storage:  StorageConfig{Type: "local", LocalPath: "./uploads"},
+	}
+}
+
+func TestValidateConfigRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"redis port out of range", func(c *Config) { c.Redis.Port = 70000 }, "invalid redis port"},
+		{"redis port ignored without host", func(c *Config) { c.Redis.Host = ""; c.Redis.Port = 0 }, ""},
+		{"idle exceeds open", func(c *Config) { c.Database.MaxIdleConns = 50 }, "max_idle_conns (50) must not exceed max_open_conns (25)"},
+		{"unknown log level", func(c *Config) { c.Logging.Level = "verbose" }, "invalid logging level"},
+		{"unknown storage type", func(c *Config) { c.Storage.Type = "ftp" }, "invalid storage type"},
+		{"local without path", func(c *Config) { c.Storage.LocalPath = "" }, "storage.local_path is required"},
+		{"s3 without bucket", func(c *Config) { c.Storage = StorageConfig{Type: "s3", S3: S3Config{Region: "us-west-2"}} }, "storage.s3.bucket is required"},
+		{"s3 complete", func(c *Config) { c.Storage = StorageConfig{Type: "s3", S3: S3Config{Region: "us-west-2", Bucket: "b"}} }, ""},
+		{"gcs without bucket", func(c *Config) { c.Storage = StorageConfig{Type: "gcs"} }, "storage.gcs.bucket is required"},
+		{"cors wildcard with credentials", func(c *Config) {
+			c.Security.CORS = CORSConfig{Enabled: true, AllowOrigins: []string{"*"}, AllowCredentials: true}
+		}, "must list explicit origins"},
+		{"route without leading slash", func(c *Config) { c.Routes = []RouteConfig{{Prefix: "api/batch", Timeout: time.Minute}} }, "routes[0].prefix must start with"},
+		{"route without timeout", func(c *Config) { c.Routes = []RouteConfig{{Prefix: "/api/batch"}} }, "routes[0].timeout must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validTestConfig()
+			tt.mutate(&config)
+
+			err := validateConfig(&config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigAggregatesErrors(t *testing.T) {
+	config := validTestConfig()
+	config.Server.Port = 0
+	config.Redis.Port = -1
+	config.Logging.Level = "loud"
+	config.Storage = StorageConfig{Type: "s3"}
+
+	err := validateConfig(&config)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	for _, want := range []string{"invalid server port", "invalid redis port", "invalid logging level", "storage.s3.bucket", "storage.s3.region"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected aggregated error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, jsonConfig)
+
+	loader := NewConfigLoader(path)
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if got := loader.GetInt("server.port"); got != 9000 {
+		t.Errorf("GetInt(server.port) = %d", got)
+	}
+	if got := loader.GetString("server.port"); got != "9000" {
+		t.Errorf("GetString(server.port) = %q", got)
+	}
+	if got := loader.GetString("database.host"); got != "db.internal" {
+		t.Errorf("GetString(database.host) = %q", got)
+	}
+	if got := loader.GetDuration("external.payment_gateway.timeout"); got != 45*time.Second {
+		t.Errorf("GetDuration(external.payment_gateway.timeout) = %v", got)
+	}
+	if got := loader.GetString("external.payment_gateway.headers.x-api-version"); got != "1" {
+		t.Errorf("GetString(headers) = %q", got)
+	}
+	if got := loader.GetInt("features.new_dashboard"); got != 1 {
+		t.Errorf("GetInt(bool) = %d", got)
+	}
+	if got := loader.GetString("server.unknown"); got != "" {
+		t.Errorf("expected empty string for unknown key, got %q", got)
+	}
+
+	db, err := Section[DatabaseConfig](loader, "database")
+	if err != nil || db.MaxOpenConns != 40 {
+		t.Errorf("Section[DatabaseConfig] = %+v, %v", db, err)
+	}
+	cors, err := Section[CORSConfig](loader, "security.cors")
+	if err != nil || len(cors.AllowOrigins) != 2 {
+		t.Errorf("Section[CORSConfig] = %+v, %v", cors, err)
+	}
+	if _, err := Section[RedisConfig](loader, "database"); err == nil {
+		t.Error("expected type mismatch error")
+	}
+}
+
+func TestGettersKeepLastGoodConfigAfterFailedReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, "server:\n  port: 8081\n  mode: debug\n")
+
+	loader := NewConfigLoader(path)
+	good, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	writeConfigFile(t, path, "server:\n  port: 99999\n  mode: debug\n")
+	if _, err := loader.Load(); err == nil {
+		t.Fatal("expected reload to fail validation")
+	}
+
+	if loader.GetConfig() != good {
+		t.Error("GetConfig should return the last good config after a failed reload")
+	}
+	if got := loader.GetInt("server.port"); got != 8081 {
+		t.Errorf("expected last good port 8081, got %d", got)
+	}
+}
+
+func TestRedactedNeverContainsSecrets(t *testing.T) {
+	secrets := []string{"db-pass-1", "redis-pass-2", "jwt-secret-3", "smtp-pass-4", "AKIA5", "s3-secret-6", "sk_live_7", "Bearer tok-8"}
+
+	config := validTestConfig()
+	config.Database.Password = secrets[0]
+	config.Redis.Password = secrets[1]
+	config.JWT.Secret = secrets[2]
+	config.Email.SMTP.Password = secrets[3]
+	config.Storage.S3.AccessKeyID = secrets[4]
+	config.Storage.S3.SecretAccessKey = secrets[5]
+	config.External.PaymentGateway.APIKey = secrets[6]
+	config.External.PaymentGateway.Headers = map[string]string{"Authorization": secrets[7], "X-Version": "1"}
+
+	redacted := config.Redacted()
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("redacted config leaks %q", secret)
+		}
+	}
+
+	if redacted.Database.Host != config.Database.Host || redacted.External.PaymentGateway.Headers["X-Version"] != "1" {
+		t.Error("non-secret values should be preserved")
+	}
+
+	// 깊은 복사: 원본은 그대로 유지
+	if config.JWT.Secret != secrets[2] || config.External.PaymentGateway.Headers["Authorization"] != secrets[7] {
+		t.Error("Redacted must not modify the original config")
+	}
+}
+
+func TestIsSecretKeyMatchesAnyKeyName(t *testing.T) {
+	secret := []string{"APP_SIGNING_KEY", "APP_ENCRYPTION_KEY", "APP_JWT_SECRET", "APP_DATABASE_PASSWORD", "api_key", "Authorization"}
+	for _, name := range secret {
+		if !isSecretKey(name) {
+			t.Errorf("expected %q to be treated as secret", name)
+		}
+	}
+	for _, name := range []string{"APP_SERVER_PORT", "APP_LOGGING_LEVEL", "host"} {
+		if isSecretKey(name) {
+			t.Errorf("expected %q not to be treated as secret", name)
+		}
+	}
+}
+
+func TestReleaseModeRequiresSecretsFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, "server:\n  mode: release\njwt:\n  secret: committed-secret\n")
+
+	if _, err := NewConfigLoader(path).Load(); err == nil || !strings.Contains(err.Error(), "APP_JWT_SECRET") {
+		t.Fatalf("expected error for secret in committed file, got %v", err)
+	}
+
+	writeConfigFile(t, path, "server:\n  mode: release\n")
+	t.Setenv("APP_JWT_SECRET", "from-env")
+	config, err := NewConfigLoader(path).Load()
+	if err != nil {
+		t.Fatalf("expected env-provided secret to load, got %v", err)
+	}
+	if config.JWT.Secret != "from-env" {
+		t.Errorf("expected secret from env, got %q", config.JWT.Secret)
+	}
+}
+
+func newCORSRouter(cfg CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORSMiddleware(func() CORSConfig { return cfg }))
+	r.GET("/api/features", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	return r
+}
+
+func corsRequest(r http.Handler, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/features", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", "GET")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := newCORSRouter(CORSConfig{
+		Enabled:          true,
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowMethods:     []string{"GET", "POST"},
+		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	w := corsRequest(r, http.MethodOptions, "https://app.example.com")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Max-Age":           "600",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+
+	// 실제 요청에는 preflight 전용 헤더 없이 허용 출처만 설정
+	w = corsRequest(r, http.MethodGet, "https://app.example.com")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("expected allowed GET, got %d %v", w.Code, w.Header())
+	}
+	if w.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Error("Allow-Methods should only be sent on preflight")
+	}
+}
+
+func TestCORSRejectsDisallowedOrigin(t *testing.T) {
+	// 자격 증명을 허용하면 "*"가 있어도 명시된 출처만 허용
+	r := newCORSRouter(CORSConfig{
+		Enabled:          true,
+		AllowOrigins:     []string{"*", "https://app.example.com"},
+		AllowCredentials: true,
+	})
+
+	for _, method := range []string{http.MethodOptions, http.MethodGet} {
+		w := corsRequest(r, method, "https://evil.example.com")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", method, w.Code)
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s: disallowed origin must not get Allow-Origin", method)
+		}
+	}
+
+	// 자격 증명 없이 "*"만 허용하면 와일드카드로 응답
+	r = newCORSRouter(CORSConfig{Enabled: true, AllowOrigins: []string{"*"}})
+	w := corsRequest(r, http.MethodGet, "https://any.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("expected wildcard without credentials, got %v", w.Header())
+	}
+
+	// 비활성화되면 헤더를 건드리지 않음
+	r = newCORSRouter(CORSConfig{Enabled: false, AllowOrigins: []string{"*"}})
+	if w := corsRequest(r, http.MethodGet, "https://any.example.com"); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("disabled CORS must not set headers")
+	}
+}
+
+func TestRouteTimeoutMatchesLongestPrefix(t *testing.T) {
+	config := loadConfigFile(t, "config.yaml", `
+server:
+  write_timeout: 15s
+routes:
+  - prefix: /api
+    timeout: 5s
+  - prefix: /api/batch/
+    timeout: 60s
+`)
+
+	tests := map[string]time.Duration{
+		"/api/batch":    60 * time.Second,
+		"/api/batch/42": 60 * time.Second,
+		"/api/batchy":   5 * time.Second,
+		"/api/health":   5 * time.Second,
+		"/metrics":      15 * time.Second,
+	}
+	for path, want := range tests {
+		if got := config.RouteTimeout(path); got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+	if got := config.MaxRequestTimeout(); got != 60*time.Second {
+		t.Errorf("expected max timeout 60s, got %s", got)
+	}
+}
+
+func TestRouteTimeoutMiddlewareEnforcesPerRouteDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := &Config{
+		Server: ServerConfig{WriteTimeout: time.Second},
+		Routes: []RouteConfig{
+			{Prefix: "/api/quick", Timeout: 20 * time.Millisecond},
+			{Prefix: "/api/batch", Timeout: 200 * time.Millisecond},
+		},
+	}
+	r := gin.New()
+	r.Use(RouteTimeoutMiddleware(func() *Config { return config }))
+
+	// 각 핸들러는 deadline까지 남은 시간을 기록하고 work만큼 일함
+	work := 60 * time.Millisecond
+	deadlines := make(map[string]time.Duration)
+	handler := func(c *gin.Context) {
+		deadline, _ := c.Request.Context().Deadline()
+		deadlines[c.Request.URL.Path] = time.Until(deadline)
+		select {
+		case <-time.After(work):
+			c.JSON(http.StatusOK, gin.H{"done": true})
+		case <-c.Request.Context().Done():
+			c.Error(c.Request.Context().Err())
+		}
+	}
+	r.GET("/api/quick", handler)
+	r.GET("/api/batch", handler)
+	r.GET("/api/other", handler)
+
+	for path, wantStatus := range map[string]int{
+		"/api/quick": http.StatusGatewayTimeout,
+		"/api/batch": http.StatusOK,
+		"/api/other": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", path, wantStatus, w.Code, w.Body.String())
+		}
+	}
+
+	if d := deadlines["/api/quick"]; d > 20*time.Millisecond {
+		t.Errorf("/api/quick: expected a 20ms deadline, got %s", d)
+	}
+	if d := deadlines["/api/batch"]; d <= 20*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("/api/batch: expected a 200ms deadline, got %s", d)
+	}
+	if d := deadlines["/api/other"]; d <= 200*time.Millisecond || d > time.Second {
+		t.Errorf("/api/other: expected the global 1s deadline, got %s", d)
+	}
+}
+
+func newFeatureService(t *testing.T, defaults FeatureFlags) (*FeatureService, *gorm.DB) {
+	t.Helper()
+	db, err := OpenFeatureStore("file:" + t.Name() + "?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open feature store: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	features, err := NewFeatureService(db, defaults)
+	if err != nil {
+		t.Fatalf("NewFeatureService failed: %v", err)
+	}
+	return features, db
+}
+
+// newFeatureRouter는 main과 같은 순서로 대시보드, 유지보수 모드, 관리 API를 등록합니다.
+func newFeatureRouter(features *FeatureService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/dashboard", DashboardHandler(features))
+	r.Use(MaintenanceMiddleware(features))
+	r.GET("/api/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "healthy"}) })
+	r.GET("/api/info", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	RegisterFeatureAdminRoutes(r, features, AdminTokenMiddleware(func() string { return testAdminToken }))
+	return r
+}
+
+const testAdminToken = "test-admin-token"
+
+// featureRequest는 관리자 토큰을 붙여 요청합니다. 인증 실패는 featureRequestAs로 확인합니다.
+func featureRequest(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	return featureRequestAs(r, method, path, body, "Bearer "+testAdminToken)
+}
+
+func featureRequestAs(r http.Handler, method, path, body, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestToggleDashboardAtRuntime(t *testing.T) {
+	features, _ := newFeatureService(t, FeatureFlags{})
+	r := newFeatureRouter(features)
+
+	if w := featureRequest(r, "GET", "/api/dashboard", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 while disabled, got %d", w.Code)
+	}
+
+	w := featureRequest(r, "PUT", "/api/admin/features/new_dashboard", `{"enabled": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := featureRequest(r, "GET", "/api/dashboard", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after enabling, got %d", w.Code)
+	}
+
+	featureRequest(r, "PUT", "/api/admin/features/new_dashboard", `{"enabled": false}`)
+	if w := featureRequest(r, "GET", "/api/dashboard", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after disabling, got %d", w.Code)
+	}
+}
+
+func TestToggleMaintenanceModeAtRuntime(t *testing.T) {
+	features, _ := newFeatureService(t, FeatureFlags{})
+	r := newFeatureRouter(features)
+
+	if w := featureRequest(r, "GET", "/api/info", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if w := featureRequest(r, "PUT", "/api/admin/features/maintenance_mode", `{"enabled": true}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for path, want := range map[string]int{
+		"/api/info":                            http.StatusServiceUnavailable,
+		"/api/health":                          http.StatusOK,
+		"/api/admin/features/maintenance_mode": http.StatusOK,
+	} {
+		if w := featureRequest(r, "GET", path, ""); w.Code != want {
+			t.Errorf("%s: expected %d during maintenance, got %d", path, want, w.Code)
+		}
+	}
+
+	// 유지보수 중에도 관리 API로 다시 끌 수 있음
+	if w := featureRequest(r, "PUT", "/api/admin/features/maintenance_mode", `{"enabled": false}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := featureRequest(r, "GET", "/api/info", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after maintenance, got %d", w.Code)
+	}
+}
+
+func TestFeatureAdminRejectsBadRequests(t *testing.T) {
+	features, _ := newFeatureService(t, FeatureFlags{})
+	r := newFeatureRouter(features)
+
+	if w := featureRequest(r, "PUT", "/api/admin/features/dark_mode", `{"enabled": true}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown flag: expected 404, got %d", w.Code)
+	}
+	if w := featureRequest(r, "PUT", "/api/admin/features/new_dashboard", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("missing enabled: expected 400, got %d", w.Code)
+	}
+	if features.Enabled("dark_mode") {
+		t.Error("unknown flag must not be cached")
+	}
+}
+
+func TestFeatureAdminRequiresAdminToken(t *testing.T) {
+	features, _ := newFeatureService(t, FeatureFlags{})
+	r := newFeatureRouter(features)
+
+	for name, authorization := range map[string]string{
+		"missing":    "",
+		"wrong":      "Bearer not-the-token",
+		"not bearer": "Basic " + testAdminToken,
+	} {
+		w := featureRequestAs(r, "PUT", "/api/admin/features/maintenance_mode", `{"enabled": true}`, authorization)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s token: expected 401, got %d", name, w.Code)
+		}
+	}
+	if w := featureRequestAs(r, "GET", "/api/admin/features", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("list without token: expected 401, got %d", w.Code)
+	}
+	if features.Enabled(FeatureMaintenanceMode) {
+		t.Fatal("unauthenticated request must not toggle maintenance mode")
+	}
+	if w := featureRequest(r, "GET", "/api/info", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the API to stay up, got %d", w.Code)
+	}
+}
+
+func TestAdminTokenMiddlewareRejectsWhenUnconfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", AdminTokenMiddleware(func() string { return "" }), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// 토큰이 설정되지 않았으면 빈 Bearer 토큰도 통과하지 못함
+	if w := featureRequestAs(r, "GET", "/admin", "", "Bearer "); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a configured token, got %d", w.Code)
+	}
+}
+
+func TestFeatureServiceSeedsDefaultsOnce(t *testing.T) {
+	features, db := newFeatureService(t, FeatureFlags{BetaFeatures: true})
+	if !features.Enabled(FeatureBetaFeatures) || features.Enabled(FeatureNewDashboard) {
+		t.Fatalf("expected config defaults, got %v", features.All())
+	}
+	if _, err := features.Set(context.Background(), FeatureBetaFeatures, false); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// 재시작 시 설정 파일 값이 런타임에 바꾼 값을 덮어쓰지 않음
+	restarted, err := NewFeatureService(db, FeatureFlags{BetaFeatures: true})
+	if err != nil {
+		t.Fatalf("NewFeatureService failed: %v", err)
+	}
+	if restarted.Enabled(FeatureBetaFeatures) {
+		t.Error("expected the stored value to survive a restart")
+	}
+	if got := len(restarted.All()); got != len(FeatureFlags{}.Map()) {
+		t.Errorf("expected %d flags, got %d", len(FeatureFlags{}.Map()), got)
+	}
+}
+
+func TestFeatureServiceRefreshPicksUpOtherInstances(t *testing.T) {
+	features, db := newFeatureService(t, FeatureFlags{})
+	other, err := NewFeatureService(db, FeatureFlags{})
+	if err != nil {
+		t.Fatalf("NewFeatureService failed: %v", err)
+	}
+
+	if _, err := other.Set(context.Background(), FeatureNewDashboard, true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if features.Enabled(FeatureNewDashboard) {
+		t.Fatal("expected the cached value until the next refresh")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	features.Start(ctx, 10*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for !features.Enabled(FeatureNewDashboard) {
+		if time.Now().After(deadline) {
+			t.Fatal("refresh did not pick up the change from the other instance")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,850 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInMemoryCacheExpiresAfterTTL(t *testing.T) {
+	now := time.Now()
+	cache := NewInMemoryCacheService().(*InMemoryCacheService)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("user:1", &User{ID: 1}, time.Minute)
+	cache.Set("user:2", &User{ID: 2}, 0)
+
+	if _, err := cache.Get("user:1"); err != nil {
+		t.Fatalf("expected hit before TTL, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, err := cache.Get("user:1"); err == nil {
+		t.Fatal("expected key to expire after TTL")
+	}
+	if _, exists := cache.store["user:1"]; exists {
+		t.Fatal("expired key should be evicted from the store")
+	}
+	if _, err := cache.Get("user:2"); err != nil {
+		t.Fatalf("key without expiration should not expire, got %v", err)
+	}
+}
+
+func TestInMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewInMemoryCacheServiceWithConfig(InMemoryCacheConfig{MaxEntries: 2, JanitorInterval: -1})
+
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 0)
+	cache.Get("a") // a가 최근 사용, b가 가장 오래됨
+	cache.Set("c", 3, 0)
+
+	if _, err := cache.Get("b"); err == nil {
+		t.Fatal("expected least recently used key to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := cache.Get(key); err != nil {
+			t.Errorf("expected %s to survive eviction, got %v", key, err)
+		}
+	}
+
+	// 기존 키 덮어쓰기는 용량을 늘리지 않음
+	cache.Set("a", 10, 0)
+	if v, _ := cache.Get("a"); v != 10 {
+		t.Errorf("expected Set to overwrite, got %v", v)
+	}
+	if stats := cache.Stats(); stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("expected size 2 with 1 eviction, got %+v", stats)
+	}
+}
+
+func TestInMemoryCacheDeleteAndStats(t *testing.T) {
+	cache := NewInMemoryCacheServiceWithConfig(InMemoryCacheConfig{JanitorInterval: -1})
+
+	cache.Set("user:1", &User{ID: 1}, time.Minute)
+	cache.Get("user:1")
+	cache.Delete("user:1")
+	if _, err := cache.Get("user:1"); err == nil {
+		t.Fatal("expected Delete to invalidate immediately")
+	}
+	cache.Get("user:2")
+
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 2 || stats.Size != 0 {
+		t.Errorf("expected 1 hit, 2 misses and an empty cache, got %+v", stats)
+	}
+}
+
+func TestInMemoryCacheJanitorRemovesExpiredKeys(t *testing.T) {
+	cache := NewInMemoryCacheServiceWithConfig(InMemoryCacheConfig{JanitorInterval: 10 * time.Millisecond})
+	defer cache.Close()
+
+	cache.Set("short", 1, 20*time.Millisecond)
+	cache.Set("forever", 2, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().Size != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected janitor to remove expired key, got %+v", cache.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// janitor가 정리한 키는 조회되지 않았으므로 미스로 집계되지 않음
+	if stats := cache.Stats(); stats.Misses != 0 {
+		t.Errorf("expected no misses, got %+v", stats)
+	}
+}
+
+func TestGetUserHonorsCacheTTL(t *testing.T) {
+	now := time.Now()
+	cache := NewInMemoryCacheServiceWithConfig(InMemoryCacheConfig{JanitorInterval: -1})
+	cache.now = func() time.Time { return now }
+
+	repo := NewMockUserRepository()
+	repo.Create(context.Background(), &User{Email: "a@example.com", Name: "Alice"})
+	users := NewUserService(repo, cache, NewMockEmailService(), newTestWorkerPool(t, 1, 1), 30*time.Second)
+
+	for i := 0; i < 3; i++ {
+		if _, err := users.GetUser(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("expected 1 miss then 2 hits, got %+v", stats)
+	}
+
+	// TTL이 지나면 저장소에서 다시 읽음
+	repo.(*MockUserRepository).users[1] = &User{ID: 1, Email: "a@example.com", Name: "Alice Updated"}
+	now = now.Add(30 * time.Second)
+	user, err := users.GetUser(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "Alice Updated" {
+		t.Errorf("expected fresh user after TTL, got %q", user.Name)
+	}
+	if stats := cache.Stats(); stats.Misses != 2 {
+		t.Errorf("expected a miss after TTL, got %+v", stats)
+	}
+}
+
+func TestRedisCacheExpiresAfterTTL(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+
+	cache := NewRedisCacheService(addr, os.Getenv("REDIS_PASSWORD"), 0)
+	if err := cache.Set("test:ttl", &User{ID: 7, Name: "Redis"}, time.Second); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	if _, err := cache.Get("test:ttl"); err != nil {
+		t.Fatalf("expected hit before TTL, got %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	if _, err := cache.Get("test:ttl"); err == nil {
+		t.Fatal("expected key to expire after TTL")
+	}
+}
+
+func newOrderServiceWithProducts(t *testing.T, products ...*Product) (OrderService, ProductRepository, OrderRepository) {
+	t.Helper()
+	ctx := context.Background()
+	productRepo := NewMockProductRepository()
+	for _, p := range products {
+		if err := productRepo.Create(ctx, p); err != nil {
+			t.Fatalf("failed to seed product: %v", err)
+		}
+	}
+	orderRepo := NewMockOrderRepository()
+	return NewOrderService(orderRepo, productRepo, NewMockTransactionManager(productRepo, orderRepo)), productRepo, orderRepo
+}
+
+func TestCreateOrderDecrementsStock(t *testing.T) {
+	ctx := context.Background()
+	service, productRepo, orderRepo := newOrderServiceWithProducts(t,
+		&Product{Name: "Keyboard", Price: 50, Stock: 10},
+		&Product{Name: "Mouse", Price: 20, Stock: 5},
+	)
+
+	order, err := service.CreateOrder(ctx, 1, []OrderItem{
+		{ProductID: 1, Quantity: 2, Price: 1}, // 클라이언트 가격은 무시되어야 함
+		{ProductID: 2, Quantity: 3},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder failed: %v", err)
+	}
+
+	if order.TotalPrice != 160 {
+		t.Errorf("expected total 160, got %v", order.TotalPrice)
+	}
+	if order.Status != "pending" {
+		t.Errorf("expected pending status, got %q", order.Status)
+	}
+
+	keyboard, _ := productRepo.FindByID(ctx, 1)
+	mouse, _ := productRepo.FindByID(ctx, 2)
+	if keyboard.Stock != 8 || mouse.Stock != 2 {
+		t.Errorf("unexpected stock: keyboard=%d mouse=%d", keyboard.Stock, mouse.Stock)
+	}
+
+	if _, err := orderRepo.FindByID(ctx, order.ID); err != nil {
+		t.Errorf("order was not persisted: %v", err)
+	}
+}
+
+func TestCreateOrderRejectsOversell(t *testing.T) {
+	ctx := context.Background()
+	service, productRepo, orderRepo := newOrderServiceWithProducts(t,
+		&Product{Name: "Keyboard", Price: 50, Stock: 10},
+		&Product{Name: "Mouse", Price: 20, Stock: 1},
+	)
+
+	_, err := service.CreateOrder(ctx, 1, []OrderItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 3},
+	})
+
+	var stockErr *OutOfStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("expected OutOfStockError, got %v", err)
+	}
+	if stockErr.ProductID != 2 {
+		t.Errorf("expected failing product 2, got %d", stockErr.ProductID)
+	}
+	if !errors.Is(err, ErrOutOfStock) {
+		t.Error("expected error to wrap ErrOutOfStock")
+	}
+
+	keyboard, _ := productRepo.FindByID(ctx, 1)
+	if keyboard.Stock != 10 {
+		t.Errorf("stock should be untouched after rollback, got %d", keyboard.Stock)
+	}
+	if orders, _ := orderRepo.FindByUserID(ctx, 1); len(orders) != 0 {
+		t.Errorf("no order should be persisted, got %d", len(orders))
+	}
+}
+
+func TestCreateOrderAggregatesDuplicateItems(t *testing.T) {
+	ctx := context.Background()
+	service, productRepo, orderRepo := newOrderServiceWithProducts(t,
+		&Product{Name: "Keyboard", Price: 50, Stock: 5},
+	)
+
+	_, err := service.CreateOrder(ctx, 1, []OrderItem{
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 1, Quantity: 3},
+	})
+
+	var stockErr *OutOfStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("expected OutOfStockError, got %v", err)
+	}
+	if stockErr.Requested != 6 || stockErr.Available != 5 {
+		t.Errorf("expected requested 6 / available 5, got %d / %d", stockErr.Requested, stockErr.Available)
+	}
+
+	keyboard, _ := productRepo.FindByID(ctx, 1)
+	if keyboard.Stock != 5 {
+		t.Errorf("stock should be untouched, got %d", keyboard.Stock)
+	}
+	if orders, _ := orderRepo.FindByUserID(ctx, 1); len(orders) != 0 {
+		t.Errorf("no order should be persisted, got %d", len(orders))
+	}
+}
+
+// staleStockRepository는 사전 확인 단계에서 실제보다 많은 재고를 보여줘
+// 동시 주문으로 재고가 바뀐 상황을 흉내냅니다.
+type staleStockRepository struct {
+	ProductRepository
+	staleID int
+	reads   int
+}
+
+func (r *staleStockRepository) FindByID(ctx context.Context, id int) (*Product, error) {
+	product, err := r.ProductRepository.FindByID(ctx, id)
+	if err == nil && id == r.staleID && r.reads == 0 {
+		r.reads++
+		product.Stock += 100
+	}
+	return product, err
+}
+
+func TestCreateOrderRollsBackWhenDecrementFails(t *testing.T) {
+	ctx := context.Background()
+	productRepo := NewMockProductRepository()
+	productRepo.Create(ctx, &Product{Name: "Keyboard", Price: 50, Stock: 10})
+	productRepo.Create(ctx, &Product{Name: "Mouse", Price: 20, Stock: 1})
+	orderRepo := NewMockOrderRepository()
+
+	service := NewOrderService(orderRepo,
+		&staleStockRepository{ProductRepository: productRepo, staleID: 2},
+		NewMockTransactionManager(productRepo, orderRepo))
+
+	_, err := service.CreateOrder(ctx, 1, []OrderItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 3},
+	})
+
+	var stockErr *OutOfStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("expected OutOfStockError, got %v", err)
+	}
+	if stockErr.ProductID != 2 || stockErr.Available != 1 {
+		t.Errorf("expected product 2 with 1 available, got product %d with %d", stockErr.ProductID, stockErr.Available)
+	}
+
+	keyboard, _ := productRepo.FindByID(ctx, 1)
+	mouse, _ := productRepo.FindByID(ctx, 2)
+	if keyboard.Stock != 10 || mouse.Stock != 1 {
+		t.Errorf("stock should be restored after rollback: keyboard=%d mouse=%d", keyboard.Stock, mouse.Stock)
+	}
+	if orders, _ := orderRepo.FindByUserID(ctx, 1); len(orders) != 0 {
+		t.Errorf("no order should be persisted, got %d", len(orders))
+	}
+}
+
+func TestServeDrainsInFlightRequestsOnShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	container, err := NewContainer(&Config{Environment: "test"})
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+
+	started := make(chan struct{})
+	router := SetupRouter(container)
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		c.JSON(200, gin.H{"status": "done"})
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	baseURL := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- Serve(ctx, &http.Server{Handler: router}, ln, container, 5*time.Second)
+	}()
+
+	slowResult := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			t.Errorf("in-flight request failed: %v", err)
+			slowResult <- nil
+			return
+		}
+		slowResult <- resp
+	}()
+
+	<-started
+	cancel()
+
+	// Shutdown이 리스너를 닫을 때까지 잠시 대기한 뒤 새 요청 시도
+	time.Sleep(50 * time.Millisecond)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	if resp, err := client.Get(baseURL + "/health"); err == nil {
+		resp.Body.Close()
+		t.Error("expected new request to be refused during shutdown")
+	}
+
+	resp := <-slowResult
+	if resp == nil {
+		t.FailNow()
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("expected in-flight request to complete with 200, got %d", resp.StatusCode)
+	}
+
+	if err := <-serveErr; err != nil {
+		t.Errorf("Serve returned error: %v", err)
+	}
+}
+
+type pingCache struct {
+	CacheService
+	err error
+}
+
+func (c *pingCache) Ping(ctx context.Context) error { return c.err }
+
+type pingEmail struct {
+	EmailService
+	delay time.Duration
+	err   error
+}
+
+func (e *pingEmail) Ping(ctx context.Context) error {
+	select {
+	case <-time.After(e.delay):
+		return e.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestContainerHealthCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		cacheErr   error
+		email      *pingEmail
+		wantStatus string
+		wantCode   int
+	}{
+		{
+			name:       "all healthy",
+			email:      &pingEmail{},
+			wantStatus: HealthStatusHealthy,
+			wantCode:   200,
+		},
+		{
+			name:       "email down is degraded",
+			email:      &pingEmail{err: errors.New("smtp unreachable")},
+			wantStatus: HealthStatusDegraded,
+			wantCode:   200,
+		},
+		{
+			name:       "slow email times out without blocking",
+			email:      &pingEmail{delay: time.Minute},
+			wantStatus: HealthStatusDegraded,
+			wantCode:   200,
+		},
+		{
+			name:       "cache down is unhealthy",
+			cacheErr:   errors.New("connection refused"),
+			email:      &pingEmail{},
+			wantStatus: HealthStatusUnhealthy,
+			wantCode:   503,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := &Container{
+				config:       &Config{Environment: "test", HealthCheckTimeout: 50 * time.Millisecond},
+				cacheService: &pingCache{CacheService: NewInMemoryCacheService(), err: tt.cacheErr},
+				emailService: tt.email,
+			}
+
+			start := time.Now()
+			report := container.HealthCheck(context.Background())
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("health check took too long: %v", elapsed)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q (%+v)", tt.wantStatus, report.Status, report.Components)
+			}
+
+			w := httptest.NewRecorder()
+			SetupRouter(container).ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("expected HTTP %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+type flakyEmailService struct {
+	MockEmailService
+	failures int // 남은 실패 횟수 (-1이면 항상 실패)
+	calls    int
+}
+
+func (e *flakyEmailService) SendEmail(to, subject, body string) error {
+	e.calls++
+	if e.failures != 0 {
+		if e.failures > 0 {
+			e.failures--
+		}
+		return errors.New("smtp: connection reset")
+	}
+	return nil
+}
+
+func newTestResilientEmailService(next EmailService, now *time.Time) *ResilientEmailService {
+	s := NewResilientEmailService(next, RetryConfig{
+		MaxRetries:       2,
+		InitialBackoff:   time.Millisecond,
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	}).(*ResilientEmailService)
+	s.now = func() time.Time { return *now }
+	s.sleep = func(time.Duration) {}
+	return s
+}
+
+func TestResilientEmailServiceRetriesTransientFailures(t *testing.T) {
+	now := time.Now()
+	smtp := &flakyEmailService{failures: 2}
+	email := newTestResilientEmailService(smtp, &now)
+
+	if err := email.SendEmail("a@example.com", "Welcome!", "hi"); err != nil {
+		t.Fatalf("expected success on retry, got %v", err)
+	}
+	if smtp.calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", smtp.calls)
+	}
+}
+
+func TestResilientEmailServiceOpensCircuit(t *testing.T) {
+	now := time.Now()
+	smtp := &flakyEmailService{failures: -1}
+	email := newTestResilientEmailService(smtp, &now)
+
+	for i := 0; i < 2; i++ {
+		if err := email.SendEmail("a@example.com", "Welcome!", "hi"); err == nil {
+			t.Fatal("expected failure")
+		}
+	}
+	callsBeforeOpen := smtp.calls
+
+	if err := email.SendEmail("a@example.com", "Welcome!", "hi"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if smtp.calls != callsBeforeOpen {
+		t.Error("open circuit should short-circuit without calling SMTP")
+	}
+
+	// cooldown 이후 half-open 시도가 성공하면 회로가 닫힘
+	now = now.Add(time.Minute)
+	smtp.failures = 0
+	if err := email.SendEmail("a@example.com", "Welcome!", "hi"); err != nil {
+		t.Fatalf("expected half-open attempt to succeed, got %v", err)
+	}
+	if err := email.SendEmail("a@example.com", "Welcome!", "hi"); err != nil {
+		t.Fatalf("expected circuit to be closed, got %v", err)
+	}
+}
+
+func TestResilientEmailServiceDoesNotRetryPermanentErrors(t *testing.T) {
+	now := time.Now()
+	smtp := &flakyEmailService{}
+	email := newTestResilientEmailService(smtp, &now)
+
+	for i := 0; i < 3; i++ {
+		err := email.SendTemplate("a@example.com", "missing", nil)
+		if !errors.Is(err, ErrTemplateNotFound) {
+			t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+		}
+	}
+
+	// 영구 실패는 회로 차단 카운트에 포함되지 않음
+	if err := email.SendEmail("a@example.com", "Welcome!", "hi"); err != nil {
+		t.Fatalf("expected circuit to stay closed, got %v", err)
+	}
+	if smtp.calls != 1 {
+		t.Errorf("expected 1 SMTP call, got %d", smtp.calls)
+	}
+}
+
+type slowEmailService struct {
+	MockEmailService
+	delay time.Duration
+}
+
+func (e *slowEmailService) SendTemplate(to, templateName string, data any) error {
+	time.Sleep(e.delay)
+	return nil
+}
+
+func TestCreateUserDoesNotWaitForSlowWelcomeEmail(t *testing.T) {
+	users := NewUserService(NewMockUserRepository(), NewInMemoryCacheService(), &slowEmailService{delay: time.Second}, newTestWorkerPool(t, 1, 1), 0)
+
+	start := time.Now()
+	if _, err := users.CreateUser(context.Background(), "slow@example.com", "Slow", "user"); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("CreateUser blocked on email delivery for %v", elapsed)
+	}
+}
+
+func TestMockEmailServiceRecordsRenderedTemplate(t *testing.T) {
+	email := NewMockEmailService().(*MockEmailService)
+	templates := NewEmailTemplates()
+	if err := templates.Register("greeting", EmailTemplate{
+		Subject: "Hello {{.Name}}",
+		Text:    "Hello {{.Name}}",
+		HTML:    `<p>Hello <b>{{.Name}}</b></p>`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	email.templates = templates
+
+	if err := email.SendTemplate("a@example.com", "greeting", struct{ Name string }{"<Alice>"}); err != nil {
+		t.Fatalf("SendTemplate failed: %v", err)
+	}
+
+	sent := email.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 recorded email, got %d", len(sent))
+	}
+	got := sent[0]
+	if got.To != "a@example.com" || got.Subject != "Hello <Alice>" || got.Template != "greeting" {
+		t.Errorf("unexpected recorded email: %+v", got)
+	}
+	// HTML 본문은 html/template으로 이스케이프된다
+	if want := `<p>Hello <b>&lt;Alice&gt;</b></p>`; got.HTML != want {
+		t.Errorf("expected HTML %q, got %q", want, got.HTML)
+	}
+	if got.Body != "Hello <Alice>" {
+		t.Errorf("expected plain text body, got %q", got.Body)
+	}
+
+	if err := email.SendTemplate("a@example.com", "missing", nil); err == nil {
+		t.Error("expected error for unregistered template")
+	}
+}
+
+func TestWelcomeAndOrderEmailsUseTemplates(t *testing.T) {
+	email := NewMockEmailService().(*MockEmailService)
+	pool := newTestWorkerPool(t, 1, 1)
+	users := NewUserService(NewMockUserRepository(), NewInMemoryCacheService(), email, pool, 0)
+
+	user, err := users.CreateUser(context.Background(), "bob@example.com", "Bob", "user")
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	// 환영 이메일은 워커 풀에서 발송되므로 풀을 비운 뒤 확인
+	if err := pool.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	order := &Order{ID: 7, Products: []OrderItem{{ProductID: 1, Quantity: 2, Price: 50}}, TotalPrice: 100}
+	if err := email.SendOrderConfirmation(order, user); err != nil {
+		t.Fatalf("SendOrderConfirmation failed: %v", err)
+	}
+
+	sent := email.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(sent))
+	}
+	if sent[0].Template != "welcome" || !strings.Contains(sent[0].HTML, "<h1>Welcome, Bob!</h1>") {
+		t.Errorf("unexpected welcome email: %+v", sent[0])
+	}
+	if sent[1].Subject != "Order #7 Confirmation" || !strings.Contains(sent[1].HTML, "<strong>100.00</strong>") {
+		t.Errorf("unexpected order confirmation: %+v", sent[1])
+	}
+}
+
+func TestRenderedEmailMultipartMessage(t *testing.T) {
+	rendered, err := DefaultEmailTemplates().Render("welcome", &User{Name: "Bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := rendered.MultipartMessage("noreply@example.com", "bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	var types []string
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("expected text and html parts, got %v", types)
+	}
+}
+
+func TestListUsersReportsTotalAndShortLastPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	container, err := NewContainer(&Config{Environment: "test"})
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+	repo := container.GetUserRepository()
+	for i := 1; i <= 23; i++ {
+		user := &User{Email: fmt.Sprintf("user%d@example.com", i), Name: fmt.Sprintf("User %d", i), Role: "user"}
+		if err := repo.Create(context.Background(), user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	router := SetupRouter(container)
+
+	tests := []struct {
+		page      int
+		wantCount int
+		wantFirst int
+	}{
+		{1, 10, 1},
+		{2, 10, 11},
+		{3, 3, 21}, // 마지막 페이지는 짧음
+		{4, 0, 0},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/users?page=%d&page_size=10", tt.page), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: expected 200, got %d", tt.page, w.Code)
+		}
+
+		var resp struct {
+			Users      []*User `json:"users"`
+			Total      int     `json:"total"`
+			TotalPages int     `json:"total_pages"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("page %d: failed to decode: %v", tt.page, err)
+		}
+		if resp.Total != 23 || resp.TotalPages != 3 {
+			t.Errorf("page %d: expected total 23 over 3 pages, got %d over %d", tt.page, resp.Total, resp.TotalPages)
+		}
+		if len(resp.Users) != tt.wantCount {
+			t.Errorf("page %d: expected %d users, got %d", tt.page, tt.wantCount, len(resp.Users))
+		}
+		if tt.wantCount > 0 && resp.Users[0].ID != tt.wantFirst {
+			t.Errorf("page %d: expected first user %d, got %d", tt.page, tt.wantFirst, resp.Users[0].ID)
+		}
+	}
+}
+
+func newTestWorkerPool(t *testing.T, workers, queueSize int) *WorkerPool {
+	t.Helper()
+	pool := NewWorkerPool(workers, queueSize)
+	t.Cleanup(func() { pool.Close(context.Background()) })
+	return pool
+}
+
+func TestWorkerPoolRunsAllJobsAndDrainsOnClose(t *testing.T) {
+	pool := NewWorkerPool(3, 20)
+
+	var ran atomic.Int64
+	var running, maxRunning atomic.Int64
+	for i := 0; i < 20; i++ {
+		err := pool.Submit(func(ctx context.Context) {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			ran.Add(1)
+		})
+		if err != nil {
+			t.Fatalf("Submit %d failed: %v", i, err)
+		}
+	}
+
+	// 큐에 남은 작업까지 모두 실행된 뒤에 반환
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := ran.Load(); got != 20 {
+		t.Errorf("expected 20 jobs to run, got %d", got)
+	}
+	if got := maxRunning.Load(); got > 3 {
+		t.Errorf("expected at most 3 concurrent jobs, got %d", got)
+	}
+
+	stats := pool.Stats()
+	if stats.Submitted != 20 || stats.Completed != 20 || stats.QueueDepth != 0 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats after drain: %+v", stats)
+	}
+
+	if err := pool.Submit(func(context.Context) {}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected ErrPoolClosed after Close, got %v", err)
+	}
+}
+
+func TestWorkerPoolDropsWhenQueueIsFull(t *testing.T) {
+	pool := newTestWorkerPool(t, 1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if err := pool.Submit(func(context.Context) {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if err := pool.Submit(func(context.Context) {}); err != nil {
+		t.Fatalf("expected second job to be queued, got %v", err)
+	}
+	if err := pool.Submit(func(context.Context) {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	stats := pool.Stats()
+	if stats.QueueDepth != 1 || stats.QueueCapacity != 1 || stats.Dropped != 1 {
+		t.Errorf("unexpected stats with a full queue: %+v", stats)
+	}
+	close(release)
+}
+
+func TestWorkerPoolCloseTimeoutCancelsJobs(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+
+	canceled := make(chan struct{})
+	started := make(chan struct{})
+	if err := pool.Submit(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("running job was not canceled after the drain timeout")
+	}
+}
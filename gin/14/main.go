@@ -2,16 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"example.com/gin-playground/pkg/metrics"
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,6 +42,7 @@ const (
 
 type ModeConfig struct {
 	Mode            RunMode
+	Addr            string
 	LogLevel        string
 	LogOutput       io.Writer
 	EnableProfiling bool
@@ -45,7 +55,9 @@ type ModeConfig struct {
 	ColoredOutput   bool
 	MaxMemory       int64 // bytes
 	MaxCPU          int
-	RateLimit       int
+	RateLimit       int         // requests per minute (0 = 제한 없음)
+	RateBurst       int         // 순간적으로 허용하는 최대 요청 수
+	RateLimiter     RateLimiter `json:"-"` // nil이면 메모리 토큰 버킷 사용
 	Timeout         time.Duration
 }
 
@@ -55,6 +67,7 @@ func GetModeConfig(mode RunMode) *ModeConfig {
 	case DebugMode:
 		return &ModeConfig{
 			Mode:            DebugMode,
+			Addr:            ":8080",
 			LogLevel:        "debug",
 			LogOutput:       os.Stdout,
 			EnableProfiling: true,
@@ -68,11 +81,13 @@ func GetModeConfig(mode RunMode) *ModeConfig {
 			MaxMemory:       0, // unlimited
 			MaxCPU:          0, // use all cores
 			RateLimit:       0, // no limit
+			RateBurst:       0,
 			Timeout:         30 * time.Second,
 		}
 	case ReleaseMode:
 		return &ModeConfig{
 			Mode:            ReleaseMode,
+			Addr:            ":8080",
 			LogLevel:        "info",
 			LogOutput:       os.Stdout, // or file
 			EnableProfiling: false,
@@ -86,11 +101,13 @@ func GetModeConfig(mode RunMode) *ModeConfig {
 			MaxMemory:       1 << 30, // 1GB
 			MaxCPU:          runtime.NumCPU(),
 			RateLimit:       100, // requests per minute
+			RateBurst:       20,
 			Timeout:         15 * time.Second,
 		}
 	case TestMode:
 		return &ModeConfig{
 			Mode:            TestMode,
+			Addr:            ":8080",
 			LogLevel:        "error",
 			LogOutput:       io.Discard, // suppress logs
 			EnableProfiling: false,
@@ -104,6 +121,7 @@ func GetModeConfig(mode RunMode) *ModeConfig {
 			MaxMemory:       1 << 28, // 256MB
 			MaxCPU:          2,
 			RateLimit:       0,
+			RateBurst:       0,
 			Timeout:         5 * time.Second,
 		}
 	default:
@@ -111,6 +129,20 @@ func GetModeConfig(mode RunMode) *ModeConfig {
 	}
 }
 
+// Graceful shutdown 시 진행 중인 요청을 기다리는 최대 시간
+func (config *ModeConfig) DrainTimeout() time.Duration {
+	switch config.Mode {
+	case ReleaseMode:
+		// 가장 긴 요청(Timeout)이 끝날 수 있도록 여유를 둔다
+		return 2 * config.Timeout
+	case TestMode:
+		return time.Second
+	default:
+		// 개발 중에는 빠른 재시작이 우선
+		return 5 * time.Second
+	}
+}
+
 // ============================================================================
 // 모드별 미들웨어
 // ============================================================================
@@ -139,6 +171,14 @@ func DebugMiddleware() gin.HandlerFunc {
 
 // Release 모드 전용 미들웨어
 func ReleaseMiddleware(config *ModeConfig) gin.HandlerFunc {
+	var limiter RateLimiter
+	if config.RateLimit > 0 {
+		limiter = config.RateLimiter
+		if limiter == nil {
+			limiter = NewTokenBucketLimiter(config.RateLimit, config.RateBurst)
+		}
+	}
+
 	return func(c *gin.Context) {
 		// 보안 헤더 추가
 		c.Header("X-Content-Type-Options", "nosniff")
@@ -146,10 +186,19 @@ func ReleaseMiddleware(config *ModeConfig) gin.HandlerFunc {
 		c.Header("X-XSS-Protection", "1; mode=block")
 		c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
 
-		// Rate limiting
-		if config.RateLimit > 0 {
-			// 실제 구현에서는 Redis 등을 사용한 분산 rate limiting
-			// 여기서는 간단한 예시만
+		// Rate limiting (클라이언트 IP별)
+		if limiter != nil {
+			allowed, retryAfter, err := limiter.Allow(c.Request.Context(), c.ClientIP())
+			if err != nil {
+				// 저장소 장애 시에는 요청을 막지 않는다 (fail open)
+				log.Printf("[WARN] rate limiter error: %v", err)
+			} else if !allowed {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error": "Too many requests",
+				})
+				return
+			}
 		}
 
 		c.Next()
@@ -159,6 +208,84 @@ func ReleaseMiddleware(config *ModeConfig) gin.HandlerFunc {
 	}
 }
 
+// ============================================================================
+// Rate Limiter
+// ============================================================================
+
+// 분산 환경에서는 Redis 등으로 구현한 limiter를 ModeConfig.RateLimiter에 넣는다
+type RateLimiter interface {
+	// key에 대한 요청 허용 여부와, 거절 시 다시 시도할 수 있을 때까지의 시간
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// 프로세스 메모리 기반 토큰 버킷 (키별로 분당 limit개 충전, 최대 burst개)
+type TokenBucketLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	rate      float64 // tokens per second
+	burst     float64
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewTokenBucketLimiter(perMinute, burst int) *TokenBucketLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &TokenBucketLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		now:     time.Now,
+	}
+}
+
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// 지난 시간만큼 토큰 충전
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// 가득 찬 상태로 돌아간 버킷은 새로 만든 것과 같으므로 주기적으로 제거한다
+func (l *TokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
 // Test 모드 전용 미들웨어
 func TestMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -307,11 +434,6 @@ func SetupReleaseRouter(config *ModeConfig) *gin.Engine {
 	router.Use(ReleaseMiddleware(config))
 	router.Use(ReleaseErrorHandler())
 
-	// 메트릭스만 활성화
-	if config.EnableMetrics {
-		router.GET("/metrics", metricsHandler)
-	}
-
 	return router
 }
 
@@ -370,70 +492,60 @@ func memStatsHandler(c *gin.Context) {
 	})
 }
 
-func metricsHandler(c *gin.Context) {
-	// Prometheus 형식의 메트릭스
-	c.String(200, `# HELP http_requests_total Total HTTP requests
-# TYPE http_requests_total counter
-http_requests_total{method="GET",status="200"} 142
-
-# HELP http_request_duration_seconds HTTP request duration
-# TYPE http_request_duration_seconds histogram
-http_request_duration_seconds_bucket{le="0.1"} 120
-http_request_duration_seconds_bucket{le="0.5"} 135
-http_request_duration_seconds_bucket{le="1"} 140
-
-# HELP go_goroutines Number of goroutines
-# TYPE go_goroutines gauge
-go_goroutines %d`, runtime.NumGoroutine())
-}
-
 // ============================================================================
 // 애플리케이션 설정
 // ============================================================================
 
 type Application struct {
-	Router *gin.Engine
-	Config *ModeConfig
-	Mode   RunMode
+	Router  *gin.Engine
+	Config  *ModeConfig
+	Mode    RunMode
+	Metrics *metrics.Registry // EnableMetrics일 때만 설정
 }
 
 func NewApplication(mode RunMode) *Application {
 	config := GetModeConfig(mode)
 
 	// 리소스 제한 설정
-	if config.MaxMemory > 0 {
-		debug.SetMemoryLimit(config.MaxMemory)
-	}
-
-	if config.MaxCPU > 0 {
-		runtime.GOMAXPROCS(config.MaxCPU)
-	}
-
-	// 라우터 설정
-	var router *gin.Engine
-	switch mode {
-	case DebugMode:
-		router = SetupDebugRouter(config)
-	case ReleaseMode:
-		router = SetupReleaseRouter(config)
-	case TestMode:
-		router = SetupTestRouter(config)
-	default:
-		router = SetupReleaseRouter(config)
-	}
+	applyResourceLimits(config)
 
+	// 애플리케이션 라우트만 담는 라우터 (모드별 미들웨어는 Run에서 적용)
 	app := &Application{
-		Router: router,
+		Router: gin.New(),
 		Config: config,
 		Mode:   mode,
 	}
 
+	// 메트릭스 수집 (라우트 등록 전에 설치해야 모든 라우트가 측정됨)
+	if config.EnableMetrics {
+		app.Metrics = metrics.NewRegistry()
+		app.Metrics.Mount(app.Router)
+	}
+
 	// 공통 라우트 설정
 	app.setupRoutes()
 
 	return app
 }
 
+// MaxCPU/MaxMemory가 0이면 런타임 기본값을 그대로 둔다
+func applyResourceLimits(config *ModeConfig) {
+	if config.MaxCPU > 0 {
+		prev := runtime.GOMAXPROCS(config.MaxCPU)
+		log.Printf("⚙️  GOMAXPROCS: %d -> %d", prev, config.MaxCPU)
+	} else {
+		log.Printf("⚙️  GOMAXPROCS: %d (default)", runtime.GOMAXPROCS(0))
+	}
+
+	if config.MaxMemory > 0 {
+		// soft limit: 한도에 가까워지면 GC가 더 자주 실행된다
+		debug.SetMemoryLimit(config.MaxMemory)
+		log.Printf("⚙️  Memory limit: %d MB", config.MaxMemory/1024/1024)
+	} else {
+		log.Printf("⚙️  Memory limit: unlimited (default)")
+	}
+}
+
 func (app *Application) setupRoutes() {
 	// 헬스체크
 	app.Router.GET("/health", func(c *gin.Context) {
@@ -537,6 +649,18 @@ func (app *Application) setupRoutes() {
 			c.JSON(200, os.Environ())
 		})
 
+		app.Router.GET("/debug/runtime", func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"gomaxprocs":   runtime.GOMAXPROCS(0),
+				"num_cpu":      runtime.NumCPU(),
+				"memory_limit": debug.SetMemoryLimit(-1), // 음수를 넘기면 현재 값만 조회
+				"configured": gin.H{
+					"max_cpu":    app.Config.MaxCPU,
+					"max_memory": app.Config.MaxMemory,
+				},
+			})
+		})
+
 	case TestMode:
 		app.Router.POST("/test/reset", func(c *gin.Context) {
 			// 테스트 데이터 리셋
@@ -562,16 +686,94 @@ func (app *Application) Run(addr string) error {
 	log.Printf("  - Request Logging: %v", app.Config.RequestLogging)
 	log.Printf("  - Max CPU: %d", app.Config.MaxCPU)
 
+	if addr != "" {
+		app.Config.Addr = addr
+	}
+	return Run(app.Config, app.Router)
+}
+
+// 모드별 미들웨어를 적용한 엔진을 만들고 router로 요청을 넘긴다.
+// pprof/metrics 라우트는 설정에 따라 SetupXXXRouter가 설치한다 (Test 모드는 설치하지 않음)
+func NewModeEngine(config *ModeConfig, router *gin.Engine, ready *atomic.Bool) *gin.Engine {
+	var engine *gin.Engine
+	switch config.Mode {
+	case DebugMode:
+		engine = SetupDebugRouter(config)
+	case TestMode:
+		engine = SetupTestRouter(config)
+	default:
+		engine = SetupReleaseRouter(config)
+	}
+
+	// 준비 상태 확인 (로드밸런서용): 리스너가 열리기 전과 종료 중에는 503
+	engine.GET("/ready", func(c *gin.Context) {
+		if !ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
+
+	// 나머지 요청은 모두 애플리케이션 라우터가 처리
+	engine.NoRoute(gin.WrapH(router))
+
+	return engine
+}
+
+// Run은 config.Addr에서 서버를 실행하고 SIGINT/SIGTERM을 받으면 graceful shutdown 한다
+func Run(config *ModeConfig, router *gin.Engine) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, config, router, ln)
+}
+
+func serve(ctx context.Context, config *ModeConfig, router *gin.Engine, ln net.Listener) error {
+	var ready atomic.Bool
+
 	// 타임아웃 설정
 	server := &http.Server{
-		Addr:         addr,
-		Handler:      app.Router,
-		ReadTimeout:  app.Config.Timeout,
-		WriteTimeout: app.Config.Timeout,
-		IdleTimeout:  app.Config.Timeout * 2,
+		Handler:      NewModeEngine(config, router, &ready),
+		ReadTimeout:  config.Timeout,
+		WriteTimeout: config.Timeout,
+		IdleTimeout:  config.Timeout * 2,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+	ready.Store(true)
+	log.Printf("✅ Ready on %s", ln.Addr())
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// 새 요청은 받지 않고, 진행 중인 요청은 DrainTimeout 동안 기다린다
+	ready.Store(false)
+	log.Printf("🛑 Shutting down (drain timeout %v)", config.DrainTimeout())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.DrainTimeout())
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// 시간 안에 끝나지 않은 연결은 강제로 닫는다
+		server.Close()
+		<-serveErr
+		return err
 	}
 
-	return server.ListenAndServe()
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // ============================================================================
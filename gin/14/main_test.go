@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRunTestModeShutsDownGracefully(t *testing.T) {
+	restoreResourceLimits(t)
+	before := runtime.NumGoroutine()
+
+	app := NewApplication(TestMode)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseURL := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, app.Config, app.Router, ln)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := client.Get(baseURL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// 준비 상태가 될 때까지 대기
+	deadline := time.Now().Add(2 * time.Second)
+	for get("/ready").StatusCode != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("server never became ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 애플리케이션 라우트는 Test 모드 미들웨어를 거친다
+	resp := get("/api/users")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Test-Mode") != "true" {
+		t.Errorf("expected test-mode response, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// Test 모드에서는 pprof/metrics가 설치되지 않는다
+	for _, path := range []string{"/metrics", "/debug/pprof"} {
+		if resp := get(path); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected %s to be absent in test mode, got %d", path, resp.StatusCode)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(app.Config.DrainTimeout() + time.Second):
+		t.Fatal("shutdown did not return within the drain timeout")
+	}
+
+	if _, err := client.Get(baseURL + "/ready"); err == nil {
+		t.Error("expected server to stop accepting connections")
+	}
+
+	// 서버 고루틴이 모두 정리될 때까지 대기
+	deadline = time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutine leak: %d before, %d after", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newRateLimitedRouter(config *ModeConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ReleaseMiddleware(config))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+func ping(router http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReleaseMiddlewareRateLimitsPerClientIP(t *testing.T) {
+	config := GetModeConfig(ReleaseMode)
+	config.RateLimit = 60 // 초당 1개 충전
+	config.RateBurst = 3
+	router := newRateLimitedRouter(config)
+
+	for i := 0; i < config.RateBurst; i++ {
+		if w := ping(router, "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := ping(router, "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 above the burst, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+
+	// 다른 클라이언트는 영향을 받지 않는다
+	if w := ping(router, "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("expected other client to be allowed, got %d", w.Code)
+	}
+}
+
+func TestReleaseMiddlewareWithoutRateLimit(t *testing.T) {
+	config := GetModeConfig(ReleaseMode)
+	config.RateLimit = 0
+	router := newRateLimitedRouter(config)
+
+	for i := 0; i < 200; i++ {
+		if w := ping(router, "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 with limiting disabled, got %d", i+1, w.Code)
+		}
+	}
+}
+
+type denyAllLimiter struct{}
+
+func (denyAllLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	return false, 30 * time.Second, nil
+}
+
+func TestReleaseMiddlewareUsesConfiguredLimiter(t *testing.T) {
+	config := GetModeConfig(ReleaseMode)
+	config.RateLimiter = denyAllLimiter{}
+	router := newRateLimitedRouter(config)
+
+	w := ping(router, "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("expected custom limiter to reject with Retry-After 30, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	now := time.Now()
+	limiter := NewTokenBucketLimiter(60, 1)
+	limiter.now = func() time.Time { return now }
+
+	if ok, _, _ := limiter.Allow(context.Background(), "k"); !ok {
+		t.Fatal("expected first request to be allowed")
+	}
+	if ok, wait, _ := limiter.Allow(context.Background(), "k"); ok || wait != time.Second {
+		t.Fatalf("expected rejection with 1s wait, got ok=%v wait=%v", ok, wait)
+	}
+
+	now = now.Add(time.Second)
+	if ok, _, _ := limiter.Allow(context.Background(), "k"); !ok {
+		t.Error("expected a token to be refilled after one second")
+	}
+}
+
+func restoreResourceLimits(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	memLimit := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(procs)
+		debug.SetMemoryLimit(memLimit)
+	})
+}
+
+func TestApplyResourceLimits(t *testing.T) {
+	t.Run("release sets configured limits", func(t *testing.T) {
+		restoreResourceLimits(t)
+		config := GetModeConfig(ReleaseMode)
+		config.MaxCPU = 1
+
+		applyResourceLimits(config)
+
+		if got := runtime.GOMAXPROCS(0); got != 1 {
+			t.Errorf("expected GOMAXPROCS 1, got %d", got)
+		}
+		if got := debug.SetMemoryLimit(-1); got != config.MaxMemory {
+			t.Errorf("expected memory limit %d, got %d", config.MaxMemory, got)
+		}
+	})
+
+	t.Run("debug keeps defaults", func(t *testing.T) {
+		restoreResourceLimits(t)
+		before := runtime.GOMAXPROCS(0)
+		beforeMem := debug.SetMemoryLimit(-1)
+
+		applyResourceLimits(GetModeConfig(DebugMode))
+
+		if got := runtime.GOMAXPROCS(0); got != before {
+			t.Errorf("expected GOMAXPROCS to stay %d, got %d", before, got)
+		}
+		if got := debug.SetMemoryLimit(-1); got != beforeMem {
+			t.Errorf("expected memory limit to stay %d, got %d", beforeMem, got)
+		}
+	})
+}
+
+func TestDebugRuntimeEndpoint(t *testing.T) {
+	restoreResourceLimits(t)
+
+	app := NewApplication(DebugMode)
+	req := httptest.NewRequest("GET", "/debug/runtime", nil)
+	w := httptest.NewRecorder()
+	app.Router.ServeHTTP(w, req)
+
+	var body struct {
+		GOMAXPROCS int `json:"gomaxprocs"`
+		Configured struct {
+			MaxCPU int `json:"max_cpu"`
+		} `json:"configured"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+	if body.GOMAXPROCS != runtime.GOMAXPROCS(0) || body.Configured.MaxCPU != 0 {
+		t.Errorf("unexpected runtime report: %+v", body)
+	}
+
+	release := NewApplication(ReleaseMode)
+	w = httptest.NewRecorder()
+	release.Router.ServeHTTP(w, httptest.NewRequest("GET", "/debug/runtime", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected /debug/runtime to be debug-only, got %d", w.Code)
+	}
+}
+
+func TestReleaseModeExposesRequestMetrics(t *testing.T) {
+	restoreResourceLimits(t)
+
+	app := NewApplication(ReleaseMode)
+	var ready atomic.Bool
+	ready.Store(true)
+	engine := NewModeEngine(app.Config, app.Router, &ready)
+
+	for i := 0; i < 3; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	want := `http_requests_total{method="GET",route="/api/users",status="2xx"} 3`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected %q in metrics output:\n%s", want, w.Body.String())
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"example.com/gin-playground/pkg/bindingerr"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -40,17 +47,35 @@ type User struct {
 // Post 모델
 type Post struct {
 	Base
-	Title      string    `gorm:"not null;size:200" json:"title" binding:"required"`
-	Content    string    `gorm:"type:text" json:"content" binding:"required"`
-	Slug       string    `gorm:"uniqueIndex;not null" json:"slug"`
-	Published  bool      `gorm:"default:false;index" json:"published"`
-	ViewCount  int       `gorm:"default:0" json:"view_count"`
-	UserID     uint      `json:"user_id" binding:"required"`
-	User       User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Tags       []Tag     `gorm:"many2many:post_tags;" json:"tags,omitempty"`
-	Comments   []Comment `gorm:"foreignKey:PostID" json:"comments,omitempty"`
-	CategoryID *uint     `json:"category_id"`
-	Category   *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Title       string     `gorm:"not null;size:200" json:"title" binding:"required"`
+	Content     string     `gorm:"type:text" json:"content" binding:"required"`
+	Slug        string     `gorm:"uniqueIndex;not null" json:"slug"`
+	Published   bool       `gorm:"default:false;index;index:idx_posts_feed,priority:1" json:"published"`
+	PublishedAt *time.Time `gorm:"index:idx_posts_feed,priority:2" json:"published_at,omitempty"` // 피드 정렬 기준
+	ViewCount   int        `gorm:"default:0" json:"view_count"`
+	UserID      uint       `json:"user_id" binding:"required"`
+	User        User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Tags        []Tag      `gorm:"many2many:post_tags;" json:"tags,omitempty"`
+	Comments    []Comment  `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	CategoryID  *uint      `json:"category_id"`
+	Category    *Category  `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Version     uint       `gorm:"not null;default:1" json:"version"` // 낙관적 잠금용
+}
+
+// syncPublishedAt - 처음 공개될 때 공개 시각을 기록하고, 비공개로 돌리면 지움
+func (p *Post) syncPublishedAt(now time.Time) {
+	switch {
+	case p.Published && p.PublishedAt == nil:
+		p.PublishedAt = &now
+	case !p.Published:
+		p.PublishedAt = nil
+	}
+}
+
+// BeforeSave - Create/Save 경로에서 공개 시각을 맞춤
+func (p *Post) BeforeSave(tx *gorm.DB) error {
+	p.syncPublishedAt(time.Now())
+	return nil
 }
 
 // Category 모델
@@ -84,122 +109,346 @@ type Comment struct {
 
 type Database struct {
 	*gorm.DB
+	replicas []*sql.DB
+}
+
+// DatabaseOptions - 연결 및 커넥션 풀 설정
+type DatabaseOptions struct {
+	DSN   string // SQLite 파일 경로 (쿼리 파라미터 포함 가능)
+	Debug bool   // SQL 로그 출력
+
+	MaxOpenConns    int           // 최대 동시 연결 수
+	MaxIdleConns    int           // 유휴 연결 유지 수
+	ConnMaxLifetime time.Duration // 연결 재사용 최대 시간
+
+	PrepareStmt bool          // Prepared Statement 캐시 사용
+	EnableWAL   bool          // WAL 저널 모드 - 읽기와 쓰기가 서로 막지 않음
+	BusyTimeout time.Duration // 잠금 대기 시간 - 즉시 "database is locked"로 실패하지 않도록
+
+	// ReplicaDSNs - 읽기 전용 복제본 (비어 있으면 모든 쿼리가 primary로)
+	// 복제 자체는 DB 쪽에서 처리한다고 가정하며, 복제본에는 마이그레이션을 실행하지 않음
+	ReplicaDSNs []string
+}
+
+// DefaultDatabaseOptions - 기본 설정
+func DefaultDatabaseOptions() DatabaseOptions {
+	return DatabaseOptions{
+		DSN:             "blog.db",
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Hour,
+		PrepareStmt:     true,
+		EnableWAL:       true,
+		BusyTimeout:     5 * time.Second,
+	}
+}
+
+// dsn - 옵션을 SQLite 드라이버 DSN 파라미터로 변환
+func (o DatabaseOptions) dsn() string {
+	return o.withParams(o.DSN)
+}
+
+func (o DatabaseOptions) withParams(dsn string) string {
+	var params []string
+	if o.EnableWAL {
+		params = append(params, "_journal_mode=WAL")
+	}
+	if o.BusyTimeout > 0 {
+		params = append(params, fmt.Sprintf("_busy_timeout=%d", o.BusyTimeout.Milliseconds()))
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(params, "&")
 }
 
-func NewDatabase(debug bool) (*Database, error) {
+func NewDatabase(opts DatabaseOptions) (*Database, error) {
 	// SQLite 연결
 	logLevel := logger.Error
-	if debug {
+	if opts.Debug {
 		logLevel = logger.Info
 	}
 
-	db, err := gorm.Open(sqlite.Open("blog.db"), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+	db, err := gorm.Open(sqlite.Open(opts.dsn()), &gorm.Config{
+		Logger:      logger.Default.LogMode(logLevel),
+		PrepareStmt: opts.PrepareStmt,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database: %w", err)
 	}
 
+	// 커넥션 풀 설정
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+	opts.configurePool(sqlDB)
+
 	// 마이그레이션
 	if err := db.AutoMigrate(&User{}, &Post{}, &Category{}, &Tag{}, &Comment{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate: %w", err)
 	}
 
-	return &Database{db}, nil
+	database := &Database{DB: db}
+	for _, dsn := range opts.ReplicaDSNs {
+		replica, err := sql.Open("sqlite3", opts.withParams(dsn))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open replica: %w", err)
+		}
+		opts.configurePool(replica)
+		database.replicas = append(database.replicas, replica)
+	}
+	if len(database.replicas) > 0 {
+		if err := database.registerReadRouting(); err != nil {
+			return nil, err
+		}
+	}
+
+	return database, nil
+}
+
+func (o DatabaseOptions) configurePool(sqlDB *sql.DB) {
+	if o.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(o.MaxOpenConns)
+	}
+	if o.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(o.MaxIdleConns)
+	}
+	if o.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(o.ConnMaxLifetime)
+	}
+}
+
+// Close - primary와 복제본 연결을 모두 닫음
+func (d *Database) Close() error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	for _, replica := range d.replicas {
+		replica.Close()
+	}
+	return sqlDB.Close()
 }
 
 // ============================================================================
-// Repository 패턴
+// 읽기/쓰기 분리 (Read Replica)
 // ============================================================================
 
-type UserRepository struct {
-	db *Database
+// Find/First/Count 같은 조회는 복제본으로, Create/Update/Delete와 트랜잭션은 primary로 보냄
+// (gorm.io/plugin/dbresolver와 같은 방식으로 Query 콜백에서 ConnPool을 바꿔치기)
+
+type primaryKey struct{}
+
+// WithPrimary - 이 컨텍스트로 실행하는 조회는 primary에서 읽음
+// 복제 지연 때문에 방금 쓴 데이터가 복제본에 아직 없을 수 있으므로, 쓰기 직후 다시 읽을 때 사용
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
 }
 
-func NewUserRepository(db *Database) *UserRepository {
-	return &UserRepository{db: db}
+func usePrimary(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
 }
 
-// Create - 사용자 생성
-func (r *UserRepository) Create(user *User) error {
-	return r.db.Create(user).Error
+// registerReadRouting - 조회 쿼리가 실행되기 직전에 복제본 하나를 골라 연결을 교체
+func (d *Database) registerReadRouting() error {
+	var next atomic.Uint64
+	return d.Callback().Query().Before("gorm:query").Register("replica:route_reads", func(tx *gorm.DB) {
+		// 트랜잭션 안의 조회는 같은 트랜잭션(primary)에서 읽어야 일관성이 유지됨
+		if _, inTx := tx.Statement.ConnPool.(gorm.TxCommitter); inTx {
+			return
+		}
+		if ctx := tx.Statement.Context; ctx != nil && usePrimary(ctx) {
+			return
+		}
+		tx.Statement.ConnPool = d.replicas[next.Add(1)%uint64(len(d.replicas))]
+	})
 }
 
-// FindByID - ID로 사용자 조회
-func (r *UserRepository) FindByID(id uint) (*User, error) {
-	var user User
-	err := r.db.Preload("Posts").Preload("Comments").First(&user, id).Error
-	if err != nil {
-		return nil, err
+// ============================================================================
+// Scopes (재사용 가능한 쿼리 조건)
+// ============================================================================
+
+// db.Scopes(...)로 조합하는 쿼리 조각. 각 scope는 조건 하나만 책임지므로
+// 저장소 메서드는 필요한 조건을 선언적으로 나열하기만 하면 됨
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// Published - 공개된 포스트만
+func Published(db *gorm.DB) *gorm.DB {
+	return db.Where("published = ?", true)
+}
+
+// Draft - 공개되지 않은 포스트만
+func Draft(db *gorm.DB) *gorm.DB {
+	return db.Where("published = ?", false)
+}
+
+// Popular - 조회수가 많은 순으로 정렬
+func Popular(db *gorm.DB) *gorm.DB {
+	return db.Order("view_count DESC")
+}
+
+// ByUser - 특정 사용자가 작성한 포스트만
+func ByUser(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
 	}
-	return &user, nil
 }
 
-// FindByEmail - 이메일로 사용자 조회
-func (r *UserRepository) FindByEmail(email string) (*User, error) {
-	var user User
-	err := r.db.Where("email = ?", email).First(&user).Error
-	if err != nil {
+// ByCategory - 특정 카테고리의 포스트만
+func ByCategory(categoryID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("category_id = ?", categoryID)
+	}
+}
+
+// Search - 제목이나 본문에 keyword가 포함된 포스트
+// 다른 조건과 AND로 묶일 때 GORM이 OR 조건을 괄호로 감쌈
+func Search(keyword string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		searchTerm := "%" + keyword + "%"
+		return db.Where("title LIKE ? OR content LIKE ?", searchTerm, searchTerm)
+	}
+}
+
+// Paginate - 1부터 시작하는 page와 size로 Offset/Limit 설정
+// 범위를 벗어난 값은 첫 페이지, 기본 크기로 보정
+func Paginate(page, size int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if page < 1 {
+			page = 1
+		}
+		if size < 1 || size > maxPageSize {
+			size = defaultPageSize
+		}
+		return db.Offset((page - 1) * size).Limit(size)
+	}
+}
+
+// ============================================================================
+// Repository 패턴
+// ============================================================================
+
+// Repository - 모든 모델이 공유하는 CRUD. 구체 저장소는 이를 임베드하고
+// preload나 필터가 필요한 메서드만 같은 이름으로 다시 정의함
+type Repository[T any] struct {
+	db *Database
+}
+
+func NewRepository[T any](db *Database) Repository[T] {
+	return Repository[T]{db: db}
+}
+
+// Create - 엔티티 생성
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	return r.db.WithContext(ctx).Create(entity).Error
+}
+
+// FindByID - ID로 조회 (연관관계 preload 없음)
+func (r *Repository[T]) FindByID(ctx context.Context, id uint) (*T, error) {
+	var entity T
+	if err := r.db.WithContext(ctx).First(&entity, id).Error; err != nil {
 		return nil, err
 	}
-	return &user, nil
+	return &entity, nil
 }
 
-// FindAll - 모든 사용자 조회 (페이지네이션)
-func (r *UserRepository) FindAll(offset, limit int) ([]User, int64, error) {
-	var users []User
+// FindAll - 전체 조회 (페이지네이션)
+func (r *Repository[T]) FindAll(ctx context.Context, offset, limit int) ([]T, int64, error) {
+	var entities []T
 	var total int64
+	db := r.db.WithContext(ctx)
 
 	// 전체 개수
-	r.db.Model(&User{}).Count(&total)
+	db.Model(new(T)).Count(&total)
 
 	// 페이지네이션 적용
-	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
-	return users, total, err
+	err := db.Offset(offset).Limit(limit).Find(&entities).Error
+	return entities, total, err
 }
 
-// Update - 사용자 업데이트
-func (r *UserRepository) Update(user *User) error {
-	return r.db.Save(user).Error
+// Update - 전체 필드 저장
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	return r.db.WithContext(ctx).Save(entity).Error
 }
 
 // UpdateFields - 특정 필드만 업데이트
-func (r *UserRepository) UpdateFields(id uint, fields map[string]interface{}) error {
-	return r.db.Model(&User{}).Where("id = ?", id).Updates(fields).Error
+func (r *Repository[T]) UpdateFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id).Updates(fields).Error
+}
+
+// Delete - 삭제 (DeletedAt이 있으면 소프트 삭제)
+func (r *Repository[T]) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(new(T), id).Error
 }
 
-// Delete - 사용자 삭제 (소프트 삭제)
-func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&User{}, id).Error
+// HardDelete - 완전 삭제
+func (r *Repository[T]) HardDelete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(new(T), id).Error
 }
 
-// HardDelete - 사용자 완전 삭제
-func (r *UserRepository) HardDelete(id uint) error {
-	return r.db.Unscoped().Delete(&User{}, id).Error
+// UserRepository - Create/FindAll/Update/UpdateFields/Delete/HardDelete는 Repository[User] 그대로 사용
+type UserRepository struct {
+	Repository[User]
+}
+
+func NewUserRepository(db *Database) *UserRepository {
+	return &UserRepository{NewRepository[User](db)}
+}
+
+// FindByID - ID로 사용자 조회 (포스트, 댓글 포함)
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*User, error) {
+	var user User
+	err := r.db.WithContext(ctx).Preload("Posts").Preload("Comments").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-// PostRepository
+// FindByEmail - 이메일로 사용자 조회
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	var user User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// PostRepository - Delete/HardDelete/UpdateFields는 Repository[Post] 그대로 사용
 type PostRepository struct {
-	db *Database
+	Repository[Post]
 }
 
 func NewPostRepository(db *Database) *PostRepository {
-	return &PostRepository{db: db}
+	return &PostRepository{NewRepository[Post](db)}
 }
 
 // Create - 포스트 생성
-func (r *PostRepository) Create(post *Post) error {
+func (r *PostRepository) Create(ctx context.Context, post *Post) error {
 	// Slug 자동 생성
 	if post.Slug == "" {
 		post.Slug = fmt.Sprintf("%s-%d", slugify(post.Title), time.Now().Unix())
 	}
-	return r.db.Create(post).Error
+	return r.db.WithContext(ctx).Create(post).Error
 }
 
 // FindByID - ID로 포스트 조회
-func (r *PostRepository) FindByID(id uint) (*Post, error) {
+func (r *PostRepository) FindByID(ctx context.Context, id uint) (*Post, error) {
 	var post Post
-	err := r.db.Preload("User").
+	db := r.db.WithContext(ctx)
+	err := db.Preload("User").
 		Preload("Tags").
 		Preload("Category").
 		Preload("Comments.User").
@@ -209,15 +458,15 @@ func (r *PostRepository) FindByID(id uint) (*Post, error) {
 	}
 
 	// 조회수 증가
-	r.db.Model(&post).Update("view_count", post.ViewCount+1)
+	db.Model(&post).Update("view_count", post.ViewCount+1)
 
 	return &post, nil
 }
 
 // FindBySlug - Slug로 포스트 조회
-func (r *PostRepository) FindBySlug(slug string) (*Post, error) {
+func (r *PostRepository) FindBySlug(ctx context.Context, slug string) (*Post, error) {
 	var post Post
-	err := r.db.Where("slug = ?", slug).
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).
 		Preload("User").
 		Preload("Tags").
 		Preload("Category").
@@ -226,31 +475,59 @@ func (r *PostRepository) FindBySlug(slug string) (*Post, error) {
 }
 
 // FindAll - 모든 포스트 조회 (필터링 + 페이지네이션)
-func (r *PostRepository) FindAll(filters map[string]interface{}, offset, limit int) ([]Post, int64, error) {
+func (r *PostRepository) FindAll(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]Post, int64, error) {
 	var posts []Post
 	var total int64
 
-	query := r.db.Model(&Post{})
-
 	// 필터링
+	var scopes []func(*gorm.DB) *gorm.DB
 	if published, ok := filters["published"].(bool); ok {
-		query = query.Where("published = ?", published)
+		if published {
+			scopes = append(scopes, Published)
+		} else {
+			scopes = append(scopes, Draft)
+		}
 	}
 	if userID, ok := filters["user_id"].(uint); ok {
-		query = query.Where("user_id = ?", userID)
+		scopes = append(scopes, ByUser(userID))
 	}
 	if categoryID, ok := filters["category_id"].(uint); ok {
-		query = query.Where("category_id = ?", categoryID)
+		scopes = append(scopes, ByCategory(categoryID))
 	}
 
 	// 전체 개수
-	query.Count(&total)
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&Post{}).Scopes(scopes...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
 	// 페이지네이션 및 조회
-	err := query.
+	err := db.Scopes(scopes...).
+		Scopes(Paginate(page, pageSize)).
 		Preload("User").
 		Preload("Category").
 		Order("created_at DESC").
+		Find(&posts).Error
+
+	return posts, total, err
+}
+
+// Feed - 공개된 포스트를 최신 공개순으로 조회 (published, published_at 복합 인덱스 사용)
+// 같은 시각에 공개된 포스트는 id로 순서를 고정해 페이지 경계가 흔들리지 않게 함
+func (r *PostRepository) Feed(ctx context.Context, offset, limit int) ([]Post, int64, error) {
+	var posts []Post
+	var total int64
+
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&Post{}).Where("published = ?", true).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Where("published = ?", true).
+		Preload("User").
+		Preload("Category").
+		Preload("Tags").
+		Order("published_at DESC, id DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&posts).Error
@@ -258,44 +535,198 @@ func (r *PostRepository) FindAll(filters map[string]interface{}, offset, limit i
 	return posts, total, err
 }
 
-// Update - 포스트 업데이트
-func (r *PostRepository) Update(post *Post) error {
-	return r.db.Save(post).Error
-}
+// ErrStaleObject - 다른 요청이 먼저 수정해서 기대한 버전이 더 이상 최신이 아님
+var ErrStaleObject = errors.New("stale object: post was modified by another request")
+
+// Update - 포스트 업데이트 (낙관적 잠금)
+// post.Version은 클라이언트가 마지막으로 읽은 버전이며, 성공하면 1 증가함
+func (r *PostRepository) Update(ctx context.Context, post *Post) error {
+	// map 업데이트는 BeforeSave가 post에 적용되지 않으므로 직접 맞춤
+	post.syncPublishedAt(time.Now())
+	result := r.db.WithContext(ctx).Model(&Post{}).
+		Where("id = ? AND version = ?", post.ID, post.Version).
+		Updates(map[string]interface{}{
+			"title":        post.Title,
+			"content":      post.Content,
+			"slug":         post.Slug,
+			"published":    post.Published,
+			"published_at": post.PublishedAt,
+			"user_id":      post.UserID,
+			"category_id":  post.CategoryID,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleObject
+	}
 
-// Delete - 포스트 삭제
-func (r *PostRepository) Delete(id uint) error {
-	return r.db.Delete(&Post{}, id).Error
+	post.Version++
+	return nil
 }
 
 // AddTag - 포스트에 태그 추가
-func (r *PostRepository) AddTag(postID uint, tagID uint) error {
+func (r *PostRepository) AddTag(ctx context.Context, postID uint, tagID uint) error {
 	var post Post
 	var tag Tag
+	db := r.db.WithContext(ctx)
 
-	if err := r.db.First(&post, postID).Error; err != nil {
+	if err := db.First(&post, postID).Error; err != nil {
 		return err
 	}
-	if err := r.db.First(&tag, tagID).Error; err != nil {
+	if err := db.First(&tag, tagID).Error; err != nil {
 		return err
 	}
 
-	return r.db.Model(&post).Association("Tags").Append(&tag)
+	return db.Model(&post).Association("Tags").Append(&tag)
 }
 
 // RemoveTag - 포스트에서 태그 제거
-func (r *PostRepository) RemoveTag(postID uint, tagID uint) error {
+func (r *PostRepository) RemoveTag(ctx context.Context, postID uint, tagID uint) error {
 	var post Post
 	var tag Tag
+	db := r.db.WithContext(ctx)
 
-	if err := r.db.First(&post, postID).Error; err != nil {
+	if err := db.First(&post, postID).Error; err != nil {
 		return err
 	}
-	if err := r.db.First(&tag, tagID).Error; err != nil {
+	if err := db.First(&tag, tagID).Error; err != nil {
 		return err
 	}
 
-	return r.db.Model(&post).Association("Tags").Delete(&tag)
+	return db.Model(&post).Association("Tags").Delete(&tag)
+}
+
+var ErrTagNotFound = errors.New("tag not found")
+
+// MissingPostsError - 일괄 태그 작업 대상 중 존재하지 않는 포스트
+type MissingPostsError struct {
+	IDs []uint
+}
+
+func (e *MissingPostsError) Error() string {
+	return fmt.Sprintf("posts not found: %v", e.IDs)
+}
+
+// loadTagAndPosts - 변경 전에 태그와 모든 포스트가 존재하는지 확인 (postIDs는 중복 제거)
+func loadTagAndPosts(tx *gorm.DB, tagID uint, postIDs []uint) (*Tag, []Post, error) {
+	var tag Tag
+	if err := tx.First(&tag, tagID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTagNotFound
+		}
+		return nil, nil, err
+	}
+
+	var posts []Post
+	if err := tx.Where("id IN ?", postIDs).Order("id").Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[uint]bool, len(posts))
+	for _, post := range posts {
+		found[post.ID] = true
+	}
+	var missing []uint
+	for _, id := range postIDs {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true // 중복 ID는 한 번만 보고
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, &MissingPostsError{IDs: missing}
+	}
+
+	return &tag, posts, nil
+}
+
+// taggedPostIDs - posts 중 이미 태그가 붙은 포스트 ID
+func taggedPostIDs(tx *gorm.DB, tagID uint, posts []Post) (map[uint]bool, error) {
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	var tagged []uint
+	if err := tx.Table("post_tags").
+		Where("tag_id = ? AND post_id IN ?", tagID, ids).
+		Pluck("post_id", &tagged).Error; err != nil {
+		return nil, err
+	}
+
+	set := make(map[uint]bool, len(tagged))
+	for _, id := range tagged {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// AssignTag - 여러 포스트에 태그를 한 트랜잭션으로 추가 (이미 붙은 포스트는 건너뜀)
+func (r *PostRepository) AssignTag(ctx context.Context, tagID uint, postIDs []uint) (assigned, skipped []uint, err error) {
+	assigned, skipped = []uint{}, []uint{} // JSON에서 null 대신 []
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tag, posts, err := loadTagAndPosts(tx, tagID, postIDs)
+		if err != nil {
+			return err
+		}
+		tagged, err := taggedPostIDs(tx, tagID, posts)
+		if err != nil {
+			return err
+		}
+
+		var toAdd []Post
+		for _, post := range posts {
+			if tagged[post.ID] {
+				skipped = append(skipped, post.ID)
+				continue
+			}
+			toAdd = append(toAdd, post)
+			assigned = append(assigned, post.ID)
+		}
+		if len(toAdd) == 0 {
+			return nil
+		}
+		return tx.Model(tag).Association("Posts").Append(&toAdd)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return assigned, skipped, nil
+}
+
+// UnassignTag - 여러 포스트에서 태그를 한 트랜잭션으로 제거 (태그가 없는 포스트는 건너뜀)
+func (r *PostRepository) UnassignTag(ctx context.Context, tagID uint, postIDs []uint) (removed, skipped []uint, err error) {
+	removed, skipped = []uint{}, []uint{} // JSON에서 null 대신 []
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tag, posts, err := loadTagAndPosts(tx, tagID, postIDs)
+		if err != nil {
+			return err
+		}
+		tagged, err := taggedPostIDs(tx, tagID, posts)
+		if err != nil {
+			return err
+		}
+
+		var toRemove []Post
+		for _, post := range posts {
+			if !tagged[post.ID] {
+				skipped = append(skipped, post.ID)
+				continue
+			}
+			toRemove = append(toRemove, post)
+			removed = append(removed, post.ID)
+		}
+		if len(toRemove) == 0 {
+			return nil
+		}
+		return tx.Model(tag).Association("Posts").Delete(&toRemove)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return removed, skipped, nil
 }
 
 // ============================================================================
@@ -317,19 +748,18 @@ func NewBlogService(db *Database) *BlogService {
 }
 
 // GetUserWithPosts - 사용자와 포스트 함께 조회
-func (s *BlogService) GetUserWithPosts(userID uint) (*User, error) {
+func (s *BlogService) GetUserWithPosts(ctx context.Context, userID uint) (*User, error) {
 	var user User
-	err := s.db.Preload("Posts", "published = ?", true).
+	err := s.db.WithContext(ctx).Preload("Posts", "published = ?", true).
 		Preload("Posts.Category").
 		First(&user, userID).Error
 	return &user, err
 }
 
 // GetPopularPosts - 인기 포스트 조회
-func (s *BlogService) GetPopularPosts(limit int) ([]Post, error) {
+func (s *BlogService) GetPopularPosts(ctx context.Context, limit int) ([]Post, error) {
 	var posts []Post
-	err := s.db.Where("published = ?", true).
-		Order("view_count DESC").
+	err := s.db.WithContext(ctx).Scopes(Published, Popular).
 		Limit(limit).
 		Preload("User").
 		Find(&posts).Error
@@ -337,11 +767,9 @@ func (s *BlogService) GetPopularPosts(limit int) ([]Post, error) {
 }
 
 // SearchPosts - 포스트 검색
-func (s *BlogService) SearchPosts(keyword string) ([]Post, error) {
+func (s *BlogService) SearchPosts(ctx context.Context, keyword string) ([]Post, error) {
 	var posts []Post
-	searchTerm := "%" + keyword + "%"
-	err := s.db.Where("title LIKE ? OR content LIKE ?", searchTerm, searchTerm).
-		Where("published = ?", true).
+	err := s.db.WithContext(ctx).Scopes(Published, Search(keyword)).
 		Preload("User").
 		Find(&posts).Error
 	return posts, err
@@ -359,6 +787,29 @@ func NewHandler(service *BlogService) *Handler {
 	return &Handler{service: service}
 }
 
+// ListQuery - 목록 조회 공통 쿼리 파라미터
+// 생략하면 page=1, page_size=10. 범위를 벗어나거나 숫자가 아니면 400 응답
+type ListQuery struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=10" binding:"min=1,max=100"`
+}
+
+func (q ListQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+func (q ListQuery) TotalPages(total int64) int64 {
+	return (total + int64(q.PageSize) - 1) / int64(q.PageSize)
+}
+
+// PostListQuery - 포스트 목록 필터 (생략한 필터는 적용하지 않음)
+type PostListQuery struct {
+	ListQuery
+	Published  *bool `form:"published"`
+	UserID     *uint `form:"user_id"`
+	CategoryID *uint `form:"category_id"`
+}
+
 // User Handlers
 func (h *Handler) CreateUser(c *gin.Context) {
 	var user User
@@ -367,7 +818,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.userRepo.Create(&user); err != nil {
+	if err := h.service.userRepo.Create(c.Request.Context(), &user); err != nil {
 		c.JSON(500, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -382,7 +833,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.userRepo.FindByID(id)
+	user, err := h.service.userRepo.FindByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "User not found"})
 		return
@@ -392,34 +843,23 @@ func (h *Handler) GetUser(c *gin.Context) {
 }
 
 func (h *Handler) GetUsers(c *gin.Context) {
-	page := c.DefaultQuery("page", "1")
-	pageSize := c.DefaultQuery("page_size", "10")
-
-	var p, ps int
-	fmt.Sscanf(page, "%d", &p)
-	fmt.Sscanf(pageSize, "%d", &ps)
-
-	if p < 1 {
-		p = 1
-	}
-	if ps < 1 || ps > 100 {
-		ps = 10
+	var q ListQuery
+	if !bindingerr.BindQuery(c, &q) {
+		return
 	}
 
-	offset := (p - 1) * ps
-
-	users, total, err := h.service.userRepo.FindAll(offset, ps)
+	users, total, err := h.service.userRepo.FindAll(c.Request.Context(), q.Offset(), q.PageSize)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch users"})
 		return
 	}
 
 	c.JSON(200, gin.H{
-		"users":      users,
-		"total":      total,
-		"page":       p,
-		"page_size":  ps,
-		"total_pages": (total + int64(ps) - 1) / int64(ps),
+		"users":       users,
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": q.TotalPages(total),
 	})
 }
 
@@ -436,7 +876,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.userRepo.UpdateFields(id, updates); err != nil {
+	if err := h.service.userRepo.UpdateFields(c.Request.Context(), id, updates); err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update user"})
 		return
 	}
@@ -455,9 +895,9 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 
 	var err error
 	if hard {
-		err = h.service.userRepo.HardDelete(id)
+		err = h.service.userRepo.HardDelete(c.Request.Context(), id)
 	} else {
-		err = h.service.userRepo.Delete(id)
+		err = h.service.userRepo.Delete(c.Request.Context(), id)
 	}
 
 	if err != nil {
@@ -476,7 +916,7 @@ func (h *Handler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.postRepo.Create(&post); err != nil {
+	if err := h.service.postRepo.Create(c.Request.Context(), &post); err != nil {
 		c.JSON(500, gin.H{"error": "Failed to create post"})
 		return
 	}
@@ -491,7 +931,7 @@ func (h *Handler) GetPost(c *gin.Context) {
 		return
 	}
 
-	post, err := h.service.postRepo.FindByID(id)
+	post, err := h.service.postRepo.FindByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "Post not found"})
 		return
@@ -503,7 +943,7 @@ func (h *Handler) GetPost(c *gin.Context) {
 func (h *Handler) GetPostBySlug(c *gin.Context) {
 	slug := c.Param("slug")
 
-	post, err := h.service.postRepo.FindBySlug(slug)
+	post, err := h.service.postRepo.FindBySlug(c.Request.Context(), slug)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "Post not found"})
 		return
@@ -513,55 +953,86 @@ func (h *Handler) GetPostBySlug(c *gin.Context) {
 }
 
 func (h *Handler) GetPosts(c *gin.Context) {
-	page := c.DefaultQuery("page", "1")
-	pageSize := c.DefaultQuery("page_size", "10")
-	published := c.DefaultQuery("published", "")
-	userID := c.DefaultQuery("user_id", "")
-	categoryID := c.DefaultQuery("category_id", "")
-
-	var p, ps int
-	fmt.Sscanf(page, "%d", &p)
-	fmt.Sscanf(pageSize, "%d", &ps)
-
-	if p < 1 {
-		p = 1
-	}
-	if ps < 1 || ps > 100 {
-		ps = 10
+	var q PostListQuery
+	if !bindingerr.BindQuery(c, &q) {
+		return
 	}
 
-	offset := (p - 1) * ps
-
 	filters := make(map[string]interface{})
-	if published != "" {
-		filters["published"] = published == "true"
+	if q.Published != nil {
+		filters["published"] = *q.Published
 	}
-	if userID != "" {
-		var uid uint
-		fmt.Sscanf(userID, "%d", &uid)
-		filters["user_id"] = uid
+	if q.UserID != nil {
+		filters["user_id"] = *q.UserID
 	}
-	if categoryID != "" {
-		var cid uint
-		fmt.Sscanf(categoryID, "%d", &cid)
-		filters["category_id"] = cid
+	if q.CategoryID != nil {
+		filters["category_id"] = *q.CategoryID
 	}
 
-	posts, total, err := h.service.postRepo.FindAll(filters, offset, ps)
+	posts, total, err := h.service.postRepo.FindAll(c.Request.Context(), filters, q.Page, q.PageSize)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch posts"})
 		return
 	}
 
 	c.JSON(200, gin.H{
-		"posts":      posts,
-		"total":      total,
-		"page":       p,
-		"page_size":  ps,
-		"total_pages": (total + int64(ps) - 1) / int64(ps),
+		"posts":       posts,
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": q.TotalPages(total),
 	})
 }
 
+// GetFeed - 공개 포스트 피드 (최신 공개순)
+func (h *Handler) GetFeed(c *gin.Context) {
+	var q ListQuery
+	if !bindingerr.BindQuery(c, &q) {
+		return
+	}
+
+	posts, total, err := h.service.postRepo.Feed(c.Request.Context(), q.Offset(), q.PageSize)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"posts":       posts,
+		"total":       total,
+		"page":        q.Page,
+		"page_size":   q.PageSize,
+		"total_pages": q.TotalPages(total),
+	})
+}
+
+// UpdatePostRequest - 포스트 수정 요청
+// 보낸 필드만 수정하고, 생략한 필드는 기존 값을 유지합니다.
+// Version은 클라이언트가 마지막으로 읽은 버전으로, 그 사이 다른 수정이 있었다면 409 응답
+type UpdatePostRequest struct {
+	Title      *string `json:"title" binding:"omitempty,min=1"`
+	Content    *string `json:"content"`
+	Published  *bool   `json:"published"`
+	CategoryID *uint   `json:"category_id"`
+	Version    uint    `json:"version" binding:"required"`
+}
+
+// applyTo - 요청에 포함된 필드만 post에 반영
+func (req *UpdatePostRequest) applyTo(post *Post) {
+	if req.Title != nil {
+		post.Title = *req.Title
+	}
+	if req.Content != nil {
+		post.Content = *req.Content
+	}
+	if req.Published != nil {
+		post.Published = *req.Published
+	}
+	if req.CategoryID != nil {
+		post.CategoryID = req.CategoryID
+	}
+}
+
 func (h *Handler) UpdatePost(c *gin.Context) {
 	var id uint
 	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
@@ -569,23 +1040,44 @@ func (h *Handler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	// 읽고 고쳐 쓰는 요청이므로 복제 지연이 없는 primary에서 읽음
+	ctx := WithPrimary(c.Request.Context())
 	var post Post
-	if err := h.service.db.First(&post, id).Error; err != nil {
+	if err := h.service.db.WithContext(ctx).First(&post, id).Error; err != nil {
 		c.JSON(404, gin.H{"error": "Post not found"})
 		return
 	}
 
-	if err := c.ShouldBindJSON(&post); err != nil {
+	var req UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	post.ID = id
-	if err := h.service.postRepo.Update(&post); err != nil {
+	currentVersion := post.Version
+	req.applyTo(&post)
+	post.Version = req.Version // 기대 버전은 반드시 클라이언트가 보낸 값을 사용
+	if err := h.service.postRepo.Update(ctx, &post); err != nil {
+		if errors.Is(err, ErrStaleObject) {
+			// 조회 이후 다른 수정이 끼어들었을 수 있으므로 저장된 버전을 다시 읽음
+			var stored Post
+			if err := h.service.db.WithContext(ctx).Select("version").First(&stored, id).Error; err == nil {
+				currentVersion = stored.Version
+			}
+			c.JSON(409, gin.H{
+				"error":           "Post was modified by another request",
+				"current_version": currentVersion,
+			})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to update post"})
 		return
 	}
 
+	if err := h.service.db.WithContext(ctx).First(&post, id).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to load updated post"})
+		return
+	}
 	c.JSON(200, post)
 }
 
@@ -596,7 +1088,7 @@ func (h *Handler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.postRepo.Delete(id); err != nil {
+	if err := h.service.postRepo.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(500, gin.H{"error": "Failed to delete post"})
 		return
 	}
@@ -604,6 +1096,56 @@ func (h *Handler) DeletePost(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "Post deleted successfully"})
 }
 
+// Tag Handlers
+
+// BulkTagRequest - 일괄 태그 지정/해제 대상
+type BulkTagRequest struct {
+	PostIDs []uint `json:"post_ids" binding:"required,min=1,dive,gt=0"`
+}
+
+// bulkTag - assign/unassign 공통 처리
+func (h *Handler) bulkTag(c *gin.Context, apply func(ctx context.Context, tagID uint, postIDs []uint) (changed, skipped []uint, err error), changedKey string) {
+	var tagID uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &tagID); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+
+	var req BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	changed, skipped, err := apply(c.Request.Context(), tagID, req.PostIDs)
+	if err != nil {
+		var missing *MissingPostsError
+		switch {
+		case errors.Is(err, ErrTagNotFound):
+			c.JSON(404, gin.H{"error": err.Error()})
+		case errors.As(err, &missing):
+			c.JSON(404, gin.H{"error": err.Error(), "missing_post_ids": missing.IDs})
+		default:
+			c.JSON(500, gin.H{"error": "Failed to update post tags"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"tag_id":   tagID,
+		changedKey: changed,
+		"skipped":  skipped,
+	})
+}
+
+func (h *Handler) AssignTag(c *gin.Context) {
+	h.bulkTag(c, h.service.postRepo.AssignTag, "assigned")
+}
+
+func (h *Handler) UnassignTag(c *gin.Context) {
+	h.bulkTag(c, h.service.postRepo.UnassignTag, "removed")
+}
+
 // Search Handler
 func (h *Handler) SearchPosts(c *gin.Context) {
 	keyword := c.Query("q")
@@ -612,7 +1154,7 @@ func (h *Handler) SearchPosts(c *gin.Context) {
 		return
 	}
 
-	posts, err := h.service.SearchPosts(keyword)
+	posts, err := h.service.SearchPosts(c.Request.Context(), keyword)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to search posts"})
 		return
@@ -635,7 +1177,7 @@ func (h *Handler) GetPopularPosts(c *gin.Context) {
 		l = 10
 	}
 
-	posts, err := h.service.GetPopularPosts(l)
+	posts, err := h.service.GetPopularPosts(c.Request.Context(), l)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch popular posts"})
 		return
@@ -680,7 +1222,7 @@ func (h *Handler) GetAdvancedQueries(c *gin.Context) {
 		{
 			"name": "Scopes",
 			"description": "Reusable query conditions",
-			"example": `db.Scopes(Published, Popular).Find(&posts)`,
+			"example": `db.Scopes(Published, ByUser(1), Paginate(2, 20)).Find(&posts)`,
 		},
 	}
 
@@ -751,12 +1293,20 @@ func SetupRouter(handler *Handler) *gin.Engine {
 	{
 		posts.POST("", handler.CreatePost)
 		posts.GET("", handler.GetPosts)
+		posts.GET("/feed", handler.GetFeed)
 		posts.GET("/:id", handler.GetPost)
 		posts.GET("/slug/:slug", handler.GetPostBySlug)
 		posts.PUT("/:id", handler.UpdatePost)
 		posts.DELETE("/:id", handler.DeletePost)
 	}
 
+	// Tag routes (일괄 지정/해제)
+	tags := router.Group("/tags")
+	{
+		tags.POST("/:id/assign", handler.AssignTag)
+		tags.POST("/:id/unassign", handler.UnassignTag)
+	}
+
 	// Search and filters
 	router.GET("/search", handler.SearchPosts)
 	router.GET("/popular", handler.GetPopularPosts)
@@ -773,10 +1323,17 @@ func SetupRouter(handler *Handler) *gin.Engine {
 
 func main() {
 	// 데이터베이스 연결
-	db, err := NewDatabase(true)
+	opts := DefaultDatabaseOptions()
+	opts.Debug = true
+	// 예: READ_REPLICA_DSNS=replica1.db,replica2.db
+	if dsns := os.Getenv("READ_REPLICA_DSNS"); dsns != "" {
+		opts.ReplicaDSNs = strings.Split(dsns, ",")
+	}
+	db, err := NewDatabase(opts)
 	if err != nil {
 		log.Fatal("Failed to connect database:", err)
 	}
+	defer db.Close()
 
 	// 서비스 초기화
 	service := NewBlogService(db)
@@ -0,0 +1,799 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+
+	opts := DefaultDatabaseOptions()
+	opts.DSN = filepath.Join(t.TempDir(), "blog.db")
+	db, err := NewDatabase(opts)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func newTestRouter(t *testing.T) (*gin.Engine, *Database) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDatabase(t)
+	return SetupRouter(NewHandler(NewBlogService(db))), db
+}
+
+func createTestPost(t *testing.T, db *Database) *Post {
+	t.Helper()
+
+	user := &User{Email: "alice@example.com", Username: "alice", Name: "Alice"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	post := &Post{Title: "Original", Content: "Body", UserID: user.ID}
+	if err := NewPostRepository(db).Create(context.Background(), post); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	return post
+}
+
+func perform(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConcurrentPostEditsConflict(t *testing.T) {
+	router, db := newTestRouter(t)
+	post := createTestPost(t, db)
+	if post.Version != 1 {
+		t.Fatalf("expected new post at version 1, got %d", post.Version)
+	}
+
+	// 두 클라이언트가 같은 버전(1)을 읽은 뒤 각자 수정
+	path := fmt.Sprintf("/posts/%d", post.ID)
+	edit := func(title string) string {
+		return fmt.Sprintf(`{"title": %q, "content": "Body", "version": 1}`, title)
+	}
+
+	w := perform(router, "PUT", path, edit("First edit"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first edit: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated Post
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("failed to decode post: %v", err)
+	}
+	if updated.Version != 2 || updated.Title != "First edit" {
+		t.Errorf("unexpected updated post: version=%d title=%q", updated.Version, updated.Title)
+	}
+
+	w = perform(router, "PUT", path, edit("Second edit"))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second edit: expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var conflict struct {
+		CurrentVersion uint `json:"current_version"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &conflict)
+	if conflict.CurrentVersion != 2 {
+		t.Errorf("expected current_version 2 in conflict response, got %d", conflict.CurrentVersion)
+	}
+
+	// 먼저 커밋된 수정이 덮어써지지 않아야 함
+	var stored Post
+	db.First(&stored, post.ID)
+	if stored.Title != "First edit" || stored.Version != 2 {
+		t.Errorf("first edit was clobbered: version=%d title=%q", stored.Version, stored.Title)
+	}
+}
+
+func TestUpdatePostKeepsOmittedFields(t *testing.T) {
+	router, db := newTestRouter(t)
+	post := createTestPost(t, db)
+	db.Model(post).Update("published", true)
+
+	w := perform(router, "PUT", fmt.Sprintf("/posts/%d", post.ID), `{"title": "Renamed", "version": 1}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stored Post
+	db.First(&stored, post.ID)
+	if stored.Title != "Renamed" || stored.Content != "Body" || !stored.Published {
+		t.Errorf("omitted fields should be kept: title=%q content=%q published=%v",
+			stored.Title, stored.Content, stored.Published)
+	}
+}
+
+func TestUpdatePostRequiresVersion(t *testing.T) {
+	router, db := newTestRouter(t)
+	post := createTestPost(t, db)
+
+	body := `{"title": "No version", "content": "Body"}`
+	if w := perform(router, "PUT", fmt.Sprintf("/posts/%d", post.ID), body); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPostRepositoryUpdateStaleVersion(t *testing.T) {
+	db := newTestDatabase(t)
+	repo := NewPostRepository(db)
+	post := createTestPost(t, db)
+
+	stale := *post
+	post.Title = "Fresh"
+	if err := repo.Update(context.Background(), post); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", post.Version)
+	}
+
+	stale.Title = "Stale"
+	if err := repo.Update(context.Background(), &stale); !errors.Is(err, ErrStaleObject) {
+		t.Errorf("expected ErrStaleObject, got %v", err)
+	}
+}
+
+func TestDatabaseOptionsDSN(t *testing.T) {
+	opts := DatabaseOptions{DSN: "blog.db", EnableWAL: true, BusyTimeout: 2 * time.Second}
+	if got := opts.dsn(); got != "blog.db?_journal_mode=WAL&_busy_timeout=2000" {
+		t.Errorf("unexpected DSN: %s", got)
+	}
+
+	opts.DSN = "blog.db?cache=shared"
+	if got := opts.dsn(); got != "blog.db?cache=shared&_journal_mode=WAL&_busy_timeout=2000" {
+		t.Errorf("unexpected DSN with existing params: %s", got)
+	}
+
+	if got := (DatabaseOptions{DSN: "blog.db"}).dsn(); got != "blog.db" {
+		t.Errorf("expected DSN untouched without options, got %s", got)
+	}
+}
+
+func TestConcurrentWritesWithWAL(t *testing.T) {
+	db := newTestDatabase(t)
+
+	var journalMode string
+	db.Raw("PRAGMA journal_mode").Scan(&journalMode)
+	if journalMode != "wal" {
+		t.Fatalf("expected WAL journal mode, got %q", journalMode)
+	}
+
+	userRepo := NewUserRepository(db)
+	postRepo := NewPostRepository(db)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			user := &User{
+				Email:    fmt.Sprintf("user%d@example.com", i),
+				Username: fmt.Sprintf("user%d", i),
+				Name:     fmt.Sprintf("User %d", i),
+			}
+			if err := userRepo.Create(context.Background(), user); err != nil {
+				errs <- err
+				return
+			}
+			post := &Post{Title: fmt.Sprintf("Post %d", i), Content: "Body", UserID: user.ID}
+			if err := postRepo.Create(context.Background(), post); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent write failed: %v", err)
+	}
+
+	var users, posts int64
+	db.Model(&User{}).Count(&users)
+	db.Model(&Post{}).Count(&posts)
+	if users != writers || posts != writers {
+		t.Errorf("expected %d users and posts, got %d users and %d posts", writers, users, posts)
+	}
+}
+
+func TestBulkTagAssignment(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	first := createTestPost(t, db)
+	var posts []uint
+	posts = append(posts, first.ID)
+	for i := 0; i < 2; i++ {
+		post := &Post{Title: fmt.Sprintf("Post %d", i), Content: "Body", UserID: first.UserID}
+		if err := NewPostRepository(db).Create(context.Background(), post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+		posts = append(posts, post.ID)
+	}
+	tag := &Tag{Name: "golang"}
+	if err := db.Create(tag).Error; err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+	// 첫 포스트에는 미리 태그를 붙여 둠
+	if err := NewPostRepository(db).AddTag(context.Background(), first.ID, tag.ID); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+
+	countRows := func() int64 {
+		var n int64
+		db.Table("post_tags").Where("tag_id = ?", tag.ID).Count(&n)
+		return n
+	}
+
+	assignPath := fmt.Sprintf("/tags/%d/assign", tag.ID)
+	body := fmt.Sprintf(`{"post_ids": [%d, %d, %d]}`, posts[0], posts[1], posts[2])
+
+	w := perform(router, "POST", assignPath, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Assigned []uint `json:"assigned"`
+		Skipped  []uint `json:"skipped"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Assigned) != 2 || len(resp.Skipped) != 1 || resp.Skipped[0] != first.ID {
+		t.Errorf("unexpected assign result: %s", w.Body.String())
+	}
+	if n := countRows(); n != 3 {
+		t.Fatalf("expected 3 post_tags rows, got %d", n)
+	}
+
+	// 같은 요청을 다시 보내도 행이 늘지 않음
+	w = perform(router, "POST", assignPath, body)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Assigned) != 0 || len(resp.Skipped) != 3 {
+		t.Errorf("expected idempotent re-assign, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := countRows(); n != 3 {
+		t.Errorf("expected still 3 post_tags rows, got %d", n)
+	}
+
+	w = perform(router, "POST", fmt.Sprintf("/tags/%d/unassign", tag.ID), fmt.Sprintf(`{"post_ids": [%d, %d]}`, posts[0], posts[1]))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := countRows(); n != 1 {
+		t.Errorf("expected 1 post_tags row after unassign, got %d", n)
+	}
+}
+
+func TestBulkTagAssignmentValidatesBeforeMutating(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	post := createTestPost(t, db)
+	tag := &Tag{Name: "golang"}
+	db.Create(tag)
+
+	w := perform(router, "POST", fmt.Sprintf("/tags/%d/assign", tag.ID), fmt.Sprintf(`{"post_ids": [%d, 999]}`, post.ID))
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "999") {
+		t.Errorf("expected 404 naming the missing post, got %d: %s", w.Code, w.Body.String())
+	}
+	var n int64
+	db.Table("post_tags").Count(&n)
+	if n != 0 {
+		t.Errorf("expected no post_tags rows when a post is missing, got %d", n)
+	}
+
+	w = perform(router, "POST", "/tags/999/assign", fmt.Sprintf(`{"post_ids": [%d]}`, post.ID))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown tag, got %d", w.Code)
+	}
+}
+
+func TestRepositoryQueryCanceledWithContext(t *testing.T) {
+	db := newTestDatabase(t)
+	post := createTestPost(t, db)
+
+	// 느린 쿼리 흉내: 컨텍스트가 끝나거나 1초가 지날 때까지 대기
+	err := db.Callback().Query().Before("gorm:query").Register("test:slow_query", func(tx *gorm.DB) {
+		select {
+		case <-tx.Statement.Context.Done():
+		case <-time.After(time.Second):
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to register hook: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = NewPostRepository(db).FindByID(ctx, post.ID)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("query was not aborted by cancellation, took %v", elapsed)
+	}
+}
+
+func TestGenericRepositoryUser(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+	repo := NewRepository[User](db)
+
+	user := &User{Email: "alice@example.com", Username: "alice", Name: "Alice"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if err := repo.Create(ctx, &User{Email: "bob@example.com", Username: "bob", Name: "Bob"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	// FindByID는 *User를 반환 - 타입 단언 없이 필드 접근
+	found, err := repo.FindByID(ctx, user.ID)
+	if err != nil || found.Email != "alice@example.com" {
+		t.Fatalf("expected alice, got %+v (%v)", found, err)
+	}
+
+	if err := repo.UpdateFields(ctx, user.ID, map[string]interface{}{"name": "Alice Kim"}); err != nil {
+		t.Fatalf("update fields failed: %v", err)
+	}
+	found, _ = repo.FindByID(ctx, user.ID)
+	if found.Name != "Alice Kim" || found.Username != "alice" {
+		t.Errorf("expected only name to change, got %+v", found)
+	}
+
+	users, total, err := repo.FindAll(ctx, 0, 1)
+	if err != nil || total != 2 || len(users) != 1 {
+		t.Errorf("expected 1 of 2 users, got %d of %d (%v)", len(users), total, err)
+	}
+
+	// 소프트 삭제 후에는 조회되지 않지만 행은 남아 있음
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound after delete, got %v", err)
+	}
+	var count int64
+	db.Unscoped().Model(&User{}).Where("id = ?", user.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("expected soft-deleted row to remain, got %d", count)
+	}
+
+	if err := repo.HardDelete(ctx, user.ID); err != nil {
+		t.Fatalf("hard delete failed: %v", err)
+	}
+	db.Unscoped().Model(&User{}).Where("id = ?", user.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected row to be removed, got %d", count)
+	}
+}
+
+func TestGenericRepositoryPost(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+	post := createTestPost(t, db)
+	repo := NewRepository[Post](db)
+
+	found, err := repo.FindByID(ctx, post.ID)
+	if err != nil || found.Title != "Original" {
+		t.Fatalf("expected post, got %+v (%v)", found, err)
+	}
+	// 공통 FindByID는 preload나 조회수 증가를 하지 않음
+	if found.User.ID != 0 || found.ViewCount != 0 {
+		t.Errorf("expected plain lookup, got user %d and view count %d", found.User.ID, found.ViewCount)
+	}
+
+	found.Title = "Saved"
+	if err := repo.Update(ctx, found); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := repo.Delete(ctx, post.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, post.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound after delete, got %v", err)
+	}
+
+	// 구체 저장소는 같은 이름의 메서드로 preload/필터 동작을 유지
+	posts := NewPostRepository(db)
+	other := &Post{Title: "Second Post", Content: "Body", UserID: post.UserID}
+	if err := posts.Create(ctx, other); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if other.Slug == "" {
+		t.Error("expected PostRepository.Create to generate a slug")
+	}
+	loaded, err := posts.FindByID(ctx, other.ID)
+	if err != nil || loaded.User.ID != post.UserID {
+		t.Errorf("expected PostRepository.FindByID to preload the author, got %+v (%v)", loaded, err)
+	}
+	list, total, err := posts.FindAll(ctx, map[string]interface{}{"user_id": post.UserID}, 1, 10)
+	if err != nil || total != 1 || len(list) != 1 {
+		t.Errorf("expected 1 remaining post, got %d of %d (%v)", len(list), total, err)
+	}
+}
+
+func TestPostFeedOnlyPublishedNewestFirst(t *testing.T) {
+	router, db := newTestRouter(t)
+	ctx := context.Background()
+	repo := NewPostRepository(db)
+
+	if !db.Migrator().HasIndex(&Post{}, "idx_posts_feed") {
+		t.Error("expected idx_posts_feed index on (published, published_at)")
+	}
+
+	user := &User{Email: "alice@example.com", Username: "alice", Name: "Alice"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(-d)
+		return &ts
+	}
+
+	// 생성 순서와 공개 순서를 일부러 다르게 둠
+	seed := []*Post{
+		{Title: "Oldest", Published: true, PublishedAt: at(3 * time.Hour)},
+		{Title: "Draft", Published: false, PublishedAt: at(time.Minute)},
+		{Title: "Newest", Published: true, PublishedAt: at(time.Hour)},
+		{Title: "Middle", Published: true, PublishedAt: at(2 * time.Hour)},
+		{Title: "Withdrawn", Published: true, PublishedAt: at(30 * time.Minute)},
+	}
+	for _, post := range seed {
+		post.Content = "Body"
+		post.UserID = user.ID
+		if err := repo.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create %s: %v", post.Title, err)
+		}
+	}
+	if seed[1].PublishedAt != nil {
+		t.Error("expected draft to have no published_at")
+	}
+
+	// 공개 후 비공개로 돌린 포스트도 피드에서 빠짐
+	withdrawn := seed[4]
+	withdrawn.Published = false
+	if err := repo.Update(ctx, withdrawn); err != nil {
+		t.Fatalf("failed to unpublish: %v", err)
+	}
+
+	posts, total, err := repo.Feed(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("Feed failed: %v", err)
+	}
+	var titles []string
+	for _, post := range posts {
+		titles = append(titles, post.Title)
+		if !post.Published {
+			t.Errorf("unpublished post %q in feed", post.Title)
+		}
+		if post.User.ID != user.ID {
+			t.Errorf("expected author to be preloaded for %q", post.Title)
+		}
+	}
+	if want := "Newest,Middle,Oldest"; strings.Join(titles, ",") != want || total != 3 {
+		t.Errorf("expected %s (total 3), got %v (total %d)", want, titles, total)
+	}
+
+	// 페이지를 넘겨도 순서 유지
+	page, total, err := repo.Feed(ctx, 2, 2)
+	if err != nil || total != 3 || len(page) != 1 || page[0].Title != "Oldest" {
+		t.Errorf("expected second page with Oldest, got %+v (total %d, %v)", page, total, err)
+	}
+
+	// 다시 공개하면 새 공개 시각으로 맨 앞에 옴
+	withdrawn.Published = true
+	if err := repo.Update(ctx, withdrawn); err != nil {
+		t.Fatalf("failed to republish: %v", err)
+	}
+
+	w := perform(router, "GET", "/posts/feed?page_size=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Posts []Post `json:"posts"`
+		Total int64  `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 4 || len(resp.Posts) != 2 || resp.Posts[0].Title != "Withdrawn" || resp.Posts[1].Title != "Newest" {
+		t.Errorf("unexpected feed page: total %d, %+v", resp.Total, resp.Posts)
+	}
+}
+
+func postIDs(posts []Post) []uint {
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+func TestScopesMatchHandWrittenQueries(t *testing.T) {
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	alice := &User{Email: "alice@example.com", Username: "alice", Name: "Alice"}
+	bob := &User{Email: "bob@example.com", Username: "bob", Name: "Bob"}
+	if err := db.Create([]*User{alice, bob}).Error; err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	for i := 0; i < 24; i++ {
+		post := &Post{
+			Title:     fmt.Sprintf("Post %d", i),
+			Content:   "Body",
+			Slug:      fmt.Sprintf("post-%d", i),
+			Published: i%3 != 0,
+			ViewCount: (i * 37) % 101,
+			UserID:    alice.ID,
+		}
+		if i%2 == 1 {
+			post.UserID = bob.ID
+		}
+		switch i % 4 {
+		case 0:
+			post.Title = fmt.Sprintf("Learning GORM %d", i)
+		case 1:
+			post.Content = "Scopes in gorm are composable"
+		}
+		if err := db.Create(post).Error; err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	find := func(query *gorm.DB) []uint {
+		t.Helper()
+		var posts []Post
+		if err := query.Find(&posts).Error; err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		return postIDs(posts)
+	}
+	equal := func(name string, got, want []uint) {
+		t.Helper()
+		if len(want) == 0 {
+			t.Fatalf("%s: hand-written query returned nothing, test data is wrong", name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: scopes returned %v, hand-written query returned %v", name, got, want)
+		}
+	}
+
+	equal("Published+Paginate",
+		find(db.Scopes(Published, Paginate(2, 5)).Order("id")),
+		find(db.Where("published = ?", true).Order("id").Offset(5).Limit(5)))
+
+	equal("Paginate clamps out-of-range values",
+		find(db.Scopes(Published, Paginate(0, maxPageSize+1)).Order("id")),
+		find(db.Where("published = ?", true).Order("id").Offset(0).Limit(defaultPageSize)))
+
+	// 조회수는 모두 다르게 시딩했으므로 정렬이 결정적임
+	equal("Published+Popular",
+		find(db.Scopes(Published, Popular).Limit(5)),
+		find(db.Where("published = ?", true).Order("view_count DESC").Limit(5)))
+
+	// FindAll는 필터 맵을 scope로 조합
+	repo := NewPostRepository(db)
+	posts, total, err := repo.FindAll(ctx, map[string]interface{}{"published": true, "user_id": alice.ID}, 2, 3)
+	if err != nil {
+		t.Fatalf("FindAll failed: %v", err)
+	}
+	var wantTotal int64
+	db.Model(&Post{}).Where("published = ?", true).Where("user_id = ?", alice.ID).Count(&wantTotal)
+	if total != wantTotal {
+		t.Errorf("expected total %d, got %d", wantTotal, total)
+	}
+	equal("FindAll",
+		postIDs(posts),
+		find(db.Where("published = ?", true).Where("user_id = ?", alice.ID).Order("created_at DESC").Offset(3).Limit(3)))
+
+	drafts, _, err := repo.FindAll(ctx, map[string]interface{}{"published": false}, 1, 100)
+	if err != nil {
+		t.Fatalf("FindAll failed: %v", err)
+	}
+	for _, post := range drafts {
+		if post.Published {
+			t.Errorf("expected only drafts, got published post %d", post.ID)
+		}
+	}
+
+	// SearchPosts: 공개 조건이 OR 검색 조건 전체에 적용되어야 함
+	service := NewBlogService(db)
+	results, err := service.SearchPosts(ctx, "gorm")
+	if err != nil {
+		t.Fatalf("SearchPosts failed: %v", err)
+	}
+	equal("SearchPosts",
+		find(db.Where("(title LIKE ? OR content LIKE ?) AND published = ?", "%gorm%", "%gorm%", true)),
+		postIDs(results))
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&Post{}).Scopes(Published, Search("gorm")).Find(&[]Post{})
+	})
+	if !strings.Contains(sql, "(title LIKE") {
+		t.Errorf("expected search condition to be parenthesized, got %s", sql)
+	}
+}
+
+func TestReadReplicaRouting(t *testing.T) {
+	dir := t.TempDir()
+	replicaDSN := filepath.Join(dir, "replica.db")
+
+	// 복제본에는 primary와 구분되는 데이터를 미리 넣어 둠
+	replica, err := NewDatabase(DatabaseOptions{DSN: replicaDSN})
+	if err != nil {
+		t.Fatalf("failed to prepare replica: %v", err)
+	}
+	if err := replica.Create(&User{Username: "replica", Email: "replica@example.com"}).Error; err != nil {
+		t.Fatalf("failed to seed replica: %v", err)
+	}
+	replica.Close()
+
+	opts := DefaultDatabaseOptions()
+	opts.DSN = filepath.Join(dir, "primary.db")
+	opts.ReplicaDSNs = []string{replicaDSN}
+	db, err := NewDatabase(opts)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// 쓰기는 primary로
+	if err := db.Create(&User{Username: "primary", Email: "primary@example.com"}).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	ctx := context.Background()
+	var user User
+	if err := db.WithContext(ctx).First(&user).Error; err != nil {
+		t.Fatalf("failed to read from replica: %v", err)
+	}
+	if user.Username != "replica" {
+		t.Errorf("expected First to hit the replica, got %q", user.Username)
+	}
+
+	var users []User
+	db.WithContext(ctx).Find(&users)
+	if len(users) != 1 || users[0].Username != "replica" {
+		t.Errorf("expected Find to hit the replica, got %+v", users)
+	}
+
+	user = User{}
+	if err := db.WithContext(WithPrimary(ctx)).First(&user).Error; err != nil {
+		t.Fatalf("failed to read from primary: %v", err)
+	}
+	if user.Username != "primary" {
+		t.Errorf("expected WithPrimary to read the primary, got %q", user.Username)
+	}
+
+	// 트랜잭션 안의 조회는 primary에서
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var u User
+		if err := tx.First(&u).Error; err != nil {
+			return err
+		}
+		if u.Username != "primary" {
+			t.Errorf("expected reads inside a transaction to use the primary, got %q", u.Username)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+}
+
+func TestNoReplicaFallsBackToPrimary(t *testing.T) {
+	db := newTestDatabase(t)
+
+	if err := db.Create(&User{Username: "primary", Email: "primary@example.com"}).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	var user User
+	if err := db.First(&user).Error; err != nil {
+		t.Fatalf("expected read from primary, got %v", err)
+	}
+	if user.Username != "primary" {
+		t.Errorf("expected primary user, got %q", user.Username)
+	}
+}
+
+func TestListQueryValidation(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	tests := []struct {
+		path  string
+		field string
+	}{
+		{"/users?page=abc", "page"},
+		{"/users?page=0", "page"},
+		{"/users?page_size=101", "page_size"},
+		{"/posts?page_size=0", "page_size"},
+		{"/posts?user_id=alice", "user_id"},
+		{"/posts/feed?page=-1", "page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := perform(router, "GET", tt.path, "")
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Success bool `json:"success"`
+				Error   struct {
+					ErrorCode string `json:"error_code"`
+					Path      string `json:"path"`
+					Details   []struct {
+						Field string `json:"field"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if resp.Success || resp.Error.ErrorCode != "BAD_REQUEST" {
+				t.Errorf("expected BAD_REQUEST envelope, got %s", w.Body.String())
+			}
+			if len(resp.Error.Details) != 1 || resp.Error.Details[0].Field != tt.field {
+				t.Errorf("expected a single %s error, got %s", tt.field, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListQueryDefaults(t *testing.T) {
+	router, db := newTestRouter(t)
+	createTestPost(t, db)
+
+	for _, path := range []string{"/users", "/posts", "/posts/feed"} {
+		w := perform(router, "GET", path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Page     int `json:"page"`
+			PageSize int `json:"page_size"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Page != 1 || resp.PageSize != 10 {
+			t.Errorf("%s: expected page=1 page_size=10, got %+v", path, resp)
+		}
+	}
+
+	// 명시한 필터만 적용: 초안 포스트는 published=false로만 조회됨
+	w := perform(router, "GET", "/posts?published=false&page_size=100", "")
+	var resp struct {
+		Total    int64 `json:"total"`
+		PageSize int   `json:"page_size"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Total != 1 || resp.PageSize != 100 {
+		t.Errorf("expected the draft with page_size=100, got %d: %s", w.Code, w.Body.String())
+	}
+}
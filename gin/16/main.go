@@ -1,18 +1,22 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-faker/faker/v4"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -91,6 +95,14 @@ type Tag struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// Like - 사용자당 게시글 하나에 한 번만 누를 수 있는 좋아요
+type Like struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	PostID    uint      `gorm:"uniqueIndex:idx_likes_post_user;not null" json:"post_id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_likes_post_user;not null" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ============================================================================
 // 마이그레이션 시스템
 // ============================================================================
@@ -131,39 +143,64 @@ func (m *Migrator) Migrate() error {
 		return fmt.Errorf("failed to create migration table: %w", err)
 	}
 
-	for _, migration := range m.migrations {
-		var count int64
-		m.db.Model(&Migration{}).Where("version = ?", migration.Version).Count(&count)
-
-		if count == 0 {
-			log.Printf("🔄 Applying migration: %s - %s", migration.Version, migration.Name)
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
 
-			// 트랜잭션으로 마이그레이션 실행
-			err := m.db.Transaction(func(tx *gorm.DB) error {
-				if err := migration.Up(tx); err != nil {
-					return err
-				}
+	for _, migration := range pending {
+		log.Printf("🔄 Applying migration: %s - %s", migration.Version, migration.Name)
 
-				// 마이그레이션 기록
-				record := Migration{
-					Version:   migration.Version,
-					Name:      migration.Name,
-					AppliedAt: time.Now(),
-				}
-				return tx.Create(&record).Error
-			})
+		// 트랜잭션으로 마이그레이션 실행
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
 
-			if err != nil {
-				return fmt.Errorf("migration %s failed: %w", migration.Version, err)
+			// 마이그레이션 기록
+			record := Migration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
 			}
+			return tx.Create(&record).Error
+		})
 
-			log.Printf("✅ Migration %s completed", migration.Version)
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.Version, err)
 		}
+
+		log.Printf("✅ Migration %s completed", migration.Version)
 	}
 
 	return nil
 }
 
+// Pending - 등록됐지만 아직 적용되지 않은 마이그레이션 (등록 순서 유지)
+func (m *Migrator) Pending() ([]MigrationFunc, error) {
+	// 마이그레이션 테이블이 없으면 전부 미적용
+	if !m.db.Migrator().HasTable(&Migration{}) {
+		return append([]MigrationFunc(nil), m.migrations...), nil
+	}
+
+	var versions []string
+	if err := m.db.Model(&Migration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	applied := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+
+	var pending []MigrationFunc
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
 func (m *Migrator) Rollback(version string) error {
 	// 특정 버전으로 롤백
 	var migration Migration
@@ -305,6 +342,16 @@ func GetMigrations() []MigrationFunc {
 				return nil
 			},
 		},
+		{
+			Version: "006_create_likes",
+			Name:    "Create likes table",
+			Up: func(db *gorm.DB) error {
+				return db.AutoMigrate(&Like{})
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&Like{})
+			},
+		},
 	}
 }
 
@@ -312,12 +359,49 @@ func GetMigrations() []MigrationFunc {
 // 시드 데이터 생성
 // ============================================================================
 
+// SeederOptions - 시드 데이터 양과 삽입 방식
+type SeederOptions struct {
+	// BatchSize - users/posts/post_tags를 CreateInBatches로 넣을 때의 배치 크기 (1 이하이면 한 행씩 삽입)
+	BatchSize int
+	// Users - faker로 만들 일반 사용자 수 (admin 제외)
+	Users int
+	// MaxPostsPerUser - 사용자마다 1~MaxPostsPerUser개의 포스트 생성
+	MaxPostsPerUser int
+	// RandSeed - 0이 아니면 포스트 개수/태그 선택 등을 재현 가능하게 고정
+	RandSeed int64
+}
+
+func DefaultSeederOptions() SeederOptions {
+	return SeederOptions{
+		BatchSize:       100,
+		Users:           10,
+		MaxPostsPerUser: 5,
+	}
+}
+
 type Seeder struct {
-	db *gorm.DB
+	db   *gorm.DB
+	opts SeederOptions
+	rng  *rand.Rand
 }
 
 func NewSeeder(db *gorm.DB) *Seeder {
-	return &Seeder{db: db}
+	return NewSeederWithOptions(db, DefaultSeederOptions())
+}
+
+func NewSeederWithOptions(db *gorm.DB, opts SeederOptions) *Seeder {
+	if opts.MaxPostsPerUser < 1 {
+		opts.MaxPostsPerUser = 1
+	}
+	seed := opts.RandSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Seeder{db: db, opts: opts, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (s *Seeder) batched() bool {
+	return s.opts.BatchSize > 1
 }
 
 func (s *Seeder) Seed() error {
@@ -408,42 +492,35 @@ func (s *Seeder) seedTags() error {
 
 func (s *Seeder) seedUsers() error {
 	// Admin user
-	admin := User{
+	users := []User{{
 		Email:    "admin@example.com",
 		Username: "admin",
 		Name:     "Admin User",
 		Bio:      "System Administrator",
 		IsAdmin:  true,
 		IsActive: true,
-	}
-
-	var count int64
-	s.db.Model(&User{}).Where("email = ?", admin.Email).Count(&count)
-	if count == 0 {
-		if err := s.db.Create(&admin).Error; err != nil {
-			return err
-		}
-	}
+	}}
 
 	// Regular users with faker
-	for i := 0; i < 10; i++ {
-		user := User{
+	for i := 0; i < s.opts.Users; i++ {
+		users = append(users, User{
 			Email:    faker.Email(),
 			Username: faker.Username(),
 			Name:     faker.Name(),
 			Bio:      faker.Sentence(),
-			Avatar:   fmt.Sprintf("https://i.pravatar.cc/150?img=%d", i+1),
+			Avatar:   fmt.Sprintf("https://i.pravatar.cc/150?img=%d", i%70+1),
 			IsActive: true,
-		}
+		})
+	}
 
-		var count int64
-		s.db.Model(&User{}).Where("email = ?", user.Email).Count(&count)
-		if count == 0 {
-			if err := s.db.Create(&user).Error; err != nil {
-				log.Printf("Failed to create user: %v", err)
-				continue
-			}
-		}
+	var err error
+	if s.batched() {
+		err = s.insertUsersInBatches(users)
+	} else {
+		err = s.insertUsersPerRow(users)
+	}
+	if err != nil {
+		return err
 	}
 
 	var total int64
@@ -452,6 +529,57 @@ func (s *Seeder) seedUsers() error {
 	return nil
 }
 
+// insertUsersPerRow - 한 명씩 이메일 중복을 확인하고 삽입
+func (s *Seeder) insertUsersPerRow(users []User) error {
+	for i, user := range users {
+		var count int64
+		s.db.Model(&User{}).Where("email = ?", user.Email).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := s.db.Create(&user).Error; err != nil {
+			if i == 0 {
+				return err // admin 생성 실패는 치명적
+			}
+			log.Printf("Failed to create user: %v", err)
+		}
+	}
+	return nil
+}
+
+// insertUsersInBatches - 이미 있는 이메일/사용자명을 한 번에 조회해 걸러낸 뒤 배치 삽입
+func (s *Seeder) insertUsersInBatches(users []User) error {
+	emails := make([]string, len(users))
+	usernames := make([]string, len(users))
+	for i, user := range users {
+		emails[i], usernames[i] = user.Email, user.Username
+	}
+
+	var existingEmails, existingUsernames []string
+	if err := s.db.Model(&User{}).Unscoped().Where("email IN ?", emails).Pluck("email", &existingEmails).Error; err != nil {
+		return err
+	}
+	if err := s.db.Model(&User{}).Unscoped().Where("username IN ?", usernames).Pluck("username", &existingUsernames).Error; err != nil {
+		return err
+	}
+	seenEmail := toSet(existingEmails)
+	seenUsername := toSet(existingUsernames)
+
+	// 한 배치 안에 중복이 있으면 배치 전체가 실패하므로 새로 넣을 행끼리도 중복 제거
+	fresh := make([]User, 0, len(users))
+	for _, user := range users {
+		if seenEmail[user.Email] || seenUsername[user.Username] {
+			continue
+		}
+		seenEmail[user.Email], seenUsername[user.Username] = true, true
+		fresh = append(fresh, user)
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	return s.db.CreateInBatches(&fresh, s.opts.BatchSize).Error
+}
+
 func (s *Seeder) seedPosts() error {
 	var users []User
 	var categories []Category
@@ -465,26 +593,48 @@ func (s *Seeder) seedPosts() error {
 		return fmt.Errorf("users or categories not found")
 	}
 
-	// 각 사용자마다 포스트 생성
+	posts := s.buildPosts(users, categories, tags)
+
+	var err error
+	if s.batched() {
+		err = s.insertPostsInBatches(posts)
+	} else {
+		err = s.insertPostsPerRow(posts)
+	}
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	s.db.Model(&Post{}).Count(&total)
+	log.Printf("✅ Seeded posts (total: %d)", total)
+	return nil
+}
+
+// buildPosts - 사용자마다 랜덤 포스트와 태그(1-3개, 중복 없음)를 만듦
+// 삽입 방식과 상관없이 같은 시드면 같은 데이터가 나오도록 랜덤 값은 여기서만 뽑음
+func (s *Seeder) buildPosts(users []User, categories []Category, tags []Tag) []Post {
+	suffix := time.Now().Unix()
+	var posts []Post
 	for _, user := range users {
-		numPosts := rand.Intn(5) + 1 // 1-5개 포스트
+		numPosts := s.rng.Intn(s.opts.MaxPostsPerUser) + 1
 
 		for i := 0; i < numPosts; i++ {
 			title := faker.Sentence()
 			content := faker.Paragraph()
-			published := rand.Float32() > 0.3 // 70% 확률로 published
+			published := s.rng.Float32() > 0.3 // 70% 확률로 published
 
 			post := Post{
 				Title:      title,
 				Content:    content,
 				Excerpt:    truncateString(content, 150),
-				Slug:       slugify(title) + fmt.Sprintf("-%d", time.Now().Unix()),
+				Slug:       slugify(title) + fmt.Sprintf("-%d-%d", suffix, len(posts)),
 				Published:  published,
-				ViewCount:  rand.Intn(1000),
-				LikeCount:  rand.Intn(100),
+				ViewCount:  s.rng.Intn(1000),
+				LikeCount:  s.rng.Intn(100),
 				UserID:     user.ID,
-				CategoryID: &categories[rand.Intn(len(categories))].ID,
-				CoverImage: fmt.Sprintf("https://picsum.photos/800/400?random=%d", rand.Intn(1000)),
+				CategoryID: &categories[s.rng.Intn(len(categories))].ID,
+				CoverImage: fmt.Sprintf("https://picsum.photos/800/400?random=%d", s.rng.Intn(1000)),
 			}
 
 			if published {
@@ -492,35 +642,111 @@ func (s *Seeder) seedPosts() error {
 				post.PublishedAt = &now
 			}
 
-			if err := s.db.Create(&post).Error; err != nil {
-				log.Printf("Failed to create post: %v", err)
-				continue
-			}
-
 			// 랜덤 태그 추가 (1-3개)
-			numTags := rand.Intn(3) + 1
-			selectedTags := make([]Tag, 0)
-			for j := 0; j < numTags && j < len(tags); j++ {
-				selectedTags = append(selectedTags, tags[rand.Intn(len(tags))])
+			if len(tags) > 0 {
+				picked := make(map[uint]bool)
+				numTags := s.rng.Intn(3) + 1
+				for j := 0; j < numTags && j < len(tags); j++ {
+					tag := tags[s.rng.Intn(len(tags))]
+					if !picked[tag.ID] {
+						picked[tag.ID] = true
+						post.Tags = append(post.Tags, tag)
+					}
+				}
 			}
 
-			if len(selectedTags) > 0 {
-				s.db.Model(&post).Association("Tags").Append(selectedTags)
-			}
+			posts = append(posts, post)
 		}
 	}
+	return posts
+}
 
-	var total int64
-	s.db.Model(&Post{}).Count(&total)
-	log.Printf("✅ Seeded posts (total: %d)", total)
+// insertPostsPerRow - 포스트를 하나씩 넣고 태그도 포스트마다 따로 연결
+func (s *Seeder) insertPostsPerRow(posts []Post) error {
+	for _, post := range posts {
+		tags := post.Tags
+		post.Tags = nil
+
+		var count int64
+		s.db.Model(&Post{}).Unscoped().Where("slug = ?", post.Slug).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := s.db.Create(&post).Error; err != nil {
+			log.Printf("Failed to create post: %v", err)
+			continue
+		}
+
+		if len(tags) > 0 {
+			s.db.Model(&post).Association("Tags").Append(tags)
+		}
+	}
 	return nil
 }
 
+// insertPostsInBatches - 기존 slug를 한 번에 조회해 걸러낸 뒤 포스트와 post_tags를 각각 배치 삽입
+func (s *Seeder) insertPostsInBatches(posts []Post) error {
+	slugs := make([]string, len(posts))
+	for i, post := range posts {
+		slugs[i] = post.Slug
+	}
+	var existing []string
+	if err := s.db.Model(&Post{}).Unscoped().Where("slug IN ?", slugs).Pluck("slug", &existing).Error; err != nil {
+		return err
+	}
+	seen := toSet(existing)
+
+	fresh := make([]Post, 0, len(posts))
+	for _, post := range posts {
+		if seen[post.Slug] {
+			continue
+		}
+		seen[post.Slug] = true
+		fresh = append(fresh, post)
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 태그는 이미 있으므로 GORM이 연관 데이터를 upsert하지 않게 Omit
+		if err := tx.Omit("Tags").CreateInBatches(&fresh, s.opts.BatchSize).Error; err != nil {
+			return err
+		}
+
+		var links []postTag
+		for _, post := range fresh {
+			for _, tag := range post.Tags {
+				links = append(links, postTag{PostID: post.ID, TagID: tag.ID})
+			}
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Table("post_tags").Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, s.opts.BatchSize).Error
+	})
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
 func (s *Seeder) Clean() error {
 	log.Println("🧹 Cleaning database...")
 
+	// Many-to-many 중간 테이블 먼저 삭제
+	if err := s.db.Exec("DELETE FROM post_tags").Error; err != nil {
+		return fmt.Errorf("failed to clean post_tags: %w", err)
+	}
+
 	// 역순으로 삭제 (외래키 제약 고려)
+	// Soft delete된 행이 남으면 같은 ID/slug로 다시 가져올 수 없으므로 완전히 삭제
 	tables := []interface{}{
+		&Like{},
 		&Post{},
 		&Tag{},
 		&Category{},
@@ -528,14 +754,11 @@ func (s *Seeder) Clean() error {
 	}
 
 	for _, table := range tables {
-		if err := s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error; err != nil {
+		if err := s.db.Unscoped().Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error; err != nil {
 			return fmt.Errorf("failed to clean table: %w", err)
 		}
 	}
 
-	// Many-to-many 중간 테이블 직접 삭제
-	s.db.Exec("DELETE FROM post_tags")
-
 	log.Println("✅ Database cleaned!")
 	return nil
 }
@@ -608,6 +831,444 @@ func (s *Seeder) ExportToFile(filename string) error {
 	return nil
 }
 
+// SeedFormat - 시드 데이터 Import/Export 형식
+type SeedFormat string
+
+const (
+	FormatJSON SeedFormat = "json" // 파일 하나에 모든 모델
+	FormatCSV  SeedFormat = "csv"  // 디렉토리에 모델별 CSV 파일
+)
+
+// ParseSeedFormat - "json" / "csv" 외의 값이면 에러
+func ParseSeedFormat(value string) (SeedFormat, error) {
+	switch format := SeedFormat(value); format {
+	case FormatJSON, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (expected json or csv)", value)
+	}
+}
+
+// Export - format에 맞춰 내보내기 (CSV는 path를 디렉토리로 사용)
+func (s *Seeder) Export(path string, format SeedFormat) error {
+	if format == FormatCSV {
+		return s.ExportToCSV(path)
+	}
+	return s.ExportToFile(path)
+}
+
+// Import - format에 맞춰 가져오기 (CSV는 path를 디렉토리로 사용)
+func (s *Seeder) Import(path string, format SeedFormat) error {
+	if format == FormatCSV {
+		return s.LoadFromCSV(path)
+	}
+	return s.LoadFromFile(path)
+}
+
+// CSV 파일 이름과 헤더 (모델별 파일 + many2many 조인 파일)
+var (
+	userCSVHeader     = []string{"id", "email", "username", "name", "bio", "avatar", "is_active", "is_admin", "last_login_at", "created_at", "updated_at"}
+	categoryCSVHeader = []string{"id", "name", "slug", "description", "parent_id", "created_at", "updated_at"}
+	tagCSVHeader      = []string{"id", "name", "slug", "created_at", "updated_at"}
+	postCSVHeader     = []string{"id", "title", "content", "slug", "excerpt", "cover_image", "published", "published_at", "view_count", "like_count", "user_id", "category_id", "created_at", "updated_at"}
+	postTagCSVHeader  = []string{"post_id", "tag_id"}
+)
+
+type postTag struct {
+	PostID uint
+	TagID  uint
+}
+
+// ExportToCSV - 현재 데이터를 dir 아래 모델별 CSV 파일로 내보내기
+// posts와 tags의 many2many 관계는 post_tags.csv로 따로 기록합니다.
+func (s *Seeder) ExportToCSV(dir string) error {
+	var users []User
+	var categories []Category
+	var tags []Tag
+	var posts []Post
+	var links []postTag
+
+	s.db.Order("id").Find(&users)
+	s.db.Order("id").Find(&categories)
+	s.db.Order("id").Find(&tags)
+	s.db.Order("id").Find(&posts)
+	if err := s.db.Table("post_tags").Order("post_id, tag_id").Find(&links).Error; err != nil {
+		return fmt.Errorf("failed to read post_tags: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	userRows := make([][]string, 0, len(users))
+	for _, u := range users {
+		userRows = append(userRows, []string{
+			formatUint(u.ID), u.Email, u.Username, u.Name, u.Bio, u.Avatar,
+			strconv.FormatBool(u.IsActive), strconv.FormatBool(u.IsAdmin),
+			formatTimePtr(u.LastLoginAt), formatTime(u.CreatedAt), formatTime(u.UpdatedAt),
+		})
+	}
+
+	categoryRows := make([][]string, 0, len(categories))
+	for _, c := range categories {
+		categoryRows = append(categoryRows, []string{
+			formatUint(c.ID), c.Name, c.Slug, c.Description, formatUintPtr(c.ParentID),
+			formatTime(c.CreatedAt), formatTime(c.UpdatedAt),
+		})
+	}
+
+	tagRows := make([][]string, 0, len(tags))
+	for _, t := range tags {
+		tagRows = append(tagRows, []string{
+			formatUint(t.ID), t.Name, t.Slug, formatTime(t.CreatedAt), formatTime(t.UpdatedAt),
+		})
+	}
+
+	postRows := make([][]string, 0, len(posts))
+	for _, p := range posts {
+		postRows = append(postRows, []string{
+			formatUint(p.ID), p.Title, p.Content, p.Slug, p.Excerpt, p.CoverImage,
+			strconv.FormatBool(p.Published), formatTimePtr(p.PublishedAt),
+			strconv.Itoa(p.ViewCount), strconv.Itoa(p.LikeCount),
+			formatUint(p.UserID), formatUintPtr(p.CategoryID),
+			formatTime(p.CreatedAt), formatTime(p.UpdatedAt),
+		})
+	}
+
+	linkRows := make([][]string, 0, len(links))
+	for _, l := range links {
+		linkRows = append(linkRows, []string{formatUint(l.PostID), formatUint(l.TagID)})
+	}
+
+	files := []struct {
+		name   string
+		header []string
+		rows   [][]string
+	}{
+		{"users.csv", userCSVHeader, userRows},
+		{"categories.csv", categoryCSVHeader, categoryRows},
+		{"tags.csv", tagCSVHeader, tagRows},
+		{"posts.csv", postCSVHeader, postRows},
+		{"post_tags.csv", postTagCSVHeader, linkRows},
+	}
+	for _, f := range files {
+		if err := writeCSV(filepath.Join(dir, f.name), f.header, f.rows); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("✅ Exported data to %s (csv)", dir)
+	return nil
+}
+
+// LoadFromCSV - ExportToCSV가 만든 디렉토리에서 데이터 가져오기
+// ID를 그대로 유지해야 관계가 맞으므로 하나의 트랜잭션으로 모두 넣거나 모두 취소합니다.
+func (s *Seeder) LoadFromCSV(dir string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		userRecords, err := readCSV(filepath.Join(dir, "users.csv"), userCSVHeader)
+		if err != nil {
+			return err
+		}
+		for _, r := range userRecords {
+			user := User{
+				ID: r.uint("id"), Email: r.str("email"), Username: r.str("username"),
+				Name: r.str("name"), Bio: r.str("bio"), Avatar: r.str("avatar"),
+				IsActive: r.bool("is_active"), IsAdmin: r.bool("is_admin"),
+				LastLoginAt: r.timePtr("last_login_at"),
+				CreatedAt:   r.time("created_at"), UpdatedAt: r.time("updated_at"),
+			}
+			if err := r.insert(tx, &user); err != nil {
+				return err
+			}
+		}
+
+		categoryRecords, err := readCSV(filepath.Join(dir, "categories.csv"), categoryCSVHeader)
+		if err != nil {
+			return err
+		}
+		for _, r := range categoryRecords {
+			category := Category{
+				ID: r.uint("id"), Name: r.str("name"), Slug: r.str("slug"),
+				Description: r.str("description"), ParentID: r.uintPtr("parent_id"),
+				CreatedAt: r.time("created_at"), UpdatedAt: r.time("updated_at"),
+			}
+			if err := r.insert(tx, &category); err != nil {
+				return err
+			}
+		}
+
+		tagRecords, err := readCSV(filepath.Join(dir, "tags.csv"), tagCSVHeader)
+		if err != nil {
+			return err
+		}
+		for _, r := range tagRecords {
+			tag := Tag{
+				ID: r.uint("id"), Name: r.str("name"), Slug: r.str("slug"),
+				CreatedAt: r.time("created_at"), UpdatedAt: r.time("updated_at"),
+			}
+			if err := r.insert(tx, &tag); err != nil {
+				return err
+			}
+		}
+
+		postRecords, err := readCSV(filepath.Join(dir, "posts.csv"), postCSVHeader)
+		if err != nil {
+			return err
+		}
+		for _, r := range postRecords {
+			post := Post{
+				ID: r.uint("id"), Title: r.str("title"), Content: r.str("content"),
+				Slug: r.str("slug"), Excerpt: r.str("excerpt"), CoverImage: r.str("cover_image"),
+				Published: r.bool("published"), PublishedAt: r.timePtr("published_at"),
+				ViewCount: r.int("view_count"), LikeCount: r.int("like_count"),
+				UserID: r.uint("user_id"), CategoryID: r.uintPtr("category_id"),
+				CreatedAt: r.time("created_at"), UpdatedAt: r.time("updated_at"),
+			}
+			if err := r.insert(tx, &post); err != nil {
+				return err
+			}
+		}
+
+		linkRecords, err := readCSV(filepath.Join(dir, "post_tags.csv"), postTagCSVHeader)
+		if err != nil {
+			return err
+		}
+		for _, r := range linkRecords {
+			postID, tagID := r.uint("post_id"), r.uint("tag_id")
+			if r.err != nil {
+				return r.err
+			}
+			if err := tx.Exec("INSERT INTO post_tags (post_id, tag_id) VALUES (?, ?)", postID, tagID).Error; err != nil {
+				return fmt.Errorf("post_tags.csv line %d: %w", r.line, err)
+			}
+		}
+
+		log.Printf("✅ Loaded seed data from %s (csv)", dir)
+		return nil
+	})
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write(header)
+	w.WriteAll(rows) // WriteAll은 Flush까지 수행
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// csvRecord - 헤더 이름으로 값을 읽는 CSV 한 줄
+// 변환 실패는 err에 첫 번째 것만 기록하고, 이후 값은 zero 값으로 채웁니다.
+type csvRecord struct {
+	file   string
+	line   int
+	values map[string]string
+	err    error
+}
+
+func readCSV(path string, header []string) ([]*csvRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: missing header row", path)
+	}
+
+	columns := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	for _, name := range header {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s: missing column %q", path, name)
+		}
+	}
+
+	records := make([]*csvRecord, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		values := make(map[string]string, len(header))
+		for _, name := range header {
+			values[name] = row[columns[name]]
+		}
+		records = append(records, &csvRecord{file: filepath.Base(path), line: i + 2, values: values})
+	}
+	return records, nil
+}
+
+func (r *csvRecord) fail(column string, err error) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%s line %d, column %s: %w", r.file, r.line, column, err)
+	}
+}
+
+func (r *csvRecord) str(column string) string {
+	return r.values[column]
+}
+
+func (r *csvRecord) uint(column string) uint {
+	v, err := strconv.ParseUint(r.values[column], 10, 64)
+	if err != nil {
+		r.fail(column, err)
+	}
+	return uint(v)
+}
+
+func (r *csvRecord) uintPtr(column string) *uint {
+	if r.values[column] == "" {
+		return nil
+	}
+	v := r.uint(column)
+	return &v
+}
+
+func (r *csvRecord) int(column string) int {
+	v, err := strconv.Atoi(r.values[column])
+	if err != nil {
+		r.fail(column, err)
+	}
+	return v
+}
+
+func (r *csvRecord) bool(column string) bool {
+	v, err := strconv.ParseBool(r.values[column])
+	if err != nil {
+		r.fail(column, err)
+	}
+	return v
+}
+
+func (r *csvRecord) time(column string) time.Time {
+	v, err := time.Parse(time.RFC3339Nano, r.values[column])
+	if err != nil {
+		r.fail(column, err)
+	}
+	return v
+}
+
+func (r *csvRecord) timePtr(column string) *time.Time {
+	if r.values[column] == "" {
+		return nil
+	}
+	v := r.time(column)
+	return &v
+}
+
+// insert - 변환 에러가 없으면 연관관계 없이 행만 저장
+func (r *csvRecord) insert(tx *gorm.DB, value interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := tx.Omit(clause.Associations).Create(value).Error; err != nil {
+		return fmt.Errorf("%s line %d: %w", r.file, r.line, err)
+	}
+	return nil
+}
+
+func formatUint(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func formatUintPtr(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return formatUint(*v)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+// ============================================================================
+// 좋아요 (원자적 카운터)
+// ============================================================================
+
+var (
+	ErrPostNotFound = errors.New("post not found")
+	ErrUserNotFound = errors.New("user not found")
+	ErrAlreadyLiked = errors.New("post already liked by this user")
+	ErrNotLiked     = errors.New("post not liked by this user")
+)
+
+// LikePost - Like 행을 추가하고 like_count를 1 올린 뒤 새 카운트를 반환
+// 읽고-더해서-쓰기 대신 like_count + 1 표현식으로 갱신하므로 동시 요청에도 카운트가 유실되지 않습니다.
+func LikePost(db *gorm.DB, postID, userID uint) (int, error) {
+	return changeLike(db, postID, userID, func(tx *gorm.DB) error {
+		// (post_id, user_id) 유니크 인덱스에 걸리면 아무것도 넣지 않음 → 이미 누른 좋아요
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&Like{PostID: postID, UserID: userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyLiked
+		}
+		return tx.Model(&Post{}).Where("id = ?", postID).
+			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error
+	})
+}
+
+// UnlikePost - Like 행을 지우고 like_count를 1 내린 뒤 새 카운트를 반환
+func UnlikePost(db *gorm.DB, postID, userID uint) (int, error) {
+	return changeLike(db, postID, userID, func(tx *gorm.DB) error {
+		result := tx.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&Like{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotLiked
+		}
+		return tx.Model(&Post{}).Where("id = ? AND like_count > 0", postID).
+			UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error
+	})
+}
+
+// changeLike - 게시글/사용자 존재 확인, 변경, 카운트 조회를 하나의 트랜잭션으로 실행
+func changeLike(db *gorm.DB, postID, userID uint, change func(tx *gorm.DB) error) (int, error) {
+	var count int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&Post{}, postID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPostNotFound
+			}
+			return err
+		}
+		if err := tx.Select("id").First(&User{}, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if err := change(tx); err != nil {
+			return err
+		}
+		return tx.Model(&Post{}).Where("id = ?", postID).Pluck("like_count", &count).Error
+	})
+	return count, err
+}
+
 // ============================================================================
 // HTTP Handlers
 // ============================================================================
@@ -637,6 +1298,27 @@ func (h *MigrationHandler) GetStatus(c *gin.Context) {
 	})
 }
 
+// Ready - 준비 상태 확인. 적용되지 않은 마이그레이션이 있으면 스키마가 뒤처진 것이므로 503
+func (h *MigrationHandler) Ready(c *gin.Context) {
+	pending, err := h.migrator.Pending()
+	if err != nil {
+		c.JSON(503, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+
+	// MigrationFunc는 함수 필드가 있어 그대로 직렬화할 수 없음
+	versions := make([]gin.H, 0, len(pending))
+	for _, migration := range pending {
+		versions = append(versions, gin.H{"version": migration.Version, "name": migration.Name})
+	}
+
+	if len(pending) > 0 {
+		c.JSON(503, gin.H{"status": "migrations_pending", "pending": versions})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ready", "pending": versions})
+}
+
 func (h *MigrationHandler) RunMigrations(c *gin.Context) {
 	if err := h.migrator.Migrate(); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
@@ -694,13 +1376,30 @@ func (h *MigrationHandler) Reset(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "Database reset successfully"})
 }
 
-func (h *MigrationHandler) Export(c *gin.Context) {
-	filename := c.DefaultQuery("file", "seed_data.json")
+// seedTarget - format 쿼리를 검증하고 파일(JSON) 또는 디렉토리(CSV) 이름을 결정
+func seedTarget(c *gin.Context) (SeedFormat, string, bool) {
+	format, err := ParseSeedFormat(c.DefaultQuery("format", string(FormatJSON)))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return "", "", false
+	}
+
+	defaultName := "seed_data.json"
+	if format == FormatCSV {
+		defaultName = "seed_data"
+	}
 
 	// Ensure file is in current directory
-	filename = filepath.Base(filename)
+	return format, filepath.Base(c.DefaultQuery("file", defaultName)), true
+}
 
-	if err := h.seeder.ExportToFile(filename); err != nil {
+func (h *MigrationHandler) Export(c *gin.Context) {
+	format, filename, ok := seedTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.seeder.Export(filename, format); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -708,16 +1407,17 @@ func (h *MigrationHandler) Export(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"message": "Data exported successfully",
 		"file":    filename,
+		"format":  format,
 	})
 }
 
 func (h *MigrationHandler) Import(c *gin.Context) {
-	filename := c.DefaultQuery("file", "seed_data.json")
-
-	// Ensure file is in current directory
-	filename = filepath.Base(filename)
+	format, filename, ok := seedTarget(c)
+	if !ok {
+		return
+	}
 
-	if err := h.seeder.LoadFromFile(filename); err != nil {
+	if err := h.seeder.Import(filename, format); err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -725,6 +1425,61 @@ func (h *MigrationHandler) Import(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"message": "Data imported successfully",
 		"file":    filename,
+		"format":  format,
+	})
+}
+
+type PostHandler struct {
+	db *gorm.DB
+}
+
+func NewPostHandler(db *gorm.DB) *PostHandler {
+	return &PostHandler{db: db}
+}
+
+type LikeRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+func (h *PostHandler) Like(c *gin.Context) {
+	h.changeLike(c, LikePost, true)
+}
+
+func (h *PostHandler) Unlike(c *gin.Context) {
+	h.changeLike(c, UnlikePost, false)
+}
+
+func (h *PostHandler) changeLike(c *gin.Context, change func(*gorm.DB, uint, uint) (int, error), liked bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var req LikeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	count, err := change(h.db, uint(postID), req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPostNotFound), errors.Is(err, ErrUserNotFound):
+			c.JSON(404, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAlreadyLiked), errors.Is(err, ErrNotLiked):
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			c.JSON(500, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"post_id":    postID,
+		"user_id":    req.UserID,
+		"liked":      liked,
+		"like_count": count,
 	})
 }
 
@@ -765,6 +1520,7 @@ func SetupRouter(handler *MigrationHandler) *gin.Engine {
 			"time":   time.Now(),
 		})
 	})
+	router.GET("/health/ready", handler.Ready)
 
 	// Migration routes
 	migrations := router.Group("/migrations")
@@ -784,6 +1540,14 @@ func SetupRouter(handler *MigrationHandler) *gin.Engine {
 		seed.POST("/import", handler.Import)
 	}
 
+	// Post routes
+	postHandler := NewPostHandler(handler.seeder.db)
+	posts := router.Group("/posts")
+	{
+		posts.POST("/:id/like", postHandler.Like)
+		posts.POST("/:id/unlike", postHandler.Unlike)
+	}
+
 	// Info route
 	router.GET("/info", func(c *gin.Context) {
 		var userCount, postCount, categoryCount, tagCount int64
@@ -811,12 +1575,15 @@ func SetupRouter(handler *MigrationHandler) *gin.Engine {
 // Main
 // ============================================================================
 
+const sqliteDSN = "blog.db?_busy_timeout=5000&_txlock=immediate"
+
 func main() {
 	// Initialize random seed
 	rand.Seed(time.Now().UnixNano())
 
 	// Database connection
-	db, err := gorm.Open(sqlite.Open("blog.db"), &gorm.Config{
+	// 동시 좋아요 요청이 "database is locked"로 실패하지 않도록 잠금 대기 + 쓰기 트랜잭션은 즉시 잠금
+	db, err := gorm.Open(sqlite.Open(sqliteDSN), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
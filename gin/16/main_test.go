@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestSeeder(t *testing.T) (*Seeder, *Migrator) {
+	t.Helper()
+	db, migrator := newTestDB(t)
+	return NewSeeder(db), migrator
+}
+
+func newTestDB(t testing.TB) (*gorm.DB, *Migrator) {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "blog.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator := NewMigrator(db)
+	for _, migration := range GetMigrations() {
+		migrator.AddMigration(migration)
+	}
+	if err := migrator.Migrate(); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	return db, migrator
+}
+
+func countRows(t *testing.T, db *gorm.DB) map[string]int64 {
+	t.Helper()
+	counts := make(map[string]int64)
+	for name, model := range map[string]interface{}{
+		"users": &User{}, "categories": &Category{}, "tags": &Tag{}, "posts": &Post{},
+	} {
+		var n int64
+		db.Model(model).Count(&n)
+		counts[name] = n
+	}
+	var links int64
+	db.Table("post_tags").Count(&links)
+	counts["post_tags"] = links
+	return counts
+}
+
+func TestCSVExportImportRoundTrip(t *testing.T) {
+	seeder, _ := newTestSeeder(t)
+	if err := seeder.Seed(); err != nil {
+		t.Fatalf("seed failed: %v", err)
+	}
+	before := countRows(t, seeder.db)
+	if before["posts"] == 0 || before["post_tags"] == 0 {
+		t.Fatalf("expected seeded posts and tags, got %v", before)
+	}
+
+	dir := filepath.Join(t.TempDir(), "export")
+	if err := seeder.Export(dir, FormatCSV); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	for _, name := range []string{"users.csv", "categories.csv", "tags.csv", "posts.csv", "post_tags.csv"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+
+	if err := seeder.Clean(); err != nil {
+		t.Fatalf("clean failed: %v", err)
+	}
+	if cleaned := countRows(t, seeder.db); cleaned["posts"] != 0 || cleaned["post_tags"] != 0 {
+		t.Fatalf("expected empty database after clean, got %v", cleaned)
+	}
+
+	if err := seeder.Import(dir, FormatCSV); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	after := countRows(t, seeder.db)
+	for name, n := range before {
+		if after[name] != n {
+			t.Errorf("%s: expected %d rows after round trip, got %d", name, n, after[name])
+		}
+	}
+
+	var post Post
+	seeder.db.Preload("Tags").Where("id IN (SELECT post_id FROM post_tags)").First(&post)
+	if len(post.Tags) == 0 {
+		t.Error("expected imported post to keep its tags")
+	}
+}
+
+func TestSeedExportRejectsUnknownFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	seeder, migrator := newTestSeeder(t)
+	router := SetupRouter(NewMigrationHandler(migrator, seeder))
+
+	req := httptest.NewRequest("POST", "/seed/export?format=xml", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unsupported format, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConcurrentLikesCountUniqueUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	seeder, migrator := newTestSeeder(t)
+	db := seeder.db
+	router := SetupRouter(NewMigrationHandler(migrator, seeder))
+
+	const likers = 20
+	post := Post{Title: "Hello", Slug: "hello"}
+	db.Create(&post)
+	for i := 1; i <= likers; i++ {
+		db.Create(&User{ID: uint(i), Email: fmt.Sprintf("u%d@example.com", i), Username: fmt.Sprintf("u%d", i)})
+	}
+
+	// 각 사용자가 두 번씩 동시에 좋아요 → 사용자당 한 번만 반영
+	var wg sync.WaitGroup
+	codes := make(chan int, likers*2)
+	for i := 1; i <= likers*2; i++ {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"user_id": %d}`, userID)
+			req := httptest.NewRequest("POST", fmt.Sprintf("/posts/%d/like", post.ID), strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			codes <- w.Code
+		}(i%likers + 1)
+	}
+	wg.Wait()
+	close(codes)
+
+	status := map[int]int{}
+	for code := range codes {
+		status[code]++
+	}
+	if status[http.StatusOK] != likers || status[http.StatusConflict] != likers {
+		t.Errorf("expected %d likes and %d conflicts, got %v", likers, likers, status)
+	}
+
+	var stored Post
+	db.First(&stored, post.ID)
+	var likes int64
+	db.Model(&Like{}).Where("post_id = ?", post.ID).Count(&likes)
+	if stored.LikeCount != likers || likes != likers {
+		t.Errorf("expected like_count %d with %d like rows, got %d and %d", likers, likers, stored.LikeCount, likes)
+	}
+}
+
+func TestUnlikePost(t *testing.T) {
+	seeder, _ := newTestSeeder(t)
+	db := seeder.db
+	post := Post{Title: "Hello", Slug: "hello"}
+	db.Create(&post)
+	db.Create(&User{ID: 1, Email: "u1@example.com", Username: "u1"})
+
+	if _, err := UnlikePost(db, post.ID, 1); !errors.Is(err, ErrNotLiked) {
+		t.Errorf("expected ErrNotLiked before liking, got %v", err)
+	}
+	if count, err := LikePost(db, post.ID, 1); err != nil || count != 1 {
+		t.Fatalf("expected count 1, got %d (%v)", count, err)
+	}
+	if count, err := UnlikePost(db, post.ID, 1); err != nil || count != 0 {
+		t.Errorf("expected count 0 after unlike, got %d (%v)", count, err)
+	}
+	if _, err := LikePost(db, 999, 1); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestReadinessReportsPendingMigrations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	seeder, migrator := newTestSeeder(t)
+	router := SetupRouter(NewMigrationHandler(migrator, seeder))
+
+	ready := func() (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/health/ready", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode %q: %v", w.Body.String(), err)
+		}
+		return w.Code, body
+	}
+
+	if code, body := ready(); code != http.StatusOK || len(body["pending"].([]interface{})) != 0 {
+		t.Fatalf("expected ready after initial migration, got %d: %v", code, body)
+	}
+
+	// 새 버전이 배포됐지만 아직 마이그레이션을 실행하지 않은 상태
+	migrator.AddMigration(MigrationFunc{
+		Version: "999_add_audit_log",
+		Name:    "Add audit log",
+		Up: func(db *gorm.DB) error {
+			return db.Exec("CREATE TABLE audit_logs (id INTEGER PRIMARY KEY)").Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Exec("DROP TABLE audit_logs").Error
+		},
+	})
+
+	code, body := ready()
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 with a pending migration, got %d: %v", code, body)
+	}
+	pending := body["pending"].([]interface{})
+	if len(pending) != 1 || pending[0].(map[string]interface{})["version"] != "999_add_audit_log" {
+		t.Errorf("expected 999_add_audit_log to be pending, got %v", pending)
+	}
+
+	if err := migrator.Migrate(); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if code, body := ready(); code != http.StatusOK {
+		t.Errorf("expected ready after migrate, got %d: %v", code, body)
+	}
+}
+
+func TestPendingWithoutMigrationTable(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "empty.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator := NewMigrator(db)
+	for _, migration := range GetMigrations() {
+		migrator.AddMigration(migration)
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil || len(pending) != len(GetMigrations()) {
+		t.Errorf("expected all %d migrations pending, got %d (%v)", len(GetMigrations()), len(pending), err)
+	}
+}
+
+func TestBatchedSeedMatchesPerRowSeed(t *testing.T) {
+	opts := SeederOptions{Users: 40, MaxPostsPerUser: 5, RandSeed: 42}
+
+	counts := make(map[int]map[string]int64)
+	for _, batchSize := range []int{1, 7} {
+		db, _ := newTestDB(t)
+		opts.BatchSize = batchSize
+		if err := NewSeederWithOptions(db, opts).Seed(); err != nil {
+			t.Fatalf("seed with batch size %d failed: %v", batchSize, err)
+		}
+		counts[batchSize] = countRows(t, db)
+	}
+
+	perRow, batched := counts[1], counts[7]
+	if perRow["users"] != int64(opts.Users+1) || perRow["posts"] < perRow["users"] || perRow["post_tags"] < perRow["posts"] {
+		t.Fatalf("unexpected per-row counts: %v", perRow)
+	}
+	for table, n := range perRow {
+		if batched[table] != n {
+			t.Errorf("%s: per-row seeded %d rows, batched seeded %d", table, n, batched[table])
+		}
+	}
+}
+
+func TestBatchedSeedSkipsExistingRows(t *testing.T) {
+	db, _ := newTestDB(t)
+	seeder := NewSeederWithOptions(db, SeederOptions{BatchSize: 10, Users: 5, MaxPostsPerUser: 3, RandSeed: 7})
+	if err := seeder.Seed(); err != nil {
+		t.Fatalf("seed failed: %v", err)
+	}
+	before := countRows(t, db)
+
+	// 이미 있는 admin과 slug는 한 번의 조회로 걸러져 배치 전체가 실패하지 않음
+	var users []User
+	var posts []Post
+	db.Find(&users)
+	db.Preload("Tags").Find(&posts)
+	for i := range posts {
+		posts[i].ID = 0
+	}
+	if err := seeder.insertUsersInBatches(users); err != nil {
+		t.Fatalf("re-inserting users failed: %v", err)
+	}
+	if err := seeder.insertPostsInBatches(posts); err != nil {
+		t.Fatalf("re-inserting posts failed: %v", err)
+	}
+	if after := countRows(t, db); fmt.Sprint(after) != fmt.Sprint(before) {
+		t.Errorf("expected no new rows, before %v after %v", before, after)
+	}
+}
+
+func benchmarkSeed(b *testing.B, batchSize int) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	opts := SeederOptions{BatchSize: batchSize, Users: 200, MaxPostsPerUser: 5, RandSeed: 1}
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db, _ := newTestDB(b)
+		seeder := NewSeederWithOptions(db, opts)
+		b.StartTimer()
+
+		if err := seeder.Seed(); err != nil {
+			b.Fatalf("seed failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSeedPerRow(b *testing.B)  { benchmarkSeed(b, 1) }
+func BenchmarkSeedBatched(b *testing.B) { benchmarkSeed(b, 100) }
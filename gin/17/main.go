@@ -2,15 +2,30 @@ package main
 
 import (
 	"context"
+	crand "crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"example.com/gin-playground/pkg/bindingerr"
+	"example.com/gin-playground/pkg/jwtauth"
+	"example.com/gin-playground/pkg/metrics"
+	"example.com/gin-playground/pkg/timeout"
 	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -22,16 +37,27 @@ import (
 // ============================================================================
 
 type Account struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	Number    string         `gorm:"uniqueIndex;not null" json:"number"`
-	Name      string         `json:"name"`
-	Balance   float64        `json:"balance"`
-	Currency  string         `json:"currency"`
-	IsLocked  bool           `gorm:"default:false" json:"is_locked"`
-	Version   int            `gorm:"default:0" json:"version"` // 낙관적 잠금용
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uint           `gorm:"primarykey" json:"id"`
+	Number         string         `gorm:"uniqueIndex;not null" json:"number"`
+	Name           string         `json:"name"`
+	Balance        float64        `json:"balance"`
+	OpeningBalance float64        `json:"opening_balance"` // 원장 밖에서 들어온 개설 잔액 (원장 검증 기준점)
+	Currency       string         `json:"currency"`
+	IsLocked       bool           `gorm:"default:false" json:"is_locked"`
+	Status         string         `gorm:"default:active" json:"status"` // active, closed
+	ClosedAt       *time.Time     `json:"closed_at,omitempty"`
+	Version        int            `gorm:"default:0" json:"version"` // 낙관적 잠금용
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BeforeCreate - 개설 잔액을 따로 지정하지 않으면 생성 시점 잔액을 기록
+func (a *Account) BeforeCreate(tx *gorm.DB) error {
+	if a.OpeningBalance == 0 {
+		a.OpeningBalance = a.Balance
+	}
+	return nil
 }
 
 type Transaction struct {
@@ -50,20 +76,23 @@ type Transaction struct {
 	ProcessingTime  int64     `json:"processing_time_ms"` // 밀리초
 	CreatedAt       time.Time `json:"created_at"`
 	CompletedAt     *time.Time `json:"completed_at"`
+	ResolvedBy      string     `json:"resolved_by,omitempty"` // 수동 복구한 관리자
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
 }
 
 type Order struct {
-	ID            uint           `gorm:"primarykey" json:"id"`
-	OrderNumber   string         `gorm:"uniqueIndex;not null" json:"order_number"`
-	CustomerID    uint           `json:"customer_id"`
-	TotalAmount   float64        `json:"total_amount"`
-	Status        string         `json:"status"` // pending, processing, completed, cancelled
-	Items         []OrderItem    `gorm:"foreignKey:OrderID" json:"items"`
-	PaymentID     *uint          `json:"payment_id"`
-	Payment       *Payment       `gorm:"foreignKey:PaymentID" json:"payment,omitempty"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	ID             uint           `gorm:"primarykey" json:"id"`
+	OrderNumber    string         `gorm:"uniqueIndex;not null" json:"order_number"`
+	IdempotencyKey *string        `gorm:"uniqueIndex" json:"idempotency_key,omitempty"` // 클라이언트 재시도 식별 (NULL은 중복 허용)
+	CustomerID     uint           `json:"customer_id"`
+	TotalAmount    float64        `json:"total_amount"`
+	Status         string         `json:"status"` // pending, processing, completed, cancelled
+	Items          []OrderItem    `gorm:"foreignKey:OrderID" json:"items"`
+	PaymentID      *uint          `json:"payment_id"`
+	Payment        *Payment       `gorm:"foreignKey:OrderID" json:"payment,omitempty"` // payments.order_id로 연결 (PaymentID는 Payment.PaymentID와 이름이 겹쳐 관계 추론에 못 씀)
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type OrderItem struct {
@@ -75,18 +104,50 @@ type OrderItem struct {
 	Price     float64 `json:"price"`
 }
 
+// Product - 가용 재고(Stock - Reserved)가 LowStockThreshold 아래로 내려가면
+// inventory.low_stock 웹훅을 보냄 (0이면 알림 없음)
 type Product struct {
-	ID        uint    `gorm:"primarykey" json:"id"`
-	Name      string  `json:"name"`
-	SKU       string  `gorm:"uniqueIndex;not null" json:"sku"`
-	Price     float64 `json:"price"`
-	Stock     int     `json:"stock"`
-	Reserved  int     `json:"reserved"` // 예약된 재고
-	Version   int     `gorm:"default:0" json:"version"`
+	ID                uint      `gorm:"primarykey" json:"id"`
+	Name              string    `json:"name"`
+	SKU               string    `gorm:"uniqueIndex;not null" json:"sku"`
+	Price             float64   `json:"price"`
+	Stock             int       `json:"stock"`
+	Reserved          int       `json:"reserved"` // 예약된 재고
+	LowStockThreshold int       `json:"low_stock_threshold"`
+	Version           int       `gorm:"default:0" json:"version"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Available - 새 주문/예약에 쓸 수 있는 재고
+func (p *Product) Available() int {
+	return p.Stock - p.Reserved
+}
+
+// StockReservation - 만료 시간이 있는 재고 예약
+// 예약된 수량은 Product.Reserved에 반영되고, 주문 확정 시 소비(consumed)되거나
+// Saga 보상으로 해제(released)되며, 어느 쪽도 없이 ExpiresAt이 지나면
+// 백그라운드 sweeper가 만료(expired) 처리하고 재고를 돌려놓습니다.
+// Token은 POST /inventory/reserve로 함께 예약한 묶음을 가리킵니다. (주문 처리 중 생긴 예약은 빈 값)
+type StockReservation struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	OrderID   *uint     `gorm:"index" json:"order_id"` // 소비될 때 주문과 연결
+	Token     string    `gorm:"index" json:"token,omitempty"`
+	ProductID uint      `gorm:"index" json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `gorm:"index" json:"status"` // held, consumed, released, expired
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+const (
+	ReservationHeld     = "held"
+	ReservationConsumed = "consumed"
+	ReservationReleased = "released"
+	ReservationExpired  = "expired"
+)
+
 type Payment struct {
 	ID            uint      `gorm:"primarykey" json:"id"`
 	PaymentID     string    `gorm:"uniqueIndex;not null" json:"payment_id"`
@@ -99,22 +160,689 @@ type Payment struct {
 	ProcessedAt   *time.Time `json:"processed_at"`
 }
 
+// OutboxEvent - 트랜잭션과 같은 커밋으로 기록되는 웹훅 이벤트
+// 릴레이가 전송에 성공하면 SentAt을 채우고, 실패하면 NextAttemptAt까지 미룹니다.
+type OutboxEvent struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	EventType     string     `gorm:"not null" json:"event_type"` // transaction.transfer 등
+	Reference     string     `gorm:"index" json:"reference"`
+	Payload       string     `gorm:"type:text" json:"payload"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	SentAt        *time.Time `gorm:"index" json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ============================================================================
+// 실시간 이벤트 (SSE)
+// ============================================================================
+
+// TransactionEvent - 커밋이 끝난 트랜잭션/주문 알림
+type TransactionEvent struct {
+	Type        string    `json:"type"`      // transfer, deposit, withdrawal, order
+	Reference   string    `json:"reference"` // transaction_id 또는 order_number
+	Amount      float64   `json:"amount"`
+	Status      string    `json:"status"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// 구독자별 버퍼 크기. 가득 차면 그 구독자에게 가는 이벤트는 버림
+const eventBufferSize = 32
+
+// EventBroker - 프로세스 내 pub/sub. Publish는 절대 블록되지 않음
+type EventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan TransactionEvent]struct{}
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscribers: make(map[chan TransactionEvent]struct{})}
+}
+
+// Subscribe - 이벤트 채널과 구독 해제 함수를 반환 (해제하면 채널이 닫힘)
+func (b *EventBroker) Subscribe() (<-chan TransactionEvent, func()) {
+	ch := make(chan TransactionEvent, eventBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish - 모든 구독자에게 전달. 느린 구독자 때문에 호출자(커밋 경로)가 기다리지 않음
+func (b *EventBroker) Publish(event TransactionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("⚠️ dropping %s event for slow subscriber", event.Type)
+		}
+	}
+}
+
+// ============================================================================
+// 트랜잭션 아웃박스 (웹훅)
+// ============================================================================
+
+// 커밋 후 웹훅을 보내기 전에 프로세스가 죽어도 이벤트를 잃지 않도록,
+// 이벤트는 트랜잭션 안에서 outbox_events에 기록하고 릴레이가 나중에 전송합니다.
+// 전송 후 sent_at을 기록하기 전에 죽으면 다시 보내므로 최소 1회(at-least-once) 전달이며,
+// 수신 측은 X-Outbox-Event-ID 헤더로 중복을 걸러야 합니다.
+
+// 릴레이 기본 설정
+var (
+	outboxPollInterval = 2 * time.Second
+	outboxBaseBackoff  = time.Second
+	outboxMaxBackoff   = 5 * time.Minute
+)
+
+const outboxBatchSize = 50
+
+// enqueueOutbox - 반드시 tx 안에서 호출 (이벤트가 트랜잭션과 함께 커밋/롤백됨)
+func enqueueOutbox(tx *gorm.DB, event TransactionEvent) error {
+	return enqueueOutboxPayload(tx, "transaction."+event.Type, event.Reference, event)
+}
+
+// enqueueOutboxPayload - 트랜잭션 이벤트가 아닌 웹훅(재고 부족 등)도 같은 outbox로 보냄
+func enqueueOutboxPayload(tx *gorm.DB, eventType, reference string, event interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&OutboxEvent{
+		EventType:     eventType,
+		Reference:     reference,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// OutboxDeliverFunc - 이벤트 하나를 전송. 에러를 반환하면 백오프 후 재시도
+type OutboxDeliverFunc func(ctx context.Context, event *OutboxEvent) error
+
+// NewWebhookDeliverer - payload를 url로 POST하고 2xx가 아니면 실패로 처리
+func NewWebhookDeliverer(url string, client *http.Client) OutboxDeliverFunc {
+	return func(ctx context.Context, event *OutboxEvent) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(event.Payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Outbox-Event-ID", strconv.FormatUint(uint64(event.ID), 10))
+		req.Header.Set("X-Event-Type", event.EventType)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// OutboxRelay - 미전송 이벤트를 폴링해서 전송하고 결과를 기록
+type OutboxRelay struct {
+	db          *gorm.DB
+	deliver     OutboxDeliverFunc
+	batchSize   int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	now         func() time.Time
+}
+
+func NewOutboxRelay(db *gorm.DB, deliver OutboxDeliverFunc) *OutboxRelay {
+	return &OutboxRelay{
+		db:          db,
+		deliver:     deliver,
+		batchSize:   outboxBatchSize,
+		baseBackoff: outboxBaseBackoff,
+		maxBackoff:  outboxMaxBackoff,
+		now:         time.Now,
+	}
+}
+
+// backoff - 시도 횟수마다 두 배로 늘어나는 대기 시간 (maxBackoff에서 멈춤)
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	d := r.baseBackoff
+	for i := 1; i < attempts && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.maxBackoff)
+}
+
+// RelayOnce - 보낼 때가 된 미전송 이벤트를 ID 순으로 전송하고 성공한 개수를 반환
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	db := r.db.WithContext(ctx)
+
+	var events []OutboxEvent
+	if err := db.Where("sent_at IS NULL AND next_attempt_at <= ?", r.now()).
+		Order("id").Limit(r.batchSize).Find(&events).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range events {
+		event := &events[i]
+		event.Attempts++
+
+		if err := r.deliver(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				return sent, ctx.Err()
+			}
+			if err := db.Model(event).Updates(map[string]interface{}{
+				"attempts":        event.Attempts,
+				"last_error":      err.Error(),
+				"next_attempt_at": r.now().Add(r.backoff(event.Attempts)),
+			}).Error; err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		// 여기서 실패하면 다음 폴링에서 다시 전송됨 (at-least-once)
+		if err := db.Model(event).Updates(map[string]interface{}{
+			"attempts":   event.Attempts,
+			"last_error": "",
+			"sent_at":    r.now(),
+		}).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// Start - 시작 즉시, 그리고 interval마다 RelayOnce를 실행하는 고루틴 시작
+// ctx가 취소되면 종료됩니다. 재시작하면 outbox에 남은 이벤트부터 이어서 전송합니다.
+func (r *OutboxRelay) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Outbox relay failed: %v", err)
+			} else if n > 0 {
+				log.Printf("📨 Delivered %d outbox event(s)", n)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// ============================================================================
+// 요청 트레이싱
+// ============================================================================
+
+// 지연 원인 추적용 최소 span 기록기 (OpenTelemetry 없이 프로세스 메모리에만 보관)
+// 요청 컨텍스트에 Trace를 달아 두면 서비스가 단계별 span을 남기고, 끝난 요청은 링 버퍼에 쌓임
+
+// 보관할 최근 트레이스 수
+const traceBufferSize = 100
+
+// Span - 한 단계(잠금, 업데이트, 커밋 등)의 소요 시간
+type Span struct {
+	Name       string    `json:"name"`
+	Start      time.Time `json:"start"`
+	DurationMs float64   `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Trace - 요청 하나에서 기록된 span 목록 (request_id로 로그와 연결)
+type Trace struct {
+	RequestID  string    `json:"request_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Start      time.Time `json:"start"`
+	DurationMs float64   `json:"duration_ms"`
+	Spans      []Span    `json:"spans"`
+
+	mu sync.Mutex
+}
+
+type traceKey struct{}
+
+// startSpan - ctx에 트레이스가 있으면 span을 시작하고, 끝낼 때 호출할 함수를 반환
+// 트레이스가 없으면 아무것도 기록하지 않음
+func startSpan(ctx context.Context, name string) func(err error) {
+	return startSpanAt(ctx, name, time.Now())
+}
+
+// startSpanAt - 이미 시작된 단계(예: 커밋)를 start 시점부터 잰 span
+func startSpanAt(ctx context.Context, name string, start time.Time) func(err error) {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	if trace == nil {
+		return func(error) {}
+	}
+	return func(err error) {
+		span := Span{Name: name, Start: start, DurationMs: durationMs(time.Since(start))}
+		if err != nil {
+			span.Error = err.Error()
+		}
+		trace.mu.Lock()
+		trace.Spans = append(trace.Spans, span)
+		trace.mu.Unlock()
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// TraceRecorder - 최근 트레이스를 고정 크기 링 버퍼에 보관
+type TraceRecorder struct {
+	mu     sync.Mutex
+	traces []*Trace
+	next   int
+	full   bool
+}
+
+func NewTraceRecorder(size int) *TraceRecorder {
+	return &TraceRecorder{traces: make([]*Trace, size)}
+}
+
+func (r *TraceRecorder) Record(trace *Trace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.traces[r.next] = trace
+	r.next = (r.next + 1) % len(r.traces)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Recent - 최근 트레이스를 최신순으로 최대 n개 반환
+func (r *TraceRecorder) Recent(n int) []*Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := r.next
+	if r.full {
+		count = len(r.traces)
+	}
+	if n > count {
+		n = count
+	}
+	recent := make([]*Trace, 0, n)
+	for i := 1; i <= n; i++ {
+		recent = append(recent, r.traces[(r.next-i+len(r.traces))%len(r.traces)])
+	}
+	return recent
+}
+
+// TracingMiddleware - 요청 컨텍스트에 트레이스를 달고, span이 하나라도 남은 요청만 기록
+// request_id 미들웨어 뒤에 등록해야 함
+func TracingMiddleware(recorder *TraceRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		trace := &Trace{
+			RequestID: c.GetString("request_id"),
+			Method:    c.Request.Method,
+			Path:      c.FullPath(),
+			Start:     time.Now(),
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), traceKey{}, trace))
+
+		c.Next()
+
+		trace.mu.Lock()
+		defer trace.mu.Unlock()
+		if len(trace.Spans) == 0 {
+			return
+		}
+		trace.Status = c.Writer.Status()
+		trace.DurationMs = durationMs(time.Since(trace.Start))
+		recorder.Record(trace)
+	}
+}
+
 // ============================================================================
 // 트랜잭션 서비스
 // ============================================================================
 
+// 서비스 에러 (핸들러는 errors.Is로 HTTP 상태 코드를 결정)
+var (
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrInvalidTransfer     = errors.New("invalid transfer request")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrAccountLocked       = errors.New("account is locked")
+	ErrConcurrentUpdate    = errors.New("concurrent update detected")
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrProductNotFound     = errors.New("product not found")
+)
+
+// TransferValidationError - 바인딩 태그로 표현할 수 없는 이체 요청 검증 실패 (필드별 사유)
+// errors.Is(err, ErrInvalidTransfer)가 성립하고, 금액 문제가 있으면 ErrInvalidAmount도 성립
+type TransferValidationError struct {
+	Fields []bindingerr.FieldError
+}
+
+func (e *TransferValidationError) Error() string {
+	reasons := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		reasons[i] = f.Field + ": " + f.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidTransfer, strings.Join(reasons, "; "))
+}
+
+func (e *TransferValidationError) Unwrap() []error {
+	errs := []error{ErrInvalidTransfer}
+	for _, f := range e.Fields {
+		if f.Field == "amount" {
+			errs = append(errs, ErrInvalidAmount)
+			break
+		}
+	}
+	return errs
+}
+
+// validateTransfer - 같은 계좌로의 이체, 0 이하 금액, 소수점 셋째 자리 이하 금액을 거절
+// 계좌 조회 전에 실행되므로 실패한 필드를 모두 한 번에 알려줌
+func validateTransfer(fromAccountID, toAccountID uint, amount float64) error {
+	var fields []bindingerr.FieldError
+	if fromAccountID != 0 && fromAccountID == toAccountID {
+		fields = append(fields, bindingerr.FieldError{
+			Field:   "to_account_id",
+			Message: "Must differ from from_account_id",
+			Value:   strconv.FormatUint(uint64(toAccountID), 10),
+		})
+	}
+
+	value := strconv.FormatFloat(amount, 'f', -1, 64)
+	switch {
+	case amount <= 0:
+		fields = append(fields, bindingerr.FieldError{Field: "amount", Message: "Must be greater than 0", Value: value})
+	case !hasCentPrecision(amount):
+		fields = append(fields, bindingerr.FieldError{Field: "amount", Message: "Must have at most 2 decimal places", Value: value})
+	}
+
+	if len(fields) > 0 {
+		return &TransferValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// hasCentPrecision - 센트 단위로 떨어지는 금액인지 (10.01*100 같은 부동소수 오차는 허용)
+func hasCentPrecision(amount float64) bool {
+	cents := amount * 100
+	return math.Abs(cents-math.Round(cents)) < 1e-6
+}
+
+// ConflictError - 낙관적 잠금 재시도를 모두 소진함 (errors.Is(err, ErrConcurrentUpdate)도 성립)
+// 몇 번 시도했는지와 클라이언트가 다시 시도하기 전에 기다릴 시간을 담음
+type ConflictError struct {
+	Resource   string
+	ID         uint
+	Attempts   int
+	RetryAfter time.Duration
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("max retries exceeded: %s %d was modified concurrently on all %d attempts",
+		e.Resource, e.ID, e.Attempts)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConcurrentUpdate
+}
+
+// 재고 업데이트의 낙관적 잠금 재시도 횟수와 재시도 간격 (n번째 재시도는 n*간격 대기)
+var (
+	stockUpdateMaxRetries = 3
+	stockRetryBackoff     = 50 * time.Millisecond
+)
+
+// stockMetricBuckets - 재고 재시도 카운터의 product_bucket 라벨 수 (제품 ID % 16)
+const stockMetricBuckets = 16
+
+// StockRetryMetrics - UpdateStock의 낙관적 잠금 시도/재시도/소진 카운터 (/metrics로 노출)
+// 제품 ID를 그대로 라벨로 쓰면 제품 수만큼 시계열이 늘어나므로 productBucket으로 묶음
+type StockRetryMetrics struct {
+	Attempts  *metrics.Counter
+	Retries   *metrics.Counter
+	Exhausted *metrics.Counter
+}
+
+func NewStockRetryMetrics(registry *metrics.Registry) *StockRetryMetrics {
+	return &StockRetryMetrics{
+		Attempts:  registry.NewCounter("stock_update_attempts_total", "Optimistic stock update attempts, including retries.", "product_bucket"),
+		Retries:   registry.NewCounter("stock_update_retries_total", "Stock update attempts repeated after a concurrent update.", "product_bucket"),
+		Exhausted: registry.NewCounter("stock_update_exhausted_total", "Stock updates that gave up after all retries conflicted.", "product_bucket"),
+	}
+}
+
+// productBucket - 제품 ID를 고정된 개수의 라벨 값으로 변환
+func productBucket(productID uint) string {
+	return strconv.FormatUint(uint64(productID%stockMetricBuckets), 10)
+}
+
 type TransactionService struct {
-	db *gorm.DB
+	db             *gorm.DB
+	events         *EventBroker
+	reservationTTL time.Duration
+	now            func() time.Time
+	metrics        *metrics.Registry
+	stockMetrics   *StockRetryMetrics
+
+	maintenanceMu sync.RWMutex
+	maintenance   *MaintenanceWindow
 }
 
 func NewTransactionService(db *gorm.DB) *TransactionService {
-	return &TransactionService{db: db}
+	registry := metrics.NewRegistry()
+	return &TransactionService{
+		db:             db,
+		events:         NewEventBroker(),
+		reservationTTL: stockReservationTTL,
+		now:            time.Now,
+		metrics:        registry,
+		stockMetrics:   NewStockRetryMetrics(registry),
+	}
+}
+
+// transactionEvent - 트랜잭션 레코드를 알림 이벤트로 변환
+func transactionEvent(tx *Transaction) TransactionEvent {
+	event := TransactionEvent{
+		Type:      tx.Type,
+		Reference: tx.TransactionID,
+		Amount:    tx.Amount,
+		Status:    tx.Status,
+	}
+	if tx.CompletedAt != nil {
+		event.CompletedAt = *tx.CompletedAt
+	}
+	return event
+}
+
+// publishTransaction - 커밋된 트랜잭션 레코드를 구독자에게 알림
+func (s *TransactionService) publishTransaction(tx *Transaction) {
+	s.events.Publish(transactionEvent(tx))
+}
+
+// publishOrder - 완료된 주문을 구독자에게 알림
+func (s *TransactionService) publishOrder(order *Order) {
+	s.events.Publish(TransactionEvent{
+		Type:        "order",
+		Reference:   order.OrderNumber,
+		Amount:      order.TotalAmount,
+		Status:      order.Status,
+		CompletedAt: s.now(),
+	})
+}
+
+// notFound - gorm.ErrRecordNotFound를 도메인 sentinel로 바꾸고 그 외 에러는 그대로 반환
+func notFound(err, sentinel error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return sentinel
+	}
+	return err
+}
+
+// ============================================================================
+// 이체 점검 시간 (Maintenance Window)
+// ============================================================================
+
+// ErrMaintenanceWindow - 야간 정산 중에는 이체를 받지 않음 (503)
+var ErrMaintenanceWindow = errors.New("transfers are paused during the maintenance window")
+
+const maintenanceTimeLayout = "15:04"
+
+// MaintenanceWindow - 매일 반복되는 이체 중단 시간 (UTC, Start 포함·End 제외)
+// Start가 End보다 늦으면 자정을 넘는 구간 (예: 23:30 ~ 00:30)
+type MaintenanceWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+
+	start, end time.Duration // 자정으로부터의 오프셋
 }
 
-// 계좌 이체 (트랜잭션 처리)
+// ParseMaintenanceWindow - "HH:MM" 형식의 시작/종료 시각으로 점검 시간 생성
+func ParseMaintenanceWindow(start, end string) (*MaintenanceWindow, error) {
+	startAt, err := time.Parse(maintenanceTimeLayout, start)
+	if err != nil {
+		return nil, fmt.Errorf("start must be HH:MM: %w", err)
+	}
+	endAt, err := time.Parse(maintenanceTimeLayout, end)
+	if err != nil {
+		return nil, fmt.Errorf("end must be HH:MM: %w", err)
+	}
+	if startAt.Equal(endAt) {
+		return nil, errors.New("start and end must differ")
+	}
+
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &MaintenanceWindow{
+		Start: start,
+		End:   end,
+		start: startAt.Sub(midnight),
+		end:   endAt.Sub(midnight),
+	}, nil
+}
+
+// sinceMidnight - t가 속한 UTC 날짜의 자정부터 지난 시간
+func sinceMidnight(t time.Time) time.Duration {
+	t = t.UTC()
+	return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+// Contains - t가 점검 시간 안에 있는지
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	d := sinceMidnight(t)
+	if w.start < w.end {
+		return d >= w.start && d < w.end
+	}
+	return d >= w.start || d < w.end
+}
+
+// Remaining - t부터 점검 시간이 끝날 때까지 남은 시간 (Retry-After 용)
+func (w *MaintenanceWindow) Remaining(t time.Time) time.Duration {
+	remaining := w.end - sinceMidnight(t)
+	if remaining <= 0 {
+		remaining += 24 * time.Hour
+	}
+	return remaining
+}
+
+// SetMaintenanceWindow - 점검 시간을 설정 (nil이면 해제). 실행 중에도 바로 반영됨
+func (s *TransactionService) SetMaintenanceWindow(w *MaintenanceWindow) {
+	s.maintenanceMu.Lock()
+	defer s.maintenanceMu.Unlock()
+	s.maintenance = w
+}
+
+// MaintenanceWindow - 현재 설정된 점검 시간 (없으면 nil)
+func (s *TransactionService) MaintenanceWindow() *MaintenanceWindow {
+	s.maintenanceMu.RLock()
+	defer s.maintenanceMu.RUnlock()
+	return s.maintenance
+}
+
+// checkMaintenance - 지금이 점검 시간이면 ErrMaintenanceWindow
+func (s *TransactionService) checkMaintenance() error {
+	if w := s.MaintenanceWindow(); w != nil && w.Contains(s.now()) {
+		return ErrMaintenanceWindow
+	}
+	return nil
+}
+
+// 연산별 기본 격리 수준 (sql.LevelDefault를 넘기면 이 값을 사용)
+// 잔액 변경은 모두 계좌 행을 FOR UPDATE로 잠그므로 같은 행에 대한 lost update는 격리 수준과 무관하게 막히고,
+// Serializable은 여러 행을 읽고 판단하는 연산(이체)에서 write skew까지 막는 대신 충돌 시 재시도가 필요함.
+// 입금은 한 계좌에 더하기만 하므로 더 가벼운 ReadCommitted로 충분함
+var (
+	transferIsolation = sql.LevelSerializable
+	depositIsolation  = sql.LevelReadCommitted
+	withdrawIsolation = sql.LevelSerializable
+)
+
+// ErrUnsupportedIsolation - 요청한 격리 수준 이름을 모름
+var ErrUnsupportedIsolation = errors.New("unsupported isolation level")
+
+// isolationLevels - 요청 본문의 "isolation" 값
+var isolationLevels = map[string]sql.IsolationLevel{
+	"read_committed":  sql.LevelReadCommitted,
+	"repeatable_read": sql.LevelRepeatableRead,
+	"serializable":    sql.LevelSerializable,
+}
+
+// parseIsolation - 빈 문자열은 sql.LevelDefault (연산별 기본값 사용)
+func parseIsolation(name string) (sql.IsolationLevel, error) {
+	if name == "" {
+		return sql.LevelDefault, nil
+	}
+	level, ok := isolationLevels[strings.ToLower(name)]
+	if !ok {
+		return sql.LevelDefault, fmt.Errorf("%w: %s", ErrUnsupportedIsolation, name)
+	}
+	return level, nil
+}
+
+// txOptions - level이 sql.LevelDefault면 연산의 기본 격리 수준으로 트랜잭션 옵션 생성.
+// SQLite 드라이버는 격리 수준을 무시하고 항상 직렬화(쓰기는 DB 전체 잠금)하므로
+// 차이는 PostgreSQL/MySQL 같은 드라이버에서만 나타남
+func txOptions(level, fallback sql.IsolationLevel) *sql.TxOptions {
+	if level == sql.LevelDefault {
+		level = fallback
+	}
+	return &sql.TxOptions{Isolation: level}
+}
+
+// 계좌 이체 (트랜잭션 처리, 기본 Serializable)
+// 점검 시간에는 이체만 막고, 조회와 입출금은 그대로 허용
 func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAccountID uint, amount float64) (*Transaction, error) {
-	if amount <= 0 {
-		return nil, errors.New("amount must be positive")
+	return s.TransferWithIsolation(ctx, fromAccountID, toAccountID, amount, sql.LevelDefault)
+}
+
+// TransferWithIsolation - 격리 수준을 지정한 이체
+func (s *TransactionService) TransferWithIsolation(ctx context.Context, fromAccountID, toAccountID uint, amount float64, isolation sql.IsolationLevel) (*Transaction, error) {
+	if err := validateTransfer(fromAccountID, toAccountID, amount); err != nil {
+		return nil, err
+	}
+	if err := s.checkMaintenance(); err != nil {
+		return nil, err
 	}
 
 	txRecord := &Transaction{
@@ -128,49 +856,40 @@ func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAcco
 	}
 
 	startTime := time.Now()
+	// 커밋 시간은 콜백이 끝난 시점부터 Transaction이 반환될 때까지
+	var commitStart time.Time
 
 	// 트랜잭션 시작 (타임아웃 설정)
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. 트랜잭션 레코드 생성
-		if err := tx.Create(txRecord).Error; err != nil {
+		endSpan := startSpan(ctx, "create_record")
+		err := tx.Create(txRecord).Error
+		endSpan(err)
+		if err != nil {
 			return err
 		}
 
-		// 2. 송금 계좌 조회 및 잠금 (비관적 잠금)
-		var fromAccount Account
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			First(&fromAccount, fromAccountID).Error; err != nil {
-			return fmt.Errorf("from account not found: %w", err)
-		}
-
-		// 3. 수신 계좌 조회 및 잠금
-		var toAccount Account
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			First(&toAccount, toAccountID).Error; err != nil {
-			return fmt.Errorf("to account not found: %w", err)
-		}
-
-		// 4. 잔액 확인
-		if fromAccount.Balance < amount {
-			return errors.New("insufficient balance")
-		}
-
-		// 5. 계좌 잠금 상태 확인
-		if fromAccount.IsLocked || toAccount.IsLocked {
-			return errors.New("account is locked")
+		// 2~5. 계좌 잠금 및 이체 가능 여부 확인
+		fromAccount, toAccount, err := lockTransferAccounts(ctx, tx, fromAccountID, toAccountID, amount)
+		if err != nil {
+			return err
 		}
 
 		// 6. 잔액 업데이트
 		fromAccount.Balance -= amount
 		toAccount.Balance += amount
 
+		endSpan = startSpan(ctx, "update_balances")
 		if err := tx.Save(&fromAccount).Error; err != nil {
+			endSpan(err)
 			return fmt.Errorf("failed to update from account: %w", err)
 		}
 
 		if err := tx.Save(&toAccount).Error; err != nil {
+			endSpan(err)
 			return fmt.Errorf("failed to update to account: %w", err)
 		}
+		endSpan(nil)
 
 		// 7. 트랜잭션 상태 업데이트
 		now := time.Now()
@@ -178,10 +897,19 @@ func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAcco
 		txRecord.CompletedAt = &now
 		txRecord.ProcessingTime = time.Since(startTime).Milliseconds()
 
+		endSpan = startSpan(ctx, "complete_record")
 		if err := tx.Save(txRecord).Error; err != nil {
+			endSpan(err)
 			return fmt.Errorf("failed to update transaction record: %w", err)
 		}
 
+		// 8. 웹훅 이벤트를 같은 트랜잭션으로 outbox에 기록
+		if err := enqueueOutbox(tx, transactionEvent(txRecord)); err != nil {
+			endSpan(err)
+			return fmt.Errorf("failed to enqueue outbox event: %w", err)
+		}
+		endSpan(nil)
+
 		// 인위적 지연 (테스트용)
 		select {
 		case <-time.After(100 * time.Millisecond):
@@ -189,10 +917,13 @@ func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAcco
 			return ctx.Err()
 		}
 
+		commitStart = time.Now()
 		return nil
-	}, &sql.TxOptions{
-		Isolation: sql.LevelSerializable, // 최고 격리 수준
-	})
+	}, txOptions(isolation, transferIsolation))
+
+	if !commitStart.IsZero() {
+		startSpanAt(ctx, "commit", commitStart)(err)
+	}
 
 	if err != nil {
 		// 트랜잭션 실패 기록
@@ -203,23 +934,116 @@ func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAcco
 		return nil, err
 	}
 
+	// 커밋 이후에만 알림
+	s.publishTransaction(txRecord)
 	return txRecord, nil
 }
 
-// 낙관적 잠금을 사용한 재고 업데이트
-func (s *TransactionService) UpdateStock(ctx context.Context, productID uint, quantity int) error {
-	maxRetries := 3
+// lockTransferAccounts - 두 계좌를 잠그고 이체 가능 여부를 확인 (Transfer와 PreviewTransfer가 공유)
+func lockTransferAccounts(ctx context.Context, tx *gorm.DB, fromAccountID, toAccountID uint, amount float64) (*Account, *Account, error) {
+	// 송금 계좌 조회 및 잠금 (비관적 잠금)
+	var fromAccount Account
+	endSpan := startSpan(ctx, "lock_from_account")
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fromAccount, fromAccountID).Error
+	endSpan(err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("from account %d: %w", fromAccountID, notFound(err, ErrAccountNotFound))
+	}
 
-	for i := 0; i < maxRetries; i++ {
-		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	// 수신 계좌 조회 및 잠금
+	var toAccount Account
+	endSpan = startSpan(ctx, "lock_to_account")
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&toAccount, toAccountID).Error
+	endSpan(err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("to account %d: %w", toAccountID, notFound(err, ErrAccountNotFound))
+	}
+
+	// 잔액 확인
+	if fromAccount.Balance < amount {
+		return nil, nil, fmt.Errorf("%w: balance %.2f, requested %.2f", ErrInsufficientBalance, fromAccount.Balance, amount)
+	}
+
+	// 계좌 잠금/해지 상태 확인
+	if fromAccount.Status == AccountStatusClosed || toAccount.Status == AccountStatusClosed {
+		return nil, nil, ErrAccountClosed
+	}
+	if fromAccount.IsLocked || toAccount.IsLocked {
+		return nil, nil, ErrAccountLocked
+	}
+
+	return &fromAccount, &toAccount, nil
+}
+
+// TransferPreview - 이체를 실제로 실행했을 때의 예상 결과
+type TransferPreview struct {
+	WouldSucceed     bool     `json:"would_succeed"`
+	Reason           string   `json:"reason,omitempty"`
+	FromBalanceAfter *float64 `json:"from_balance_after,omitempty"`
+	ToBalanceAfter   *float64 `json:"to_balance_after,omitempty"`
+}
+
+// 미리보기 트랜잭션을 롤백하려고 일부러 반환하는 에러
+var errPreviewRollback = errors.New("preview rollback")
+
+// transferRejection - 계좌 상태 때문에 이체가 거절되는지 (그 외 에러는 미리보기 자체의 실패)
+func transferRejection(err error) bool {
+	return errors.Is(err, ErrAccountNotFound) || errors.Is(err, ErrInsufficientBalance) ||
+		errors.Is(err, ErrAccountClosed) || errors.Is(err, ErrAccountLocked)
+}
+
+// PreviewTransfer - Transfer와 같은 검사를 트랜잭션 안에서 실행한 뒤 롤백
+// Transaction 레코드나 outbox 이벤트를 남기지 않고 잔액도 바꾸지 않음
+// 요청 자체가 잘못된 경우(TransferValidationError)는 거절 사유가 아니라 에러로 반환
+func (s *TransactionService) PreviewTransfer(ctx context.Context, fromAccountID, toAccountID uint, amount float64) (*TransferPreview, error) {
+	if err := validateTransfer(fromAccountID, toAccountID, amount); err != nil {
+		return nil, err
+	}
+	if err := s.checkMaintenance(); err != nil {
+		return &TransferPreview{Reason: err.Error()}, nil
+	}
+
+	preview := &TransferPreview{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fromAccount, toAccount, err := lockTransferAccounts(ctx, tx, fromAccountID, toAccountID, amount)
+		if err != nil {
+			return err
+		}
+		fromAfter, toAfter := fromAccount.Balance-amount, toAccount.Balance+amount
+		preview.FromBalanceAfter, preview.ToBalanceAfter = &fromAfter, &toAfter
+		return errPreviewRollback
+	})
+
+	switch {
+	case errors.Is(err, errPreviewRollback):
+		preview.WouldSucceed = true
+		return preview, nil
+	case transferRejection(err):
+		return &TransferPreview{Reason: err.Error()}, nil
+	default:
+		return nil, err
+	}
+}
+
+// 낙관적 잠금을 사용한 재고 업데이트
+// 시도/재시도/소진 횟수는 stockMetrics에 제품 버킷별로 기록
+func (s *TransactionService) UpdateStock(ctx context.Context, productID uint, quantity int) error {
+	bucket := productBucket(productID)
+	for i := 0; i < stockUpdateMaxRetries; i++ {
+		s.stockMetrics.Attempts.Inc(bucket)
+		if i > 0 {
+			s.stockMetrics.Retries.Inc(bucket)
+		}
+
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 			var product Product
 			if err := tx.First(&product, productID).Error; err != nil {
-				return err
+				return fmt.Errorf("product %d: %w", productID, notFound(err, ErrProductNotFound))
 			}
 
 			// 재고 확인
 			if product.Stock < quantity {
-				return errors.New("insufficient stock")
+				return fmt.Errorf("%w for product %s", ErrInsufficientStock, product.Name)
 			}
 
 			// 낙관적 잠금: Version 체크와 업데이트
@@ -230,32 +1054,79 @@ func (s *TransactionService) UpdateStock(ctx context.Context, productID uint, qu
 					"version": product.Version + 1,
 				})
 
+			if result.Error != nil {
+				return result.Error
+			}
 			if result.RowsAffected == 0 {
-				return errors.New("concurrent update detected")
+				return ErrConcurrentUpdate
 			}
-
-			return result.Error
+			return nil
 		})
 
 		if err == nil {
 			return nil
 		}
 
-		if err.Error() == "concurrent update detected" {
-			// 재시도 대기
-			time.Sleep(time.Duration(i*50) * time.Millisecond)
-			continue
+		if errors.Is(err, ErrConcurrentUpdate) {
+			// 재시도 대기 (요청이 취소되면 즉시 중단)
+			select {
+			case <-time.After(time.Duration(i) * stockRetryBackoff):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 
 		return err
 	}
 
-	return errors.New("max retries exceeded")
+	// 재시도를 모두 써도 경합 중이므로 다음 간격만큼 기다렸다 다시 시도하도록 안내
+	s.stockMetrics.Exhausted.Inc(bucket)
+	return &ConflictError{
+		Resource:   "product",
+		ID:         productID,
+		Attempts:   stockUpdateMaxRetries,
+		RetryAfter: time.Duration(stockUpdateMaxRetries) * stockRetryBackoff,
+	}
+}
+
+// ErrIdempotencyKeyReused - 같은 멱등성 키로 내용이 다른 주문을 요청함
+var ErrIdempotencyKeyReused = errors.New("idempotency key already used for a different order")
+
+// findIdempotentOrder - 같은 키로 이미 처리된 주문을 찾음. 없으면 nil
+func findIdempotentOrder(tx *gorm.DB, order *Order) (*Order, error) {
+	var existing Order
+	err := tx.Preload("Items").Preload("Payment").
+		Where("idempotency_key = ?", *order.IdempotencyKey).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.CustomerID != order.CustomerID || existing.TotalAmount != order.TotalAmount {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return &existing, nil
 }
 
 // 주문 처리 (복잡한 트랜잭션)
-func (s *TransactionService) ProcessOrder(ctx context.Context, order *Order) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+// order.IdempotencyKey가 있으면 같은 키로 처리된 주문을 재고 예약/결제 없이 그대로 돌려주고 replayed=true
+func (s *TransactionService) ProcessOrder(ctx context.Context, order *Order) (replayed bool, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 0. 재시도 확인 - 이미 처리된 주문이면 재고/결제를 건드리지 않음
+		if order.IdempotencyKey != nil {
+			existing, err := findIdempotentOrder(tx, order)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				*order = *existing
+				replayed = true
+				return nil
+			}
+		}
+
 		// 1. 주문 생성
 		order.Status = "processing"
 		order.OrderNumber = fmt.Sprintf("ORD%d", time.Now().UnixNano())
@@ -265,32 +1136,20 @@ func (s *TransactionService) ProcessOrder(ctx context.Context, order *Order) err
 		}
 
 		// 2. 재고 확인 및 예약
+		holds, err := s.holdStock(tx, order.Items, "")
+		if err != nil {
+			return err
+		}
+
+		// 주문 아이템 가격 확정 (아이템은 주문과 함께 저장됨)
 		for _, item := range order.Items {
 			var product Product
-
-			// 비관적 잠금으로 제품 조회
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-				First(&product, item.ProductID).Error; err != nil {
-				return fmt.Errorf("product not found: %w", err)
-			}
-
-			// 재고 확인
-			availableStock := product.Stock - product.Reserved
-			if availableStock < item.Quantity {
-				return fmt.Errorf("insufficient stock for product %s", product.Name)
-			}
-
-			// 재고 예약
-			product.Reserved += item.Quantity
-			if err := tx.Save(&product).Error; err != nil {
-				return fmt.Errorf("failed to reserve stock: %w", err)
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				return fmt.Errorf("product %d: %w", item.ProductID, notFound(err, ErrProductNotFound))
 			}
-
-			// 주문 아이템 저장
-			item.OrderID = order.ID
-			item.Price = product.Price
-			if err := tx.Create(&item).Error; err != nil {
-				return fmt.Errorf("failed to create order item: %w", err)
+			if err := tx.Model(&OrderItem{}).Where("id = ?", item.ID).
+				Update("price", product.Price).Error; err != nil {
+				return fmt.Errorf("failed to update order item: %w", err)
 			}
 		}
 
@@ -326,29 +1185,32 @@ func (s *TransactionService) ProcessOrder(ctx context.Context, order *Order) err
 		}
 
 		// 5. 재고 확정 (예약 → 실제 차감)
-		for _, item := range order.Items {
-			result := tx.Model(&Product{}).
-				Where("id = ?", item.ProductID).
-				Updates(map[string]interface{}{
-					"stock":    gorm.Expr("stock - ?", item.Quantity),
-					"reserved": gorm.Expr("reserved - ?", item.Quantity),
-				})
-
-			if result.Error != nil {
-				return fmt.Errorf("failed to update stock: %w", result.Error)
+		return s.consumeReservations(tx, holds, &order.ID)
+	})
+	if err != nil {
+		// 같은 키의 동시 요청이 먼저 커밋해 unique 제약에 걸렸다면 그 주문을 돌려줌
+		if order.IdempotencyKey != nil {
+			if existing, findErr := findIdempotentOrder(s.db.WithContext(ctx), order); findErr == nil && existing != nil {
+				*order = *existing
+				return true, nil
 			}
 		}
+		return false, err
+	}
 
-		return nil
-	})
+	if !replayed {
+		s.publishOrder(order)
+	}
+	return replayed, nil
 }
 
 // Saga 패턴 예시
 func (s *TransactionService) ProcessOrderSaga(ctx context.Context, order *Order) error {
 	// 각 단계를 독립적으로 처리하고 실패 시 보상 트랜잭션 실행
 
-	// Step 1: 재고 예약
-	if err := s.reserveStock(ctx, order); err != nil {
+	// Step 1: 재고 예약 (만료 시간이 있어 Saga가 중간에 죽어도 sweeper가 회수)
+	holds, err := s.reserveStock(ctx, order)
+	if err != nil {
 		return err
 	}
 
@@ -356,52 +1218,292 @@ func (s *TransactionService) ProcessOrderSaga(ctx context.Context, order *Order)
 	payment, err := s.processPayment(ctx, order)
 	if err != nil {
 		// 보상: 재고 예약 취소
-		s.cancelStockReservation(ctx, order)
+		s.cancelStockReservation(ctx, holds)
 		return err
 	}
 
-	// Step 3: 주문 확정
-	if err := s.confirmOrder(ctx, order, payment); err != nil {
+	// Step 3: 주문 확정 (예약 소비)
+	if err := s.confirmOrder(ctx, order, payment, holds); err != nil {
 		// 보상: 결제 취소, 재고 예약 취소
 		s.cancelPayment(ctx, payment)
-		s.cancelStockReservation(ctx, order)
+		s.cancelStockReservation(ctx, holds)
 		return err
 	}
 
+	s.publishOrder(order)
 	return nil
 }
 
-func (s *TransactionService) reserveStock(ctx context.Context, order *Order) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		for _, item := range order.Items {
-			var product Product
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-				First(&product, item.ProductID).Error; err != nil {
-				return err
-			}
+func (s *TransactionService) reserveStock(ctx context.Context, order *Order) ([]StockReservation, error) {
+	var holds []StockReservation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		holds, err = s.holdStock(tx, order.Items, "")
+		return err
+	})
+	return holds, err
+}
+
+// holdStock - 가용 재고(Stock - Reserved)를 확인하고 만료 시간이 있는 예약 생성
+// token이 있으면 예약들을 하나의 묶음으로 조회/확정할 수 있도록 함께 저장
+func (s *TransactionService) holdStock(tx *gorm.DB, items []OrderItem, token string) ([]StockReservation, error) {
+	holds := make([]StockReservation, 0, len(items))
+	for _, item := range items {
+		var product Product
+
+		// 비관적 잠금으로 제품 조회
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&product, item.ProductID).Error; err != nil {
+			return nil, fmt.Errorf("product %d: %w", item.ProductID, notFound(err, ErrProductNotFound))
+		}
+
+		if product.Available() < item.Quantity {
+			return nil, fmt.Errorf("%w for product %s", ErrInsufficientStock, product.Name)
+		}
+
+		if err := tx.Model(&Product{}).Where("id = ?", product.ID).
+			Update("reserved", gorm.Expr("reserved + ?", item.Quantity)).Error; err != nil {
+			return nil, fmt.Errorf("failed to reserve stock: %w", err)
+		}
 
-			if product.Stock < item.Quantity {
-				return errors.New("insufficient stock")
+		// 예약 소비는 Stock과 Reserved를 같이 줄여 가용 재고가 그대로이므로 경계를 넘는 건 여기뿐
+		before := product.Available()
+		product.Reserved += item.Quantity
+		if crossedLowStock(&product, before) {
+			if err := enqueueLowStock(tx, &product, s.now()); err != nil {
+				return nil, fmt.Errorf("failed to enqueue low stock event: %w", err)
 			}
+		}
 
-			product.Reserved += item.Quantity
-			if err := tx.Save(&product).Error; err != nil {
-				return err
+		hold := StockReservation{
+			Token:     token,
+			ProductID: product.ID,
+			Quantity:  item.Quantity,
+			Status:    ReservationHeld,
+			ExpiresAt: s.now().Add(s.reservationTTL),
+		}
+		if err := tx.Create(&hold).Error; err != nil {
+			return nil, fmt.Errorf("failed to create reservation: %w", err)
+		}
+		holds = append(holds, hold)
+	}
+	return holds, nil
+}
+
+var (
+	// ErrReservationExpired - 주문 확정 전에 예약이 만료되어 재고가 이미 회수됨
+	ErrReservationExpired = errors.New("stock reservation expired")
+	// ErrReservationNotFound - 예약 토큰에 해당하는 예약이 없음
+	ErrReservationNotFound = errors.New("stock reservation not found")
+	// ErrReservationNotHeld - 이미 확정되었거나 해제된 예약
+	ErrReservationNotHeld = errors.New("stock reservation is no longer held")
+)
+
+// consumeReservations - 예약을 소비하고 재고를 실제로 차감 (orderID가 nil이면 주문과 연결하지 않음)
+// 이미 만료/해제된 예약이 있으면 ErrReservationExpired (재고는 sweeper가 돌려놓았음)
+func (s *TransactionService) consumeReservations(tx *gorm.DB, holds []StockReservation, orderID *uint) error {
+	for _, hold := range holds {
+		result := tx.Model(&StockReservation{}).
+			Where("id = ? AND status = ?", hold.ID, ReservationHeld).
+			Updates(map[string]interface{}{"status": ReservationConsumed, "order_id": orderID})
+		if result.Error != nil {
+			return fmt.Errorf("failed to consume reservation: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("reservation %d: %w", hold.ID, ErrReservationExpired)
+		}
+
+		if err := tx.Model(&Product{}).Where("id = ?", hold.ProductID).
+			Updates(map[string]interface{}{
+				"stock":    gorm.Expr("stock - ?", hold.Quantity),
+				"reserved": gorm.Expr("reserved - ?", hold.Quantity),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update stock: %w", err)
+		}
+	}
+	return nil
+}
+
+// releaseReservations - 아직 held 상태인 예약만 status로 바꾸고 예약 수량을 돌려놓음
+// 조건부 업데이트라 보상 트랜잭션과 sweeper가 같은 예약을 두 번 해제하지 않습니다.
+func releaseReservations(tx *gorm.DB, holds []StockReservation, status string) (int, error) {
+	released := 0
+	for _, hold := range holds {
+		result := tx.Model(&StockReservation{}).
+			Where("id = ? AND status = ?", hold.ID, ReservationHeld).
+			Update("status", status)
+		if result.Error != nil {
+			return released, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := tx.Model(&Product{}).Where("id = ?", hold.ProductID).
+			Update("reserved", gorm.Expr("reserved - ?", hold.Quantity)).Error; err != nil {
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
+
+func (s *TransactionService) cancelStockReservation(ctx context.Context, holds []StockReservation) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := releaseReservations(tx, holds, ReservationReleased)
+		return err
+	})
+}
+
+// ReleaseExpiredReservations - 만료 시간이 지난 held 예약을 해제하고 해제한 개수 반환
+func (s *TransactionService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	released := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var expired []StockReservation
+		if err := tx.Where("status = ? AND expires_at <= ?", ReservationHeld, s.now()).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+
+		var err error
+		released, err = releaseReservations(tx, expired, ReservationExpired)
+		return err
+	})
+	return released, err
+}
+
+// StartReservationSweeper - interval마다 만료된 예약을 회수하는 백그라운드 고루틴 시작
+// ctx가 취소되면 종료됩니다.
+func (s *TransactionService) StartReservationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				n, err := s.ReleaseExpiredReservations(ctx)
+				if err != nil {
+					log.Printf("Reservation sweep failed: %v", err)
+				} else if n > 0 {
+					log.Printf("♻️ Released %d expired stock reservation(s)", n)
+				}
+			case <-ctx.Done():
+				return
 			}
 		}
+	}()
+}
+
+// InventoryReservation - 주문과 분리된 2단계 재고 예약 (장바구니에서 예약 → 결제 시 확정)
+type InventoryReservation struct {
+	Token     string             `json:"token"`
+	Status    string             `json:"status"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	Items     []StockReservation `json:"items"`
+}
+
+// ReserveInventory - 주문을 만들지 않고 재고만 예약하고 예약 토큰 발급
+// 확정/해제하지 않으면 reservationTTL 뒤 sweeper가 회수합니다.
+func (s *TransactionService) ReserveInventory(ctx context.Context, items []OrderItem) (*InventoryReservation, error) {
+	token := "RSV" + crand.Text()
+	var holds []StockReservation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		holds, err = s.holdStock(tx, items, token)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newInventoryReservation(token, holds), nil
+}
+
+// ConfirmReservation - 예약을 소비해 재고를 실제로 차감
+// 만료 시간이 지났으면 sweeper를 기다리지 않고 바로 회수한 뒤 ErrReservationExpired
+func (s *TransactionService) ConfirmReservation(ctx context.Context, token string) (*InventoryReservation, error) {
+	var reservation *InventoryReservation
+	expired := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		holds, err := findHeldReservation(tx, token)
+		if err != nil {
+			return err
+		}
+
+		if !s.now().Before(holds[0].ExpiresAt) {
+			expired = true
+			_, err := releaseReservations(tx, holds, ReservationExpired)
+			return err
+		}
+
+		if err := s.consumeReservations(tx, holds, nil); err != nil {
+			return err
+		}
+		for i := range holds {
+			holds[i].Status = ReservationConsumed
+		}
+		reservation = newInventoryReservation(token, holds)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, fmt.Errorf("reservation %s: %w", token, ErrReservationExpired)
+	}
+	return reservation, nil
 }
 
-func (s *TransactionService) cancelStockReservation(ctx context.Context, order *Order) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		for _, item := range order.Items {
-			tx.Model(&Product{}).
-				Where("id = ?", item.ProductID).
-				Update("reserved", gorm.Expr("reserved - ?", item.Quantity))
+// ReleaseReservation - 결제 전에 취소된 장바구니의 예약을 즉시 해제
+func (s *TransactionService) ReleaseReservation(ctx context.Context, token string) (*InventoryReservation, error) {
+	var reservation *InventoryReservation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		holds, err := findHeldReservation(tx, token)
+		if err != nil {
+			return err
+		}
+
+		if _, err := releaseReservations(tx, holds, ReservationReleased); err != nil {
+			return err
+		}
+		for i := range holds {
+			holds[i].Status = ReservationReleased
 		}
+		reservation = newInventoryReservation(token, holds)
 		return nil
 	})
+	return reservation, err
+}
+
+// findHeldReservation - 토큰의 예약을 잠그고 조회. 아직 held 상태가 아니면 에러
+// (sweeper가 만료 처리한 예약은 ErrReservationExpired, 확정/해제된 예약은 ErrReservationNotHeld)
+func findHeldReservation(tx *gorm.DB, token string) ([]StockReservation, error) {
+	var holds []StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token = ?", token).Order("id").Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	if token == "" || len(holds) == 0 {
+		return nil, ErrReservationNotFound
+	}
+
+	switch status := holds[0].Status; status {
+	case ReservationHeld:
+		return holds, nil
+	case ReservationExpired:
+		return nil, fmt.Errorf("reservation %s: %w", token, ErrReservationExpired)
+	default:
+		return nil, fmt.Errorf("reservation %s is %s: %w", token, status, ErrReservationNotHeld)
+	}
+}
+
+func newInventoryReservation(token string, holds []StockReservation) *InventoryReservation {
+	reservation := &InventoryReservation{Token: token, Items: holds}
+	if len(holds) > 0 {
+		reservation.Status = holds[0].Status
+		reservation.ExpiresAt = holds[0].ExpiresAt
+	}
+	return reservation
 }
 
 func (s *TransactionService) processPayment(ctx context.Context, order *Order) (*Payment, error) {
@@ -426,107 +1528,1096 @@ func (s *TransactionService) cancelPayment(ctx context.Context, payment *Payment
 		Update("status", "cancelled").Error
 }
 
-func (s *TransactionService) confirmOrder(ctx context.Context, order *Order, payment *Payment) error {
+func (s *TransactionService) confirmOrder(ctx context.Context, order *Order, payment *Payment, holds []StockReservation) error {
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		order.Status = "completed"
 		order.PaymentID = &payment.ID
-		return tx.Save(order).Error
+		if order.OrderNumber == "" {
+			order.OrderNumber = fmt.Sprintf("ORD%d", time.Now().UnixNano())
+		}
+		if err := tx.Save(order).Error; err != nil {
+			return err
+		}
+		return s.consumeReservations(tx, holds, &order.ID)
 	})
 }
 
-// ============================================================================
-// 동시성 테스트 서비스
-// ============================================================================
+// 입금 (기본 ReadCommitted)
+func (s *TransactionService) Deposit(ctx context.Context, accountID uint, amount float64) (*Transaction, error) {
+	return s.DepositWithIsolation(ctx, accountID, amount, sql.LevelDefault)
+}
 
-type ConcurrencyTestService struct {
-	db      *gorm.DB
-	service *TransactionService
+// DepositWithIsolation - 격리 수준을 지정한 입금
+func (s *TransactionService) DepositWithIsolation(ctx context.Context, accountID uint, amount float64, isolation sql.IsolationLevel) (*Transaction, error) {
+	return s.adjustBalance(ctx, "deposit", accountID, amount, txOptions(isolation, depositIsolation))
 }
 
-func NewConcurrencyTestService(db *gorm.DB, service *TransactionService) *ConcurrencyTestService {
-	return &ConcurrencyTestService{db: db, service: service}
+// 출금 (잔액 초과 출금 불가, 기본 Serializable)
+func (s *TransactionService) Withdraw(ctx context.Context, accountID uint, amount float64) (*Transaction, error) {
+	return s.WithdrawWithIsolation(ctx, accountID, amount, sql.LevelDefault)
 }
 
-// 동시 이체 테스트
-func (s *ConcurrencyTestService) TestConcurrentTransfers(numWorkers int) map[string]interface{} {
-	results := make(map[string]interface{})
-	successCount := 0
-	failureCount := 0
-	timeoutCount := 0
+// WithdrawWithIsolation - 격리 수준을 지정한 출금
+func (s *TransactionService) WithdrawWithIsolation(ctx context.Context, accountID uint, amount float64, isolation sql.IsolationLevel) (*Transaction, error) {
+	return s.adjustBalance(ctx, "withdrawal", accountID, amount, txOptions(isolation, withdrawIsolation))
+}
 
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+// adjustBalance - 단일 계좌 입출금. Transfer와 같은 잠금/상태 확인/이력 기록을 따름
+func (s *TransactionService) adjustBalance(ctx context.Context, txType string, accountID uint, amount float64, opts *sql.TxOptions) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
 
-	// 테스트 계좌 생성
-	account1 := Account{Number: "TEST001", Name: "Test Account 1", Balance: 10000}
-	account2 := Account{Number: "TEST002", Name: "Test Account 2", Balance: 10000}
-	s.db.Create(&account1)
-	s.db.Create(&account2)
+	txRecord := &Transaction{
+		TransactionID: fmt.Sprintf("TXN%d", time.Now().UnixNano()),
+		Amount:        amount,
+		Type:          txType,
+		Status:        "pending",
+	}
+	// 입금은 수신 계좌, 출금은 송금 계좌로 기록
+	if txType == "deposit" {
+		txRecord.ToAccountID = accountID
+	} else {
+		txRecord.FromAccountID = accountID
+	}
 
 	startTime := time.Now()
 
-	// 동시 이체 실행
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-
-			// 랜덤 타임아웃 설정
-			timeout := time.Duration(rand.Intn(500)+500) * time.Millisecond
-			ctx, cancel := context.WithTimeout(context.Background(), timeout)
-			defer cancel()
-
-			// 양방향 이체
-			var fromID, toID uint
-			if workerID%2 == 0 {
-				fromID, toID = account1.ID, account2.ID
-			} else {
-				fromID, toID = account2.ID, account1.ID
-			}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(txRecord).Error; err != nil {
+			return err
+		}
 
-			amount := float64(rand.Intn(100) + 1)
+		var account Account
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&account, accountID).Error; err != nil {
+			return fmt.Errorf("account %d: %w", accountID, notFound(err, ErrAccountNotFound))
+		}
+		txRecord.Currency = account.Currency
 
-			_, err := s.service.Transfer(ctx, fromID, toID, amount)
+		if account.Status == AccountStatusClosed {
+			return ErrAccountClosed
+		}
+		if account.IsLocked {
+			return ErrAccountLocked
+		}
 
-			mu.Lock()
-			if err != nil {
-				if errors.Is(err, context.DeadlineExceeded) {
-					timeoutCount++
-				} else {
-					failureCount++
-				}
-			} else {
-				successCount++
+		if txType == "deposit" {
+			account.Balance += amount
+		} else {
+			if account.Balance < amount {
+				return fmt.Errorf("%w: balance %.2f, requested %.2f", ErrInsufficientBalance, account.Balance, amount)
 			}
-			mu.Unlock()
-		}(i)
-	}
-
-	wg.Wait()
-	duration := time.Since(startTime)
+			account.Balance -= amount
+		}
 
-	// 최종 잔액 확인
-	s.db.First(&account1, account1.ID)
-	s.db.First(&account2, account2.ID)
+		if err := tx.Save(&account).Error; err != nil {
+			return fmt.Errorf("failed to update account: %w", err)
+		}
 
-	results["duration_ms"] = duration.Milliseconds()
-	results["total_workers"] = numWorkers
+		now := time.Now()
+		txRecord.Status = "completed"
+		txRecord.CompletedAt = &now
+		txRecord.ProcessingTime = time.Since(startTime).Milliseconds()
+
+		if err := tx.Save(txRecord).Error; err != nil {
+			return fmt.Errorf("failed to update transaction record: %w", err)
+		}
+		if err := enqueueOutbox(tx, transactionEvent(txRecord)); err != nil {
+			return fmt.Errorf("failed to enqueue outbox event: %w", err)
+		}
+		return nil
+	}, opts)
+
+	if err != nil {
+		txRecord.Status = "failed"
+		txRecord.ErrorMessage = err.Error()
+		txRecord.ProcessingTime = time.Since(startTime).Milliseconds()
+		s.db.Save(txRecord)
+		return nil, err
+	}
+
+	// 커밋 이후에만 알림
+	s.publishTransaction(txRecord)
+	return txRecord, nil
+}
+
+// ============================================================================
+// 트랜잭션 통계
+// ============================================================================
+
+// 통계에 항상 포함되는 트랜잭션 유형
+var transactionTypes = []string{"transfer", "deposit", "withdrawal"}
+
+// TransactionTypeStats - 유형별 집계
+type TransactionTypeStats struct {
+	Count             int64   `json:"count"`
+	Volume            float64 `json:"volume"` // 완료된 트랜잭션 금액 합계
+	AvgProcessingTime float64 `json:"avg_processing_time_ms"`
+	FailureRate       float64 `json:"failure_rate"`
+}
+
+// TransactionStats - 기간 내 트랜잭션 집계
+type TransactionStats struct {
+	TransactionTypeStats                                 // 전체 합계 (JSON에서는 최상위 필드로 펼쳐짐)
+	From                 time.Time                       `json:"from"`
+	To                   time.Time                       `json:"to"`
+	CountByStatus        map[string]int64                `json:"count_by_status"`
+	ByType               map[string]TransactionTypeStats `json:"by_type"`
+}
+
+// statsRow - type/status 그룹별 집계 행
+type statsRow struct {
+	Type           string
+	Status         string
+	Count          int64
+	Volume         float64
+	ProcessingTime int64
+}
+
+// isFailedStatus - 실패율에 포함되는 상태
+func isFailedStatus(status string) bool {
+	return status == "failed" || status == "timeout"
+}
+
+// Stats - [from, to) 구간의 트랜잭션을 type/status별로 GROUP BY 집계
+func (s *TransactionService) Stats(ctx context.Context, from, to time.Time) (*TransactionStats, error) {
+	var rows []statsRow
+	err := s.db.WithContext(ctx).
+		Model(&Transaction{}).
+		Select(`type, status, COUNT(*) AS count,
+			COALESCE(SUM(CASE WHEN status = 'completed' THEN amount ELSE 0 END), 0) AS volume,
+			COALESCE(SUM(processing_time), 0) AS processing_time`).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("type, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &TransactionStats{
+		From:          from,
+		To:            to,
+		CountByStatus: make(map[string]int64),
+		ByType:        make(map[string]TransactionTypeStats),
+	}
+	for _, t := range transactionTypes {
+		stats.ByType[t] = TransactionTypeStats{}
+	}
+
+	// 평균/실패율은 합계로 누적한 뒤 마지막에 나눔
+	type totals struct {
+		count, failed, processing int64
+		volume                    float64
+	}
+	all := totals{}
+	byType := make(map[string]*totals)
+	for _, row := range rows {
+		stats.CountByStatus[row.Status] += row.Count
+
+		t, ok := byType[row.Type]
+		if !ok {
+			t = &totals{}
+			byType[row.Type] = t
+		}
+		for _, acc := range []*totals{&all, t} {
+			acc.count += row.Count
+			acc.volume += row.Volume
+			acc.processing += row.ProcessingTime
+			if isFailedStatus(row.Status) {
+				acc.failed += row.Count
+			}
+		}
+	}
+
+	summarize := func(t totals) TransactionTypeStats {
+		if t.count == 0 {
+			return TransactionTypeStats{}
+		}
+		return TransactionTypeStats{
+			Count:             t.count,
+			Volume:            t.volume,
+			AvgProcessingTime: float64(t.processing) / float64(t.count),
+			FailureRate:       float64(t.failed) / float64(t.count),
+		}
+	}
+	stats.TransactionTypeStats = summarize(all)
+	for name, t := range byType {
+		stats.ByType[name] = summarize(*t)
+	}
+
+	return stats, nil
+}
+
+// ============================================================================
+// 트랜잭션 이력 (키셋 페이지네이션)
+// ============================================================================
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// HistoryQuery - 이력 조회 조건. Cursor가 비어 있으면 첫 페이지
+type HistoryQuery struct {
+	Status string
+	Type   string
+	Cursor string
+	Limit  int
+}
+
+// 커서는 마지막 행의 (created_at, id)를 base64로 감싼 값 (주문 이력도 같은 형식).
+// created_at은 저장된 오프셋을 유지해야 SQLite 문자열 비교가 정확함
+func encodeKeysetCursor(createdAt time.Time, id uint) string {
+	raw := createdAt.Format(time.RFC3339Nano) + "|" + strconv.FormatUint(uint64(id), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func encodeHistoryCursor(tx Transaction) string {
+	return encodeKeysetCursor(tx.CreatedAt, tx.ID)
+}
+
+func decodeKeysetCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	createdAt, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return t, uint(id), nil
+}
+
+// History - created_at DESC, id DESC 순서로 한 페이지를 반환.
+// 같은 시각의 행은 id로 구분되므로 순서가 항상 결정적이고, 다음 페이지가 없으면 nextCursor는 ""
+func (s *TransactionService) History(ctx context.Context, q HistoryQuery) (transactions []Transaction, nextCursor string, err error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	query := historyFilter(s.db.WithContext(ctx), q).Order("created_at DESC, id DESC").Limit(limit + 1)
+	if q.Cursor != "" {
+		createdAt, id, err := decodeKeysetCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", createdAt, createdAt, id)
+	}
+
+	if err := query.Find(&transactions).Error; err != nil {
+		return nil, "", err
+	}
+
+	// limit+1개를 읽어 다음 페이지 존재 여부를 판단
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		nextCursor = encodeHistoryCursor(transactions[limit-1])
+	}
+	return transactions, nextCursor, nil
+}
+
+// historyFilter - status/type 조건 (History와 ExportHistory가 공유)
+func historyFilter(query *gorm.DB, q HistoryQuery) *gorm.DB {
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Type != "" {
+		query = query.Where("type = ?", q.Type)
+	}
+	return query
+}
+
+// ExportHistory - History와 같은 조건/순서로 모든 행을 한 건씩 fn에 전달 (페이지 없음)
+// 커서로 한 행씩 읽으므로 결과 전체를 메모리에 올리지 않음
+func (s *TransactionService) ExportHistory(ctx context.Context, q HistoryQuery, fn func(*Transaction) error) error {
+	rows, err := historyFilter(s.db.WithContext(ctx).Model(&Transaction{}), q).
+		Order("created_at DESC, id DESC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tx Transaction
+		if err := s.db.ScanRows(rows, &tx); err != nil {
+			return err
+		}
+		if err := fn(&tx); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ============================================================================
+// 고객 주문 이력 (키셋 페이지네이션)
+// ============================================================================
+
+const (
+	defaultOrderLimit = 20
+	maxOrderLimit     = 100
+)
+
+// OrderQuery - 고객 주문 조회 조건. Cursor가 비어 있으면 첫 페이지
+type OrderQuery struct {
+	CustomerID uint
+	Cursor     string
+	Limit      int
+}
+
+// CustomerOrders - 고객의 주문을 최신순(created_at DESC, id DESC)으로 한 페이지 반환.
+// 아이템(제품 포함)과 결제는 Preload로 페이지당 한 번씩만 읽어 주문 수만큼 쿼리가 늘지 않음 (N+1 방지)
+func (s *TransactionService) CustomerOrders(ctx context.Context, q OrderQuery) (orders []Order, nextCursor string, err error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultOrderLimit
+	}
+	if limit > maxOrderLimit {
+		limit = maxOrderLimit
+	}
+
+	query := s.db.WithContext(ctx).
+		Where("customer_id = ?", q.CustomerID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.Product").
+		Preload("Payment").
+		Order("created_at DESC, id DESC").
+		Limit(limit + 1)
+	if q.Cursor != "" {
+		createdAt, id, err := decodeKeysetCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", createdAt, createdAt, id)
+	}
+
+	orders = []Order{}
+	if err := query.Find(&orders).Error; err != nil {
+		return nil, "", err
+	}
+
+	// limit+1개를 읽어 다음 페이지 존재 여부를 판단 (Preload도 limit+1개 주문 기준)
+	if len(orders) > limit {
+		orders = orders[:limit]
+		nextCursor = encodeKeysetCursor(orders[limit-1].CreatedAt, orders[limit-1].ID)
+	}
+	return orders, nextCursor, nil
+}
+
+// ============================================================================
+// 멈춘 트랜잭션 복구
+// ============================================================================
+
+// 이 시간보다 오래 pending인 트랜잭션만 복구 대상 (아직 처리 중일 수 있으므로)
+var pendingResolveAfter = 5 * time.Minute
+
+// 잔액 비교 허용 오차 (센트 미만 부동소수점 오차)
+const balanceEpsilon = 0.005
+
+var (
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrTransactionNotPending = errors.New("transaction is not pending")
+	ErrTransactionTooRecent  = errors.New("transaction is still within the processing window")
+	ErrLedgerMismatch        = errors.New("account balance does not match the ledger")
+)
+
+// ledgerEffect - 트랜잭션이 계좌 잔액에 주는 변화량
+func ledgerEffect(t *Transaction, accountID uint) float64 {
+	var delta float64
+	if t.ToAccountID == accountID {
+		delta += t.Amount
+	}
+	if t.FromAccountID == accountID {
+		delta -= t.Amount
+	}
+	return delta
+}
+
+// ledgerNet - 완료된 트랜잭션의 입출금 합계. tx에 건 조건(기간 등)은 그대로 적용됨
+func ledgerNet(tx *gorm.DB, accountID uint) (float64, error) {
+	var net float64
+	err := tx.Model(&Transaction{}).
+		Select("COALESCE(SUM(CASE WHEN to_account_id = ? THEN amount ELSE 0 END), 0) - "+
+			"COALESCE(SUM(CASE WHEN from_account_id = ? THEN amount ELSE 0 END), 0)", accountID, accountID).
+		Where("status = ? AND (to_account_id = ? OR from_account_id = ?)", "completed", accountID, accountID).
+		Scan(&net).Error
+	return net, err
+}
+
+// ledgerBalance - 개설 잔액 + 완료된 트랜잭션의 입출금 합계
+func ledgerBalance(tx *gorm.DB, account *Account) (float64, error) {
+	net, err := ledgerNet(tx, account.ID)
+	return account.OpeningBalance + net, err
+}
+
+func balanceEqual(a, b float64) bool {
+	return math.Abs(a-b) < balanceEpsilon
+}
+
+// Resolve - 오래 pending으로 남은 트랜잭션을 원장과 대조해 completed 또는 failed로 확정.
+// 관련 계좌 잔액이 모두 "원장 + 이 트랜잭션"과 같으면 반영된 것으로, 모두 원장과 같으면
+// 반영되지 않은 것으로 본다. 어느 쪽도 아니면 수동 조사가 필요하므로 ErrLedgerMismatch
+func (s *TransactionService) Resolve(ctx context.Context, id uint, resolvedBy string) (*Transaction, error) {
+	var txRecord Transaction
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&txRecord, id).Error; err != nil {
+			return notFound(err, ErrTransactionNotFound)
+		}
+		if txRecord.Status != "pending" {
+			return fmt.Errorf("%w: status is %s", ErrTransactionNotPending, txRecord.Status)
+		}
+		now := s.now()
+		if now.Sub(txRecord.CreatedAt) < pendingResolveAfter {
+			return ErrTransactionTooRecent
+		}
+
+		applied, unapplied := true, true
+		for _, accountID := range []uint{txRecord.FromAccountID, txRecord.ToAccountID} {
+			if accountID == 0 {
+				continue
+			}
+			var account Account
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, accountID).Error; err != nil {
+				return fmt.Errorf("account %d: %w", accountID, notFound(err, ErrAccountNotFound))
+			}
+			expected, err := ledgerBalance(tx, &account)
+			if err != nil {
+				return err
+			}
+			applied = applied && balanceEqual(account.Balance, expected+ledgerEffect(&txRecord, accountID))
+			unapplied = unapplied && balanceEqual(account.Balance, expected)
+		}
+
+		switch {
+		case applied && !unapplied:
+			txRecord.Status = "completed"
+			txRecord.CompletedAt = &now
+		case unapplied && !applied:
+			txRecord.Status = "failed"
+			txRecord.ErrorMessage = "resolved: balance change was never applied"
+		default:
+			return ErrLedgerMismatch
+		}
+		txRecord.ResolvedBy = resolvedBy
+		txRecord.ResolvedAt = &now
+
+		// 다른 요청이 먼저 확정했다면 덮어쓰지 않음
+		result := tx.Model(&Transaction{}).Where("id = ? AND status = ?", txRecord.ID, "pending").
+			Updates(map[string]interface{}{
+				"status":        txRecord.Status,
+				"completed_at":  txRecord.CompletedAt,
+				"error_message": txRecord.ErrorMessage,
+				"resolved_by":   txRecord.ResolvedBy,
+				"resolved_at":   txRecord.ResolvedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTransactionNotPending
+		}
+		return enqueueOutbox(tx, transactionEvent(&txRecord))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishTransaction(&txRecord)
+	return &txRecord, nil
+}
+
+// ============================================================================
+// 계좌 서비스
+// ============================================================================
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusClosed = "closed"
+)
+
+// 개설 가능한 통화
+var supportedCurrencies = map[string]bool{
+	"USD": true,
+	"EUR": true,
+	"KRW": true,
+	"JPY": true,
+}
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrAccountClosed       = errors.New("account is closed")
+	ErrNonZeroBalance      = errors.New("account balance must be zero to close")
+)
+
+type AccountService struct {
+	db *gorm.DB
+}
+
+func NewAccountService(db *gorm.DB) *AccountService {
+	return &AccountService{db: db}
+}
+
+// 계좌 개설 - 고유한 계좌번호를 생성하고 통화를 검증
+func (s *AccountService) Open(ctx context.Context, name, currency string, initialDeposit float64) (*Account, error) {
+	currency = strings.ToUpper(currency)
+	if !supportedCurrencies[currency] {
+		return nil, ErrUnsupportedCurrency
+	}
+
+	number, err := s.generateNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &Account{
+		Number:   number,
+		Name:     name,
+		Balance:  initialDeposit,
+		Currency: currency,
+		Status:   AccountStatusActive,
+	}
+	if err := s.db.WithContext(ctx).Create(account).Error; err != nil {
+		return nil, fmt.Errorf("failed to open account: %w", err)
+	}
+
+	return account, nil
+}
+
+// 해지된 계좌를 포함해 사용된 적 없는 계좌번호 생성
+func (s *AccountService) generateNumber(ctx context.Context) (string, error) {
+	for i := 0; i < 5; i++ {
+		number := fmt.Sprintf("ACC%010d", rand.Int63n(1e10))
+
+		var count int64
+		if err := s.db.WithContext(ctx).Unscoped().Model(&Account{}).
+			Where("number = ?", number).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return number, nil
+		}
+	}
+	return "", errors.New("failed to generate unique account number")
+}
+
+// 계좌 조회
+func (s *AccountService) Get(ctx context.Context, id uint) (*Account, error) {
+	var account Account
+	if err := s.db.WithContext(ctx).First(&account, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// 계좌 해지 - 잔액이 남아 있으면 거부
+func (s *AccountService) Close(ctx context.Context, id uint) (*Account, error) {
+	var account Account
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return err
+		}
+
+		if account.Status == AccountStatusClosed {
+			return ErrAccountClosed
+		}
+		if account.Balance != 0 {
+			return ErrNonZeroBalance
+		}
+
+		now := time.Now()
+		account.Status = AccountStatusClosed
+		account.ClosedAt = &now
+		account.IsLocked = true
+		return tx.Save(&account).Error
+	})
+	if err != nil {
+		return &account, err
+	}
+
+	return &account, nil
+}
+
+// ============================================================================
+// 월별 계좌 명세서
+// ============================================================================
+
+// statementMonthLayout - ?month= 형식 (YYYY-MM)
+const statementMonthLayout = "2006-01"
+
+// StatementDay - 거래가 있었던 하루의 순변동과 그날 마감 잔액
+type StatementDay struct {
+	Date             string  `json:"date"` // YYYY-MM-DD (UTC)
+	Net              float64 `json:"net"`
+	Balance          float64 `json:"balance"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+// Statement - 한 달 동안의 잔액 흐름
+type Statement struct {
+	AccountID      uint           `json:"account_id"`
+	Number         string         `json:"number"`
+	Currency       string         `json:"currency"`
+	Month          string         `json:"month"`
+	OpeningBalance float64        `json:"opening_balance"`
+	ClosingBalance float64        `json:"closing_balance"`
+	Days           []StatementDay `json:"days"` // 거래가 없는 날은 생략
+}
+
+// Statement - month(UTC 기준 그 달의 1일)의 명세서를 원장에서 계산.
+// 기초 잔액은 개설 잔액 + 그 달 이전에 완료된 트랜잭션 합계이고,
+// 거래가 없는 달은 Days가 비어 있고 기초 잔액과 기말 잔액이 같다
+func (s *AccountService) Statement(ctx context.Context, id uint, month time.Time) (*Statement, error) {
+	account, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	before, err := ledgerNet(s.db.WithContext(ctx).Where("created_at < ?", start), account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	err = s.db.WithContext(ctx).
+		Where("status = ? AND (to_account_id = ? OR from_account_id = ?)", "completed", account.ID, account.ID).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Order("created_at, id").
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		AccountID:      account.ID,
+		Number:         account.Number,
+		Currency:       account.Currency,
+		Month:          start.Format(statementMonthLayout),
+		OpeningBalance: account.OpeningBalance + before,
+		Days:           []StatementDay{},
+	}
+
+	// created_at 순으로 정렬돼 있으므로 날짜가 바뀔 때마다 새 항목을 추가
+	balance := statement.OpeningBalance
+	for i := range transactions {
+		date := transactions[i].CreatedAt.UTC().Format("2006-01-02")
+		if n := len(statement.Days); n == 0 || statement.Days[n-1].Date != date {
+			statement.Days = append(statement.Days, StatementDay{Date: date})
+		}
+		day := &statement.Days[len(statement.Days)-1]
+		delta := ledgerEffect(&transactions[i], account.ID)
+		day.Net += delta
+		day.TransactionCount++
+		balance += delta
+		day.Balance = balance
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+// ============================================================================
+// 제품 서비스
+// ============================================================================
+
+var (
+	ErrDuplicateSKU       = errors.New("product SKU already exists")
+	ErrStockBelowReserved = errors.New("stock cannot be lower than reserved quantity")
+)
+
+// LowStockEvent - inventory.low_stock 웹훅 payload
+type LowStockEvent struct {
+	ProductID  uint      `json:"product_id"`
+	SKU        string    `json:"sku"`
+	Name       string    `json:"name"`
+	Available  int       `json:"available"`
+	Threshold  int       `json:"threshold"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// crossedLowStock - 가용 재고가 이번 변경으로 경고 기준 아래로 처음 내려갔는지
+// (이미 기준 아래였다면 주문마다 다시 알리지 않음)
+func crossedLowStock(product *Product, before int) bool {
+	threshold := product.LowStockThreshold
+	return threshold > 0 && before >= threshold && product.Available() < threshold
+}
+
+// enqueueLowStock - 반드시 tx 안에서 호출 (주문이 롤백되면 알림도 사라짐)
+func enqueueLowStock(tx *gorm.DB, product *Product, now time.Time) error {
+	return enqueueOutboxPayload(tx, "inventory.low_stock", product.SKU, LowStockEvent{
+		ProductID:  product.ID,
+		SKU:        product.SKU,
+		Name:       product.Name,
+		Available:  product.Available(),
+		Threshold:  product.LowStockThreshold,
+		OccurredAt: now,
+	})
+}
+
+type ProductService struct {
+	db *gorm.DB
+}
+
+func NewProductService(db *gorm.DB) *ProductService {
+	return &ProductService{db: db}
+}
+
+// 제품 등록 - SKU는 고유해야 함
+func (s *ProductService) Create(ctx context.Context, product *Product) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Product{}).Where("sku = ?", product.SKU).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %s", ErrDuplicateSKU, product.SKU)
+		}
+		return tx.Create(product).Error
+	})
+	if err != nil && !errors.Is(err, ErrDuplicateSKU) {
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+	return err
+}
+
+// ProductUpdate - nil인 필드는 그대로 둠
+type ProductUpdate struct {
+	Name              *string  `json:"name" binding:"omitempty,min=1"`
+	Price             *float64 `json:"price" binding:"omitempty,gt=0"`
+	Stock             *int     `json:"stock" binding:"omitempty,gte=0"`
+	LowStockThreshold *int     `json:"low_stock_threshold" binding:"omitempty,gte=0"`
+}
+
+// 제품 수정 - 예약된 수량보다 재고를 적게 만들 수 없고,
+// UpdateStock의 낙관적 잠금이 변경을 감지하도록 Version을 올림
+func (s *ProductService) Update(ctx context.Context, id uint, update ProductUpdate) (*Product, error) {
+	var product Product
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error; err != nil {
+			return fmt.Errorf("product %d: %w", id, notFound(err, ErrProductNotFound))
+		}
+
+		if update.Name != nil {
+			product.Name = *update.Name
+		}
+		if update.Price != nil {
+			product.Price = *update.Price
+		}
+		if update.Stock != nil {
+			if *update.Stock < product.Reserved {
+				return fmt.Errorf("%w: reserved %d, requested %d", ErrStockBelowReserved, product.Reserved, *update.Stock)
+			}
+			product.Stock = *update.Stock
+		}
+		if update.LowStockThreshold != nil {
+			product.LowStockThreshold = *update.LowStockThreshold
+		}
+		product.Version++
+
+		return tx.Save(&product).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// 재고 부족 제품 - 가용 재고(Stock - Reserved)가 threshold 미만인 제품을 가용 재고 오름차순으로.
+// threshold가 nil이면 제품별 LowStockThreshold를 기준으로 함 (0인 제품은 제외)
+func (s *ProductService) LowStock(ctx context.Context, threshold *int) ([]Product, error) {
+	query := s.db.WithContext(ctx).Model(&Product{})
+	if threshold != nil {
+		query = query.Where("stock - reserved < ?", *threshold)
+	} else {
+		query = query.Where("low_stock_threshold > 0 AND stock - reserved < low_stock_threshold")
+	}
+
+	products := []Product{}
+	if err := query.Order("stock - reserved, id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// ============================================================================
+// 동시성 테스트 서비스
+// ============================================================================
+
+type ConcurrencyTestService struct {
+	db      *gorm.DB
+	service *TransactionService
+}
+
+func NewConcurrencyTestService(db *gorm.DB, service *TransactionService) *ConcurrencyTestService {
+	return &ConcurrencyTestService{db: db, service: service}
+}
+
+// 이체 금액 분포
+const (
+	AmountDistributionUniform = "uniform"
+	AmountDistributionNormal  = "normal"
+)
+
+// ErrInvalidLoadConfig - 동시 이체 테스트 파라미터가 잘못됨
+var ErrInvalidLoadConfig = errors.New("invalid load test config")
+
+// 잠금 경합으로 실패한 이체의 재시도 간격 (시도 횟수에 비례)
+const transferRetryBackoff = 20 * time.Millisecond
+
+// TransferLoadConfig - 동시 이체 테스트의 부하 모양.
+// ForwardRatio를 0.5에서 멀리 두면 한쪽 계좌로 이체가 몰리는 핫스팟 경합을 재현할 수 있고,
+// Seed를 고정하면 같은 이체 계획(방향, 금액, 타임아웃)이 다시 만들어짐 (0이면 매번 다름)
+type TransferLoadConfig struct {
+	Workers      int     `form:"workers" json:"workers"`
+	ForwardRatio float64 `form:"forward_ratio" json:"forward_ratio"` // 1번 → 2번 계좌로 보내는 비율
+	Distribution string  `form:"distribution" json:"distribution"`   // uniform | normal
+	MinAmount    float64 `form:"min_amount" json:"min_amount"`
+	MaxAmount    float64 `form:"max_amount" json:"max_amount"`
+	MaxRetries   int     `form:"max_retries" json:"max_retries"`
+	Seed         int64   `form:"seed" json:"seed"`
+}
+
+// DefaultTransferLoadConfig - 기존 동작과 같은 양방향 균등 부하
+func DefaultTransferLoadConfig() TransferLoadConfig {
+	return TransferLoadConfig{
+		Workers:      10,
+		ForwardRatio: 0.5,
+		Distribution: AmountDistributionUniform,
+		MinAmount:    1,
+		MaxAmount:    100,
+		MaxRetries:   3,
+	}
+}
+
+func (cfg TransferLoadConfig) Validate() error {
+	switch {
+	case cfg.Workers < 1 || cfg.Workers > 100:
+		return fmt.Errorf("%w: workers must be between 1 and 100", ErrInvalidLoadConfig)
+	case cfg.ForwardRatio < 0 || cfg.ForwardRatio > 1:
+		return fmt.Errorf("%w: forward_ratio must be between 0 and 1", ErrInvalidLoadConfig)
+	case cfg.Distribution != AmountDistributionUniform && cfg.Distribution != AmountDistributionNormal:
+		return fmt.Errorf("%w: distribution must be %q or %q", ErrInvalidLoadConfig, AmountDistributionUniform, AmountDistributionNormal)
+	case cfg.MinAmount <= 0 || cfg.MaxAmount < cfg.MinAmount:
+		return fmt.Errorf("%w: amounts must satisfy 0 < min_amount <= max_amount", ErrInvalidLoadConfig)
+	case cfg.MaxRetries < 0:
+		return fmt.Errorf("%w: max_retries must not be negative", ErrInvalidLoadConfig)
+	}
+	return nil
+}
+
+// sampleAmount - 분포에 따라 [MinAmount, MaxAmount] 안의 금액을 뽑음.
+// 정규분포는 구간 중앙을 평균으로, 구간 양 끝을 ±3σ로 두고 벗어나면 잘라냄
+func (cfg TransferLoadConfig) sampleAmount(rng *rand.Rand) float64 {
+	var amount float64
+	switch cfg.Distribution {
+	case AmountDistributionNormal:
+		amount = (cfg.MinAmount+cfg.MaxAmount)/2 + rng.NormFloat64()*(cfg.MaxAmount-cfg.MinAmount)/6
+	default:
+		amount = cfg.MinAmount + rng.Float64()*(cfg.MaxAmount-cfg.MinAmount)
+	}
+	// 정수 금액이라 잔액 합계 비교에 부동소수 오차가 끼지 않음
+	return math.Max(cfg.MinAmount, math.Min(cfg.MaxAmount, math.Round(amount)))
+}
+
+// transferPlan - 워커 하나가 실행할 이체
+type transferPlan struct {
+	forward bool
+	amount  float64
+	timeout time.Duration
+}
+
+// isLockContention - 다른 트랜잭션이 잡은 잠금 때문에 SQLite가 거절한 경우 (재시도 대상)
+func isLockContention(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// lockWait - 시도 시작부터 두 계좌 잠금을 모두 얻을 때까지 걸린 시간 (커넥션 대기 포함)
+func lockWait(trace *Trace, start time.Time) time.Duration {
+	var acquired time.Time
+	for _, span := range trace.Spans {
+		if span.Name != "lock_from_account" && span.Name != "lock_to_account" {
+			continue
+		}
+		if end := span.Start.Add(time.Duration(span.DurationMs * float64(time.Millisecond))); end.After(acquired) {
+			acquired = end
+		}
+	}
+	if acquired.IsZero() {
+		return 0
+	}
+	return acquired.Sub(start)
+}
+
+// 동시 이체 테스트 - 워커 컨텍스트는 요청 컨텍스트에서 파생되어 요청이 끝나면 함께 취소됨.
+// 잠금 경합(SQLite busy/locked)으로 실패한 이체는 MaxRetries까지 재시도하고,
+// 재시도 횟수와 잠금 대기 시간을 결과에 함께 보고함
+func (s *ConcurrencyTestService) TestConcurrentTransfers(ctx context.Context, cfg TransferLoadConfig) map[string]interface{} {
+	results := make(map[string]interface{})
+	successCount := 0
+	failureCount := 0
+	timeoutCount := 0
+	retryCount := 0
+	var totalLockWait, maxLockWait time.Duration
+	lockWaitSamples := 0
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	// 이체 계획은 워커 시작 전에 한 번에 뽑아 두어 시드가 같으면 실행 순서와 무관하게 같음
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	plans := make([]transferPlan, cfg.Workers)
+	forwardCount := 0
+	for i := range plans {
+		plans[i] = transferPlan{
+			forward: rng.Float64() < cfg.ForwardRatio,
+			amount:  cfg.sampleAmount(rng),
+			timeout: time.Duration(rng.Intn(500)+500) * time.Millisecond, // 랜덤 타임아웃
+		}
+		if plans[i].forward {
+			forwardCount++
+		}
+	}
+
+	// 테스트 계좌 생성
+	account1 := Account{Number: "TEST001", Name: "Test Account 1", Balance: 10000}
+	account2 := Account{Number: "TEST002", Name: "Test Account 2", Balance: 10000}
+	if err := s.db.Create([]*Account{&account1, &account2}).Error; err != nil {
+		results["error"] = fmt.Sprintf("failed to create test accounts: %v", err)
+		return results
+	}
+
+	startTime := time.Now()
+
+	// 동시 이체 실행
+	for _, plan := range plans {
+		wg.Add(1)
+		go func(plan transferPlan) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, plan.timeout)
+			defer cancel()
+
+			fromID, toID := account2.ID, account1.ID
+			if plan.forward {
+				fromID, toID = account1.ID, account2.ID
+			}
+
+			var err error
+			retries := 0
+			for attempt := 0; ; attempt++ {
+				// 시도마다 트레이스를 달아 잠금 span으로 대기 시간을 잼
+				trace := &Trace{}
+				attemptStart := time.Now()
+				_, err = s.service.Transfer(context.WithValue(ctx, traceKey{}, trace), fromID, toID, plan.amount)
+
+				wait := lockWait(trace, attemptStart)
+				mu.Lock()
+				if wait > 0 {
+					totalLockWait += wait
+					maxLockWait = max(maxLockWait, wait)
+					lockWaitSamples++
+				}
+				mu.Unlock()
+
+				if !isLockContention(err) || attempt >= cfg.MaxRetries {
+					break
+				}
+				// 기다리는 중에 타임아웃되면 다음 시도가 바로 ctx 에러로 끝남
+				retries++
+				select {
+				case <-time.After(time.Duration(attempt+1) * transferRetryBackoff):
+				case <-ctx.Done():
+				}
+			}
+
+			mu.Lock()
+			retryCount += retries
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					timeoutCount++
+				} else {
+					failureCount++
+				}
+			} else {
+				successCount++
+			}
+			mu.Unlock()
+		}(plan)
+	}
+
+	wg.Wait()
+	duration := time.Since(startTime)
+
+	// 최종 잔액 확인
+	s.db.First(&account1, account1.ID)
+	s.db.First(&account2, account2.ID)
+
+	var avgLockWait time.Duration
+	if lockWaitSamples > 0 {
+		avgLockWait = totalLockWait / time.Duration(lockWaitSamples)
+	}
+
+	cfg.Seed = seed
+	results["config"] = cfg
+	results["duration_ms"] = duration.Milliseconds()
+	results["total_workers"] = cfg.Workers
+	results["forward_count"] = forwardCount
+	results["reverse_count"] = cfg.Workers - forwardCount
 	results["success_count"] = successCount
 	results["failure_count"] = failureCount
 	results["timeout_count"] = timeoutCount
+	results["retry_count"] = retryCount
+	results["lock_wait_ms"] = gin.H{
+		"total": durationMs(totalLockWait),
+		"avg":   durationMs(avgLockWait),
+		"max":   durationMs(maxLockWait),
+	}
 	results["final_balance_1"] = account1.Balance
 	results["final_balance_2"] = account2.Balance
 	results["total_balance"] = account1.Balance + account2.Balance
 
-	// 테스트 데이터 정리
-	s.db.Delete(&account1)
-	s.db.Delete(&account2)
+	// 테스트 데이터 정리 (soft delete면 계좌 번호 unique 제약 때문에 다음 실행이 실패함)
+	s.db.Unscoped().Delete(&account1)
+	s.db.Unscoped().Delete(&account2)
 
 	return results
 }
 
 // 데드락 테스트
-func (s *ConcurrencyTestService) TestDeadlock() map[string]interface{} {
+func (s *ConcurrencyTestService) TestDeadlock(ctx context.Context) map[string]interface{} {
 	results := make(map[string]interface{})
 
 	// 테스트 계좌 생성
@@ -544,7 +2635,7 @@ func (s *ConcurrencyTestService) TestDeadlock() map[string]interface{} {
 	// Worker 1: Account1 → Account2
 	go func() {
 		defer wg.Done()
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 		defer cancel()
 
 		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -555,78 +2646,462 @@ func (s *ConcurrencyTestService) TestDeadlock() map[string]interface{} {
 			// 대기
 			time.Sleep(100 * time.Millisecond)
 
-			// Account2 잠금 시도
-			var acc2 Account
-			return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&acc2, account2.ID).Error
-		})
+			// Account2 잠금 시도
+			var acc2 Account
+			return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&acc2, account2.ID).Error
+		})
+
+		if err != nil {
+			deadlockDetected = true
+		}
+	}()
+
+	// Worker 2: Account2 → Account1 (역순)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Account2 잠금
+			var acc2 Account
+			tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&acc2, account2.ID)
+
+			// 대기
+			time.Sleep(100 * time.Millisecond)
+
+			// Account1 잠금 시도
+			var acc1 Account
+			return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&acc1, account1.ID).Error
+		})
+
+		if err != nil {
+			deadlockDetected = true
+		}
+	}()
+
+	wg.Wait()
+
+	results["deadlock_detected"] = deadlockDetected
+	results["test_scenario"] = "Circular wait condition"
+
+	// 테스트 데이터 정리
+	s.db.Delete(&account1)
+	s.db.Delete(&account2)
+
+	return results
+}
+
+// ============================================================================
+// HTTP Handlers
+// ============================================================================
+
+type Handler struct {
+	service        *TransactionService
+	accountService *AccountService
+	productService *ProductService
+	testService    *ConcurrencyTestService
+	traces         *TraceRecorder
+}
+
+func NewHandler(db *gorm.DB) *Handler {
+	service := NewTransactionService(db)
+	testService := NewConcurrencyTestService(db, service)
+
+	return &Handler{
+		service:        service,
+		accountService: NewAccountService(db),
+		productService: NewProductService(db),
+		testService:    testService,
+		traces:         NewTraceRecorder(traceBufferSize),
+	}
+}
+
+// errorStatus - 서비스 sentinel 에러를 HTTP 상태 코드로 매핑
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidTransfer):
+		return 422
+	case errors.Is(err, ErrInvalidAmount), errors.Is(err, ErrUnsupportedCurrency), errors.Is(err, ErrInvalidLoadConfig),
+		errors.Is(err, ErrUnsupportedIsolation):
+		return 400
+	case errors.Is(err, ErrAccountNotFound), errors.Is(err, ErrProductNotFound),
+		errors.Is(err, ErrTransactionNotFound), errors.Is(err, ErrReservationNotFound):
+		return 404
+	case errors.Is(err, ErrConcurrentUpdate), errors.Is(err, ErrAccountClosed), errors.Is(err, ErrDuplicateSKU), errors.Is(err, ErrStockBelowReserved),
+		errors.Is(err, ErrNonZeroBalance), errors.Is(err, ErrReservationExpired), errors.Is(err, ErrReservationNotHeld),
+		errors.Is(err, ErrTransactionNotPending), errors.Is(err, ErrTransactionTooRecent),
+		errors.Is(err, ErrLedgerMismatch), errors.Is(err, ErrIdempotencyKeyReused):
+		return 409
+	case errors.Is(err, ErrInsufficientBalance), errors.Is(err, ErrInsufficientStock):
+		return 422
+	case errors.Is(err, ErrAccountLocked):
+		return 423
+	case errors.Is(err, ErrMaintenanceWindow):
+		return 503
+	default:
+		return 500
+	}
+}
+
+// respondError - 서비스 에러 응답 (타임아웃은 미들웨어가 504로 작성)
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.Error(err)
+		return
+	}
+
+	// 요청 검증 실패는 바인딩 에러와 같은 09 레슨의 검증 에러 형식으로 응답
+	var invalid *TransferValidationError
+	if errors.As(err, &invalid) {
+		bindingerr.Invalid(c, invalid.Fields)
+		return
+	}
+
+	// 재시도 소진은 경합 정도와 재시도 시점을 함께 알려줌
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(conflict.RetryAfter.Seconds()))))
+		c.JSON(errorStatus(err), gin.H{
+			"error":          err.Error(),
+			"resource":       conflict.Resource,
+			"id":             conflict.ID,
+			"attempts":       conflict.Attempts,
+			"retry_after_ms": conflict.RetryAfter.Milliseconds(),
+		})
+		return
+	}
+
+	c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+}
+
+// 계좌 이체
+func (h *Handler) Transfer(c *gin.Context) {
+	var req struct {
+		FromAccountID uint    `json:"from_account_id" binding:"required"`
+		ToAccountID   uint    `json:"to_account_id" binding:"required"`
+		Amount        float64 `json:"amount"`     // 금액 규칙은 validateTransfer가 검사
+		Timeout       int     `json:"timeout_ms"` // 밀리초
+		Isolation     string  `json:"isolation"`  // read_committed, repeatable_read, serializable (기본)
+	}
+
+	if !bindingerr.BindJSON(c, &req) {
+		return
+	}
+	isolation, err := parseIsolation(req.Isolation)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// 요청 데드라인은 타임아웃 미들웨어가 설정하고, timeout_ms로 더 짧게만 줄일 수 있음
+	ctx := c.Request.Context()
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	transaction, err := h.service.TransferWithIsolation(ctx, req.FromAccountID, req.ToAccountID, req.Amount, isolation)
+	if err != nil {
+		if w := h.service.MaintenanceWindow(); w != nil && errors.Is(err, ErrMaintenanceWindow) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(w.Remaining(h.service.now()).Seconds()))))
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, transaction)
+}
+
+// 이체 미리보기 (돈을 옮기지 않고 성공 여부만 확인)
+func (h *Handler) PreviewTransfer(c *gin.Context) {
+	var req struct {
+		FromAccountID uint    `json:"from_account_id" binding:"required"`
+		ToAccountID   uint    `json:"to_account_id" binding:"required"`
+		Amount        float64 `json:"amount"`
+	}
+
+	if !bindingerr.BindJSON(c, &req) {
+		return
+	}
+
+	preview, err := h.service.PreviewTransfer(c.Request.Context(), req.FromAccountID, req.ToAccountID, req.Amount)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, preview)
+}
+
+// 입출금 요청
+type balanceChangeRequest struct {
+	AccountID uint    `json:"account_id" binding:"required"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Isolation string  `json:"isolation"` // 비우면 입금 read_committed, 출금 serializable
+}
+
+// 입금
+func (h *Handler) Deposit(c *gin.Context) {
+	var req balanceChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	isolation, err := parseIsolation(req.Isolation)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	transaction, err := h.service.DepositWithIsolation(c.Request.Context(), req.AccountID, req.Amount, isolation)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, transaction)
+}
+
+// 출금
+func (h *Handler) Withdraw(c *gin.Context) {
+	var req balanceChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	isolation, err := parseIsolation(req.Isolation)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	transaction, err := h.service.WithdrawWithIsolation(c.Request.Context(), req.AccountID, req.Amount, isolation)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, transaction)
+}
+
+// 주문 처리
+func (h *Handler) ProcessOrder(c *gin.Context) {
+	var order Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 재시도해도 주문이 한 번만 처리되도록 Idempotency-Key 헤더를 주문에 저장
+	order.IdempotencyKey = nil
+	if key := c.GetHeader("Idempotency-Key"); key != "" {
+		order.IdempotencyKey = &key
+	}
+
+	replayed, err := h.service.ProcessOrder(c.Request.Context(), &order)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+	c.JSON(200, order)
+}
+
+// 재고 예약 (주문 생성 없이 예약 토큰만 발급)
+func (h *Handler) ReserveInventory(c *gin.Context) {
+	var req struct {
+		Items []struct {
+			ProductID uint `json:"product_id" binding:"required"`
+			Quantity  int  `json:"quantity" binding:"required,gt=0"`
+		} `json:"items" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]OrderItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	reservation, err := h.service.ReserveInventory(c.Request.Context(), items)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(201, reservation)
+}
+
+// reservationTokenRequest - 예약 확정/해제 요청
+type reservationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// 재고 예약 확정 (결제 완료 시점)
+func (h *Handler) ConfirmReservation(c *gin.Context) {
+	var req reservationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation, err := h.service.ConfirmReservation(c.Request.Context(), req.Token)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(200, reservation)
+}
+
+// 재고 예약 해제 (장바구니 취소)
+func (h *Handler) ReleaseReservation(c *gin.Context) {
+	var req reservationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation, err := h.service.ReleaseReservation(c.Request.Context(), req.Token)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(200, reservation)
+}
+
+// 재고 업데이트
+func (h *Handler) UpdateStock(c *gin.Context) {
+	var req struct {
+		ProductID uint `json:"product_id" binding:"required"`
+		Quantity  int  `json:"quantity" binding:"required,gt=0"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateStock(c.Request.Context(), req.ProductID, req.Quantity); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Stock updated successfully"})
+}
 
+// 트랜잭션 통계 (기본 구간: 최근 24시간)
+func (h *Handler) GetTransactionStats(c *gin.Context) {
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
 		if err != nil {
-			deadlockDetected = true
+			c.JSON(400, gin.H{"error": "to must be RFC3339"})
+			return
 		}
-	}()
-
-	// Worker 2: Account2 → Account1 (역순)
-	go func() {
-		defer wg.Done()
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
+		to = t
+	}
+	from := to.Add(-24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "from must be RFC3339"})
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
 
-		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-			// Account2 잠금
-			var acc2 Account
-			tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&acc2, account2.ID)
+	stats, err := h.service.Stats(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-			// 대기
-			time.Sleep(100 * time.Millisecond)
+	c.JSON(200, stats)
+}
 
-			// Account1 잠금 시도
-			var acc1 Account
-			return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&acc1, account1.ID).Error
-		})
+// 주기적으로 보내는 SSE 주석. 프록시가 유휴 연결을 끊지 않게 함
+var streamHeartbeat = 15 * time.Second
 
-		if err != nil {
-			deadlockDetected = true
-		}
-	}()
+// 완료된 트랜잭션 실시간 스트림 (SSE)
+func (h *Handler) StreamTransactions(c *gin.Context) {
+	events, unsubscribe := h.service.events.Subscribe()
+	defer unsubscribe()
 
-	wg.Wait()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
 
-	results["deadlock_detected"] = deadlockDetected
-	results["test_scenario"] = "Circular wait condition"
+	// 구독이 등록된 뒤 헤더를 바로 내보내 클라이언트가 연결 완료를 알 수 있게 함
+	c.Status(200)
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
 
-	// 테스트 데이터 정리
-	s.db.Delete(&account1)
-	s.db.Delete(&account2)
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
 
-	return results
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("transaction", event)
+			return true
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
 }
 
-// ============================================================================
-// HTTP Handlers
-// ============================================================================
-
-type Handler struct {
-	service     *TransactionService
-	testService *ConcurrencyTestService
-}
+// 계좌 개설
+func (h *Handler) OpenAccount(c *gin.Context) {
+	var req struct {
+		Name           string  `json:"name" binding:"required"`
+		Currency       string  `json:"currency" binding:"required,len=3"`
+		InitialDeposit float64 `json:"initial_deposit" binding:"gte=0"`
+	}
 
-func NewHandler(db *gorm.DB) *Handler {
-	service := NewTransactionService(db)
-	testService := NewConcurrencyTestService(db, service)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
-	return &Handler{
-		service:     service,
-		testService: testService,
+	account, err := h.accountService.Open(c.Request.Context(), req.Name, req.Currency, req.InitialDeposit)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedCurrency) {
+			c.JSON(400, gin.H{"error": err.Error(), "currency": req.Currency})
+			return
+		}
+		respondError(c, err)
+		return
 	}
+
+	c.JSON(201, account)
 }
 
-// 계좌 이체
-func (h *Handler) Transfer(c *gin.Context) {
+// 제품 등록
+func (h *Handler) CreateProduct(c *gin.Context) {
 	var req struct {
-		FromAccountID uint    `json:"from_account_id" binding:"required"`
-		ToAccountID   uint    `json:"to_account_id" binding:"required"`
-		Amount        float64 `json:"amount" binding:"required,gt=0"`
-		Timeout       int     `json:"timeout_ms"` // 밀리초
+		Name              string  `json:"name" binding:"required"`
+		SKU               string  `json:"sku" binding:"required"`
+		Price             float64 `json:"price" binding:"gt=0"`
+		Stock             int     `json:"stock" binding:"gte=0"`
+		LowStockThreshold int     `json:"low_stock_threshold" binding:"gte=0"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -634,118 +3109,388 @@ func (h *Handler) Transfer(c *gin.Context) {
 		return
 	}
 
-	// 타임아웃 설정
-	timeout := 5 * time.Second
-	if req.Timeout > 0 {
-		timeout = time.Duration(req.Timeout) * time.Millisecond
+	product := &Product{
+		Name:              req.Name,
+		SKU:               req.SKU,
+		Price:             req.Price,
+		Stock:             req.Stock,
+		LowStockThreshold: req.LowStockThreshold,
+	}
+	if err := h.productService.Create(c.Request.Context(), product); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
-	defer cancel()
+	c.JSON(201, product)
+}
+
+// 제품 수정 (보낸 필드만 변경)
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req ProductUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
-	transaction, err := h.service.Transfer(ctx, req.FromAccountID, req.ToAccountID, req.Amount)
+	product, err := h.productService.Update(c.Request.Context(), id, req)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			c.JSON(408, gin.H{"error": "Transaction timeout"})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, product)
+}
+
+// 재고 부족 제품 조회 (?threshold=가 없으면 제품별 기준 사용)
+func (h *Handler) GetLowStockProducts(c *gin.Context) {
+	var threshold *int
+	if v := c.Query("threshold"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(400, gin.H{"error": "threshold must be a non-negative integer"})
 			return
 		}
-		c.JSON(500, gin.H{"error": err.Error()})
+		threshold = &n
+	}
+
+	products, err := h.productService.LowStock(c.Request.Context(), threshold)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(200, transaction)
+	c.JSON(200, gin.H{"products": products, "count": len(products)})
 }
 
-// 주문 처리
-func (h *Handler) ProcessOrder(c *gin.Context) {
-	var order Order
-	if err := c.ShouldBindJSON(&order); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+// 계좌 조회
+func (h *Handler) GetAccount(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid account ID"})
 		return
 	}
 
-	// 타임아웃 설정
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
-
-	if err := h.service.ProcessOrder(ctx, &order); err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			c.JSON(408, gin.H{"error": "Order processing timeout"})
-			return
-		}
-		c.JSON(500, gin.H{"error": err.Error()})
+	account, err := h.accountService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(200, order)
+	c.JSON(200, account)
 }
 
-// 재고 업데이트
-func (h *Handler) UpdateStock(c *gin.Context) {
-	var req struct {
-		ProductID uint `json:"product_id" binding:"required"`
-		Quantity  int  `json:"quantity" binding:"required,gt=0"`
+// 월별 계좌 명세서 (?month=YYYY-MM)
+func (h *Handler) GetStatement(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid account ID"})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	month, err := time.Parse(statementMonthLayout, c.Query("month"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "month must be YYYY-MM"})
+		return
+	}
+
+	statement, err := h.accountService.Statement(c.Request.Context(), id, month)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
-	defer cancel()
+	c.JSON(200, statement)
+}
+
+// 계좌 해지
+func (h *Handler) CloseAccount(c *gin.Context) {
+	var id uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid account ID"})
+		return
+	}
 
-	if err := h.service.UpdateStock(ctx, req.ProductID, req.Quantity); err != nil {
-		if err.Error() == "max retries exceeded" {
-			c.JSON(409, gin.H{"error": "Conflict: Too many concurrent updates"})
+	account, err := h.accountService.Close(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrNonZeroBalance) {
+			c.JSON(409, gin.H{"error": err.Error(), "balance": account.Balance})
 			return
 		}
-		c.JSON(500, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Stock updated successfully"})
+	c.JSON(200, account)
 }
 
 // 동시성 테스트
+// 예: ?workers=50&forward_ratio=0.9&distribution=normal&seed=42 (쿼리에 없는 값은 기본값)
 func (h *Handler) TestConcurrency(c *gin.Context) {
-	workers := c.DefaultQuery("workers", "10")
-	var numWorkers int
-	fmt.Sscanf(workers, "%d", &numWorkers)
-
-	if numWorkers < 1 || numWorkers > 100 {
-		numWorkers = 10
+	cfg := DefaultTransferLoadConfig()
+	if err := c.ShouldBindQuery(&cfg); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	results := h.testService.TestConcurrentTransfers(numWorkers)
+	results := h.testService.TestConcurrentTransfers(c.Request.Context(), cfg)
 	c.JSON(200, results)
 }
 
 // 데드락 테스트
 func (h *Handler) TestDeadlock(c *gin.Context) {
-	results := h.testService.TestDeadlock()
+	results := h.testService.TestDeadlock(c.Request.Context())
 	c.JSON(200, results)
 }
 
 // 트랜잭션 이력 조회
 func (h *Handler) GetTransactionHistory(c *gin.Context) {
-	var transactions []Transaction
+	q := HistoryQuery{
+		Status: c.Query("status"),
+		Type:   c.Query("type"),
+		Cursor: c.Query("cursor"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(400, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		q.Limit = limit
+	}
+
+	transactions, nextCursor, err := h.service.History(c.Request.Context(), q)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"transactions": transactions,
+		"count":        len(transactions),
+		"next_cursor":  nextCursor,
+	})
+}
 
-	query := h.service.db.Order("created_at DESC").Limit(100)
+// 고객 주문 이력 (?limit=&cursor=, 최신순)
+func (h *Handler) GetCustomerOrders(c *gin.Context) {
+	var customerID uint
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &customerID); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid customer ID"})
+		return
+	}
 
-	status := c.Query("status")
-	if status != "" {
-		query = query.Where("status = ?", status)
+	q := OrderQuery{CustomerID: customerID, Cursor: c.Query("cursor")}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(400, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		q.Limit = limit
 	}
 
-	query.Find(&transactions)
+	orders, nextCursor, err := h.service.CustomerOrders(c.Request.Context(), q)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		respondError(c, err)
+		return
+	}
 
 	c.JSON(200, gin.H{
-		"transactions": transactions,
-		"count":       len(transactions),
+		"orders":      orders,
+		"count":       len(orders),
+		"next_cursor": nextCursor,
+	})
+}
+
+// transactionCSVHeader - CSV 내보내기 열 순서
+var transactionCSVHeader = []string{
+	"id", "transaction_id", "type", "status", "from_account_id", "to_account_id",
+	"amount", "currency", "description", "error_message", "processing_time_ms",
+	"created_at", "completed_at",
+}
+
+// csvFlushRows - 이만큼 쓸 때마다 클라이언트로 내보냄
+const csvFlushRows = 100
+
+// 트랜잭션 이력 CSV 다운로드 (?status=&type=, 페이지 없이 전체)
+func (h *Handler) ExportTransactionHistory(c *gin.Context) {
+	q := HistoryQuery{Status: c.Query("status"), Type: c.Query("type")}
+
+	filename := fmt.Sprintf("transactions-%s.csv", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(200)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(transactionCSVHeader)
+	n := 0
+	err := h.service.ExportHistory(c.Request.Context(), q, func(t *Transaction) error {
+		completedAt := ""
+		if t.CompletedAt != nil {
+			completedAt = t.CompletedAt.UTC().Format(time.RFC3339)
+		}
+		w.Write([]string{
+			strconv.FormatUint(uint64(t.ID), 10),
+			t.TransactionID,
+			t.Type,
+			t.Status,
+			strconv.FormatUint(uint64(t.FromAccountID), 10),
+			strconv.FormatUint(uint64(t.ToAccountID), 10),
+			strconv.FormatFloat(t.Amount, 'f', 2, 64),
+			t.Currency,
+			t.Description,
+			t.ErrorMessage,
+			strconv.FormatInt(t.ProcessingTime, 10),
+			t.CreatedAt.UTC().Format(time.RFC3339),
+			completedAt,
+		})
+		if n++; n%csvFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	w.Flush()
+
+	// 헤더를 이미 보냈으므로 상태 코드는 바꿀 수 없음 - 에러 로그만 남김
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// 멈춘 트랜잭션 수동 복구 (관리자 전용)
+func (h *Handler) ResolveTransaction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid transaction id"})
+		return
+	}
+
+	claims := c.MustGet("claims").(*jwtauth.Claims)
+	transaction, err := h.service.Resolve(c.Request.Context(), uint(id), claims.Username)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(200, transaction)
+}
+
+// maintenanceWindowResponse - 점검 시간 설정과 지금 적용 중인지 여부
+func (h *Handler) maintenanceWindowResponse(c *gin.Context) {
+	w := h.service.MaintenanceWindow()
+	c.JSON(200, gin.H{
+		"window": w,
+		"active": w != nil && w.Contains(h.service.now()),
 	})
 }
 
+// 이체 점검 시간 조회
+func (h *Handler) GetMaintenanceWindow(c *gin.Context) {
+	h.maintenanceWindowResponse(c)
+}
+
+// 이체 점검 시간 설정 (HH:MM, UTC)
+func (h *Handler) SetMaintenanceWindow(c *gin.Context) {
+	var req struct {
+		Start string `json:"start" binding:"required"`
+		End   string `json:"end" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := ParseMaintenanceWindow(req.Start, req.End)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.service.SetMaintenanceWindow(w)
+	h.maintenanceWindowResponse(c)
+}
+
+// 이체 점검 시간 해제
+func (h *Handler) ClearMaintenanceWindow(c *gin.Context) {
+	h.service.SetMaintenanceWindow(nil)
+	c.Status(204)
+}
+
+// 최근 트레이스 조회 (?limit=N, 기본 20)
+func (h *Handler) GetTraces(c *gin.Context) {
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > traceBufferSize {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", traceBufferSize)})
+			return
+		}
+		limit = n
+	}
+
+	traces := h.traces.Recent(limit)
+	c.JSON(200, gin.H{"traces": traces, "count": len(traces)})
+}
+
+// ============================================================================
+// 관리자 인증
+// ============================================================================
+
+const roleAdmin = "admin"
+
+// gin/19 JWT 예제와 같은 issuer/audience — 같은 JWT_SECRET을 주면 그 토큰을 그대로 사용
+// 공개된 기본 키로 누구나 관리자 토큰을 만들 수 없도록 JWT_SECRET이 없으면 시작하지 않음 (main)
+var authConfig = jwtauth.Config{
+	SecretKey: os.Getenv("JWT_SECRET"),
+	Algorithm: jwtauth.AlgorithmHS256,
+	Issuer:    "gin-jwt-example",
+	Audience:  []string{"gin-api"},
+}
+
+// AdminMiddleware - admin 역할의 Bearer 토큰을 요구하고 claims를 저장
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := jwtauth.BearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.AbortWithStatusJSON(401, gin.H{"error": "Bearer token required"})
+			return
+		}
+		claims, err := authConfig.ValidateAccessToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
+			return
+		}
+		if claims.Role != roleAdmin {
+			c.AbortWithStatusJSON(403, gin.H{"error": "Admin role required"})
+			return
+		}
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
 // ============================================================================
 // 초기 데이터 생성
 // ============================================================================
@@ -766,11 +3511,11 @@ func InitializeData(db *gorm.DB) {
 
 	// 제품 생성
 	products := []Product{
-		{Name: "Laptop", SKU: "SKU001", Price: 999.99, Stock: 50},
-		{Name: "Mouse", SKU: "SKU002", Price: 29.99, Stock: 200},
-		{Name: "Keyboard", SKU: "SKU003", Price: 79.99, Stock: 150},
-		{Name: "Monitor", SKU: "SKU004", Price: 299.99, Stock: 75},
-		{Name: "Headphones", SKU: "SKU005", Price: 149.99, Stock: 100},
+		{Name: "Laptop", SKU: "SKU001", Price: 999.99, Stock: 50, LowStockThreshold: 10},
+		{Name: "Mouse", SKU: "SKU002", Price: 29.99, Stock: 200, LowStockThreshold: 20},
+		{Name: "Keyboard", SKU: "SKU003", Price: 79.99, Stock: 150, LowStockThreshold: 15},
+		{Name: "Monitor", SKU: "SKU004", Price: 299.99, Stock: 75, LowStockThreshold: 10},
+		{Name: "Headphones", SKU: "SKU005", Price: 149.99, Stock: 100, LowStockThreshold: 10},
 	}
 
 	for _, product := range products {
@@ -784,14 +3529,37 @@ func InitializeData(db *gorm.DB) {
 // Router Setup
 // ============================================================================
 
+// 재고 예약 유지 시간과 만료된 예약을 회수하는 주기
+var (
+	stockReservationTTL    = 15 * time.Minute
+	reservationSweepPeriod = time.Minute
+)
+
+// 라우트별 요청 타임아웃 (pkg/timeout 미들웨어가 데드라인 초과 시 504 응답)
+var (
+	transferTimeout = 5 * time.Second
+	orderTimeout    = 10 * time.Second
+	stockTimeout    = 2 * time.Second
+	batchTimeout    = 30 * time.Second
+)
+
 func SetupRouter(handler *Handler) *gin.Engine {
 	router := gin.Default()
 
-	// Middleware for request ID
+	// Middleware for request ID - 클라이언트가 보낸 X-Request-ID가 있으면 그대로 사용
 	router.Use(func(c *gin.Context) {
-		c.Set("request_id", fmt.Sprintf("REQ%d", time.Now().UnixNano()))
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = fmt.Sprintf("REQ%d", time.Now().UnixNano())
+		}
+		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
 		c.Next()
 	})
+	router.Use(TracingMiddleware(handler.traces))
+
+	// 요청 지표와 재고 재시도 카운터 (GET /metrics, ?format=json)
+	handler.service.metrics.Mount(router)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -819,32 +3587,72 @@ func SetupRouter(handler *Handler) *gin.Engine {
 	// Transaction routes
 	transactions := router.Group("/transactions")
 	{
-		transactions.POST("/transfer", handler.Transfer)
-		transactions.POST("/order", handler.ProcessOrder)
-		transactions.POST("/stock", handler.UpdateStock)
+		transactions.POST("/transfer", timeout.New(transferTimeout), handler.Transfer)
+		transactions.POST("/transfer/preview", timeout.New(transferTimeout), handler.PreviewTransfer)
+		transactions.POST("/deposit", timeout.New(transferTimeout), handler.Deposit)
+		transactions.POST("/withdraw", timeout.New(transferTimeout), handler.Withdraw)
+		transactions.POST("/order", timeout.New(orderTimeout), handler.ProcessOrder)
+		transactions.POST("/stock", timeout.New(stockTimeout), handler.UpdateStock)
 		transactions.GET("/history", handler.GetTransactionHistory)
+		transactions.GET("/export.csv", handler.ExportTransactionHistory)
+		transactions.GET("/stats", handler.GetTransactionStats)
+		transactions.GET("/stream", handler.StreamTransactions) // 장기 연결이라 타임아웃 미들웨어 없음
+		transactions.POST("/:id/resolve", AdminMiddleware(), handler.ResolveTransaction)
+	}
+
+	// 2단계 재고 예약 (reserve → confirm 또는 release)
+	inventory := router.Group("/inventory", timeout.New(stockTimeout))
+	{
+		inventory.POST("/reserve", handler.ReserveInventory)
+		inventory.POST("/confirm", handler.ConfirmReservation)
+		inventory.POST("/release", handler.ReleaseReservation)
 	}
 
 	// Test routes
-	tests := router.Group("/tests")
+	tests := router.Group("/tests", timeout.New(batchTimeout))
 	{
 		tests.GET("/concurrency", handler.TestConcurrency)
 		tests.GET("/deadlock", handler.TestDeadlock)
 	}
 
 	// Account management
-	router.GET("/accounts", func(c *gin.Context) {
-		var accounts []Account
-		handler.service.db.Find(&accounts)
-		c.JSON(200, accounts)
-	})
+	accounts := router.Group("/accounts")
+	{
+		accounts.GET("", func(c *gin.Context) {
+			var accounts []Account
+			handler.service.db.Find(&accounts)
+			c.JSON(200, accounts)
+		})
+		accounts.POST("", handler.OpenAccount)
+		accounts.GET("/:id", handler.GetAccount)
+		accounts.GET("/:id/statement", handler.GetStatement)
+		accounts.POST("/:id/close", handler.CloseAccount)
+	}
+
+	// Admin routes
+	admin := router.Group("/admin", AdminMiddleware())
+	{
+		admin.GET("/maintenance-window", handler.GetMaintenanceWindow)
+		admin.PUT("/maintenance-window", handler.SetMaintenanceWindow)
+		admin.DELETE("/maintenance-window", handler.ClearMaintenanceWindow)
+		admin.GET("/traces", handler.GetTraces)
+	}
+
+	// Customer order history
+	router.GET("/customers/:id/orders", handler.GetCustomerOrders)
 
 	// Product management
-	router.GET("/products", func(c *gin.Context) {
-		var products []Product
-		handler.service.db.Find(&products)
-		c.JSON(200, products)
-	})
+	products := router.Group("/products")
+	{
+		products.GET("", func(c *gin.Context) {
+			var products []Product
+			handler.service.db.Find(&products)
+			c.JSON(200, products)
+		})
+		products.POST("", handler.CreateProduct)
+		products.GET("/low-stock", handler.GetLowStockProducts)
+		products.PUT("/:id", handler.UpdateProduct)
+	}
 
 	return router
 }
@@ -854,6 +3662,10 @@ func SetupRouter(handler *Handler) *gin.Engine {
 // ============================================================================
 
 func main() {
+	if authConfig.SecretKey == "" {
+		log.Fatal("JWT_SECRET is required to sign and verify admin tokens")
+	}
+
 	// Database connection
 	db, err := gorm.Open(sqlite.Open("transaction.db?_journal_mode=WAL"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
@@ -874,7 +3686,7 @@ func main() {
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
 	// Auto migrate
-	db.AutoMigrate(&Account{}, &Transaction{}, &Order{}, &OrderItem{}, &Product{}, &Payment{})
+	db.AutoMigrate(&Account{}, &Transaction{}, &Order{}, &OrderItem{}, &Product{}, &Payment{}, &StockReservation{}, &OutboxEvent{})
 
 	// Initialize data
 	var count int64
@@ -886,6 +3698,27 @@ func main() {
 	// Initialize handler
 	handler := NewHandler(db)
 
+	// 야간 정산 중 이체 중단 (예: TRANSFER_MAINTENANCE_WINDOW=23:30-00:30, UTC)
+	if spec := os.Getenv("TRANSFER_MAINTENANCE_WINDOW"); spec != "" {
+		start, end, _ := strings.Cut(spec, "-")
+		w, err := ParseMaintenanceWindow(start, end)
+		if err != nil {
+			log.Fatal("Invalid TRANSFER_MAINTENANCE_WINDOW:", err)
+		}
+		handler.service.SetMaintenanceWindow(w)
+		log.Printf("🛠️ Transfers paused daily %s-%s UTC", w.Start, w.End)
+	}
+
+	// 만료된 재고 예약 회수
+	handler.service.StartReservationSweeper(context.Background(), reservationSweepPeriod)
+
+	// outbox에 쌓인 트랜잭션 이벤트를 웹훅으로 전달 (WEBHOOK_URL이 있을 때만)
+	if url := os.Getenv("WEBHOOK_URL"); url != "" {
+		relay := NewOutboxRelay(db, NewWebhookDeliverer(url, &http.Client{Timeout: 10 * time.Second}))
+		relay.Start(context.Background(), outboxPollInterval)
+		log.Printf("📨 Webhook relay delivering to %s", url)
+	}
+
 	// Setup router
 	router := SetupRouter(handler)
 
@@ -0,0 +1,2021 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"example.com/gin-playground/pkg/bindingerr"
+	"example.com/gin-playground/pkg/jwtauth"
+	"example.com/gin-playground/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	// main은 JWT_SECRET 없이 시작하지 않으므로 테스트용 키로 관리자 토큰을 서명
+	authConfig.SecretKey = "test-secret"
+	os.Exit(m.Run())
+}
+
+func newTestRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&Account{}, &Transaction{}, &Order{}, &OrderItem{}, &Product{}, &Payment{}, &StockReservation{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	InitializeData(db)
+
+	return SetupRouter(NewHandler(db)), db
+}
+
+func postJSON(r http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestProcessOrderTimesOut(t *testing.T) {
+	original := orderTimeout
+	orderTimeout = 50 * time.Millisecond // 결제 시뮬레이션(200ms)보다 짧게
+	defer func() { orderTimeout = original }()
+
+	router, db := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/order", `{"customer_id": 1, "total_amount": 10}`)
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Success bool `json:"success"`
+		Error   struct {
+			Code      int    `json:"code"`
+			ErrorCode string `json:"error_code"`
+			Path      string `json:"path"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode %q: %v", w.Body.String(), err)
+	}
+	if resp.Success || resp.Error.Code != http.StatusGatewayTimeout || resp.Error.ErrorCode != "GATEWAY_TIMEOUT" ||
+		resp.Error.Path != "/transactions/order" || resp.Error.RequestID == "" {
+		t.Errorf("unexpected envelope: %+v", resp)
+	}
+
+	// 취소된 트랜잭션은 롤백되어야 함
+	var orders int64
+	db.Model(&Order{}).Count(&orders)
+	if orders != 0 {
+		t.Errorf("expected rolled back order, found %d", orders)
+	}
+}
+
+func TestProcessOrderWithinDeadline(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/order", `{"customer_id": 1, "total_amount": 10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"status":"completed"`) {
+		t.Errorf("expected completed order, got %s", w.Body.String())
+	}
+}
+
+func TestTransferWithinDeadline(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOpenAndGetAccount(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := postJSON(router, "/accounts", `{"name": "Frank Castle", "currency": "eur", "initial_deposit": 100}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var opened Account
+	if err := json.Unmarshal(w.Body.Bytes(), &opened); err != nil {
+		t.Fatalf("failed to decode account: %v", err)
+	}
+	if opened.ID == 0 || !strings.HasPrefix(opened.Number, "ACC") || opened.Currency != "EUR" ||
+		opened.Balance != 100 || opened.Status != AccountStatusActive {
+		t.Errorf("unexpected opened account: %+v", opened)
+	}
+
+	// 계좌번호는 계좌마다 고유
+	w = postJSON(router, "/accounts", `{"name": "Second", "currency": "USD"}`)
+	var second Account
+	json.Unmarshal(w.Body.Bytes(), &second)
+	if second.Number == "" || second.Number == opened.Number {
+		t.Errorf("expected unique account numbers, got %q and %q", opened.Number, second.Number)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/accounts/%d", opened.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var fetched Account
+	json.Unmarshal(w.Body.Bytes(), &fetched)
+	if fetched.Number != opened.Number || fetched.Name != "Frank Castle" {
+		t.Errorf("unexpected fetched account: %+v", fetched)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/accounts/9999", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown account, got %d", w.Code)
+	}
+}
+
+func TestOpenAccountRejectsUnsupportedCurrency(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := postJSON(router, "/accounts", `{"name": "Frank Castle", "currency": "XYZ"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCloseAccount(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	// 시드 계좌(ACC001)는 잔액이 남아 있어 해지 불가
+	w := postJSON(router, "/accounts/1/close", "")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for nonzero balance, got %d: %s", w.Code, w.Body.String())
+	}
+	var account Account
+	db.First(&account, 1)
+	if account.Status != AccountStatusActive {
+		t.Errorf("account with balance must stay active, got %q", account.Status)
+	}
+
+	w = postJSON(router, "/accounts", `{"name": "Empty", "currency": "KRW"}`)
+	var empty Account
+	json.Unmarshal(w.Body.Bytes(), &empty)
+
+	closePath := fmt.Sprintf("/accounts/%d/close", empty.ID)
+	w = postJSON(router, closePath, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var closed Account
+	json.Unmarshal(w.Body.Bytes(), &closed)
+	if closed.Status != AccountStatusClosed || closed.ClosedAt == nil {
+		t.Errorf("unexpected closed account: %+v", closed)
+	}
+
+	if w := postJSON(router, closePath, ""); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 when closing twice, got %d", w.Code)
+	}
+
+	// 해지된 계좌로는 이체 불가
+	body := fmt.Sprintf(`{"from_account_id": 1, "to_account_id": %d, "amount": 10}`, empty.ID)
+	if w := postJSON(router, "/transactions/transfer", body); w.Code == http.StatusOK {
+		t.Errorf("expected transfer to closed account to fail, got %d", w.Code)
+	}
+}
+
+func TestProcessOrderConsumesReservation(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/order", `{"customer_id": 1, "total_amount": 60, "items": [{"product_id": 2, "quantity": 2}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var mouse Product
+	db.First(&mouse, 2)
+	if mouse.Stock != 198 || mouse.Reserved != 0 {
+		t.Errorf("expected stock 198 with nothing reserved, got stock=%d reserved=%d", mouse.Stock, mouse.Reserved)
+	}
+
+	var hold StockReservation
+	db.First(&hold)
+	if hold.Status != ReservationConsumed || hold.OrderID == nil {
+		t.Errorf("expected consumed reservation linked to the order, got %+v", hold)
+	}
+}
+
+func TestExpiredReservationReturnsStock(t *testing.T) {
+	_, db := newTestRouter(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	service := NewTransactionService(db)
+	service.now = func() time.Time { return now }
+
+	// Saga가 예약 직후 죽은 상황
+	order := &Order{CustomerID: 1, Items: []OrderItem{{ProductID: 1, Quantity: 5}}}
+	holds, err := service.reserveStock(ctx, order)
+	if err != nil {
+		t.Fatalf("reserveStock failed: %v", err)
+	}
+
+	var laptop Product
+	db.First(&laptop, 1)
+	if laptop.Reserved != 5 {
+		t.Fatalf("expected 5 reserved, got %d", laptop.Reserved)
+	}
+
+	// 만료 전에는 회수되지 않음
+	if n, _ := service.ReleaseExpiredReservations(ctx); n != 0 {
+		t.Errorf("expected nothing released before expiry, got %d", n)
+	}
+
+	now = now.Add(stockReservationTTL + time.Second)
+	n, err := service.ReleaseExpiredReservations(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 released reservation, got %d (%v)", n, err)
+	}
+
+	db.First(&laptop, 1)
+	if laptop.Reserved != 0 || laptop.Stock != 50 {
+		t.Errorf("expected stock returned: stock=%d reserved=%d", laptop.Stock, laptop.Reserved)
+	}
+	var hold StockReservation
+	db.First(&hold, holds[0].ID)
+	if hold.Status != ReservationExpired {
+		t.Errorf("expected expired reservation, got %q", hold.Status)
+	}
+
+	// 만료된 예약으로는 주문을 확정할 수 없음
+	payment, err := service.processPayment(ctx, order)
+	if err != nil {
+		t.Fatalf("processPayment failed: %v", err)
+	}
+	if err := service.confirmOrder(ctx, order, payment, holds); !errors.Is(err, ErrReservationExpired) {
+		t.Errorf("expected ErrReservationExpired, got %v", err)
+	}
+	db.First(&laptop, 1)
+	if laptop.Stock != 50 || laptop.Reserved != 0 {
+		t.Errorf("stock must be untouched after failed confirm: stock=%d reserved=%d", laptop.Stock, laptop.Reserved)
+	}
+}
+
+func TestServiceErrorsMapToStatus(t *testing.T) {
+	router, db := newTestRouter(t)
+	db.Model(&Account{}).Where("id = ?", 5).Update("is_locked", true)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"insufficient balance", "/transactions/transfer", `{"from_account_id": 2, "to_account_id": 1, "amount": 999999}`, http.StatusUnprocessableEntity},
+		{"locked account", "/transactions/transfer", `{"from_account_id": 5, "to_account_id": 1, "amount": 10}`, http.StatusLocked},
+		{"unknown account", "/transactions/transfer", `{"from_account_id": 999, "to_account_id": 1, "amount": 10}`, http.StatusNotFound},
+		{"insufficient stock", "/transactions/stock", `{"product_id": 1, "quantity": 10000}`, http.StatusUnprocessableEntity},
+		{"unknown product", "/transactions/stock", `{"product_id": 999, "quantity": 1}`, http.StatusNotFound},
+		{"order over stock", "/transactions/order", `{"customer_id": 1, "total_amount": 10, "items": [{"product_id": 1, "quantity": 10000}]}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postJSON(router, tt.path, tt.body); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTransferValidation(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"same account", `{"from_account_id": 1, "to_account_id": 1, "amount": 10}`, []string{"to_account_id"}},
+		{"zero amount", `{"from_account_id": 1, "to_account_id": 2, "amount": 0}`, []string{"amount"}},
+		{"negative amount", `{"from_account_id": 1, "to_account_id": 2, "amount": -5}`, []string{"amount"}},
+		{"sub-cent amount", `{"from_account_id": 1, "to_account_id": 2, "amount": 10.005}`, []string{"amount"}},
+		{"all fields reported", `{"from_account_id": 2, "to_account_id": 2, "amount": 0.001}`, []string{"to_account_id", "amount"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/transactions/transfer", "/transactions/transfer/preview"} {
+				w := postJSON(router, path, tt.body)
+				if w.Code != http.StatusUnprocessableEntity {
+					t.Fatalf("%s: expected 422, got %d: %s", path, w.Code, w.Body.String())
+				}
+				var resp struct {
+					Success bool `json:"success"`
+					Error   struct {
+						ErrorCode string                  `json:"error_code"`
+						Details   []bindingerr.FieldError `json:"details"`
+						Path      string                  `json:"path"`
+					} `json:"error"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("invalid response: %v", err)
+				}
+				if resp.Success || resp.Error.ErrorCode != bindingerr.ErrorCodeValidation || resp.Error.Path != path {
+					t.Errorf("%s: unexpected envelope: %s", path, w.Body.String())
+				}
+				var fields []string
+				for _, d := range resp.Error.Details {
+					fields = append(fields, d.Field)
+				}
+				if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+					t.Errorf("%s: expected fields %v, got %v", path, tt.fields, fields)
+				}
+			}
+		})
+	}
+
+	// 검증에 실패한 이체는 이력도 남기지 않음
+	var count int64
+	db.Model(&Transaction{}).Count(&count)
+	if count != 0 {
+		t.Errorf("rejected transfers must not create records, found %d", count)
+	}
+
+	// 센트 단위 금액은 부동소수 오차가 있어도 통과
+	w := postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 10.01}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var from Account
+	db.First(&from, 1)
+	if from.Balance != 4989.99 {
+		t.Errorf("expected balance 4989.99, got %v", from.Balance)
+	}
+
+	_, err := NewTransactionService(db).Transfer(context.Background(), 1, 1, -1)
+	if !errors.Is(err, ErrInvalidTransfer) || !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("expected ErrInvalidTransfer and ErrInvalidAmount, got %v", err)
+	}
+}
+
+func TestErrorStatusUnwrapsSentinels(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrInvalidAmount, http.StatusBadRequest},
+		{&TransferValidationError{Fields: []bindingerr.FieldError{{Field: "amount"}}}, http.StatusUnprocessableEntity},
+		{fmt.Errorf("max retries exceeded: %w", ErrConcurrentUpdate), http.StatusConflict},
+		{fmt.Errorf("to account 3: %w", ErrAccountClosed), http.StatusConflict},
+		{fmt.Errorf("%w: balance 1.00, requested 2.00", ErrInsufficientBalance), http.StatusUnprocessableEntity},
+		{errors.New("disk full"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestTransactionStats(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []Transaction{
+		{Type: "transfer", Status: "completed", Amount: 100, ProcessingTime: 10, CreatedAt: base.Add(1 * time.Hour)},
+		{Type: "transfer", Status: "completed", Amount: 300, ProcessingTime: 30, CreatedAt: base.Add(2 * time.Hour)},
+		{Type: "transfer", Status: "failed", Amount: 50, ProcessingTime: 20, CreatedAt: base.Add(3 * time.Hour)},
+		{Type: "deposit", Status: "completed", Amount: 1000, ProcessingTime: 5, CreatedAt: base.Add(4 * time.Hour)},
+		{Type: "withdrawal", Status: "timeout", Amount: 70, ProcessingTime: 15, CreatedAt: base.Add(5 * time.Hour)},
+		// 구간 밖
+		{Type: "transfer", Status: "completed", Amount: 9999, ProcessingTime: 99, CreatedAt: base.Add(48 * time.Hour)},
+	}
+	for i := range seed {
+		seed[i].TransactionID = fmt.Sprintf("TXNSTAT%d", i)
+		if err := db.Create(&seed[i]).Error; err != nil {
+			t.Fatalf("failed to seed transaction: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/transactions/stats?from=2024-01-01T00:00:00Z&to=2024-01-02T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats TransactionStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+
+	if stats.Count != 5 || stats.Volume != 1400 {
+		t.Errorf("expected 5 transactions with volume 1400, got %d / %.2f", stats.Count, stats.Volume)
+	}
+	if stats.AvgProcessingTime != 16 {
+		t.Errorf("expected avg processing 16ms, got %.2f", stats.AvgProcessingTime)
+	}
+	if stats.FailureRate != 0.4 {
+		t.Errorf("expected failure rate 0.4, got %.2f", stats.FailureRate)
+	}
+	if stats.CountByStatus["completed"] != 3 || stats.CountByStatus["failed"] != 1 || stats.CountByStatus["timeout"] != 1 {
+		t.Errorf("unexpected count by status: %v", stats.CountByStatus)
+	}
+
+	transfer := stats.ByType["transfer"]
+	if transfer.Count != 3 || transfer.Volume != 400 || transfer.AvgProcessingTime != 20 {
+		t.Errorf("unexpected transfer stats: %+v", transfer)
+	}
+	if rate := transfer.FailureRate; rate < 0.33 || rate > 0.34 {
+		t.Errorf("expected transfer failure rate 1/3, got %.4f", rate)
+	}
+	if withdrawal := stats.ByType["withdrawal"]; withdrawal.Count != 1 || withdrawal.Volume != 0 || withdrawal.FailureRate != 1 {
+		t.Errorf("unexpected withdrawal stats: %+v", withdrawal)
+	}
+
+	req = httptest.NewRequest("GET", "/transactions/stats?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for inverted window, got %d", w.Code)
+	}
+}
+
+func TestDepositAndWithdraw(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/deposit", `{"account_id": 2, "amount": 500}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var deposit Transaction
+	json.Unmarshal(w.Body.Bytes(), &deposit)
+	if deposit.Type != "deposit" || deposit.Status != "completed" || deposit.ToAccountID != 2 {
+		t.Errorf("unexpected deposit record: %+v", deposit)
+	}
+
+	w = postJSON(router, "/transactions/withdraw", `{"account_id": 2, "amount": 1500}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var account Account
+	db.First(&account, 2)
+	if account.Balance != 2000 {
+		t.Errorf("expected balance 3000+500-1500=2000, got %.2f", account.Balance)
+	}
+}
+
+func TestWithdrawRejectsOverdraft(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/withdraw", `{"account_id": 5, "amount": 2000.01}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var account Account
+	db.First(&account, 5)
+	if account.Balance != 2000 {
+		t.Errorf("balance must be unchanged after failed withdrawal, got %.2f", account.Balance)
+	}
+
+	var failed Transaction
+	if err := db.Where("type = ? AND status = ?", "withdrawal", "failed").First(&failed).Error; err != nil {
+		t.Fatalf("expected failed withdrawal to be recorded: %v", err)
+	}
+	if failed.FromAccountID != 5 || failed.ErrorMessage == "" {
+		t.Errorf("unexpected failed record: %+v", failed)
+	}
+}
+
+func TestTransactionHistoryKeysetPagination(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	// 같은 시각에 생성된 행이 여러 개여도 순서가 결정적이어야 함
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	seeded := make(map[uint]bool)
+	for i := 0; i < 25; i++ {
+		tx := Transaction{
+			TransactionID: fmt.Sprintf("TXNPAGE%d", i),
+			Type:          "transfer",
+			Status:        "completed",
+			Amount:        float64(i),
+			CreatedAt:     base.Add(time.Duration(i/4) * time.Millisecond),
+		}
+		if i%5 == 0 {
+			tx.Type = "deposit"
+		}
+		if err := db.Create(&tx).Error; err != nil {
+			t.Fatalf("failed to seed transaction: %v", err)
+		}
+		seeded[tx.ID] = true
+	}
+
+	paginate := func(query string) []uint {
+		t.Helper()
+		var ids []uint
+		cursor := ""
+		for page := 0; page < 20; page++ {
+			path := "/transactions/history?limit=7" + query
+			if cursor != "" {
+				path += "&cursor=" + cursor
+			}
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Transactions []Transaction `json:"transactions"`
+				NextCursor   string        `json:"next_cursor"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			for _, tx := range resp.Transactions {
+				ids = append(ids, tx.ID)
+			}
+			if resp.NextCursor == "" {
+				return ids
+			}
+			cursor = resp.NextCursor
+		}
+		t.Fatal("pagination did not terminate")
+		return nil
+	}
+
+	ids := paginate("")
+	visited := make(map[uint]int)
+	for _, id := range ids {
+		visited[id]++
+	}
+	if len(ids) != len(seeded) {
+		t.Errorf("expected %d rows, visited %d", len(seeded), len(ids))
+	}
+	for id := range seeded {
+		if visited[id] != 1 {
+			t.Errorf("transaction %d visited %d times", id, visited[id])
+		}
+	}
+
+	if deposits := paginate("&type=deposit"); len(deposits) != 5 {
+		t.Errorf("expected 5 deposits with type filter, got %d", len(deposits))
+	}
+
+	req := httptest.NewRequest("GET", "/transactions/history?cursor=not-a-cursor", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid cursor, got %d", w.Code)
+	}
+}
+
+func TestStreamTransactionsReceivesTransfer(t *testing.T) {
+	router, _ := newTestRouter(t)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/transactions/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	// 응답 헤더를 받았다면 구독은 이미 등록된 상태
+	received := make(chan TransactionEvent, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+				var event TransactionEvent
+				if json.Unmarshal([]byte(data), &event) == nil {
+					received <- event
+					return
+				}
+			}
+		}
+	}()
+
+	w := postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("transfer failed: %d %s", w.Code, w.Body.String())
+	}
+	var transfer Transaction
+	json.Unmarshal(w.Body.Bytes(), &transfer)
+
+	select {
+	case event := <-received:
+		if event.Type != "transfer" || event.Reference != transfer.TransactionID || event.Status != "completed" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestEventBrokerDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	broker := NewEventBroker()
+	slow, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventBufferSize*2; i++ {
+			broker.Publish(TransactionEvent{Type: "transfer"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a subscriber that never reads")
+	}
+	if len(slow) != eventBufferSize {
+		t.Errorf("expected buffer of %d events, got %d", eventBufferSize, len(slow))
+	}
+
+	unsubscribe()
+	if _, ok := <-drain(slow); ok {
+		t.Error("expected channel closed after unsubscribe")
+	}
+}
+
+// drain은 버퍼에 남은 이벤트를 버리고 닫힌 채널을 반환
+func drain(ch <-chan TransactionEvent) <-chan TransactionEvent {
+	for range ch {
+	}
+	return ch
+}
+
+// waitFor - cond가 참이 될 때까지 폴링 (timeout이 지나면 실패)
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOutboxDeliversTransferAfterRelayRestart(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	// 커밋 직후 프로세스가 죽은 상황: 릴레이 없이 이체만 커밋됨
+	w := postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("transfer failed: %d %s", w.Code, w.Body.String())
+	}
+	var transfer Transaction
+	json.Unmarshal(w.Body.Bytes(), &transfer)
+
+	var outbox []OutboxEvent
+	db.Find(&outbox)
+	if len(outbox) != 1 || outbox[0].Reference != transfer.TransactionID || outbox[0].SentAt != nil {
+		t.Fatalf("expected one unsent outbox event for the transfer, got %+v", outbox)
+	}
+	eventID := outbox[0].ID
+
+	// 첫 릴레이는 웹훅이 계속 실패하는 동안 종료됨
+	relay := NewOutboxRelay(db, func(ctx context.Context, event *OutboxEvent) error {
+		return errors.New("connection refused")
+	})
+	relay.baseBackoff = time.Millisecond
+	ctx, kill := context.WithCancel(context.Background())
+	relay.Start(ctx, 5*time.Millisecond)
+	waitFor(t, 2*time.Second, func() bool {
+		var event OutboxEvent
+		db.First(&event, eventID)
+		return event.Attempts >= 2
+	})
+	kill()
+
+	// 재시작한 릴레이가 outbox에 남은 같은 이벤트를 전송
+	type delivery struct {
+		id    string
+		event TransactionEvent
+	}
+	received := make(chan delivery, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event TransactionEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- delivery{id: r.Header.Get("X-Outbox-Event-ID"), event: event}
+	}))
+	defer webhook.Close()
+
+	relay = NewOutboxRelay(db, NewWebhookDeliverer(webhook.URL, webhook.Client()))
+	relay.baseBackoff = time.Millisecond
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	relay.Start(ctx, 5*time.Millisecond)
+
+	select {
+	case d := <-received:
+		if d.id != fmt.Sprint(eventID) || d.event.Reference != transfer.TransactionID || d.event.Type != "transfer" {
+			t.Errorf("unexpected delivery: %+v", d)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not delivered after relay restart")
+	}
+
+	waitFor(t, time.Second, func() bool {
+		var event OutboxEvent
+		db.First(&event, eventID)
+		return event.SentAt != nil
+	})
+
+	// 전송 완료 후에는 다시 보내지 않음
+	time.Sleep(30 * time.Millisecond)
+	if extra := len(received); extra != 0 {
+		t.Errorf("expected a single delivery, got %d more", extra)
+	}
+}
+
+func TestOutboxRolledBackWithFailedTransfer(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 99999999}`)
+	if w.Code == http.StatusOK {
+		t.Fatalf("expected overdraft transfer to fail")
+	}
+
+	var count int64
+	db.Model(&OutboxEvent{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no outbox events for a rolled back transfer, got %d", count)
+	}
+}
+
+func TestOutboxRelayBackoff(t *testing.T) {
+	relay := NewOutboxRelay(nil, nil)
+	relay.baseBackoff = time.Second
+	relay.maxBackoff = 5 * time.Second
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := relay.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func adminToken(t *testing.T, username, role string) string {
+	t.Helper()
+	token, err := authConfig.Sign(jwtauth.Claims{
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			Issuer:    authConfig.Issuer,
+			Audience:  authConfig.Audience,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func resolveTransaction(r http.Handler, id uint, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", fmt.Sprintf("/transactions/%d/resolve", id), nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestResolveStalePendingTransaction(t *testing.T) {
+	router, db := newTestRouter(t)
+	admin := adminToken(t, "ops-admin", "admin")
+	stale := time.Now().Add(-time.Hour)
+
+	// 잔액은 반영됐지만 상태 갱신 전에 프로세스가 죽은 이체
+	applied := Transaction{TransactionID: "TXN-APPLIED", FromAccountID: 1, ToAccountID: 2, Amount: 100,
+		Type: "transfer", Status: "pending", CreatedAt: stale}
+	db.Create(&applied)
+	db.Model(&Account{}).Where("id = ?", 1).Update("balance", 4900)
+	db.Model(&Account{}).Where("id = ?", 2).Update("balance", 3100)
+
+	// 잔액에 반영되지 않은 입금
+	unapplied := Transaction{TransactionID: "TXN-UNAPPLIED", ToAccountID: 3, Amount: 50,
+		Type: "deposit", Status: "pending", CreatedAt: stale}
+	db.Create(&unapplied)
+
+	recent := Transaction{TransactionID: "TXN-RECENT", ToAccountID: 4, Amount: 10,
+		Type: "deposit", Status: "pending"}
+	db.Create(&recent)
+
+	if w := resolveTransaction(router, applied.ID, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", w.Code)
+	}
+	if w := resolveTransaction(router, applied.ID, adminToken(t, "alice", "user")); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+
+	w := resolveTransaction(router, applied.ID, admin)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resolved Transaction
+	db.First(&resolved, applied.ID)
+	if resolved.Status != "completed" || resolved.ResolvedBy != "ops-admin" || resolved.ResolvedAt == nil || resolved.CompletedAt == nil {
+		t.Errorf("expected completed transaction resolved by ops-admin, got %+v", resolved)
+	}
+
+	if w := resolveTransaction(router, unapplied.ID, admin); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var failed Transaction
+	db.First(&failed, unapplied.ID)
+	if failed.Status != "failed" || failed.ResolvedBy != "ops-admin" {
+		t.Errorf("expected failed transaction resolved by ops-admin, got %+v", failed)
+	}
+
+	// 처리 중일 수 있는 최근 트랜잭션과 이미 확정된 트랜잭션은 거부
+	if w := resolveTransaction(router, recent.ID, admin); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for recent pending transaction, got %d", w.Code)
+	}
+	if w := resolveTransaction(router, applied.ID, admin); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for already resolved transaction, got %d", w.Code)
+	}
+	if w := resolveTransaction(router, 999, admin); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown transaction, got %d", w.Code)
+	}
+
+	var balance Account
+	db.First(&balance, 3)
+	if balance.Balance != 10000 {
+		t.Errorf("resolving must not move funds, got balance %.2f", balance.Balance)
+	}
+}
+
+func TestResolveRefusesLedgerMismatch(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	// 잔액이 원장과도, 원장 + 트랜잭션과도 맞지 않으면 사람이 조사해야 함
+	pending := Transaction{TransactionID: "TXN-DRIFT", ToAccountID: 1, Amount: 100,
+		Type: "deposit", Status: "pending", CreatedAt: time.Now().Add(-time.Hour)}
+	db.Create(&pending)
+	db.Model(&Account{}).Where("id = ?", 1).Update("balance", 5042)
+
+	w := resolveTransaction(router, pending.ID, adminToken(t, "ops-admin", "admin"))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for ledger mismatch, got %d: %s", w.Code, w.Body.String())
+	}
+	var stored Transaction
+	db.First(&stored, pending.ID)
+	if stored.Status != "pending" || stored.ResolvedBy != "" {
+		t.Errorf("expected transaction to stay pending, got %+v", stored)
+	}
+}
+
+func postOrder(r http.Handler, body, idempotencyKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/transactions/order", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestProcessOrderIdempotencyKey(t *testing.T) {
+	router, db := newTestRouter(t)
+	body := `{"customer_id": 1, "total_amount": 60, "items": [{"product_id": 2, "quantity": 2}]}`
+
+	first := postOrder(router, body, "order-abc")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", first.Code, first.Body.String())
+	}
+	// 클라이언트가 응답을 못 받고 같은 키로 재시도
+	retry := postOrder(router, body, "order-abc")
+	if retry.Code != http.StatusOK {
+		t.Fatalf("expected 200 on retry, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected retry to be marked as replayed")
+	}
+
+	var original, replayed Order
+	json.Unmarshal(first.Body.Bytes(), &original)
+	json.Unmarshal(retry.Body.Bytes(), &replayed)
+	if original.ID == 0 || replayed.ID != original.ID || replayed.OrderNumber != original.OrderNumber {
+		t.Errorf("expected the same order back, got %d/%s and %d/%s",
+			original.ID, original.OrderNumber, replayed.ID, replayed.OrderNumber)
+	}
+
+	// 재고는 한 번만 차감되고 결제도 하나뿐
+	var mouse Product
+	db.First(&mouse, 2)
+	if mouse.Stock != 198 || mouse.Reserved != 0 {
+		t.Errorf("expected stock 198 with nothing reserved, got stock=%d reserved=%d", mouse.Stock, mouse.Reserved)
+	}
+	var orders, payments int64
+	db.Model(&Order{}).Count(&orders)
+	db.Model(&Payment{}).Count(&payments)
+	if orders != 1 || payments != 1 {
+		t.Errorf("expected 1 order and 1 payment, got %d and %d", orders, payments)
+	}
+
+	// 같은 키로 다른 주문을 보내면 거부
+	if w := postOrder(router, `{"customer_id": 2, "total_amount": 60, "items": [{"product_id": 2, "quantity": 2}]}`, "order-abc"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a reused key, got %d", w.Code)
+	}
+
+	// 키가 없으면 매번 새 주문
+	postOrder(router, body, "")
+	postOrder(router, body, "")
+	db.First(&mouse, 2)
+	if mouse.Stock != 194 {
+		t.Errorf("expected orders without a key to be processed each time, got stock=%d", mouse.Stock)
+	}
+}
+
+func getStatement(r http.Handler, path string) (*httptest.ResponseRecorder, Statement) {
+	req := httptest.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var statement Statement
+	json.Unmarshal(w.Body.Bytes(), &statement)
+	return w, statement
+}
+
+func TestAccountStatementGroupsByDay(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	seed := []Transaction{
+		// 3월 이전 거래는 기초 잔액에 포함
+		{ToAccountID: 1, Amount: 200, Type: "deposit", Status: "completed", CreatedAt: at(time.February, 20, 10, 0)},
+		// 3/3: +100, -30
+		{ToAccountID: 1, Amount: 100, Type: "deposit", Status: "completed", CreatedAt: at(time.March, 3, 9, 0)},
+		{FromAccountID: 1, ToAccountID: 2, Amount: 30, Type: "transfer", Status: "completed", CreatedAt: at(time.March, 3, 15, 0)},
+		// 3/10: -50, +25 (실패한 입금은 제외)
+		{FromAccountID: 1, Amount: 50, Type: "withdrawal", Status: "completed", CreatedAt: at(time.March, 10, 8, 0)},
+		{ToAccountID: 1, Amount: 999, Type: "deposit", Status: "failed", CreatedAt: at(time.March, 10, 9, 0)},
+		{FromAccountID: 2, ToAccountID: 1, Amount: 25, Type: "transfer", Status: "completed", CreatedAt: at(time.March, 10, 12, 0)},
+		// 3/31 마지막 거래와 4/1 0시 거래의 경계
+		{ToAccountID: 1, Amount: 5, Type: "deposit", Status: "completed", CreatedAt: at(time.March, 31, 23, 30)},
+		{ToAccountID: 1, Amount: 1000, Type: "deposit", Status: "completed", CreatedAt: at(time.April, 1, 0, 0)},
+		// 다른 계좌 거래는 무관
+		{ToAccountID: 3, Amount: 70, Type: "deposit", Status: "completed", CreatedAt: at(time.March, 3, 10, 0)},
+	}
+	for i := range seed {
+		seed[i].TransactionID = fmt.Sprintf("TXNSTMT%d", i)
+		if err := db.Create(&seed[i]).Error; err != nil {
+			t.Fatalf("failed to seed transaction: %v", err)
+		}
+	}
+
+	w, statement := getStatement(router, "/accounts/1/statement?month=2024-03")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if statement.Month != "2024-03" || statement.Currency != "USD" {
+		t.Errorf("unexpected header: %+v", statement)
+	}
+	if !balanceEqual(statement.OpeningBalance, 5200) {
+		t.Errorf("expected opening balance 5200, got %.2f", statement.OpeningBalance)
+	}
+
+	want := []StatementDay{
+		{Date: "2024-03-03", Net: 70, Balance: 5270, TransactionCount: 2},
+		{Date: "2024-03-10", Net: -25, Balance: 5245, TransactionCount: 2},
+		{Date: "2024-03-31", Net: 5, Balance: 5250, TransactionCount: 1},
+	}
+	if len(statement.Days) != len(want) {
+		t.Fatalf("expected %d days, got %+v", len(want), statement.Days)
+	}
+	for i, day := range want {
+		got := statement.Days[i]
+		if got.Date != day.Date || !balanceEqual(got.Net, day.Net) || !balanceEqual(got.Balance, day.Balance) ||
+			got.TransactionCount != day.TransactionCount {
+			t.Errorf("day %d: expected %+v, got %+v", i, day, got)
+		}
+	}
+	if !balanceEqual(statement.ClosingBalance, 5250) {
+		t.Errorf("expected closing balance 5250, got %.2f", statement.ClosingBalance)
+	}
+
+	// 거래가 없는 달은 기초 잔액 = 기말 잔액, days는 빈 배열
+	w, statement = getStatement(router, "/accounts/1/statement?month=2024-06")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !balanceEqual(statement.OpeningBalance, 6250) || !balanceEqual(statement.ClosingBalance, 6250) {
+		t.Errorf("expected flat 6250 for a quiet month, got %.2f -> %.2f", statement.OpeningBalance, statement.ClosingBalance)
+	}
+	if !strings.Contains(w.Body.String(), `"days":[]`) {
+		t.Errorf("expected empty days array, got %s", w.Body.String())
+	}
+}
+
+func TestAccountStatementValidation(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, path := range []string{
+		"/accounts/1/statement",
+		"/accounts/1/statement?month=2024-3",
+		"/accounts/1/statement?month=2024-13",
+		"/accounts/1/statement?month=03-2024",
+		"/accounts/abc/statement?month=2024-03",
+	} {
+		if w, _ := getStatement(router, path); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, w.Code)
+		}
+	}
+
+	if w, _ := getStatement(router, "/accounts/999/statement?month=2024-03"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown account, got %d", w.Code)
+	}
+}
+
+func doRequest(r http.Handler, method, path, body, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMaintenanceWindowBlocksTransfers(t *testing.T) {
+	router, _ := newTestRouter(t)
+	admin := adminToken(t, "ops-admin", "admin")
+	transfer := `{"from_account_id": 1, "to_account_id": 2, "amount": 10}`
+
+	now := time.Now().UTC()
+	window := fmt.Sprintf(`{"start": %q, "end": %q}`,
+		now.Add(-time.Hour).Format(maintenanceTimeLayout), now.Add(time.Hour).Format(maintenanceTimeLayout))
+
+	if w := doRequest(router, "PUT", "/admin/maintenance-window", window, adminToken(t, "alice", "user")); w.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admin to get 403, got %d", w.Code)
+	}
+	w := doRequest(router, "PUT", "/admin/maintenance-window", window, admin)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"active":true`) {
+		t.Fatalf("expected active window, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = postJSON(router, "/transactions/transfer", transfer)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 during maintenance, got %d: %s", w.Code, w.Body.String())
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry <= 0 || retry > 3600 {
+		t.Errorf("expected Retry-After within the hour, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// 조회와 입금은 점검 중에도 허용
+	if w := doRequest(router, "GET", "/accounts/1", "", ""); w.Code != http.StatusOK {
+		t.Errorf("expected reads during maintenance, got %d", w.Code)
+	}
+	if w := postJSON(router, "/transactions/deposit", `{"account_id": 1, "amount": 5}`); w.Code != http.StatusOK {
+		t.Errorf("expected deposits during maintenance, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doRequest(router, "DELETE", "/admin/maintenance-window", "", admin); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 clearing the window, got %d", w.Code)
+	}
+	if w := postJSON(router, "/transactions/transfer", transfer); w.Code != http.StatusOK {
+		t.Fatalf("expected transfer to succeed after clearing, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, _ := time.Parse(maintenanceTimeLayout, clock)
+		return time.Date(2026, time.March, 10, parsed.Hour(), parsed.Minute(), 0, 0, time.UTC)
+	}
+
+	overnight, err := ParseMaintenanceWindow("23:30", "00:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for clock, want := range map[string]bool{"23:29": false, "23:30": true, "00:00": true, "00:29": true, "00:30": false, "12:00": false} {
+		if got := overnight.Contains(at(clock)); got != want {
+			t.Errorf("23:30-00:30 contains %s = %v, want %v", clock, got, want)
+		}
+	}
+	if got := overnight.Remaining(at("23:45")); got != 45*time.Minute {
+		t.Errorf("expected 45m remaining at 23:45, got %v", got)
+	}
+
+	for _, bad := range [][2]string{{"25:00", "01:00"}, {"02:00", "2am"}, {"02:00", "02:00"}} {
+		if _, err := ParseMaintenanceWindow(bad[0], bad[1]); err == nil {
+			t.Errorf("expected %v to be rejected", bad)
+		}
+	}
+}
+
+func TestUpdateStockConflictCarriesAttemptMetadata(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	original := stockRetryBackoff
+	stockRetryBackoff = 10 * time.Millisecond
+	defer func() { stockRetryBackoff = original }()
+
+	// 모든 시도에서 읽기와 쓰기 사이에 다른 writer가 먼저 커밋하는 상황을 재현
+	var bumps int
+	err := db.Callback().Update().Before("gorm:update").Register("test:competing_writer", func(tx *gorm.DB) {
+		if tx.Statement.Table != "products" {
+			return
+		}
+		bumps++
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE products SET version = version + 1 WHERE id = ?", 1)
+	})
+	if err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	var before Product
+	db.First(&before, 1)
+
+	w := postJSON(router, "/transactions/stock", `{"product_id": 1, "quantity": 1}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Error        string `json:"error"`
+		Resource     string `json:"resource"`
+		ID           uint   `json:"id"`
+		Attempts     int    `json:"attempts"`
+		RetryAfterMs int64  `json:"retry_after_ms"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if body.Resource != "product" || body.ID != 1 || body.Attempts != stockUpdateMaxRetries || bumps != stockUpdateMaxRetries {
+		t.Errorf("expected %d attempts on product 1, got %+v (%d competing writes)", stockUpdateMaxRetries, body, bumps)
+	}
+	if want := (time.Duration(stockUpdateMaxRetries) * stockRetryBackoff).Milliseconds(); body.RetryAfterMs != want {
+		t.Errorf("expected retry_after_ms %d, got %d", want, body.RetryAfterMs)
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 1 {
+		t.Errorf("expected Retry-After of at least 1s, got %q", w.Header().Get("Retry-After"))
+	}
+
+	var after Product
+	db.First(&after, 1)
+	if after.Stock != before.Stock {
+		t.Errorf("expected stock to stay %d after a conflict, got %d", before.Stock, after.Stock)
+	}
+
+	conflict := &ConflictError{Resource: "product", ID: 1, Attempts: 3}
+	if !errors.Is(conflict, ErrConcurrentUpdate) || errorStatus(fmt.Errorf("wrapped: %w", conflict)) != http.StatusConflict {
+		t.Error("expected ConflictError to unwrap to ErrConcurrentUpdate and map to 409")
+	}
+}
+
+func TestUpdateStockRetryMetricsUnderContention(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	original := stockRetryBackoff
+	stockRetryBackoff = time.Millisecond
+	defer func() { stockRetryBackoff = original }()
+
+	// 세 번의 쓰기 중 한 번꼴로 읽기와 쓰기 사이에 다른 writer가 먼저 커밋하는 상황을 재현
+	var writes atomic.Int64
+	err := db.Callback().Update().Before("gorm:update").Register("test:competing_writer", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" && writes.Add(1)%3 == 1 {
+			tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE products SET version = version + 1 WHERE id = ?", 2)
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+
+	var before Product
+	db.First(&before, 2)
+
+	const requests = 20
+	var succeeded, exhausted atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch w := postJSON(router, "/transactions/stock", `{"product_id": 2, "quantity": 1}`); w.Code {
+			case http.StatusOK:
+				succeeded.Add(1)
+			case http.StatusConflict:
+				exhausted.Add(1)
+			default:
+				t.Errorf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 재시도가 있어도 성공한 요청만큼만 재고가 줄어듦
+	var after Product
+	db.First(&after, 2)
+	if after.Stock != before.Stock-int(succeeded.Load()) {
+		t.Errorf("expected stock %d after %d successful updates, got %d", before.Stock-int(succeeded.Load()), succeeded.Load(), after.Stock)
+	}
+
+	w := doRequest(router, "GET", "/metrics?format=json", "", "")
+	var resp struct {
+		Counters []metrics.CounterMetrics `json:"counters"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid metrics response: %v", err)
+	}
+	counts := make(map[string]uint64)
+	for _, c := range resp.Counters {
+		for _, series := range c.Series {
+			if series.Labels["product_bucket"] != "2" {
+				t.Errorf("%s: expected only product bucket 2, got %v", c.Name, series.Labels)
+			}
+			counts[c.Name] += series.Value
+		}
+	}
+
+	retries := counts["stock_update_retries_total"]
+	if retries == 0 {
+		t.Fatalf("expected retries under contention, got %v", counts)
+	}
+	if got := counts["stock_update_attempts_total"]; got != requests+retries {
+		t.Errorf("expected %d attempts (%d requests + %d retries), got %d", requests+retries, requests, retries, got)
+	}
+	if got := counts["stock_update_exhausted_total"]; got != uint64(exhausted.Load()) {
+		t.Errorf("expected %d exhausted updates, got %d", exhausted.Load(), got)
+	}
+
+	if body := doRequest(router, "GET", "/metrics", "", "").Body.String(); !strings.Contains(body, fmt.Sprintf("stock_update_retries_total{product_bucket=\"2\"} %d\n", retries)) {
+		t.Errorf("expected the retry counter in Prometheus output:\n%s", body)
+	}
+}
+
+func TestProductBucketIsBounded(t *testing.T) {
+	for _, id := range []uint{1, 17, 1000003} {
+		bucket, _ := strconv.Atoi(productBucket(id))
+		if bucket < 0 || bucket >= stockMetricBuckets {
+			t.Errorf("product %d: bucket %d out of range", id, bucket)
+		}
+	}
+	if productBucket(1) != productBucket(1+stockMetricBuckets) {
+		t.Error("expected ids stockMetricBuckets apart to share a bucket")
+	}
+}
+
+func TestTransferRecordsTraceSpans(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	req := httptest.NewRequest("POST", "/transactions/transfer", strings.NewReader(`{"from_account_id": 1, "to_account_id": 2, "amount": 10}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "trace-me")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Request-ID"); got != "trace-me" {
+		t.Errorf("expected X-Request-ID to be echoed, got %q", got)
+	}
+
+	// span이 없는 요청은 기록되지 않음
+	postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 0}`)
+
+	if w := doRequest(router, "GET", "/admin/traces", "", adminToken(t, "alice", "user")); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %d", w.Code)
+	}
+	w = doRequest(router, "GET", "/admin/traces?limit=5", "", adminToken(t, "ops-admin", "admin"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Traces []*Trace `json:"traces"`
+		Count  int      `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Count != 1 || len(resp.Traces) != 1 {
+		t.Fatalf("expected exactly one trace, got %s", w.Body.String())
+	}
+
+	trace := resp.Traces[0]
+	if trace.RequestID != "trace-me" || trace.Path != "/transactions/transfer" || trace.Status != http.StatusOK {
+		t.Errorf("unexpected trace metadata: %+v", trace)
+	}
+	want := []string{"create_record", "lock_from_account", "lock_to_account", "update_balances", "complete_record", "commit"}
+	if len(trace.Spans) != len(want) {
+		t.Fatalf("expected spans %v, got %+v", want, trace.Spans)
+	}
+	for i, span := range trace.Spans {
+		if span.Name != want[i] {
+			t.Errorf("span %d: expected %q, got %q", i, want[i], span.Name)
+		}
+		if span.Error != "" {
+			t.Errorf("span %s: unexpected error %q", span.Name, span.Error)
+		}
+		if i > 0 && span.Start.Before(trace.Spans[i-1].Start) {
+			t.Errorf("span %s started before %s", span.Name, trace.Spans[i-1].Name)
+		}
+	}
+}
+
+func TestTraceRecorderKeepsMostRecent(t *testing.T) {
+	recorder := NewTraceRecorder(3)
+	for i := 1; i <= 5; i++ {
+		recorder.Record(&Trace{RequestID: strconv.Itoa(i)})
+	}
+
+	var ids []string
+	for _, trace := range recorder.Recent(10) {
+		ids = append(ids, trace.RequestID)
+	}
+	if strings.Join(ids, ",") != "5,4,3" {
+		t.Errorf("expected newest first 5,4,3, got %v", ids)
+	}
+	if got := recorder.Recent(2); len(got) != 2 || got[0].RequestID != "5" {
+		t.Errorf("expected the two newest traces, got %d", len(got))
+	}
+}
+
+func previewTransfer(t *testing.T, r http.Handler, body string) TransferPreview {
+	t.Helper()
+	w := postJSON(r, "/transactions/transfer/preview", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var preview TransferPreview
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	return preview
+}
+
+func TestPreviewTransferDoesNotMoveMoney(t *testing.T) {
+	router, db := newTestRouter(t)
+	db.Model(&Account{}).Where("id = ?", 3).Update("is_locked", true)
+
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"success", `{"from_account_id": 1, "to_account_id": 2, "amount": 100}`, ""},
+		{"insufficient balance", `{"from_account_id": 5, "to_account_id": 2, "amount": 2500}`, "insufficient balance: balance 2000.00, requested 2500.00"},
+		{"locked account", `{"from_account_id": 1, "to_account_id": 3, "amount": 100}`, ErrAccountLocked.Error()},
+		{"unknown account", `{"from_account_id": 1, "to_account_id": 99, "amount": 100}`, "to account 99: " + ErrAccountNotFound.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview := previewTransfer(t, router, tt.body)
+			if preview.WouldSucceed != (tt.reason == "") || preview.Reason != tt.reason {
+				t.Errorf("expected would_succeed=%v reason %q, got %+v", tt.reason == "", tt.reason, preview)
+			}
+		})
+	}
+
+	preview := previewTransfer(t, router, tests[0].body)
+	if preview.FromBalanceAfter == nil || *preview.FromBalanceAfter != 4900 || *preview.ToBalanceAfter != 3100 {
+		t.Errorf("expected balances after 4900/3100, got %+v", preview)
+	}
+
+	var count int64
+	db.Model(&Transaction{}).Count(&count)
+	if count != 0 {
+		t.Errorf("preview must not create transaction records, found %d", count)
+	}
+	db.Model(&OutboxEvent{}).Count(&count)
+	if count != 0 {
+		t.Errorf("preview must not enqueue outbox events, found %d", count)
+	}
+	var from, to Account
+	db.First(&from, 1)
+	db.First(&to, 2)
+	if from.Balance != 5000 || to.Balance != 3000 {
+		t.Errorf("preview changed balances: %.2f / %.2f", from.Balance, to.Balance)
+	}
+}
+
+func TestPreviewTransferDuringMaintenance(t *testing.T) {
+	router, _ := newTestRouter(t)
+	now := time.Now().UTC()
+	window := fmt.Sprintf(`{"start": %q, "end": %q}`,
+		now.Add(-time.Hour).Format(maintenanceTimeLayout), now.Add(time.Hour).Format(maintenanceTimeLayout))
+	w := doRequest(router, "PUT", "/admin/maintenance-window", window, adminToken(t, "ops-admin", "admin"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to set maintenance window: %d %s", w.Code, w.Body.String())
+	}
+
+	preview := previewTransfer(t, router, `{"from_account_id": 1, "to_account_id": 2, "amount": 100}`)
+	if preview.WouldSucceed || preview.Reason != ErrMaintenanceWindow.Error() {
+		t.Errorf("expected maintenance rejection, got %+v", preview)
+	}
+}
+
+func TestExportTransactionHistoryCSV(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	postJSON(router, "/transactions/deposit", `{"account_id": 1, "amount": 50}`)
+	postJSON(router, "/transactions/withdraw", `{"account_id": 2, "amount": 20}`)
+	postJSON(router, "/transactions/withdraw", `{"account_id": 5, "amount": 999999}`) // 실패 기록
+	postJSON(router, "/transactions/deposit", `{"account_id": 3, "amount": 75}`)
+
+	export := func(params string) [][]string {
+		t.Helper()
+		w := doRequest(router, "GET", "/transactions/export.csv?"+params, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+			t.Errorf("expected text/csv, got %q", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="transactions-`) {
+			t.Errorf("unexpected Content-Disposition %q", cd)
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(transactionCSVHeader, ",") {
+			t.Fatalf("unexpected header row: %v", records)
+		}
+		return records[1:]
+	}
+
+	if rows := export(""); len(rows) != 4 {
+		t.Errorf("expected all 4 transactions, got %d", len(rows))
+	}
+
+	// JSON 이력과 같은 최신순
+	deposits := export("type=deposit")
+	if len(deposits) != 2 || deposits[0][5] != "3" || deposits[0][6] != "75.00" || deposits[1][5] != "1" {
+		t.Errorf("expected the two deposits newest first, got %v", deposits)
+	}
+
+	failed := export("type=withdrawal&status=failed")
+	if len(failed) != 1 || failed[0][4] != "5" || !strings.Contains(failed[0][9], "insufficient balance") {
+		t.Errorf("expected the failed withdrawal with its error, got %v", failed)
+	}
+}
+
+func newInventoryTestRouter(t *testing.T) (*gin.Engine, *gorm.DB, *time.Time) {
+	t.Helper()
+	_, db := newTestRouter(t)
+
+	now := time.Now()
+	handler := NewHandler(db)
+	handler.service.now = func() time.Time { return now }
+	return SetupRouter(handler), db, &now
+}
+
+func reserveInventory(t *testing.T, r http.Handler, body string) InventoryReservation {
+	t.Helper()
+	w := postJSON(r, "/inventory/reserve", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var reservation InventoryReservation
+	if err := json.Unmarshal(w.Body.Bytes(), &reservation); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return reservation
+}
+
+func productStock(db *gorm.DB, id uint) (stock, reserved int) {
+	var product Product
+	db.First(&product, id)
+	return product.Stock, product.Reserved
+}
+
+func TestReserveThenConfirmInventory(t *testing.T) {
+	router, db, _ := newInventoryTestRouter(t)
+
+	reservation := reserveInventory(t, router, `{"items": [{"product_id": 1, "quantity": 2}, {"product_id": 2, "quantity": 3}]}`)
+	if !strings.HasPrefix(reservation.Token, "RSV") || reservation.Status != ReservationHeld || len(reservation.Items) != 2 {
+		t.Fatalf("unexpected reservation: %+v", reservation)
+	}
+	if stock, reserved := productStock(db, 1); stock != 50 || reserved != 2 {
+		t.Errorf("expected 2 laptops reserved, got stock=%d reserved=%d", stock, reserved)
+	}
+
+	w := postJSON(router, "/inventory/confirm", `{"token": "`+reservation.Token+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stock, reserved := productStock(db, 1); stock != 48 || reserved != 0 {
+		t.Errorf("expected laptop stock consumed, got stock=%d reserved=%d", stock, reserved)
+	}
+	if stock, reserved := productStock(db, 2); stock != 197 || reserved != 0 {
+		t.Errorf("expected mouse stock consumed, got stock=%d reserved=%d", stock, reserved)
+	}
+
+	// 같은 토큰으로 두 번 확정하거나 확정 후 해제할 수 없음
+	for _, path := range []string{"/inventory/confirm", "/inventory/release"} {
+		if w := postJSON(router, path, `{"token": "`+reservation.Token+`"}`); w.Code != http.StatusConflict {
+			t.Errorf("%s after confirm: expected 409, got %d", path, w.Code)
+		}
+	}
+	if w := postJSON(router, "/inventory/confirm", `{"token": "RSVUNKNOWN"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown token, got %d", w.Code)
+	}
+}
+
+func TestReserveThenReleaseInventory(t *testing.T) {
+	router, db, _ := newInventoryTestRouter(t)
+
+	reservation := reserveInventory(t, router, `{"items": [{"product_id": 1, "quantity": 5}]}`)
+
+	w := postJSON(router, "/inventory/release", `{"token": "`+reservation.Token+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stock, reserved := productStock(db, 1); stock != 50 || reserved != 0 {
+		t.Errorf("expected reservation returned, got stock=%d reserved=%d", stock, reserved)
+	}
+
+	if w := postJSON(router, "/inventory/confirm", `{"token": "`+reservation.Token+`"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 confirming a released reservation, got %d", w.Code)
+	}
+
+	// 가용 재고를 넘는 예약은 거부
+	if w := postJSON(router, "/inventory/reserve", `{"items": [{"product_id": 1, "quantity": 51}]}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for insufficient stock, got %d", w.Code)
+	}
+}
+
+func TestReserveThenExpireInventory(t *testing.T) {
+	router, db, now := newInventoryTestRouter(t)
+
+	reservation := reserveInventory(t, router, `{"items": [{"product_id": 1, "quantity": 5}]}`)
+	*now = now.Add(stockReservationTTL)
+
+	w := postJSON(router, "/inventory/confirm", `{"token": "`+reservation.Token+`"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), ErrReservationExpired.Error()) {
+		t.Fatalf("expected 409 expired, got %d: %s", w.Code, w.Body.String())
+	}
+	if stock, reserved := productStock(db, 1); stock != 50 || reserved != 0 {
+		t.Errorf("expected expired reservation returned, got stock=%d reserved=%d", stock, reserved)
+	}
+
+	var hold StockReservation
+	db.Where("token = ?", reservation.Token).First(&hold)
+	if hold.Status != ReservationExpired {
+		t.Errorf("expected expired status, got %q", hold.Status)
+	}
+
+	// 만료 처리된 뒤에도 계속 만료로 응답
+	if w := postJSON(router, "/inventory/confirm", `{"token": "`+reservation.Token+`"}`); !strings.Contains(w.Body.String(), ErrReservationExpired.Error()) {
+		t.Errorf("expected expired error on retry, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSkewedConcurrentTransfersConserveBalance(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, distribution := range []string{AmountDistributionUniform, AmountDistributionNormal} {
+		t.Run(distribution, func(t *testing.T) {
+			// 모든 이체가 1번 계좌에서 나가는 핫스팟 + 잔액을 넘기는 큰 금액 섞기
+			path := "/tests/concurrency?workers=8&forward_ratio=1&min_amount=1000&max_amount=6000&seed=7&distribution=" + distribution
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var results struct {
+				Config       TransferLoadConfig `json:"config"`
+				ForwardCount int                `json:"forward_count"`
+				SuccessCount int                `json:"success_count"`
+				FailureCount int                `json:"failure_count"`
+				TimeoutCount int                `json:"timeout_count"`
+				RetryCount   *int               `json:"retry_count"`
+				LockWaitMs   map[string]float64 `json:"lock_wait_ms"`
+				TotalBalance float64            `json:"total_balance"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+				t.Fatalf("failed to decode results: %v", err)
+			}
+
+			if results.TotalBalance != 20000 {
+				t.Errorf("expected total balance 20000 to be conserved, got %v", results.TotalBalance)
+			}
+			if results.SuccessCount == 0 {
+				t.Errorf("expected at least one transfer to go through: %s", w.Body.String())
+			}
+			if n := results.SuccessCount + results.FailureCount + results.TimeoutCount; n != 8 {
+				t.Errorf("expected every worker to be accounted for, got %d", n)
+			}
+			if results.ForwardCount != 8 || results.Config.Distribution != distribution || results.Config.Seed != 7 {
+				t.Errorf("unexpected plan: forward=%d config=%+v", results.ForwardCount, results.Config)
+			}
+			if results.RetryCount == nil || results.LockWaitMs == nil {
+				t.Errorf("expected contention metrics in results: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestConcurrencyTestRejectsInvalidConfig(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, query := range []string{"distribution=pareto", "forward_ratio=1.5", "min_amount=10&max_amount=5", "workers=0"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/tests/concurrency?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestSampleAmountStaysInRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, distribution := range []string{AmountDistributionUniform, AmountDistributionNormal} {
+		cfg := DefaultTransferLoadConfig()
+		cfg.Distribution = distribution
+		for i := 0; i < 1000; i++ {
+			if amount := cfg.sampleAmount(rng); amount < cfg.MinAmount || amount > cfg.MaxAmount || amount != math.Round(amount) {
+				t.Fatalf("%s: amount %v outside [%v, %v]", distribution, amount, cfg.MinAmount, cfg.MaxAmount)
+			}
+		}
+	}
+}
+
+func putJSON(r http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateAndUpdateProduct(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := postJSON(router, "/products", `{"name": "Webcam", "sku": "SKU100", "price": 59.99, "stock": 30, "low_stock_threshold": 5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created Product
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID == 0 || created.SKU != "SKU100" || created.Stock != 30 || created.LowStockThreshold != 5 {
+		t.Fatalf("unexpected product: %+v", created)
+	}
+
+	if w := postJSON(router, "/products", `{"name": "Webcam 2", "sku": "SKU100", "price": 10}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for duplicate SKU, got %d", w.Code)
+	}
+	if w := postJSON(router, "/products", `{"name": "Freebie", "sku": "SKU101", "price": 0}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-positive price, got %d", w.Code)
+	}
+
+	path := fmt.Sprintf("/products/%d", created.ID)
+	w = putJSON(router, path, `{"price": 49.99, "stock": 40}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated Product
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Price != 49.99 || updated.Stock != 40 || updated.Name != "Webcam" || updated.Version != created.Version+1 {
+		t.Errorf("expected only price and stock to change and version to bump, got %+v", updated)
+	}
+
+	// 예약된 수량보다 적게 줄일 수 없음
+	if w := postJSON(router, "/inventory/reserve", fmt.Sprintf(`{"items": [{"product_id": %d, "quantity": 25}]}`, created.ID)); w.Code != http.StatusCreated {
+		t.Fatalf("reserve failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := putJSON(router, path, `{"stock": 20}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 lowering stock below reserved, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := putJSON(router, "/products/9999", `{"price": 1}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown product, got %d", w.Code)
+	}
+}
+
+func TestLowStockProducts(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	lowStock := func(query string) []uint {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/products/low-stock"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Products []Product `json:"products"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ids := []uint{}
+		for _, p := range resp.Products {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	if ids := lowStock(""); len(ids) != 0 {
+		t.Fatalf("expected no low stock products after seeding, got %v", ids)
+	}
+
+	// Mouse(2): 재고 자체를 줄임, Laptop(1): 예약으로 가용 재고만 줄임
+	if w := putJSON(router, "/products/2", `{"stock": 5}`); w.Code != http.StatusOK {
+		t.Fatalf("update failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(router, "/inventory/reserve", `{"items": [{"product_id": 1, "quantity": 46}]}`); w.Code != http.StatusCreated {
+		t.Fatalf("reserve failed: %d %s", w.Code, w.Body.String())
+	}
+
+	// 가용 재고 오름차순: Laptop 4, Mouse 5
+	if ids := lowStock(""); fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("expected products below their own thresholds [1 2], got %v", ids)
+	}
+	if ids := lowStock("?threshold=5"); fmt.Sprint(ids) != "[1]" {
+		t.Errorf("expected only available < 5, got %v", ids)
+	}
+	if ids := lowStock("?threshold=100"); fmt.Sprint(ids) != "[1 2 4]" {
+		t.Errorf("expected Laptop, Mouse and Monitor below 100, got %v", ids)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/products/low-stock?threshold=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid threshold, got %d", w.Code)
+	}
+}
+
+func TestLowStockEventFiresOnceWhenCrossingThreshold(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	lowStockEvents := func() []OutboxEvent {
+		var events []OutboxEvent
+		db.Where("event_type = ?", "inventory.low_stock").Order("id").Find(&events)
+		return events
+	}
+
+	// Laptop: 재고 50, 기준 10 → 40개까지는 기준 이상
+	if w := postJSON(router, "/inventory/reserve", `{"items": [{"product_id": 1, "quantity": 40}]}`); w.Code != http.StatusCreated {
+		t.Fatalf("reserve failed: %d %s", w.Code, w.Body.String())
+	}
+	if events := lowStockEvents(); len(events) != 0 {
+		t.Fatalf("expected no alert at the threshold, got %d", len(events))
+	}
+
+	if w := postJSON(router, "/inventory/reserve", `{"items": [{"product_id": 1, "quantity": 1}]}`); w.Code != http.StatusCreated {
+		t.Fatalf("reserve failed: %d %s", w.Code, w.Body.String())
+	}
+	events := lowStockEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected one low stock alert, got %d", len(events))
+	}
+	var payload LowStockEvent
+	json.Unmarshal([]byte(events[0].Payload), &payload)
+	if payload.ProductID != 1 || payload.Available != 9 || payload.Threshold != 10 || events[0].Reference != "SKU001" {
+		t.Errorf("unexpected alert payload: %+v (reference %s)", payload, events[0].Reference)
+	}
+
+	// 이미 기준 아래면 다시 알리지 않음
+	if w := postJSON(router, "/inventory/reserve", `{"items": [{"product_id": 1, "quantity": 1}]}`); w.Code != http.StatusCreated {
+		t.Fatalf("reserve failed: %d %s", w.Code, w.Body.String())
+	}
+	if events := lowStockEvents(); len(events) != 1 {
+		t.Errorf("expected no repeated alert below the threshold, got %d", len(events))
+	}
+}
+
+func TestIsolationLevelsKeepBalancesUnderConcurrency(t *testing.T) {
+	levels := map[string]sql.IsolationLevel{
+		"read_committed": sql.LevelReadCommitted,
+		"serializable":   sql.LevelSerializable,
+	}
+	for name, level := range levels {
+		t.Run(name, func(t *testing.T) {
+			const workers = 10
+			db := newConcurrentTestDB(t, workers)
+			service := NewTransactionService(db)
+
+			// 짝수 워커는 1번 계좌에 입금, 홀수 워커는 1번 → 2번 이체 (같은 행을 동시에 갱신)
+			var wg sync.WaitGroup
+			errs := make(chan error, workers)
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					var err error
+					if i%2 == 0 {
+						_, err = service.DepositWithIsolation(context.Background(), 1, 10, level)
+					} else {
+						_, err = service.TransferWithIsolation(context.Background(), 1, 2, 5, level)
+					}
+					errs <- err
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("operation failed: %v", err)
+				}
+			}
+
+			// lost update가 있으면 저장된 잔액이 원장(개설 잔액 + 완료된 트랜잭션)과 어긋남
+			for _, id := range []uint{1, 2} {
+				var account Account
+				db.First(&account, id)
+				expected, err := ledgerBalance(db, &account)
+				if err != nil {
+					t.Fatalf("ledger failed: %v", err)
+				}
+				if account.Balance != expected {
+					t.Errorf("account %d: lost update, balance %v but ledger says %v", id, account.Balance, expected)
+				}
+			}
+
+			var first, second Account
+			db.First(&first, 1)
+			db.First(&second, 2)
+			if first.Balance != 5000+5*10-5*5 || second.Balance != 3000+5*5 {
+				t.Errorf("unexpected balances: %v, %v", first.Balance, second.Balance)
+			}
+
+			// 워커들이 커넥션 하나로 줄 서지 않고 실제로 겹쳐 실행되었는지
+			sqlDB, _ := db.DB()
+			if stats := sqlDB.Stats(); stats.OpenConnections < 2 {
+				t.Errorf("expected workers to use several connections, pool opened %d", stats.OpenConnections)
+			}
+		})
+	}
+}
+
+// newConcurrentTestDB - 여러 커넥션이 동시에 트랜잭션을 여는 파일 기반 WAL DB
+// newTestRouter의 커넥션 1개짜리 DB에서는 트랜잭션이 차례로 실행되어 lost update가 드러나지 않음.
+// SQLite는 격리 수준을 무시하고 쓰기 잠금 하나로 쓰기를 직렬화하므로, BEGIN IMMEDIATE(_txlock)로
+// 잠금을 트랜잭션 시작 시점에 잡고 busy_timeout 동안 기다리게 함 (DEFERRED면 읽은 뒤 쓰기로 올릴 때 SQLITE_BUSY)
+func newConcurrentTestDB(t *testing.T, conns int) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "concurrent.db") + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(conns)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&Account{}, &Transaction{}, &Order{}, &OrderItem{}, &Product{}, &Payment{}, &StockReservation{}, &OutboxEvent{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	InitializeData(db)
+	return db
+}
+
+func TestTransferRejectsUnknownIsolation(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := postJSON(router, "/transactions/transfer", `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "isolation": "chaos"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown isolation, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postJSON(router, "/transactions/deposit", `{"account_id": 1, "amount": 10, "isolation": "serializable"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected explicit isolation to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTxOptionsFallsBackToOperationDefault(t *testing.T) {
+	if opts := txOptions(sql.LevelDefault, depositIsolation); opts.Isolation != sql.LevelReadCommitted {
+		t.Errorf("expected deposit default ReadCommitted, got %v", opts.Isolation)
+	}
+	if opts := txOptions(sql.LevelDefault, transferIsolation); opts.Isolation != sql.LevelSerializable {
+		t.Errorf("expected transfer default Serializable, got %v", opts.Isolation)
+	}
+	if opts := txOptions(sql.LevelRepeatableRead, transferIsolation); opts.Isolation != sql.LevelRepeatableRead {
+		t.Errorf("expected explicit level to win, got %v", opts.Isolation)
+	}
+}
+
+func TestCustomerOrdersPreloadsItemsNewestFirst(t *testing.T) {
+	router, db := newTestRouter(t)
+
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	seed := func(number string, customerID uint, at time.Time, paid bool, items ...OrderItem) {
+		t.Helper()
+		order := Order{OrderNumber: number, CustomerID: customerID, Status: "completed", Items: items, CreatedAt: at}
+		if err := db.Create(&order).Error; err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+		if paid {
+			payment := Payment{PaymentID: "PAY-" + number, OrderID: order.ID, Amount: 100, Method: "card", Status: "completed"}
+			db.Create(&payment)
+			db.Model(&order).Update("payment_id", payment.ID)
+		}
+	}
+	seed("ORD-A", 7, base, true, OrderItem{ProductID: 1, Quantity: 1, Price: 999.99})
+	seed("ORD-B", 7, base.Add(time.Hour), false, OrderItem{ProductID: 2, Quantity: 2, Price: 29.99}, OrderItem{ProductID: 3, Quantity: 1, Price: 79.99})
+	seed("ORD-C", 7, base.Add(2*time.Hour), true, OrderItem{ProductID: 4, Quantity: 1, Price: 299.99})
+	seed("ORD-OTHER", 8, base.Add(3*time.Hour), true, OrderItem{ProductID: 5, Quantity: 1, Price: 149.99})
+
+	type page struct {
+		Orders     []Order `json:"orders"`
+		NextCursor string  `json:"next_cursor"`
+	}
+	get := func(path string) page {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var p page
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("failed to decode orders: %v", err)
+		}
+		return p
+	}
+
+	// 주문 수와 상관없이 주문/아이템/제품/결제 4번의 쿼리만 실행
+	queries := 0
+	db.Callback().Query().After("gorm:query").Register("count_queries", func(*gorm.DB) { queries++ })
+	first := get("/customers/7/orders?limit=2")
+	db.Callback().Query().Remove("count_queries")
+	if queries != 4 {
+		t.Errorf("expected 4 queries with preloading, got %d", queries)
+	}
+
+	if len(first.Orders) != 2 || first.Orders[0].OrderNumber != "ORD-C" || first.Orders[1].OrderNumber != "ORD-B" {
+		t.Fatalf("expected newest orders first [ORD-C ORD-B], got %+v", first.Orders)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	c := first.Orders[0]
+	if len(c.Items) != 1 || c.Items[0].Product.Name != "Monitor" || c.Payment == nil || c.Payment.Status != "completed" {
+		t.Errorf("expected ORD-C with Monitor and a completed payment, got %+v", c)
+	}
+	b := first.Orders[1]
+	if len(b.Items) != 2 || b.Items[0].Product.Name != "Mouse" || b.Items[1].Product.Name != "Keyboard" || b.Payment != nil {
+		t.Errorf("expected unpaid ORD-B with Mouse and Keyboard, got %+v", b)
+	}
+
+	second := get("/customers/7/orders?limit=2&cursor=" + first.NextCursor)
+	if len(second.Orders) != 1 || second.Orders[0].OrderNumber != "ORD-A" || second.NextCursor != "" {
+		t.Errorf("expected last page [ORD-A] without cursor, got %+v", second)
+	}
+
+	if empty := get("/customers/99/orders"); len(empty.Orders) != 0 {
+		t.Errorf("expected no orders for an unknown customer, got %d", len(empty.Orders))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/customers/7/orders?cursor=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid cursor, got %d", w.Code)
+	}
+}
@@ -1,19 +1,33 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "embed"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"example.com/gin-playground/pkg/jwtauth"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 // ============================================================================
@@ -21,11 +35,47 @@ import (
 // ============================================================================
 
 type User struct {
-	ID       uint   `json:"id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Password string `json:"-"` // Never expose password
-	Role     string `json:"role"`
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
+	Username  string    `json:"username" gorm:"not null"`
+	Password  string    `json:"-" gorm:"not null"` // Never expose password
+	Role      string    `json:"role" gorm:"not null;default:user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RefreshTokenRecord is the persisted state of an issued refresh token, keyed by its jti.
+// Tokens issued by rotating a refresh token share the FamilyID of the login that started the chain.
+type RefreshTokenRecord struct {
+	ID         string    `gorm:"primaryKey;size:64"`
+	UserID     uint      `gorm:"index;not null"`
+	FamilyID   string    `gorm:"index;size:64;not null"`
+	ParentID   string    `gorm:"size:64"` // token this one was rotated from
+	ReplacedBy string    `gorm:"size:64"` // token this one was rotated into
+	ExpiresAt  time.Time `gorm:"not null"`
+	Revoked    bool      `gorm:"not null;default:false"`
+	CreatedAt  time.Time
+}
+
+func (RefreshTokenRecord) TableName() string { return "refresh_tokens" }
+
+// PasswordResetToken is a single-use reset token; only its SHA-256 hash is stored
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
 type LoginRequest struct {
@@ -36,7 +86,7 @@ type LoginRequest struct {
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Username string `json:"username" binding:"required,min=3,max=20"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // checked against passwordPolicy
 }
 
 type TokenResponse struct {
@@ -52,13 +102,7 @@ type RefreshRequest struct {
 }
 
 // JWT Claims
-type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
-	jwt.RegisteredClaims
-}
+type Claims = jwtauth.Claims
 
 type RefreshClaims struct {
 	UserID uint   `json:"user_id"`
@@ -71,41 +115,430 @@ type RefreshClaims struct {
 // ============================================================================
 
 type JWTConfig struct {
-	SecretKey           string
-	AccessTokenExpiry   time.Duration
-	RefreshTokenExpiry  time.Duration
-	Issuer              string
-	Audience            []string
+	jwtauth.Config
+	AccessTokenExpiry  time.Duration
+	RefreshTokenExpiry time.Duration
 }
 
+const (
+	AlgorithmHS256 = jwtauth.AlgorithmHS256
+	AlgorithmRS256 = jwtauth.AlgorithmRS256
+)
+
 var jwtConfig = JWTConfig{
-	SecretKey:          getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+	Config: jwtauth.Config{
+		SecretKey: getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		Algorithm: getEnv("JWT_ALGORITHM", AlgorithmHS256),
+		Issuer:    "gin-jwt-example",
+		Audience:  []string{"gin-api"},
+	},
 	AccessTokenExpiry:  15 * time.Minute,
 	RefreshTokenExpiry: 7 * 24 * time.Hour,
-	Issuer:             "gin-jwt-example",
-	Audience:           []string{"gin-api"},
-}
-
-// Mock database
-var users = map[string]*User{
-	"admin@example.com": {
-		ID:       1,
-		Email:    "admin@example.com",
-		Username: "admin",
-		Password: "$2a$10$YKxocKxRyCeH9XeRT2sUDOaQJCwFO3V9PGq8f7kBBJKtTjOxPxF7e", // password: admin123
-		Role:     "admin",
-	},
-	"user@example.com": {
-		ID:       2,
-		Email:    "user@example.com",
-		Username: "user",
-		Password: "$2a$10$mQ0W6yfCfqJWVWDy0BfQJeRAZTu3bBG7TfKFKxMgDfhLi7P8gJkLa", // password: user123
-		Role:     "user",
-	},
 }
 
-// Store for refresh tokens (in production use Redis/Database)
-var refreshTokenStore = make(map[string]uint) // token -> userID
+// signToken signs claims with the configured algorithm
+func signToken(claims jwt.Claims) (string, error) {
+	return jwtConfig.Sign(claims)
+}
+
+// parseToken verifies the signature with the configured algorithm only
+func parseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwtConfig.Parse(tokenString, claims)
+}
+
+// rsaKeyBits is the size of RSA keys generated on rotation
+const rsaKeyBits = 2048
+
+// initialSigningKey wraps the configured secret/RSA keys as the first key of the key set
+func initialSigningKey(id string) jwtauth.SigningKey {
+	return jwtauth.SigningKey{
+		ID:         id,
+		Secret:     []byte(jwtConfig.SecretKey),
+		PrivateKey: jwtConfig.PrivateKey,
+		PublicKey:  jwtConfig.PublicKey,
+	}
+}
+
+// generateSigningKey creates a fresh key for the configured algorithm
+func generateSigningKey(id string) (jwtauth.SigningKey, error) {
+	key := jwtauth.SigningKey{ID: id}
+	if jwtConfig.Algorithm == AlgorithmRS256 {
+		privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return jwtauth.SigningKey{}, err
+		}
+		key.PrivateKey, key.PublicKey = privateKey, &privateKey.PublicKey
+		return key, nil
+	}
+
+	key.Secret = make([]byte, 32)
+	if _, err := rand.Read(key.Secret); err != nil {
+		return jwtauth.SigningKey{}, err
+	}
+	return key, nil
+}
+
+// maxTokenLifetime is how long a token signed by a rotated-out key can stay valid;
+// retiring the key earlier logs out users holding such tokens
+func maxTokenLifetime() time.Duration {
+	return max(jwtConfig.AccessTokenExpiry, jwtConfig.RefreshTokenExpiry)
+}
+
+// Role -> permissions granted in the access token.
+// "*" grants everything, "users:*" grants every action on users.
+var rolePermissions = map[string][]string{
+	"admin":   {"*"},
+	"manager": {"users:*", "dashboard:read"},
+	"support": {"users:read"},
+	"user":    {"profile:read", "profile:write"},
+}
+
+// Password reset tokens are short-lived
+const passwordResetExpiry = 15 * time.Minute
+
+// sendPasswordResetEmail delivers the reset token (replace with a real mailer in production)
+var sendPasswordResetEmail = func(user *User, token string) error {
+	log.Printf("📧 Password reset requested for user %d", user.ID)
+	if gin.Mode() == gin.DebugMode {
+		log.Printf("   reset token (debug only): %s", token)
+	}
+	return nil
+}
+
+// Database holding users and refresh tokens
+var db *gorm.DB
+
+// Store for revoked access tokens (in production use Redis)
+var revocationStore RevocationStore = NewInMemoryRevocationStore()
+
+// ============================================================================
+// Access Token Revocation
+// ============================================================================
+
+// RevocationStore tracks issued access token IDs (jti) and which of them have been revoked
+type RevocationStore interface {
+	// Track records an issued access token so it can be revoked later by user
+	Track(userID uint, jti string, expiresAt time.Time)
+	// Revoke revokes a single access token until it would have expired anyway
+	Revoke(jti string, expiresAt time.Time)
+	// RevokeAllForUser revokes every outstanding access token of the user
+	RevokeAllForUser(userID uint) int
+	IsRevoked(jti string) bool
+}
+
+// InMemoryRevocationStore is a process-local RevocationStore
+type InMemoryRevocationStore struct {
+	mu      sync.Mutex
+	issued  map[uint]map[string]time.Time // userID -> jti -> expiresAt
+	revoked map[string]time.Time          // jti -> expiresAt
+	now     func() time.Time
+}
+
+func NewInMemoryRevocationStore() *InMemoryRevocationStore {
+	return &InMemoryRevocationStore{
+		issued:  make(map[uint]map[string]time.Time),
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (s *InMemoryRevocationStore) Track(userID uint, jti string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, ok := s.issued[userID]
+	if !ok {
+		tokens = make(map[string]time.Time)
+		s.issued[userID] = tokens
+	}
+	// Drop expired entries so the map doesn't grow forever
+	for id, exp := range tokens {
+		if !s.now().Before(exp) {
+			delete(tokens, id)
+		}
+	}
+	tokens[jti] = expiresAt
+}
+
+func (s *InMemoryRevocationStore) Revoke(jti string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+}
+
+func (s *InMemoryRevocationStore) RevokeAllForUser(userID uint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for jti, exp := range s.issued[userID] {
+		if s.now().Before(exp) {
+			s.revoked[jti] = exp
+			count++
+		}
+	}
+	delete(s.issued, userID)
+	return count
+}
+
+func (s *InMemoryRevocationStore) IsRevoked(jti string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exp, ok := s.revoked[jti]
+	if !ok {
+		return false
+	}
+	// Expired tokens are rejected by the exp claim anyway
+	if !s.now().Before(exp) {
+		delete(s.revoked, jti)
+		return false
+	}
+	return true
+}
+
+// ============================================================================
+// Login Attempt Limiting
+// ============================================================================
+
+// Login brute-force protection: after maxLoginAttempts failures the key is locked for loginLockout
+const (
+	maxLoginAttempts = 5
+	loginLockout     = 15 * time.Minute
+)
+
+// Login attempt limiter (memory for dev, Redis when REDIS_ADDR is set)
+var loginLimiter LoginAttemptLimiter = NewMemoryLoginLimiter(maxLoginAttempts, loginLockout)
+
+// LoginAttemptLimiter counts failed logins per key (email, IP) and locks keys out
+type LoginAttemptLimiter interface {
+	// Locked returns how long the key remains locked, or 0 if it isn't
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	// RecordFailure counts a failed attempt and returns the lockout if it was triggered
+	RecordFailure(ctx context.Context, key string) (time.Duration, error)
+	// Reset clears the failure count after a successful login
+	Reset(ctx context.Context, key string) error
+}
+
+type loginAttempts struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// MemoryLoginLimiter is a process-local LoginAttemptLimiter
+type MemoryLoginLimiter struct {
+	mu          sync.Mutex
+	maxAttempts int
+	lockout     time.Duration
+	attempts    map[string]*loginAttempts
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+func NewMemoryLoginLimiter(maxAttempts int, lockout time.Duration) *MemoryLoginLimiter {
+	return &MemoryLoginLimiter{
+		maxAttempts: maxAttempts,
+		lockout:     lockout,
+		attempts:    make(map[string]*loginAttempts),
+		now:         time.Now,
+	}
+}
+
+func (l *MemoryLoginLimiter) Locked(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	a, ok := l.attempts[key]
+	if !ok {
+		return 0, nil
+	}
+	if remaining := a.lockedUntil.Sub(now); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+func (l *MemoryLoginLimiter) RecordFailure(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	a, ok := l.attempts[key]
+	// Failures are counted within a window as long as the lockout
+	if !ok || now.Sub(a.windowStart) >= l.lockout {
+		a = &loginAttempts{windowStart: now}
+		l.attempts[key] = a
+	}
+
+	a.failures++
+	if a.failures >= l.maxAttempts {
+		a.lockedUntil = now.Add(l.lockout)
+		a.failures = 0
+		a.windowStart = now
+		return l.lockout, nil
+	}
+	return 0, nil
+}
+
+// sweep periodically drops entries whose lockout and counting window have both
+// expired, so keys that failed once don't stay in memory forever
+func (l *MemoryLoginLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, a := range l.attempts {
+		if !now.Before(a.lockedUntil) && now.Sub(a.windowStart) >= l.lockout {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+func (l *MemoryLoginLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+	return nil
+}
+
+// RedisLoginLimiter shares attempt counters across instances
+type RedisLoginLimiter struct {
+	client      *redis.Client
+	maxAttempts int
+	lockout     time.Duration
+}
+
+func NewRedisLoginLimiter(client *redis.Client, maxAttempts int, lockout time.Duration) *RedisLoginLimiter {
+	return &RedisLoginLimiter{client: client, maxAttempts: maxAttempts, lockout: lockout}
+}
+
+func (l *RedisLoginLimiter) Locked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := l.client.PTTL(ctx, "login:lock:"+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 { // -2: no key, -1: no expiry
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (l *RedisLoginLimiter) RecordFailure(ctx context.Context, key string) (time.Duration, error) {
+	countKey := "login:failures:" + key
+
+	pipe := l.client.TxPipeline()
+	incr := pipe.Incr(ctx, countKey)
+	pipe.ExpireNX(ctx, countKey, l.lockout)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	if incr.Val() < int64(l.maxAttempts) {
+		return 0, nil
+	}
+
+	pipe = l.client.TxPipeline()
+	pipe.Set(ctx, "login:lock:"+key, 1, l.lockout)
+	pipe.Del(ctx, countKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return l.lockout, nil
+}
+
+func (l *RedisLoginLimiter) Reset(ctx context.Context, key string) error {
+	return l.client.Del(ctx, "login:failures:"+key).Err()
+}
+
+// ============================================================================
+// Password Policy
+// ============================================================================
+
+// bcrypt only looks at the first 72 bytes, so longer passwords are rejected
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// Lowercased set of passwords that are always guessed first
+var commonPasswords = func() map[string]bool {
+	set := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[strings.ToLower(line)] = true
+		}
+	}
+	return set
+}()
+
+// PasswordPolicy describes what Register accepts as a password
+type PasswordPolicy struct {
+	MinLength        int
+	RequireMixedCase bool // at least one upper and one lower case letter
+	RequireDigit     bool
+	RequireSymbol    bool
+	RejectCommon     bool // reject passwords from the embedded common list
+}
+
+// DefaultPasswordPolicy requires 8+ characters with mixed case and a digit
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        8,
+		RequireMixedCase: true,
+		RequireDigit:     true,
+		RejectCommon:     true,
+	}
+}
+
+// Policy applied on registration (override with PASSWORD_* env vars in main)
+var passwordPolicy = DefaultPasswordPolicy()
+
+// Violations lists every rule the password breaks; nil means it is accepted
+func (p PasswordPolicy) Violations(password string) []string {
+	var violations []string
+
+	if n := len([]rune(password)); n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireMixedCase && !(hasUpper && hasLower) {
+		violations = append(violations, "must contain both upper and lower case letters")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+	if p.RejectCommon && commonPasswords[strings.ToLower(password)] {
+		violations = append(violations, "is too common")
+	}
+
+	return violations
+}
 
 // ============================================================================
 // JWT Functions
@@ -137,33 +570,93 @@ func GenerateTokenPair(user *User) (*TokenResponse, error) {
 // generateAccessToken creates a new access token
 func generateAccessToken(user *User) (string, time.Time, error) {
 	expiresAt := time.Now().Add(jwtConfig.AccessTokenExpiry)
+	tokenID := generateTokenID()
 
 	claims := Claims{
-		UserID:   user.ID,
-		Email:    user.Email,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:      user.ID,
+		Email:       user.Email,
+		Username:    user.Username,
+		Role:        user.Role,
+		Permissions: permissionsForRole(user.Role),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    jwtConfig.Issuer,
 			Subject:   fmt.Sprintf("%d", user.ID),
-			ID:        generateTokenID(),
+			ID:        tokenID,
 			Audience:  jwtConfig.Audience,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtConfig.SecretKey))
+	tokenString, err := signToken(claims)
+
+	if err == nil {
+		// Track access token so logout-all can revoke it
+		revocationStore.Track(user.ID, tokenID, expiresAt)
+	}
 
 	return tokenString, expiresAt, err
 }
 
-// generateRefreshToken creates a new refresh token
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// RotateTokenPair exchanges the parent refresh token for a new pair in the same token family.
+// The parent is marked as replaced atomically, so a concurrent second use is reported as reuse.
+func RotateTokenPair(user *User, parentID string) (*TokenResponse, error) {
+	var parent RefreshTokenRecord
+	if err := db.First(&parent, "id = ?", parentID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+
+	var refreshToken string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tokenID := generateTokenID()
+
+		result := tx.Model(&RefreshTokenRecord{}).
+			Where("id = ? AND replaced_by = ? AND revoked = ?", parent.ID, "", false).
+			Update("replaced_by", tokenID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		refreshToken, err = issueRefreshToken(tx, user, tokenID, parent.FamilyID, parent.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			RevokeTokenFamily(parent.FamilyID)
+		}
+		return nil, err
+	}
+
+	accessToken, expiresAt, err := generateAccessToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(jwtConfig.AccessTokenExpiry.Seconds()),
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// generateRefreshToken creates a new refresh token that starts a new token family
 func generateRefreshToken(user *User) (string, error) {
 	tokenID := generateTokenID()
+	return issueRefreshToken(db, user, tokenID, tokenID, "")
+}
 
+// issueRefreshToken signs a refresh token and stores it with its lineage
+func issueRefreshToken(tx *gorm.DB, user *User, tokenID, familyID, parentID string) (string, error) {
 	claims := RefreshClaims{
 		UserID: user.ID,
 		Token:  tokenID,
@@ -177,53 +670,37 @@ func generateRefreshToken(user *User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtConfig.SecretKey))
+	tokenString, err := signToken(claims)
 
-	if err == nil {
-		// Store refresh token
-		refreshTokenStore[tokenString] = user.ID
+	if err != nil {
+		return "", err
+	}
+
+	// Store refresh token
+	record := &RefreshTokenRecord{
+		ID:        tokenID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ParentID:  parentID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
 	}
 
-	return tokenString, err
+	return tokenString, nil
 }
 
 // ValidateToken validates and parses the token
 func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtConfig.SecretKey), nil
-	})
-
+	claims, err := jwtConfig.ValidateAccessToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
-	}
-
-	// Additional validations
-	if claims.Issuer != jwtConfig.Issuer {
-		return nil, errors.New("invalid issuer")
-	}
-
-	// Check audience
-	validAudience := false
-	for _, aud := range claims.Audience {
-		for _, expectedAud := range jwtConfig.Audience {
-			if aud == expectedAud {
-				validAudience = true
-				break
-			}
-		}
-	}
-	if !validAudience {
-		return nil, errors.New("invalid audience")
+	// Check revocation
+	if revocationStore.IsRevoked(claims.ID) {
+		return nil, errors.New("token has been revoked")
 	}
 
 	return claims, nil
@@ -231,12 +708,7 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 // ValidateRefreshToken validates refresh token
 func ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtConfig.SecretKey), nil
-	})
+	token, err := parseToken(tokenString, &RefreshClaims{})
 
 	if err != nil {
 		return nil, err
@@ -248,16 +720,62 @@ func ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
 	}
 
 	// Check if token exists in store
-	if _, exists := refreshTokenStore[tokenString]; !exists {
+	var record RefreshTokenRecord
+	if err := db.First(&record, "id = ?", claims.ID).Error; err != nil {
 		return nil, errors.New("refresh token not found or revoked")
 	}
+	if record.Revoked || record.UserID != claims.UserID || time.Now().After(record.ExpiresAt) {
+		return nil, errors.New("refresh token not found or revoked")
+	}
+
+	// An already rotated token means it was stolen (or replayed): kill the whole family
+	if record.ReplacedBy != "" {
+		RevokeTokenFamily(record.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
 
 	return claims, nil
 }
 
-// RevokeRefreshToken removes refresh token from store
-func RevokeRefreshToken(tokenString string) {
-	delete(refreshTokenStore, tokenString)
+// RevokeTokenFamily revokes every refresh token descended from the same login
+func RevokeTokenFamily(familyID string) error {
+	return db.Model(&RefreshTokenRecord{}).Where("family_id = ?", familyID).Update("revoked", true).Error
+}
+
+// RevokeRefreshToken marks a refresh token (by its jti) as revoked
+func RevokeRefreshToken(tokenID string) error {
+	return db.Model(&RefreshTokenRecord{}).Where("id = ?", tokenID).Update("revoked", true).Error
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of the user
+func RevokeUserRefreshTokens(userID uint) error {
+	return db.Model(&RefreshTokenRecord{}).
+		Where("user_id = ? AND revoked = ?", userID, false).
+		Update("revoked", true).Error
+}
+
+// permissionsForRole returns the scopes granted to a role
+func permissionsForRole(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
+
+// hasPermission reports whether granted scopes cover the required one
+func hasPermission(granted []string, required string) bool {
+	for _, p := range granted {
+		if p == "*" || p == required {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, ":*"); ok && strings.HasPrefix(required, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// hashToken hashes opaque tokens before they are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // generateTokenID generates a unique token ID
@@ -283,15 +801,15 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Check Bearer prefix
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+		tokenString, ok := jwtauth.BearerToken(authHeader)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
 			c.Abort()
 			return
 		}
 
 		// Validate token
-		claims, err := ValidateToken(bearerToken[1])
+		claims, err := ValidateToken(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
@@ -338,14 +856,36 @@ func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermission checks that the access token carries the required scope
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		if !hasPermission(claims.(*Claims).Permissions, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      "Insufficient permissions",
+				"permission": permission,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // OptionalAuthMiddleware allows both authenticated and unauthenticated access
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader != "" {
-			bearerToken := strings.Split(authHeader, " ")
-			if len(bearerToken) == 2 && bearerToken[0] == "Bearer" {
-				if claims, err := ValidateToken(bearerToken[1]); err == nil {
+			if tokenString, ok := jwtauth.BearerToken(authHeader); ok {
+				if claims, err := ValidateToken(tokenString); err == nil {
 					c.Set("claims", claims)
 					c.Set("user_id", claims.UserID)
 					c.Set("authenticated", true)
@@ -368,8 +908,22 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if violations := passwordPolicy.Violations(req.Password); len(violations) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Password does not meet the password policy",
+			"field":      "password",
+			"violations": violations,
+		})
+		return
+	}
+
 	// Check if user exists
-	if _, exists := users[req.Email]; exists {
+	var count int64
+	if err := db.Model(&User{}).Where("email = ?", req.Email).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user"})
+		return
+	}
+	if count > 0 {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
 	}
@@ -383,14 +937,16 @@ func Register(c *gin.Context) {
 
 	// Create user
 	user := &User{
-		ID:       uint(len(users) + 1),
 		Email:    req.Email,
 		Username: req.Username,
 		Password: string(hashedPassword),
 		Role:     "user",
 	}
 
-	users[req.Email] = user
+	if err := db.Create(user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
 
 	// Generate tokens
 	tokens, err := GenerateTokenPair(user)
@@ -419,21 +975,44 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Find user
-	user, exists := users[req.Email]
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
+	// Brute-force protection: both the account and the client IP can be locked out
+	ctx := c.Request.Context()
+	emailKey := "email:" + strings.ToLower(req.Email)
+	ipKey := "ip:" + c.ClientIP()
+	for _, key := range []string{emailKey, ipKey} {
+		if retryAfter, err := loginLimiter.Locked(ctx, key); err == nil && retryAfter > 0 {
+			tooManyLoginAttempts(c, retryAfter)
+			return
+		}
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	// Find user and verify password
+	var user User
+	err := db.Where("email = ?", req.Email).First(&user).Error
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	}
+	if err != nil {
+		var lockout time.Duration
+		for _, key := range []string{emailKey, ipKey} {
+			if d, err := loginLimiter.RecordFailure(ctx, key); err == nil && d > lockout {
+				lockout = d
+			}
+		}
+		if lockout > 0 {
+			tooManyLoginAttempts(c, lockout)
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
+	// Only the account counter is reset, so logging into one's own account
+	// doesn't clear failures an IP accumulated against other accounts
+	loginLimiter.Reset(ctx, emailKey)
+
 	// Generate tokens
-	tokens, err := GenerateTokenPair(user)
+	tokens, err := GenerateTokenPair(&user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 		return
@@ -451,6 +1030,16 @@ func Login(c *gin.Context) {
 	})
 }
 
+// tooManyLoginAttempts responds 429 with the remaining lockout
+func tooManyLoginAttempts(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts",
+		"retry_after": seconds,
+	})
+}
+
 // Refresh token handler
 func RefreshToken(c *gin.Context) {
 	var req RefreshRequest
@@ -461,30 +1050,28 @@ func RefreshToken(c *gin.Context) {
 
 	// Validate refresh token
 	claims, err := ValidateRefreshToken(req.RefreshToken)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please log in again"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
 	}
 
 	// Find user
-	var user *User
-	for _, u := range users {
-		if u.ID == claims.UserID {
-			user = u
-			break
-		}
-	}
-
-	if user == nil {
+	var user User
+	if err := db.First(&user, claims.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	// Revoke old refresh token
-	RevokeRefreshToken(req.RefreshToken)
-
-	// Generate new tokens
-	tokens, err := GenerateTokenPair(user)
+	// Rotate: the old refresh token is replaced by a new one in the same family
+	tokens, err := RotateTokenPair(&user, claims.ID)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please log in again"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 		return
@@ -504,14 +1091,140 @@ func Logout(c *gin.Context) {
 	}
 	c.ShouldBindJSON(&req)
 
-	// Revoke refresh token if provided
+	claims := c.MustGet("claims").(*Claims)
+
+	// Revoke refresh token if provided (only the caller's own)
 	if req.RefreshToken != "" {
-		RevokeRefreshToken(req.RefreshToken)
+		if refreshClaims, err := ValidateRefreshToken(req.RefreshToken); err == nil && refreshClaims.UserID == claims.UserID {
+			RevokeRefreshToken(refreshClaims.ID)
+		}
 	}
 
+	// Revoke the access token used for this request
+	revocationStore.Revoke(claims.ID, claims.ExpiresAt.Time)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
+// LogoutAll revokes every outstanding access and refresh token of the current user
+func LogoutAll(c *gin.Context) {
+	claims := c.MustGet("claims").(*Claims)
+
+	revoked := revocationStore.RevokeAllForUser(claims.UserID)
+	if err := RevokeUserRefreshTokens(claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Logged out from all sessions",
+		"revoked_tokens": revoked,
+	})
+}
+
+// ForgotPassword issues a password reset token. The response is the same
+// whether or not the email exists, so it can't be used to enumerate accounts.
+func ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "If the email is registered, a reset link has been sent"}
+
+	var user User
+	if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token := generateTokenID()
+	record := &PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetExpiry),
+	}
+	if err := db.Create(record).Error; err != nil {
+		log.Printf("failed to store password reset token: %v", err)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if err := sendPasswordResetEmail(&user, token); err != nil {
+		log.Printf("failed to send password reset email: %v", err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword verifies a reset token, sets the new password and
+// signs the user out everywhere
+func ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	errInvalidResetToken := errors.New("invalid or expired reset token")
+	var record PasswordResetToken
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", hashToken(req.Token)).First(&record).Error; err != nil {
+			return errInvalidResetToken
+		}
+		if record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+			return errInvalidResetToken
+		}
+
+		// Mark as used only if nobody else used it concurrently
+		now := time.Now()
+		result := tx.Model(&PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidResetToken
+		}
+
+		// Invalidate any other outstanding reset tokens of the user
+		if err := tx.Model(&PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", record.UserID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&User{}).Where("id = ?", record.UserID).
+			Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&RefreshTokenRecord{}).
+			Where("user_id = ? AND revoked = ?", record.UserID, false).
+			Update("revoked", true).Error
+	})
+	if errors.Is(err, errInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	revocationStore.RevokeAllForUser(record.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
+
 // Protected route handlers
 func GetProfile(c *gin.Context) {
 	claims, _ := c.Get("claims")
@@ -532,6 +1245,150 @@ func GetProfile(c *gin.Context) {
 	})
 }
 
+// DeleteUser removes a user and revokes their refresh tokens
+func DeleteUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	result := db.Delete(&User{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	RevokeUserRefreshTokens(uint(id))
+	revocationStore.RevokeAllForUser(uint(id))
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// signingKeySet returns the key set, responding 404 when key rotation is disabled
+func signingKeySet(c *gin.Context) (*jwtauth.KeySet, bool) {
+	if jwtConfig.Keys == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Key rotation is not enabled"})
+		return nil, false
+	}
+	return jwtConfig.Keys, true
+}
+
+func signingKeyResponse(key jwtauth.SigningKey, current bool) gin.H {
+	response := gin.H{
+		"kid":        key.ID,
+		"current":    current,
+		"created_at": key.CreatedAt,
+	}
+	if !key.RotatedAt.IsZero() {
+		response["rotated_at"] = key.RotatedAt
+		response["retirable_at"] = key.RotatedAt.Add(maxTokenLifetime())
+	}
+	return response
+}
+
+// ListSigningKeys lists the keys tokens are currently verified with
+func ListSigningKeys(c *gin.Context) {
+	keys, ok := signingKeySet(c)
+	if !ok {
+		return
+	}
+
+	currentID := keys.Current().ID
+	keyList := []gin.H{}
+	for _, key := range keys.Keys() {
+		keyList = append(keyList, signingKeyResponse(key, key.ID == currentID))
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keyList})
+}
+
+type RotateKeyRequest struct {
+	KID string `json:"kid"`
+}
+
+// RotateSigningKey generates a new key and signs new tokens with it;
+// the previous key keeps verifying until it is retired
+func RotateSigningKey(c *gin.Context) {
+	keys, ok := signingKeySet(c)
+	if !ok {
+		return
+	}
+
+	var req RotateKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.KID == "" {
+		req.KID = generateTokenID()
+	}
+
+	key, err := generateSigningKey(req.KID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate signing key"})
+		return
+	}
+	if err := keys.Rotate(key); err != nil {
+		if errors.Is(err, jwtauth.ErrDuplicateKeyID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Key ID already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate signing key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, signingKeyResponse(keys.Current(), true))
+}
+
+// RetireSigningKey removes a rotated-out key once every token it signed has expired.
+// ?force=true retires it immediately, invalidating those tokens (e.g. after a leak).
+func RetireSigningKey(c *gin.Context) {
+	keys, ok := signingKeySet(c)
+	if !ok {
+		return
+	}
+
+	kid := c.Param("kid")
+	key, found := keys.Lookup(kid)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Signing key not found"})
+		return
+	}
+	if kid == keys.Current().ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot retire the current signing key; rotate first"})
+		return
+	}
+
+	retirableAt := key.RotatedAt.Add(maxTokenLifetime())
+	if force, _ := strconv.ParseBool(c.Query("force")); !force && time.Now().Before(retirableAt) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "Tokens signed with this key may still be valid",
+			"retirable_at": retirableAt,
+		})
+		return
+	}
+
+	if err := keys.Retire(kid); err != nil {
+		switch {
+		case errors.Is(err, jwtauth.ErrUnknownKeyID):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Signing key not found"})
+		case errors.Is(err, jwtauth.ErrCurrentKey):
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot retire the current signing key; rotate first"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retire signing key"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Signing key retired", "kid": kid})
+}
+
 func AdminOnly(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Admin access granted",
@@ -569,6 +1426,8 @@ func setupRouter() *gin.Engine {
 		public.POST("/register", Register)
 		public.POST("/login", Login)
 		public.POST("/refresh", RefreshToken)
+		public.POST("/forgot-password", ForgotPassword)
+		public.POST("/reset-password", ResetPassword)
 		public.GET("/public", OptionalAuthMiddleware(), PublicEndpoint)
 	}
 
@@ -577,6 +1436,7 @@ func setupRouter() *gin.Engine {
 	protected.Use(AuthMiddleware())
 	{
 		protected.POST("/logout", Logout)
+		protected.POST("/logout-all", LogoutAll)
 		protected.GET("/profile", GetProfile)
 		protected.GET("/protected", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "This is a protected endpoint"})
@@ -585,9 +1445,15 @@ func setupRouter() *gin.Engine {
 
 	// Admin routes
 	admin := router.Group("/api/v1/admin")
-	admin.Use(AuthMiddleware(), RoleMiddleware("admin"))
+	admin.Use(AuthMiddleware())
 	{
-		admin.GET("/users", func(c *gin.Context) {
+		admin.GET("/users", RequirePermission("users:read"), func(c *gin.Context) {
+			var users []User
+			if err := db.Order("id").Find(&users).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+				return
+			}
+
 			userList := []gin.H{}
 			for _, user := range users {
 				userList = append(userList, gin.H{
@@ -599,7 +1465,11 @@ func setupRouter() *gin.Engine {
 			}
 			c.JSON(http.StatusOK, userList)
 		})
-		admin.GET("/dashboard", AdminOnly)
+		admin.DELETE("/users/:id", RequirePermission("users:delete"), DeleteUser)
+		admin.GET("/dashboard", RequirePermission("dashboard:read"), AdminOnly)
+		admin.GET("/keys", RequirePermission("keys:read"), ListSigningKeys)
+		admin.POST("/keys", RequirePermission("keys:rotate"), RotateSigningKey)
+		admin.DELETE("/keys/:kid", RequirePermission("keys:retire"), RetireSigningKey)
 	}
 
 	// Health check
@@ -612,18 +1482,76 @@ func setupRouter() *gin.Engine {
 
 	// JWT info endpoint
 	router.GET("/jwt/info", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
+		info := gin.H{
 			"issuer":               jwtConfig.Issuer,
 			"audience":             jwtConfig.Audience,
 			"access_token_expiry":  jwtConfig.AccessTokenExpiry.String(),
 			"refresh_token_expiry": jwtConfig.RefreshTokenExpiry.String(),
-			"algorithm":            "HS256",
-		})
+			"algorithm":            jwtConfig.Algorithm,
+		}
+		if jwtConfig.Keys != nil {
+			info["current_kid"] = jwtConfig.Keys.Current().ID
+		}
+		c.JSON(http.StatusOK, info)
 	})
 
 	return router
 }
 
+// ============================================================================
+// Database
+// ============================================================================
+
+// initDB opens the database, migrates the schema and seeds the demo users
+func initDB(dsn string) (*gorm.DB, error) {
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := database.AutoMigrate(&User{}, &RefreshTokenRecord{}, &PasswordResetToken{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if err := seedUsers(database); err != nil {
+		return nil, fmt.Errorf("failed to seed users: %w", err)
+	}
+
+	return database, nil
+}
+
+// seedUsers creates the demo accounts if they don't exist yet
+func seedUsers(database *gorm.DB) error {
+	seeds := []struct {
+		email, username, password, role string
+	}{
+		{"admin@example.com", "admin", "admin123", "admin"},
+		{"user@example.com", "user", "user123", "user"},
+	}
+
+	for _, seed := range seeds {
+		var count int64
+		if err := database.Model(&User{}).Where("email = ?", seed.email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(seed.password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user := &User{Email: seed.email, Username: seed.username, Password: string(hashed), Role: seed.role}
+		if err := database.Create(user).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ============================================================================
 // Utility Functions
 // ============================================================================
@@ -640,10 +1568,36 @@ func getEnv(key, defaultValue string) string {
 // ============================================================================
 
 func main() {
+	// RS256: JWT_ALGORITHM=RS256 JWT_PRIVATE_KEY_PATH=private.pem JWT_PUBLIC_KEY_PATH=public.pem
+	if err := jwtConfig.LoadRSAKeys(os.Getenv("JWT_PRIVATE_KEY_PATH"), os.Getenv("JWT_PUBLIC_KEY_PATH")); err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	// Tokens carry a kid so keys can be rotated via /api/v1/admin/keys
+	jwtConfig.Keys = jwtauth.NewKeySet(initialSigningKey(getEnv("JWT_KEY_ID", "initial")))
+
+	// e.g. PASSWORD_MIN_LENGTH=12 PASSWORD_REQUIRE_SYMBOL=true
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n > 0 {
+		passwordPolicy.MinLength = n
+	}
+	if v, err := strconv.ParseBool(os.Getenv("PASSWORD_REQUIRE_SYMBOL")); err == nil {
+		passwordPolicy.RequireSymbol = v
+	}
+
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")})
+		loginLimiter = NewRedisLoginLimiter(client, maxLoginAttempts, loginLockout)
+	}
+
+	database, err := initDB(getEnv("DATABASE_DSN", "auth.db"))
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	db = database
+
 	router := setupRouter()
 
 	log.Println("🚀 JWT Authentication Server starting on :8080")
-	log.Println("🔑 JWT configuration loaded")
+	log.Printf("🔑 JWT configuration loaded (%s)", jwtConfig.Algorithm)
 	log.Println("")
 	log.Println("Test credentials:")
 	log.Println("  Admin: admin@example.com / admin123")
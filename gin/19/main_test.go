@@ -0,0 +1,748 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/gin-playground/pkg/jwtauth"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	useTestDB(t, fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_")))
+
+	saved := loginLimiter
+	loginLimiter = NewMemoryLoginLimiter(maxLoginAttempts, loginLockout)
+	t.Cleanup(func() { loginLimiter = saved })
+
+	return setupRouter()
+}
+
+// useTestDB points the global db at dsn until the test ends
+func useTestDB(t *testing.T, dsn string) {
+	t.Helper()
+	database, err := initDB(dsn)
+	if err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	closeDB(t, database)
+
+	saved := db
+	db = database
+	t.Cleanup(func() { db = saved })
+}
+
+func closeDB(t *testing.T, database *gorm.DB) {
+	t.Helper()
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+func doJSON(r http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// testPassword satisfies DefaultPasswordPolicy
+const testPassword = "Tester-Pass42"
+
+// registerUser registers a user and returns the issued token pair
+func registerUser(t *testing.T, r http.Handler, email, password string) TokenResponse {
+	t.Helper()
+	w := doJSON(r, "POST", "/api/v1/register", "", RegisterRequest{
+		Email:    email,
+		Username: "tester",
+		Password: password,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register failed: %d %s", w.Code, w.Body.String())
+	}
+	return decodeTokens(t, w)
+}
+
+func loginUser(t *testing.T, r http.Handler, email, password string) TokenResponse {
+	t.Helper()
+	w := doJSON(r, "POST", "/api/v1/login", "", LoginRequest{Email: email, Password: password})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", w.Code, w.Body.String())
+	}
+	return decodeTokens(t, w)
+}
+
+func decodeTokens(t *testing.T, w *httptest.ResponseRecorder) TokenResponse {
+	t.Helper()
+	var resp struct {
+		Tokens TokenResponse `json:"tokens"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode tokens: %v", err)
+	}
+	return resp.Tokens
+}
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	r := newTestRouter(t)
+	tokens := registerUser(t, r, "logout@example.com", testPassword)
+
+	if w := doJSON(r, "GET", "/api/v1/profile", tokens.AccessToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected token to work before logout, got %d", w.Code)
+	}
+	if w := doJSON(r, "POST", "/api/v1/logout", tokens.AccessToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("logout failed: %d", w.Code)
+	}
+	if w := doJSON(r, "GET", "/api/v1/profile", tokens.AccessToken, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestLogoutAllRevokesEveryAccessToken(t *testing.T) {
+	r := newTestRouter(t)
+	first := registerUser(t, r, "logout-all@example.com", testPassword)
+	second := loginUser(t, r, "logout-all@example.com", testPassword)
+	other := registerUser(t, r, "bystander@example.com", testPassword)
+
+	for _, token := range []string{first.AccessToken, second.AccessToken} {
+		if w := doJSON(r, "GET", "/api/v1/profile", token, nil); w.Code != http.StatusOK {
+			t.Fatalf("expected token to work before logout-all, got %d", w.Code)
+		}
+	}
+
+	if w := doJSON(r, "POST", "/api/v1/logout-all", first.AccessToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("logout-all failed: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, token := range []string{first.AccessToken, second.AccessToken} {
+		if w := doJSON(r, "GET", "/api/v1/profile", token, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected revoked token to be rejected, got %d", w.Code)
+		}
+	}
+	if w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: second.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected refresh token to be revoked, got %d", w.Code)
+	}
+	if w := doJSON(r, "GET", "/api/v1/profile", other.AccessToken, nil); w.Code != http.StatusOK {
+		t.Errorf("other users' tokens should be unaffected, got %d", w.Code)
+	}
+}
+
+// useRS256 switches jwtConfig to RS256 with a fresh key pair for the duration of the test
+func useRS256(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "private.pem")
+	pubPath := filepath.Join(dir, "public.pem")
+	pubDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644)
+
+	saved := jwtConfig
+	t.Cleanup(func() { jwtConfig = saved })
+
+	jwtConfig.Algorithm = AlgorithmRS256
+	if err := jwtConfig.LoadRSAKeys(privPath, pubPath); err != nil {
+		t.Fatalf("failed to load private key: %v", err)
+	}
+	return key
+}
+
+func TestRS256TokenVerifiesWithPublicKey(t *testing.T) {
+	useRS256(t)
+	user := &User{ID: 42, Email: "rs@example.com", Username: "rs", Role: "user"}
+
+	token, _, err := generateAccessToken(user)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	// A verifying-only service has the public key but no private key
+	jwtConfig.PrivateKey = nil
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("expected RS256 token to verify with public key, got %v", err)
+	}
+	if claims.UserID != 42 {
+		t.Errorf("expected user 42, got %d", claims.UserID)
+	}
+	if _, _, err := generateAccessToken(user); err == nil {
+		t.Error("expected signing to fail without a private key")
+	}
+}
+
+func TestRS256RejectsAlgorithmConfusion(t *testing.T) {
+	key := useRS256(t)
+	claims := Claims{
+		UserID: 1,
+		Role:   "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    jwtConfig.Issuer,
+			Audience:  jwtConfig.Audience,
+		},
+	}
+
+	// Attacker signs an HS256 token using the (public) RSA key as the HMAC secret
+	pubDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(pubPEM)
+	if _, err := ValidateToken(forged); err == nil {
+		t.Error("expected HS256 token to be rejected when RS256 is configured")
+	}
+
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := ValidateToken(unsigned); err == nil {
+		t.Error("expected alg=none token to be rejected")
+	}
+
+	// And the other way around: an RS256 token is rejected when HS256 is configured
+	rsToken, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	jwtConfig.Algorithm = AlgorithmHS256
+	if _, err := ValidateToken(rsToken); err == nil {
+		t.Error("expected RS256 token to be rejected when HS256 is configured")
+	}
+}
+
+func TestRegisteredUserSurvivesStoreReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		dsn       func(t *testing.T) string
+		wantLogin int
+	}{
+		{
+			name:      "persisted database",
+			dsn:       func(t *testing.T) string { return filepath.Join(t.TempDir(), "auth.db") },
+			wantLogin: http.StatusOK,
+		},
+		{
+			// An in-memory database is lost when its connections close,
+			// just like the old process-local maps
+			name:      "in-memory database",
+			dsn:       func(t *testing.T) string { return "file:reset-test?mode=memory&cache=shared" },
+			wantLogin: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn := tt.dsn(t)
+
+			first, err := initDB(dsn)
+			if err != nil {
+				t.Fatalf("failed to init db: %v", err)
+			}
+			db = first
+			tokens := registerUser(t, setupRouter(), "persist@example.com", testPassword)
+
+			// Simulate a restart: drop the connection and open the store again
+			sqlDB, _ := first.DB()
+			sqlDB.Close()
+			useTestDB(t, dsn)
+			r := setupRouter()
+
+			w := doJSON(r, "POST", "/api/v1/login", "", LoginRequest{Email: "persist@example.com", Password: testPassword})
+			if w.Code != tt.wantLogin {
+				t.Fatalf("expected login status %d after reset, got %d", tt.wantLogin, w.Code)
+			}
+
+			wantRefresh := http.StatusOK
+			if tt.wantLogin != http.StatusOK {
+				wantRefresh = http.StatusUnauthorized
+			}
+			if w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: tokens.RefreshToken}); w.Code != wantRefresh {
+				t.Errorf("expected refresh status %d after reset, got %d", wantRefresh, w.Code)
+			}
+		})
+	}
+}
+
+func TestSeededUsersCanLogIn(t *testing.T) {
+	r := newTestRouter(t)
+	loginUser(t, r, "admin@example.com", "admin123")
+	loginUser(t, r, "user@example.com", "user123")
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	r := newTestRouter(t)
+	original := registerUser(t, r, "rotate@example.com", testPassword)
+	otherSession := loginUser(t, r, "rotate@example.com", testPassword)
+
+	w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: original.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("first refresh failed: %d %s", w.Code, w.Body.String())
+	}
+	rotated := decodeTokens(t, w)
+
+	w = doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: rotated.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("second refresh failed: %d %s", w.Code, w.Body.String())
+	}
+	latest := decodeTokens(t, w)
+
+	// Attacker replays the stolen, already rotated original token
+	w = doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: original.RefreshToken})
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "reuse") {
+		t.Fatalf("expected reuse to be detected, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Every token in the family is now dead, forcing re-login
+	for _, token := range []string{rotated.RefreshToken, latest.RefreshToken} {
+		if w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: token}); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected family member to be revoked, got %d", w.Code)
+		}
+	}
+
+	var record RefreshTokenRecord
+	token, _ := parseToken(latest.RefreshToken, &RefreshClaims{})
+	db.First(&record, "id = ?", token.Claims.(*RefreshClaims).ID)
+	if record.ParentID == "" || !record.Revoked {
+		t.Errorf("expected latest token to record its parent and be revoked, got %+v", record)
+	}
+
+	// A separate login starts its own family and is unaffected
+	if w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: otherSession.RefreshToken}); w.Code != http.StatusOK {
+		t.Errorf("other session should be unaffected, got %d", w.Code)
+	}
+}
+
+// tokenWithPermissions signs an access token carrying the given scopes
+func tokenWithPermissions(t *testing.T, userID uint, permissions ...string) string {
+	t.Helper()
+	token, err := signToken(Claims{
+		UserID:      userID,
+		Role:        "custom",
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    jwtConfig.Issuer,
+			Audience:  jwtConfig.Audience,
+			ID:        generateTokenID(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		want        int
+	}{
+		{name: "exact scope", permissions: []string{"users:read", "users:delete"}, want: http.StatusOK},
+		{name: "missing scope", permissions: []string{"users:read"}, want: http.StatusForbidden},
+		{name: "resource wildcard", permissions: []string{"users:*"}, want: http.StatusOK},
+		{name: "other resource wildcard", permissions: []string{"orders:*"}, want: http.StatusForbidden},
+		{name: "global wildcard", permissions: []string{"*"}, want: http.StatusOK},
+		{name: "no scopes", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(t)
+			victim := registerUser(t, r, "victim@example.com", testPassword)
+			claims, _ := ValidateToken(victim.AccessToken)
+
+			token := tokenWithPermissions(t, 99, tt.permissions...)
+			w := doJSON(r, "DELETE", fmt.Sprintf("/api/v1/admin/users/%d", claims.UserID), token, nil)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRolePermissionsAreIssuedInToken(t *testing.T) {
+	r := newTestRouter(t)
+	admin := loginUser(t, r, "admin@example.com", "admin123")
+	user := loginUser(t, r, "user@example.com", "user123")
+
+	if w := doJSON(r, "GET", "/api/v1/admin/users", admin.AccessToken, nil); w.Code != http.StatusOK {
+		t.Errorf("admin should list users, got %d", w.Code)
+	}
+	if w := doJSON(r, "GET", "/api/v1/admin/users", user.AccessToken, nil); w.Code != http.StatusForbidden {
+		t.Errorf("plain user should be forbidden, got %d", w.Code)
+	}
+
+	claims, err := ValidateToken(user.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasPermission(claims.Permissions, "profile:read") {
+		t.Errorf("expected user scopes in token, got %v", claims.Permissions)
+	}
+}
+
+func TestLoginLockoutAfterFailedAttempts(t *testing.T) {
+	r := newTestRouter(t)
+	now := time.Now()
+	limiter := loginLimiter.(*MemoryLoginLimiter)
+	limiter.now = func() time.Time { return now }
+
+	wrong := LoginRequest{Email: "user@example.com", Password: "wrong-password"}
+	for i := 1; i < maxLoginAttempts; i++ {
+		if w := doJSON(r, "POST", "/api/v1/login", "", wrong); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, w.Code)
+		}
+	}
+	if w := doJSON(r, "POST", "/api/v1/login", "", wrong); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected lockout on attempt %d, got %d", maxLoginAttempts, w.Code)
+	}
+
+	// Correct password during lockout is still rejected
+	w := doJSON(r, "POST", "/api/v1/login", "", LoginRequest{Email: "user@example.com", Password: "user123"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 during lockout, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != strconv.Itoa(int(loginLockout.Seconds())) {
+		t.Errorf("expected Retry-After %v, got %q", loginLockout.Seconds(), got)
+	}
+
+	// After the lockout expires the correct password works again
+	now = now.Add(loginLockout)
+	loginUser(t, r, "user@example.com", "user123")
+}
+
+func TestMemoryLoginLimiterEvictsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := NewMemoryLoginLimiter(maxLoginAttempts, loginLockout)
+	limiter.now = func() time.Time { return now }
+
+	limiter.RecordFailure(ctx, "ip:198.51.100.1")
+	for i := 0; i < maxLoginAttempts; i++ {
+		limiter.RecordFailure(ctx, "email:locked@example.com")
+	}
+
+	// Entries are dropped only once their counting window has passed
+	now = now.Add(loginLockout / 2)
+	limiter.RecordFailure(ctx, "ip:198.51.100.2")
+	now = now.Add(loginLockout / 2)
+	limiter.Locked(ctx, "ip:203.0.113.9")
+	if _, ok := limiter.attempts["ip:198.51.100.1"]; ok {
+		t.Error("expected expired single-failure entry to be evicted")
+	}
+	if _, ok := limiter.attempts["ip:198.51.100.2"]; !ok {
+		t.Error("entry still inside its window should be kept")
+	}
+
+	now = now.Add(loginLockout)
+	limiter.Locked(ctx, "ip:203.0.113.9")
+	if len(limiter.attempts) != 0 {
+		t.Errorf("expected every expired entry to be evicted, got %d", len(limiter.attempts))
+	}
+}
+
+func TestSuccessfulLoginResetsFailures(t *testing.T) {
+	r := newTestRouter(t)
+	wrong := LoginRequest{Email: "user@example.com", Password: "wrong-password"}
+
+	for round := 0; round < 2; round++ {
+		for i := 1; i < maxLoginAttempts; i++ {
+			doJSON(r, "POST", "/api/v1/login", "", wrong)
+		}
+		loginUser(t, r, "user@example.com", "user123")
+
+		// The IP counter isn't reset by a successful login; use a fresh one
+		// so only the account counter is exercised here
+		loginLimiter.Reset(context.Background(), "ip:192.0.2.1")
+	}
+}
+
+// captureResetTokens records reset tokens sent to users during the test
+func captureResetTokens(t *testing.T) map[string]string {
+	t.Helper()
+	sent := make(map[string]string)
+	saved := sendPasswordResetEmail
+	sendPasswordResetEmail = func(user *User, token string) error {
+		sent[user.Email] = token
+		return nil
+	}
+	t.Cleanup(func() { sendPasswordResetEmail = saved })
+	return sent
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	r := newTestRouter(t)
+	sent := captureResetTokens(t)
+	session := loginUser(t, r, "user@example.com", "user123")
+
+	known := doJSON(r, "POST", "/api/v1/forgot-password", "", ForgotPasswordRequest{Email: "user@example.com"})
+	unknown := doJSON(r, "POST", "/api/v1/forgot-password", "", ForgotPasswordRequest{Email: "nobody@example.com"})
+	if known.Code != http.StatusOK || unknown.Code != http.StatusOK || known.Body.String() != unknown.Body.String() {
+		t.Fatalf("forgot-password must not reveal whether the email exists: %d %s / %d %s",
+			known.Code, known.Body.String(), unknown.Code, unknown.Body.String())
+	}
+	if _, ok := sent["nobody@example.com"]; ok {
+		t.Error("no token should be issued for an unknown email")
+	}
+
+	token := sent["user@example.com"]
+	var stored PasswordResetToken
+	db.First(&stored, "user_id = ?", 2)
+	if stored.TokenHash == token || stored.TokenHash != hashToken(token) {
+		t.Errorf("reset token must be stored hashed")
+	}
+
+	w := doJSON(r, "POST", "/api/v1/reset-password", "", ResetPasswordRequest{Token: token, NewPassword: "new-password"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(r, "POST", "/api/v1/login", "", LoginRequest{Email: "user@example.com", Password: "user123"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("old password should no longer work, got %d", w.Code)
+	}
+	loginUser(t, r, "user@example.com", "new-password")
+
+	if w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("existing refresh tokens should be revoked, got %d", w.Code)
+	}
+
+	// Reusing the same token fails
+	w = doJSON(r, "POST", "/api/v1/reset-password", "", ResetPasswordRequest{Token: token, NewPassword: "another-password"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected reused token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestPasswordResetTokenExpires(t *testing.T) {
+	r := newTestRouter(t)
+	sent := captureResetTokens(t)
+
+	doJSON(r, "POST", "/api/v1/forgot-password", "", ForgotPasswordRequest{Email: "user@example.com"})
+	token := sent["user@example.com"]
+
+	db.Model(&PasswordResetToken{}).Where("token_hash = ?", hashToken(token)).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	w := doJSON(r, "POST", "/api/v1/reset-password", "", ResetPasswordRequest{Token: token, NewPassword: "new-password"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected expired token to be rejected, got %d", w.Code)
+	}
+	loginUser(t, r, "user@example.com", "user123")
+}
+
+func TestRegisterEnforcesPasswordPolicy(t *testing.T) {
+	r := newTestRouter(t)
+
+	tests := []struct {
+		name       string
+		password   string
+		violations []string
+	}{
+		{"too short", "Ab1", []string{"must be at least 8 characters"}},
+		{"common password", "Password123", []string{"is too common"}},
+		{"missing classes", "lowercaseonly", []string{"must contain both upper and lower case letters", "must contain a digit"}},
+		{"longer than bcrypt allows", "Aa1" + strings.Repeat("x", maxPasswordBytes), []string{fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(r, "POST", "/api/v1/register", "", RegisterRequest{Email: "policy@example.com", Username: "policy", Password: tt.password})
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Field      string   `json:"field"`
+				Violations []string `json:"violations"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if resp.Field != "password" {
+				t.Errorf("expected field password, got %q", resp.Field)
+			}
+			if strings.Join(resp.Violations, "; ") != strings.Join(tt.violations, "; ") {
+				t.Errorf("expected violations %q, got %q", tt.violations, resp.Violations)
+			}
+		})
+	}
+
+	var count int64
+	db.Model(&User{}).Where("email = ?", "policy@example.com").Count(&count)
+	if count != 0 {
+		t.Fatalf("rejected registrations must not create a user, found %d", count)
+	}
+
+	tokens := registerUser(t, r, "policy@example.com", "Compliant-Pass9")
+	if tokens.AccessToken == "" {
+		t.Error("expected tokens for a compliant password")
+	}
+}
+
+func TestPasswordPolicyIsConfigurable(t *testing.T) {
+	saved := passwordPolicy
+	t.Cleanup(func() { passwordPolicy = saved })
+
+	passwordPolicy = PasswordPolicy{MinLength: 12, RequireSymbol: true}
+	if got := passwordPolicy.Violations("Tester42"); len(got) != 2 {
+		t.Errorf("expected length and symbol violations, got %q", got)
+	}
+	if got := passwordPolicy.Violations("correct horse battery!"); got != nil {
+		t.Errorf("expected passphrase to pass, got %q", got)
+	}
+
+	// With RejectCommon off the embedded list is ignored
+	if got := (PasswordPolicy{MinLength: 6}).Violations("password"); got != nil {
+		t.Errorf("expected no violations, got %q", got)
+	}
+}
+
+// useKeyRotation enables a key set with kid "k1" for the duration of the test
+func useKeyRotation(t *testing.T) {
+	t.Helper()
+	saved := jwtConfig
+	t.Cleanup(func() { jwtConfig = saved })
+	jwtConfig.Keys = jwtauth.NewKeySet(initialSigningKey("k1"))
+}
+
+func TestKeyRotationOverlapAndRetire(t *testing.T) {
+	r := newTestRouter(t)
+	useKeyRotation(t)
+
+	old := loginUser(t, r, "user@example.com", "user123")
+	admin := tokenWithPermissions(t, 1, "keys:*")
+
+	w := doJSON(r, "POST", "/api/v1/admin/keys", admin, RotateKeyRequest{KID: "k2"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("rotate failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, "POST", "/api/v1/admin/keys", admin, RotateKeyRequest{KID: "k2"}); w.Code != http.StatusConflict {
+		t.Errorf("expected duplicate kid to be rejected, got %d", w.Code)
+	}
+
+	// During the overlap the token signed with k1 still verifies
+	if w := doJSON(r, "GET", "/api/v1/profile", old.AccessToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected k1 token to verify during overlap, got %d", w.Code)
+	}
+
+	current := loginUser(t, r, "user@example.com", "user123")
+	token, _, _ := jwt.NewParser().ParseUnverified(current.AccessToken, &Claims{})
+	if token.Header["kid"] != "k2" {
+		t.Errorf("expected new tokens to be signed with k2, got kid %v", token.Header["kid"])
+	}
+
+	if w := doJSON(r, "DELETE", "/api/v1/admin/keys/k2", admin, nil); w.Code != http.StatusConflict {
+		t.Errorf("expected retiring the current key to be rejected, got %d", w.Code)
+	}
+	// k1 tokens may still be valid, so retiring needs the max token lifetime to pass or force
+	if w := doJSON(r, "DELETE", "/api/v1/admin/keys/k1", admin, nil); w.Code != http.StatusConflict {
+		t.Fatalf("expected early retire to be rejected, got %d", w.Code)
+	}
+
+	// The admin token was signed with k1 as well, so use a k2 token from here on
+	admin = tokenWithPermissions(t, 1, "keys:*")
+	if w := doJSON(r, "DELETE", "/api/v1/admin/keys/k1?force=true", admin, nil); w.Code != http.StatusOK {
+		t.Fatalf("retire failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(r, "GET", "/api/v1/profile", old.AccessToken, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected k1 token to be rejected after retire, got %d", w.Code)
+	}
+	if w := doJSON(r, "POST", "/api/v1/refresh", "", RefreshRequest{RefreshToken: old.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected k1 refresh token to be rejected after retire, got %d", w.Code)
+	}
+	if w := doJSON(r, "GET", "/api/v1/profile", current.AccessToken, nil); w.Code != http.StatusOK {
+		t.Errorf("expected k2 token to keep working, got %d", w.Code)
+	}
+}
+
+func TestRetireAfterMaxTokenLifetime(t *testing.T) {
+	r := newTestRouter(t)
+	useKeyRotation(t)
+	jwtConfig.Keys = jwtauth.NewKeySet(jwtauth.SigningKey{ID: "k1", Secret: []byte(jwtConfig.SecretKey)})
+	jwtConfig.Keys.Rotate(jwtauth.SigningKey{ID: "k2", Secret: []byte("second-secret")})
+
+	// Shrink the lifetime instead of waiting a week
+	jwtConfig.AccessTokenExpiry = time.Millisecond
+	jwtConfig.RefreshTokenExpiry = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+
+	admin := tokenWithPermissions(t, 1, "keys:retire")
+	if w := doJSON(r, "DELETE", "/api/v1/admin/keys/k1", admin, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected retire after max token lifetime, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, "DELETE", "/api/v1/admin/keys/k1", admin, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected retired key to be gone, got %d", w.Code)
+	}
+}
+
+func TestUnknownKidIsRejected(t *testing.T) {
+	r := newTestRouter(t)
+	useKeyRotation(t)
+
+	claims := Claims{
+		UserID: 1,
+		Role:   "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    jwtConfig.Issuer,
+			Audience:  jwtConfig.Audience,
+		},
+	}
+	for _, kid := range []string{"unknown", ""} {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, _ := token.SignedString([]byte(jwtConfig.SecretKey))
+		if w := doJSON(r, "GET", "/api/v1/protected", signed, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("kid %q: expected 401, got %d", kid, w.Code)
+		}
+	}
+}
+
+func TestKeyRotationWithRS256(t *testing.T) {
+	r := newTestRouter(t)
+	useRS256(t)
+	useKeyRotation(t)
+
+	old := tokenWithPermissions(t, 1, "keys:*")
+	if w := doJSON(r, "POST", "/api/v1/admin/keys", old, nil); w.Code != http.StatusCreated {
+		t.Fatalf("rotate failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, "GET", "/api/v1/admin/keys", tokenWithPermissions(t, 1, "keys:read"), nil); w.Code != http.StatusOK {
+		t.Errorf("expected token signed with generated RSA key to verify, got %d", w.Code)
+	} else if !strings.Contains(w.Body.String(), `"kid":"k1"`) {
+		t.Errorf("expected k1 to be listed during overlap: %s", w.Body.String())
+	}
+	if w := doJSON(r, "GET", "/api/v1/admin/keys", old, nil); w.Code != http.StatusOK {
+		t.Errorf("expected k1 token to verify during overlap, got %d", w.Code)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 	"unicode"
 
+	"example.com/gin-playground/pkg/acceptlang"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/locales/en"
@@ -113,22 +114,141 @@ type FileUpload struct {
 
 var (
 	validate *validator.Validate
-	trans    ut.Translator
+	trans    ut.Translator // 기본 로케일 translator
+	uni      *ut.UniversalTranslator
 )
 
+// 지원 로케일과 기본 로케일
+const defaultLocale = "ko"
+
+var supportedLocales = []string{"ko", "en"}
+
+// CustomValidator는 검증 함수와 로케일별 번역 메시지를 한 번에 등록하기 위한 정의입니다.
+type CustomValidator struct {
+	Tag         string
+	Fn          validator.Func
+	Translation map[string]string // locale → message ({0}은 필드명)
+}
+
+// 커스텀 validator 목록 (새 validator는 여기에만 추가)
+var customValidators = []CustomValidator{
+	{
+		Tag: "strong_password",
+		Fn:  strongPassword,
+		Translation: map[string]string{
+			"ko": "{0}은(는) 대문자, 소문자, 숫자, 특수문자를 포함해야 합니다",
+			"en": "{0} must contain upper and lower case letters, a number and a special character",
+		},
+	},
+	{
+		Tag: "korean_phone",
+		Fn:  koreanPhone,
+		Translation: map[string]string{
+			"ko": "{0}은(는) 올바른 한국 전화번호 형식이어야 합니다",
+			"en": "{0} must be a valid Korean phone number",
+		},
+	},
+	{
+		Tag: "postal_code",
+		Fn:  postalCode,
+		Translation: map[string]string{
+			"ko": "{0}은(는) 5자리 우편번호여야 합니다",
+			"en": "{0} must be a 5-digit postal code",
+		},
+	},
+	{
+		Tag: "category",
+		Fn:  categoryValidator,
+		Translation: map[string]string{
+			"ko": "{0}은(는) 유효한 카테고리여야 합니다",
+			"en": "{0} must be a valid category",
+		},
+	},
+	{
+		Tag: "before_today",
+		Fn:  beforeToday,
+		Translation: map[string]string{
+			"ko": "{0}은(는) 오늘 이전 날짜여야 합니다",
+			"en": "{0} must be a date before today",
+		},
+	},
+	{
+		Tag: "credit_card",
+		Fn:  creditCard,
+		Translation: map[string]string{
+			"ko": "{0}은(는) 유효한 신용카드 번호여야 합니다",
+			"en": "{0} must be a valid credit card number",
+		},
+	},
+}
+
+// RegisterAll은 검증 함수와 지원하는 모든 로케일의 번역을 함께 등록합니다.
+// 번역은 setupTranslator 이후에 호출해야 적용됩니다.
+func RegisterAll(v *validator.Validate, validators []CustomValidator) error {
+	for _, cv := range validators {
+		if err := v.RegisterValidation(cv.Tag, cv.Fn); err != nil {
+			return fmt.Errorf("failed to register validator %q: %w", cv.Tag, err)
+		}
+
+		if uni == nil {
+			continue
+		}
+
+		for _, locale := range supportedLocales {
+			t, found := uni.GetTranslator(locale)
+			if !found {
+				continue
+			}
+
+			message := cv.Translation[locale]
+			if message == "" {
+				message = cv.Translation[defaultLocale]
+			}
+			if message == "" {
+				continue
+			}
+
+			if err := registerTranslation(v, t, cv.Tag, message); err != nil {
+				return fmt.Errorf("failed to register %s translation for %q: %w", locale, cv.Tag, err)
+			}
+		}
+	}
+	return nil
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag, message string) error {
+	return v.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, message, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T(tag, fe.Field())
+			return t
+		},
+	)
+}
+
+func customValidatorTags() []string {
+	tags := make([]string, 0, len(customValidators))
+	for _, cv := range customValidators {
+		tags = append(tags, cv.Tag)
+	}
+	return tags
+}
+
 // Initialize validators
 func initValidators() {
 	// Create validator instance
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		validate = v
 
-		// Register custom validators
-		v.RegisterValidation("strong_password", strongPassword)
-		v.RegisterValidation("korean_phone", koreanPhone)
-		v.RegisterValidation("postal_code", postalCode)
-		v.RegisterValidation("category", categoryValidator)
-		v.RegisterValidation("before_today", beforeToday)
-		v.RegisterValidation("credit_card", creditCard)
+		// Register custom validators (+ translations)
+		if err := RegisterAll(v, customValidators); err != nil {
+			log.Fatal(err)
+		}
+
+		// Register struct-level validators
+		v.RegisterStructValidation(orderStructLevelValidation, Order{})
 
 		// Register custom tag name func
 		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -245,6 +365,22 @@ func creditCard(fl validator.FieldLevel) bool {
 	return sum%10 == 0
 }
 
+// Order struct-level validator (cross-field conditional rules)
+// - 카드 결제 시 청구주소 필수 (주소 자체의 필드 검증은 중첩 구조체 검증으로 수행)
+// - 메모에 할인 언급이 있으면 쿠폰코드 필수
+func orderStructLevelValidation(sl validator.StructLevel) {
+	order := sl.Current().Interface().(Order)
+
+	if order.PaymentMethod == "card" && order.BillingAddr == nil {
+		sl.ReportError(order.BillingAddr, "청구주소", "BillingAddr", "required", "")
+	}
+
+	notes := strings.ToLower(order.Notes)
+	if (strings.Contains(notes, "discount") || strings.Contains(notes, "할인")) && order.CouponCode == "" {
+		sl.ReportError(order.CouponCode, "쿠폰코드", "CouponCode", "required", "")
+	}
+}
+
 // ============================================================================
 // Translator Setup
 // ============================================================================
@@ -252,65 +388,45 @@ func creditCard(fl validator.FieldLevel) bool {
 func setupTranslator() ut.Translator {
 	en := en.New()
 	ko := ko.New()
-	uni := ut.New(en, en, ko)
-
-	// Get translator for Korean
-	trans, _ = uni.GetTranslator("ko")
+	uni = ut.New(en, en, ko)
 
-	// Register translations
+	// 모든 로케일에 기본 번역 등록 (커스텀 번역은 RegisterAll에서 등록)
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		// Korean translations
-		ko_translations.RegisterDefaultTranslations(v, trans)
+		enTrans, _ := uni.GetTranslator("en")
+		en_translations.RegisterDefaultTranslations(v, enTrans)
 
-		// Custom error messages
-		registerCustomTranslations(v, trans)
+		koTrans, _ := uni.GetTranslator("ko")
+		ko_translations.RegisterDefaultTranslations(v, koTrans)
 	}
 
+	trans, _ = uni.GetTranslator(defaultLocale)
 	return trans
 }
 
-func registerCustomTranslations(v *validator.Validate, trans ut.Translator) {
-	translations := []struct {
-		tag         string
-		translation string
-	}{
-		{
-			tag:         "strong_password",
-			translation: "{0}은(는) 대문자, 소문자, 숫자, 특수문자를 포함해야 합니다",
-		},
-		{
-			tag:         "korean_phone",
-			translation: "{0}은(는) 올바른 한국 전화번호 형식이어야 합니다",
-		},
-		{
-			tag:         "postal_code",
-			translation: "{0}은(는) 5자리 우편번호여야 합니다",
-		},
-		{
-			tag:         "category",
-			translation: "{0}은(는) 유효한 카테고리여야 합니다",
-		},
-		{
-			tag:         "before_today",
-			translation: "{0}은(는) 오늘 이전 날짜여야 합니다",
-		},
-		{
-			tag:         "credit_card",
-			translation: "{0}은(는) 유효한 신용카드 번호여야 합니다",
-		},
+// translatorFor는 ?lang= 쿼리 또는 Accept-Language 헤더로 요청의 translator를 선택합니다.
+// 지원하지 않는 로케일이면 기본 로케일로 대체합니다.
+func translatorFor(c *gin.Context) ut.Translator {
+	if uni == nil {
+		return trans
 	}
 
-	for _, t := range translations {
-		v.RegisterTranslation(t.tag, trans,
-			func(ut ut.Translator) error {
-				return ut.Add(t.tag, t.translation, true)
-			},
-			func(ut ut.Translator, fe validator.FieldError) string {
-				t, _ := ut.T(t.tag, fe.Field())
+	candidates := acceptlang.Parse(c.GetHeader("Accept-Language"))
+	if lang := c.Query("lang"); lang != "" {
+		candidates = append([]string{lang}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		// "en-US" → "en"
+		base := strings.ToLower(strings.SplitN(candidate, "-", 2)[0])
+		for _, locale := range supportedLocales {
+			if base == locale {
+				t, _ := uni.GetTranslator(locale)
 				return t
-			},
-		)
+			}
+		}
 	}
+
+	return trans
 }
 
 // ============================================================================
@@ -325,14 +441,80 @@ type ValidationError struct {
 	Value   interface{} `json:"value,omitempty"`
 }
 
+// fieldPath는 StructNamespace("Order.Items[2].Quantity")를
+// JSON(또는 form) 필드 경로("items[2].quantity")로 변환합니다.
+// 경로를 해석할 수 없으면 label 기반 Field()를 그대로 사용합니다.
+func fieldPath(e validator.FieldError, root reflect.Type) string {
+	segments := strings.Split(e.StructNamespace(), ".")
+	if root == nil || len(segments) < 2 {
+		return e.Field()
+	}
+
+	current := root
+	path := make([]string, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		for current.Kind() == reflect.Ptr {
+			current = current.Elem()
+		}
+		if current.Kind() != reflect.Struct {
+			return e.Field()
+		}
+
+		// "Items[2]" → 필드명 "Items", 인덱스 "[2]" (dive 에러)
+		fieldName, indexes := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 {
+			fieldName, indexes = segment[:i], segment[i:]
+		}
+
+		field, ok := current.FieldByName(fieldName)
+		if !ok {
+			return e.Field()
+		}
+		path = append(path, wireName(field)+indexes)
+
+		// 인덱스 개수만큼 slice/array/map의 요소 타입으로 이동
+		current = field.Type
+		for n := strings.Count(indexes, "["); n > 0; n-- {
+			for current.Kind() == reflect.Ptr {
+				current = current.Elem()
+			}
+			switch current.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				current = current.Elem()
+			default:
+				return e.Field()
+			}
+		}
+	}
+
+	return strings.Join(path, ".")
+}
+
+// wireName은 요청에서 쓰는 필드 이름(json → form → Go 필드명 순)을 반환합니다.
+func wireName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" {
+		name = strings.SplitN(field.Tag.Get("form"), ",", 2)[0]
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
 // Format validation errors
-func formatValidationErrors(err error) []ValidationError {
+func formatValidationErrors(err error, trans ut.Translator, obj interface{}) []ValidationError {
 	var errors []ValidationError
 
+	var root reflect.Type
+	if obj != nil {
+		root = reflect.TypeOf(obj)
+	}
+
 	if errs, ok := err.(validator.ValidationErrors); ok {
 		for _, e := range errs {
 			errors = append(errors, ValidationError{
-				Field:   e.Field(),
+				Field:   fieldPath(e, root),
 				Message: e.Translate(trans),
 				Tag:     e.Tag(),
 				Value:   e.Value(),
@@ -343,6 +525,186 @@ func formatValidationErrors(err error) []ValidationError {
 	return errors
 }
 
+// ============================================================================
+// Validation Schema (프론트엔드용 검증 규칙 문서)
+// ============================================================================
+
+// schemaModels - /validation/schema로 공개하는 요청 모델 (새 모델은 여기에만 추가)
+var schemaModels = []struct {
+	Name  string
+	Model interface{}
+}{
+	{"UserRegistration", UserRegistration{}},
+	{"Product", Product{}},
+	{"Order", Order{}},
+	{"CreditCard", CreditCard{}},
+	{"SearchQuery", SearchQuery{}},
+	{"FileUpload", FileUpload{}},
+}
+
+// ModelSchema - 모델 하나의 필드별 검증 규칙
+type ModelSchema struct {
+	Name   string        `json:"name"`
+	Fields []FieldSchema `json:"fields"`
+}
+
+// FieldSchema - binding 태그를 풀어 쓴 필드 설명
+type FieldSchema struct {
+	Name     string        `json:"name"`
+	Label    string        `json:"label,omitempty"`
+	Type     string        `json:"type"`             // string, integer, number, boolean, array, object
+	Format   string        `json:"format,omitempty"` // time_format (날짜 필드)
+	Required bool          `json:"required"`
+	Rules    []Rule        `json:"rules,omitempty"`
+	Items    *FieldSchema  `json:"items,omitempty"`  // 배열 요소 (dive 이후 규칙)
+	Fields   []FieldSchema `json:"fields,omitempty"` // 중첩 구조체
+}
+
+// Rule - binding 태그 하나 ("min=3", "oneof=a b", "e164|korean_phone")
+type Rule struct {
+	Tag    string   `json:"tag"`
+	Param  string   `json:"param,omitempty"`
+	Values []string `json:"values,omitempty"` // oneof 허용값
+	Field  string   `json:"field,omitempty"`  // eqfield 등 비교 대상의 요청 필드 이름
+	Custom bool     `json:"custom,omitempty"` // customValidators에 등록된 태그
+	AnyOf  []Rule   `json:"any_of,omitempty"` // "|"로 묶인 규칙 중 하나만 통과하면 됨
+}
+
+// 다른 필드와 비교하는 태그 (param이 Go 필드명)
+var crossFieldTags = map[string]bool{
+	"eqfield": true, "nefield": true,
+	"gtfield": true, "gtefield": true,
+	"ltfield": true, "ltefield": true,
+}
+
+// buildSchemas - 등록된 모든 모델의 스키마를 만듭니다.
+func buildSchemas() []ModelSchema {
+	custom := make(map[string]bool, len(customValidators))
+	for _, tag := range customValidatorTags() {
+		custom[tag] = true
+	}
+
+	schemas := make([]ModelSchema, 0, len(schemaModels))
+	for _, m := range schemaModels {
+		schemas = append(schemas, ModelSchema{
+			Name:   m.Name,
+			Fields: describeFields(reflect.TypeOf(m.Model), custom),
+		})
+	}
+	return schemas
+}
+
+func describeFields(t reflect.Type, custom map[string]bool) []FieldSchema {
+	fields := make([]FieldSchema, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		schema := FieldSchema{
+			Name:   wireName(field),
+			Label:  strings.SplitN(field.Tag.Get("label"), ",", 2)[0],
+			Format: field.Tag.Get("time_format"),
+		}
+
+		// dive 앞은 필드 자체, 뒤는 배열 요소의 규칙
+		tags := strings.Split(field.Tag.Get("binding"), ",")
+		var elemTags []string
+		for j, tag := range tags {
+			if tag == "dive" {
+				tags, elemTags = tags[:j], tags[j+1:]
+				break
+			}
+		}
+		schema.Required, schema.Rules = parseRules(tags, t, custom)
+		describeType(&schema, field.Type, custom)
+
+		if schema.Items != nil {
+			_, schema.Items.Rules = parseRules(elemTags, t, custom)
+		}
+		fields = append(fields, schema)
+	}
+	return fields
+}
+
+// describeType - Go 타입을 JSON 타입으로 옮기고 배열 요소/중첩 구조체를 채웁니다.
+func describeType(schema *FieldSchema, t reflect.Type, custom map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema.Type = "string"
+	case reflect.Bool:
+		schema.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema.Type = "number"
+	case reflect.Slice, reflect.Array:
+		schema.Type = "array"
+		schema.Items = &FieldSchema{}
+		describeType(schema.Items, t.Elem(), custom)
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			schema.Type = "string"
+			return
+		}
+		schema.Type = "object"
+		schema.Fields = describeFields(t, custom)
+	default:
+		schema.Type = t.Kind().String()
+	}
+}
+
+// parseRules - binding 태그 목록을 규칙으로 변환 (required는 플래그로, omitempty는 생략)
+func parseRules(tags []string, parent reflect.Type, custom map[string]bool) (bool, []Rule) {
+	required := false
+	var rules []Rule
+	for _, tag := range tags {
+		switch tag {
+		case "":
+			continue
+		case "required":
+			required = true
+			continue
+		case "omitempty":
+			continue
+		}
+
+		alternatives := strings.Split(tag, "|")
+		if len(alternatives) == 1 {
+			rules = append(rules, parseRule(tag, parent, custom))
+			continue
+		}
+
+		or := Rule{Tag: "or"}
+		for _, alt := range alternatives {
+			or.AnyOf = append(or.AnyOf, parseRule(alt, parent, custom))
+		}
+		rules = append(rules, or)
+	}
+	return required, rules
+}
+
+func parseRule(tag string, parent reflect.Type, custom map[string]bool) Rule {
+	name, param, _ := strings.Cut(tag, "=")
+	rule := Rule{Tag: name, Param: param, Custom: custom[name]}
+
+	if name == "oneof" {
+		rule.Values = strings.Fields(param)
+	}
+	if crossFieldTags[name] {
+		if field, ok := parent.FieldByName(param); ok {
+			rule.Field = wireName(field)
+		}
+	}
+	return rule
+}
+
 // ============================================================================
 // Handlers
 // ============================================================================
@@ -354,7 +716,7 @@ func handleUserRegistration(c *gin.Context) {
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
-			"details": formatValidationErrors(err),
+			"details": formatValidationErrors(err, translatorFor(c), user),
 		})
 		return
 	}
@@ -372,7 +734,7 @@ func handleProductCreation(c *gin.Context) {
 	if err := c.ShouldBindJSON(&product); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
-			"details": formatValidationErrors(err),
+			"details": formatValidationErrors(err, translatorFor(c), product),
 		})
 		return
 	}
@@ -390,7 +752,7 @@ func handleOrderCreation(c *gin.Context) {
 	if err := c.ShouldBindJSON(&order); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
-			"details": formatValidationErrors(err),
+			"details": formatValidationErrors(err, translatorFor(c), order),
 		})
 		return
 	}
@@ -416,7 +778,7 @@ func handleSearch(c *gin.Context) {
 	if err := c.ShouldBindQuery(&query); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid search parameters",
-			"details": formatValidationErrors(err),
+			"details": formatValidationErrors(err, translatorFor(c), query),
 		})
 		return
 	}
@@ -443,7 +805,7 @@ func handleCreditCardValidation(c *gin.Context) {
 	if err := c.ShouldBindJSON(&card); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid credit card information",
-			"details": formatValidationErrors(err),
+			"details": formatValidationErrors(err, translatorFor(c), card),
 		})
 		return
 	}
@@ -464,7 +826,7 @@ func handleFileUpload(c *gin.Context) {
 	if err := c.ShouldBindJSON(&file); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid file upload",
-			"details": formatValidationErrors(err),
+			"details": formatValidationErrors(err, translatorFor(c), file),
 		})
 		return
 	}
@@ -503,7 +865,7 @@ func handleDynamicValidation(c *gin.Context) {
 	}
 
 	// Validate dynamically
-	errors := validateMap(data, rules)
+	errors := validateMap(data, rules, translatorFor(c))
 	if len(errors) > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
@@ -519,7 +881,7 @@ func handleDynamicValidation(c *gin.Context) {
 }
 
 // Validate map with dynamic rules
-func validateMap(data map[string]interface{}, rules map[string]string) []ValidationError {
+func validateMap(data map[string]interface{}, rules map[string]string, trans ut.Translator) []ValidationError {
 	var errors []ValidationError
 
 	for field, rule := range rules {
@@ -583,10 +945,14 @@ func setupRouter() *gin.Engine {
 				"ascii", "base64", "ip", "ipv4", "ipv6",
 				"datetime", "timezone",
 			},
-			"custom_validators": []string{
-				"strong_password", "korean_phone", "postal_code",
-				"category", "before_today", "credit_card",
-			},
+			"custom_validators": customValidatorTags(),
+		})
+	})
+
+	// Validation schema endpoint (모델별 필드 규칙)
+	router.GET("/validation/schema", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"models": buildSchemas(),
 		})
 	})
 
@@ -632,22 +998,23 @@ func setupRouter() *gin.Engine {
 // ============================================================================
 
 func main() {
+	// Setup translator (validator 번역 등록 전에 준비)
+	setupTranslator()
+
 	// Initialize validators
 	initValidators()
 
-	// Setup translator
-	setupTranslator()
-
 	// Setup router
 	router := setupRouter()
 
 	log.Println("🚀 Validation Server starting on :8080")
 	log.Println("✅ Custom validators registered")
-	log.Println("🌍 Translator configured (Korean)")
+	log.Println("🌍 Translators configured (ko, en) - select with Accept-Language or ?lang=")
 	log.Println("")
 	log.Println("Try the test endpoints:")
 	log.Println("  GET  /test/data - Get sample valid/invalid data")
 	log.Println("  GET  /validation/rules - List all validation rules")
+	log.Println("  GET  /validation/schema - Describe field rules of each model")
 	log.Println("  POST /api/v1/register - Test user registration")
 	log.Println("")
 
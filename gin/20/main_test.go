@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	setupTranslator()
+	initValidators()
+	os.Exit(m.Run())
+}
+
+type validationResponse struct {
+	Error   string            `json:"error"`
+	Details []ValidationError `json:"details"`
+}
+
+func postJSON(t *testing.T, router http.Handler, path string, body interface{}, headers map[string]string) (*httptest.ResponseRecorder, validationResponse) {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to marshal body: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp validationResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func findDetail(details []ValidationError, tag string) *ValidationError {
+	for i := range details {
+		if details[i].Tag == tag {
+			return &details[i]
+		}
+	}
+	return nil
+}
+
+func validProduct() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "Keyboard",
+		"description": "Mechanical keyboard with RGB",
+		"price":       99.5,
+		"sku":         "ABCDE12345",
+		"category":    "electronics",
+		"tags":        []string{"pc", "input"},
+		"stock":       10,
+		"images":      []string{"https://example.com/k.png"},
+	}
+}
+
+func TestValidationMessagesFollowAcceptLanguage(t *testing.T) {
+	router := setupRouter()
+
+	product := validProduct()
+	product["category"] = "weapons"
+
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		contains string
+	}{
+		{"english header", "/api/v1/products", map[string]string{"Accept-Language": "en-US,en;q=0.9"}, "must be a valid category"},
+		{"korean header", "/api/v1/products", map[string]string{"Accept-Language": "ko-KR"}, "유효한 카테고리여야 합니다"},
+		{"q-value ordering", "/api/v1/products", map[string]string{"Accept-Language": "ko;q=0.5, en;q=0.8"}, "must be a valid category"},
+		{"lang query wins", "/api/v1/products?lang=en", map[string]string{"Accept-Language": "ko"}, "must be a valid category"},
+		{"unsupported falls back to default", "/api/v1/products", map[string]string{"Accept-Language": "fr-FR"}, "유효한 카테고리여야 합니다"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, resp := postJSON(t, router, tt.path, product, tt.headers)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", w.Code)
+			}
+
+			detail := findDetail(resp.Details, "category")
+			if detail == nil {
+				t.Fatalf("expected category error, got %+v", resp.Details)
+			}
+			if !strings.Contains(detail.Message, tt.contains) {
+				t.Errorf("expected message containing %q, got %q", tt.contains, detail.Message)
+			}
+		})
+	}
+}
+
+func TestBuiltInValidationMessagesAreTranslated(t *testing.T) {
+	router := setupRouter()
+
+	product := validProduct()
+	product["stock"] = -1
+
+	_, en := postJSON(t, router, "/api/v1/products", product, map[string]string{"Accept-Language": "en"})
+	_, ko := postJSON(t, router, "/api/v1/products", product, map[string]string{"Accept-Language": "ko"})
+
+	enDetail, koDetail := findDetail(en.Details, "min"), findDetail(ko.Details, "min")
+	if enDetail == nil || koDetail == nil {
+		t.Fatalf("expected min errors, got en=%+v ko=%+v", en.Details, ko.Details)
+	}
+	if enDetail.Message == koDetail.Message {
+		t.Errorf("expected different messages per locale, both were %q", enDetail.Message)
+	}
+}
+
+func validOrder() map[string]interface{} {
+	return map[string]interface{}{
+		"customer_id": 1,
+		"items": []map[string]interface{}{
+			{"product_id": 1, "quantity": 2, "price": 10.5},
+		},
+		"shipping_address": map[string]interface{}{
+			"street":      "123 Teheran-ro",
+			"city":        "Seoul",
+			"country":     "KR",
+			"postal_code": "06234",
+		},
+		"payment_method": "cash",
+	}
+}
+
+func TestOrderBillingAddressRequiredWhenCard(t *testing.T) {
+	router := setupRouter()
+
+	t.Run("card without billing address", func(t *testing.T) {
+		order := validOrder()
+		order["payment_method"] = "card"
+
+		w, resp := postJSON(t, router, "/api/v1/orders", order, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(resp.Details) != 1 || resp.Details[0].Field != "billing_address" || resp.Details[0].Tag != "required" {
+			t.Errorf("expected required billing_address error, got %+v", resp.Details)
+		}
+	})
+
+	t.Run("card with invalid billing address", func(t *testing.T) {
+		order := validOrder()
+		order["payment_method"] = "card"
+		order["billing_address"] = map[string]interface{}{
+			"street":      "456 Gangnam-daero",
+			"city":        "Seoul",
+			"country":     "KR",
+			"postal_code": "abc",
+		}
+
+		w, resp := postJSON(t, router, "/api/v1/orders", order, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", w.Code)
+		}
+		if len(resp.Details) != 1 || resp.Details[0].Field != "billing_address.postal_code" {
+			t.Errorf("expected billing_address.postal_code error, got %+v", resp.Details)
+		}
+	})
+
+	t.Run("discount note requires coupon", func(t *testing.T) {
+		order := validOrder()
+		order["notes"] = "Please apply my discount"
+
+		_, resp := postJSON(t, router, "/api/v1/orders", order, nil)
+		if detail := findDetail(resp.Details, "required"); detail == nil || detail.Field != "coupon_code" {
+			t.Errorf("expected required coupon_code error, got %+v", resp.Details)
+		}
+	})
+
+	t.Run("card with valid billing address", func(t *testing.T) {
+		order := validOrder()
+		order["payment_method"] = "card"
+		order["billing_address"] = map[string]interface{}{
+			"street":      "456 Gangnam-daero",
+			"city":        "Seoul",
+			"country":     "KR",
+			"postal_code": "06000",
+		}
+
+		w, _ := postJSON(t, router, "/api/v1/orders", order, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestRegisterAllWiresValidationAndTranslation(t *testing.T) {
+	type Coupon struct {
+		Code string `json:"code" binding:"required,even_length" label:"쿠폰"`
+	}
+
+	err := RegisterAll(validate, []CustomValidator{{
+		Tag: "even_length",
+		Fn: func(fl validator.FieldLevel) bool {
+			return len(fl.Field().String())%2 == 0
+		},
+		Translation: map[string]string{
+			"ko": "{0}은(는) 짝수 길이여야 합니다",
+			"en": "{0} must have an even length",
+		},
+	}})
+	if err != nil {
+		t.Fatalf("RegisterAll failed: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/coupons", func(c *gin.Context) {
+		var coupon Coupon
+		if err := c.ShouldBindJSON(&coupon); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"details": formatValidationErrors(err, translatorFor(c), coupon)})
+			return
+		}
+		c.JSON(http.StatusOK, coupon)
+	})
+
+	if w, _ := postJSON(t, router, "/coupons", map[string]string{"code": "AB"}, nil); w.Code != http.StatusOK {
+		t.Errorf("expected valid coupon to pass, got %d", w.Code)
+	}
+
+	for locale, want := range map[string]string{"ko": "쿠폰은(는) 짝수 길이여야 합니다", "en": "쿠폰 must have an even length"} {
+		_, resp := postJSON(t, router, "/coupons", map[string]string{"code": "ABC"}, map[string]string{"Accept-Language": locale})
+		detail := findDetail(resp.Details, "even_length")
+		if detail == nil {
+			t.Fatalf("[%s] expected even_length error, got %+v", locale, resp.Details)
+		}
+		if detail.Message != want || detail.Field != "code" {
+			t.Errorf("[%s] expected %q on code, got %q on %s", locale, want, detail.Message, detail.Field)
+		}
+	}
+}
+
+func TestDiveErrorsReportIndexedFieldPaths(t *testing.T) {
+	router := setupRouter()
+
+	order := validOrder()
+	order["items"] = []map[string]interface{}{
+		{"product_id": 1, "quantity": 1, "price": 10},
+		{"product_id": 2, "quantity": 2, "price": 20},
+		{"product_id": 3, "quantity": 500, "price": 30},
+	}
+
+	w, resp := postJSON(t, router, "/api/v1/orders", order, map[string]string{"Accept-Language": "en"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if len(resp.Details) != 1 {
+		t.Fatalf("expected one error, got %+v", resp.Details)
+	}
+	if resp.Details[0].Field != "items[2].quantity" {
+		t.Errorf("expected items[2].quantity, got %q", resp.Details[0].Field)
+	}
+	if !strings.Contains(resp.Details[0].Message, "100") {
+		t.Errorf("expected translated max message, got %q", resp.Details[0].Message)
+	}
+
+	product := validProduct()
+	product["tags"] = []string{"ok", "x"}
+	_, resp = postJSON(t, router, "/api/v1/products", product, nil)
+	if len(resp.Details) != 1 || resp.Details[0].Field != "tags[1]" {
+		t.Errorf("expected tags[1] error, got %+v", resp.Details)
+	}
+}
+
+func findField(fields []FieldSchema, name string) *FieldSchema {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func findRule(rules []Rule, tag string) *Rule {
+	for i := range rules {
+		if rules[i].Tag == tag {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func TestValidationSchemaDescribesUserRegistration(t *testing.T) {
+	router := setupRouter()
+	req := httptest.NewRequest(http.MethodGet, "/validation/schema", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Models []ModelSchema `json:"models"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	var user *ModelSchema
+	for i := range resp.Models {
+		if resp.Models[i].Name == "UserRegistration" {
+			user = &resp.Models[i]
+		}
+	}
+	if user == nil {
+		t.Fatalf("UserRegistration missing from schema: %s", w.Body.String())
+	}
+
+	password := findField(user.Fields, "password")
+	if password == nil || !password.Required || password.Label != "비밀번호" {
+		t.Fatalf("unexpected password field: %+v", password)
+	}
+	if rule := findRule(password.Rules, "strong_password"); rule == nil || !rule.Custom {
+		t.Errorf("expected custom strong_password rule, got %+v", password.Rules)
+	}
+	if rule := findRule(password.Rules, "min"); rule == nil || rule.Param != "8" {
+		t.Errorf("expected min=8 rule, got %+v", password.Rules)
+	}
+
+	confirm := findField(user.Fields, "confirm_password")
+	if confirm == nil {
+		t.Fatal("confirm_password missing")
+	}
+	if rule := findRule(confirm.Rules, "eqfield"); rule == nil || rule.Param != "Password" || rule.Field != "password" {
+		t.Errorf("expected eqfield=Password pointing at password, got %+v", confirm.Rules)
+	}
+
+	if gender := findField(user.Fields, "gender"); gender == nil || findRule(gender.Rules, "oneof") == nil ||
+		len(findRule(gender.Rules, "oneof").Values) != 3 {
+		t.Errorf("expected oneof with 3 values on gender, got %+v", gender)
+	}
+	if phone := findField(user.Fields, "phone"); phone == nil || findRule(phone.Rules, "or") == nil ||
+		len(findRule(phone.Rules, "or").AnyOf) != 2 {
+		t.Errorf("expected e164|korean_phone alternatives, got %+v", phone)
+	}
+	if website := findField(user.Fields, "website"); website == nil || website.Required {
+		t.Errorf("expected optional website, got %+v", website)
+	}
+}
+
+func TestValidationSchemaDescribesDiveAndNestedFields(t *testing.T) {
+	var product, order *ModelSchema
+	schemas := buildSchemas()
+	for i := range schemas {
+		switch schemas[i].Name {
+		case "Product":
+			product = &schemas[i]
+		case "Order":
+			order = &schemas[i]
+		}
+	}
+
+	tags := findField(product.Fields, "tags")
+	if tags == nil || tags.Type != "array" || tags.Items == nil || tags.Items.Type != "string" {
+		t.Fatalf("unexpected tags field: %+v", tags)
+	}
+	if rule := findRule(tags.Rules, "max"); rule == nil || rule.Param != "5" {
+		t.Errorf("expected max=5 on the array, got %+v", tags.Rules)
+	}
+	if rule := findRule(tags.Items.Rules, "max"); rule == nil || rule.Param != "20" {
+		t.Errorf("expected max=20 on each tag, got %+v", tags.Items.Rules)
+	}
+
+	items := findField(order.Fields, "items")
+	if items == nil || items.Items == nil || findField(items.Items.Fields, "quantity") == nil {
+		t.Errorf("expected order items to describe OrderItem fields, got %+v", items)
+	}
+	if billing := findField(order.Fields, "billing_address"); billing == nil || billing.Type != "object" || billing.Required {
+		t.Errorf("expected optional billing_address object, got %+v", billing)
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,11 +13,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"example.com/gin-playground/pkg/bindingerr"
+	"example.com/gin-playground/pkg/bodylimit"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -40,6 +48,32 @@ type LoginResponse struct {
 	User  User   `json:"user"`
 }
 
+// Bulk import
+const maxBulkUsers = 100
+
+type BulkCreateRequest struct {
+	Users []User `json:"users" binding:"required"`
+}
+
+type BulkCreateResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"` // "created" or "error"
+	User   *User  `json:"user,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Repository errors
+var (
+	ErrDuplicateEmail    = errors.New("email already exists")
+	ErrDuplicateUsername = errors.New("username already exists")
+)
+
+// CreateResult is the per-row outcome of CreateMany
+type CreateResult struct {
+	User *User
+	Err  error
+}
+
 // Repository interface for testing
 type UserRepository interface {
 	FindByID(id uint) (*User, error)
@@ -48,11 +82,13 @@ type UserRepository interface {
 	Update(user *User) error
 	Delete(id uint) error
 	List(limit, offset int) ([]User, error)
+	CreateMany(users []User) []CreateResult
 }
 
 // Mock repository for testing
 type MockUserRepository struct {
-	users map[uint]*User
+	users  map[uint]*User
+	nextID uint
 }
 
 func NewMockUserRepository() *MockUserRepository {
@@ -79,14 +115,44 @@ func (r *MockUserRepository) FindByEmail(email string) (*User, error) {
 }
 
 func (r *MockUserRepository) Create(user *User) error {
+	for _, existing := range r.users {
+		if existing.ID == user.ID {
+			continue
+		}
+		if strings.EqualFold(existing.Email, user.Email) {
+			return ErrDuplicateEmail
+		}
+		if strings.EqualFold(existing.Username, user.Username) {
+			return ErrDuplicateUsername
+		}
+	}
+
+	// IDs are never reused, even after deletes
 	if user.ID == 0 {
-		user.ID = uint(len(r.users) + 1)
+		r.nextID++
+		user.ID = r.nextID
+	} else if user.ID > r.nextID {
+		r.nextID = user.ID
 	}
 	user.CreatedAt = time.Now()
 	r.users[user.ID] = user
 	return nil
 }
 
+// CreateMany inserts each user independently; a failed row does not stop the rest
+func (r *MockUserRepository) CreateMany(users []User) []CreateResult {
+	results := make([]CreateResult, len(users))
+	for i := range users {
+		user := users[i]
+		if err := r.Create(&user); err != nil {
+			results[i] = CreateResult{Err: err}
+			continue
+		}
+		results[i] = CreateResult{User: &user}
+	}
+	return results
+}
+
 func (r *MockUserRepository) Update(user *User) error {
 	if _, exists := r.users[user.ID]; !exists {
 		return fmt.Errorf("user not found")
@@ -126,11 +192,15 @@ func NewUserService(repo UserRepository) *UserService {
 
 // Handlers
 type UserHandler struct {
-	service *UserService
+	service   *UserService
+	uploadDir string // 아바타 저장 디렉터리
 }
 
 func NewUserHandler(service *UserService) *UserHandler {
-	return &UserHandler{service: service}
+	return &UserHandler{
+		service:   service,
+		uploadDir: filepath.Join(os.TempDir(), "avatars"),
+	}
 }
 
 func (h *UserHandler) GetUser(c *gin.Context) {
@@ -153,12 +223,15 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var user User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindingerr.BindJSON(c, &user) {
 		return
 	}
 
 	if err := h.service.repo.Create(&user); err != nil {
+		if errors.Is(err, ErrDuplicateEmail) || errors.Is(err, ErrDuplicateUsername) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -166,6 +239,55 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, user)
 }
 
+// Bulk create handler: rows are validated and inserted independently
+func (h *UserHandler) BulkCreateUsers(c *gin.Context) {
+	var req BulkCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Users) == 0 || len(req.Users) > maxBulkUsers {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("users must contain between 1 and %d rows", maxBulkUsers),
+		})
+		return
+	}
+
+	results := make([]BulkCreateResult, len(req.Users))
+	var valid []User
+	var validIndex []int
+	for i := range req.Users {
+		// Same binding rules as POST /users, applied per row
+		if err := binding.Validator.ValidateStruct(&req.Users[i]); err != nil {
+			results[i] = BulkCreateResult{Index: i, Status: "error", Error: err.Error()}
+			continue
+		}
+		valid = append(valid, req.Users[i])
+		validIndex = append(validIndex, i)
+	}
+
+	created := 0
+	for j, result := range h.service.repo.CreateMany(valid) {
+		i := validIndex[j]
+		if result.Err != nil {
+			results[i] = BulkCreateResult{Index: i, Status: "error", Error: result.Err.Error()}
+			continue
+		}
+		results[i] = BulkCreateResult{Index: i, Status: "created", User: result.User}
+		created++
+	}
+
+	status := http.StatusCreated
+	if created < len(req.Users) {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"results": results,
+		"created": created,
+		"failed":  len(req.Users) - created,
+	})
+}
+
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	var id uint
 	if err := c.ShouldBindUri(&struct {
@@ -176,8 +298,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	var user User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindingerr.BindJSON(c, &user) {
 		return
 	}
 
@@ -235,8 +356,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 // Login handler for authentication testing
 func (h *UserHandler) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindingerr.BindJSON(c, &req) {
 		return
 	}
 
@@ -257,33 +377,121 @@ func (h *UserHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 }
 
-// File upload handler for multipart testing
+// maxAvatarSize - 아바타 파일 최대 크기 (라우트 본문 제한은 multipart 오버헤드만큼 여유를 둠)
+const maxAvatarSize = 5 * 1024 * 1024
+
+// checksumHeader - 클라이언트가 보낸 파일 SHA-256 (hex). 있으면 저장 후 대조
+const checksumHeader = "X-Checksum-SHA256"
+
+// UploadAvatar - multipart 파트를 메모리에 올리지 않고 디스크로 스트리밍하면서 SHA-256을 계산
 func (h *UserHandler) UploadAvatar(c *gin.Context) {
-	file, err := c.FormFile("avatar")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	// Check file size (max 5MB)
-	if file.Size > 5*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+	expected := strings.ToLower(c.GetHeader(checksumHeader))
+	if expected != "" {
+		if decoded, err := hex.DecodeString(expected); err != nil || len(decoded) != sha256.Size {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + checksumHeader + " header"})
+			return
+		}
+	}
+
+	part, err := avatarPart(c.Request)
+	if err != nil {
+		// 길이를 모르는(chunked) 본문이 제한을 넘으면 미들웨어가 413으로 응답
+		if bodylimit.Exceeded(err) {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
 		return
 	}
+	defer part.Close()
 
 	// Check file type
-	if !strings.HasPrefix(file.Header.Get("Content-Type"), "image/") {
+	if !strings.HasPrefix(part.Header.Get("Content-Type"), "image/") {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Only image files allowed"})
 		return
 	}
 
+	if err := os.MkdirAll(h.uploadDir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	tmp, err := os.CreateTemp(h.uploadDir, "upload-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	// 검증에 실패하면 임시 파일을 지움 (성공 시에는 Rename으로 이미 옮겨짐)
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Check file size (max 5MB) - 한 바이트 더 읽어 초과 여부를 판단
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(part, maxAvatarSize+1))
+	if err != nil {
+		if bodylimit.Exceeded(err) {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	if size > maxAvatarSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+		return
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if expected != "" && expected != checksum {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Checksum mismatch",
+			"expected": expected,
+			"actual":   checksum,
+		})
+		return
+	}
+
+	if err := tmp.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	name := fmt.Sprintf("user-%d%s", id, filepath.Ext(filepath.Base(part.FileName())))
+	if err := os.Rename(tmp.Name(), filepath.Join(h.uploadDir, name)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"filename": file.Filename,
-		"size":     file.Size,
+		"filename": part.FileName(),
+		"size":     size,
+		"sha256":   checksum,
 		"message":  "Avatar uploaded successfully",
 	})
 }
 
+// avatarPart - multipart 본문에서 "avatar" 파일 파트를 찾음. 앞선 파트는 건너뜀
+func avatarPart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "avatar" && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
 // Middleware for testing
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -317,9 +525,18 @@ func SetupRouter(handler *UserHandler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
+	// 모든 요청 본문은 1MB로 제한하고, 아바타 업로드만 더 크게 허용
+	router.Use(bodylimit.NewWithConfig(bodylimit.Config{
+		Limit: bodylimit.DefaultLimit,
+		Routes: map[string]int64{
+			"/users/:id/avatar": maxAvatarSize + 1<<20,
+		},
+	}))
+
 	// Public routes
 	router.POST("/login", handler.Login)
 	router.POST("/users", handler.CreateUser)
+	router.POST("/users/bulk", handler.BulkCreateUsers)
 	router.GET("/users", handler.ListUsers)
 	router.GET("/users/:id", handler.GetUser)
 
@@ -449,7 +666,7 @@ func TestCreateUser_ValidationError(t *testing.T) {
 	w := performRequest(router, "POST", "/users", bytes.NewBuffer(jsonBody))
 
 	// Assertions
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 }
 
 func TestUpdateUser_WithAuth(t *testing.T) {
@@ -756,7 +973,7 @@ func TestUserValidation_TableDriven(t *testing.T) {
 				Username: "ab",
 				Email:    "valid@example.com",
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "Username too long",
@@ -764,7 +981,7 @@ func TestUserValidation_TableDriven(t *testing.T) {
 				Username: strings.Repeat("a", 21),
 				Email:    "valid@example.com",
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "Invalid email",
@@ -772,7 +989,7 @@ func TestUserValidation_TableDriven(t *testing.T) {
 				Username: "validuser",
 				Email:    "invalid-email",
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "Empty username",
@@ -780,7 +997,7 @@ func TestUserValidation_TableDriven(t *testing.T) {
 				Username: "",
 				Email:    "valid@example.com",
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusUnprocessableEntity,
 		},
 		{
 			name: "Empty email",
@@ -788,7 +1005,7 @@ func TestUserValidation_TableDriven(t *testing.T) {
 				Username: "validuser",
 				Email:    "",
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusUnprocessableEntity,
 		},
 	}
 
@@ -834,15 +1051,14 @@ func BenchmarkCreateUser(b *testing.B) {
 	handler := NewUserHandler(service)
 	router := SetupRouter(handler)
 
-	user := User{
-		Username: "benchuser",
-		Email:    "bench@example.com",
-	}
-	jsonBody, _ := json.Marshal(user)
-
 	// Run benchmark
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
+		// Usernames and emails must be unique
+		jsonBody, _ := json.Marshal(User{
+			Username: fmt.Sprintf("bench%d", i),
+			Email:    fmt.Sprintf("bench%d@example.com", i),
+		})
 		w := performRequest(router, "POST", "/users", bytes.NewBuffer(jsonBody))
 		if w.Code != http.StatusCreated {
 			b.Errorf("Expected status 201, got %d", w.Code)
@@ -940,6 +1156,7 @@ func main() {
 	fmt.Println("  GET    /users")
 	fmt.Println("  GET    /users/:id")
 	fmt.Println("  POST   /users")
+	fmt.Println("  POST   /users/bulk")
 	fmt.Println("  PUT    /users/:id (requires auth)")
 	fmt.Println("  DELETE /users/:id (requires auth)")
 	fmt.Println("  POST   /users/:id/avatar (requires auth)")
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"example.com/gin-playground/pkg/bindingerr"
+	"example.com/gin-playground/pkg/bodylimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkCreateResponse struct {
+	Results []BulkCreateResult `json:"results"`
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+}
+
+func TestBulkCreateUsers_PartialFailure(t *testing.T) {
+	// Setup
+	repo := NewMockUserRepository()
+	router := SetupRouter(NewUserHandler(NewUserService(repo)))
+	require.NoError(t, repo.Create(&User{Username: "existing", Email: "existing@example.com"}))
+
+	body := `{"users": [
+		{"username": "alice", "email": "alice@example.com"},
+		{"username": "bob", "email": "EXISTING@example.com"},
+		{"username": "carol", "email": "carol@example.com"},
+		{"username": "alice", "email": "alice2@example.com"},
+		{"username": "ab", "email": "not-an-email"}
+	]}`
+
+	// Perform request
+	w := performRequest(router, "POST", "/users/bulk", strings.NewReader(body))
+
+	// Assertions
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+
+	var response bulkCreateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Created)
+	assert.Equal(t, 3, response.Failed)
+	require.Len(t, response.Results, 5)
+
+	want := []struct {
+		status string
+		err    string
+	}{
+		{"created", ""},
+		{"error", ErrDuplicateEmail.Error()},
+		{"created", ""},
+		{"error", ErrDuplicateUsername.Error()},
+		{"error", "Field validation"},
+	}
+	for i, expected := range want {
+		result := response.Results[i]
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, expected.status, result.Status, "row %d", i)
+		assert.Contains(t, result.Error, expected.err, "row %d", i)
+	}
+
+	// Created rows get unique IDs and are persisted
+	alice, carol := response.Results[0].User, response.Results[2].User
+	require.NotNil(t, alice)
+	require.NotNil(t, carol)
+	assert.NotEqual(t, alice.ID, carol.ID)
+	stored, err := repo.FindByEmail("carol@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, carol.ID, stored.ID)
+	assert.Len(t, repo.users, 3)
+}
+
+func TestBulkCreateUsers_AllCreated(t *testing.T) {
+	router := SetupRouter(NewUserHandler(NewUserService(NewMockUserRepository())))
+
+	body := `{"users": [
+		{"username": "alice", "email": "alice@example.com"},
+		{"username": "bob", "email": "bob@example.com"}
+	]}`
+	w := performRequest(router, "POST", "/users/bulk", strings.NewReader(body))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestBulkCreateUsers_BatchSizeLimit(t *testing.T) {
+	router := SetupRouter(NewUserHandler(NewUserService(NewMockUserRepository())))
+
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{"empty batch", 0},
+		{"too many rows", maxBulkUsers + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := make([]User, tt.count)
+			for i := range users {
+				users[i] = User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i)}
+			}
+			jsonBody, _ := json.Marshal(BulkCreateRequest{Users: users})
+
+			w := performRequest(router, "POST", "/users/bulk", bytes.NewBuffer(jsonBody))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestMockUserRepository_IDsAreNotReused(t *testing.T) {
+	repo := NewMockUserRepository()
+	first := &User{Username: "first", Email: "first@example.com"}
+	second := &User{Username: "second", Email: "second@example.com"}
+	require.NoError(t, repo.Create(first))
+	require.NoError(t, repo.Create(second))
+	require.NoError(t, repo.Delete(first.ID))
+
+	third := &User{Username: "third", Email: "third@example.com"}
+	require.NoError(t, repo.Create(third))
+	assert.NotEqual(t, second.ID, third.ID)
+}
+
+func TestCreateUser_OversizedBodyRejected(t *testing.T) {
+	repo := NewMockUserRepository()
+	router := SetupRouter(NewUserHandler(NewUserService(repo)))
+
+	// 유효한 JSON이라도 전역 제한(1MB)을 넘으면 핸들러 전에 거절
+	padding := strings.Repeat(" ", int(bodylimit.DefaultLimit))
+	body := `{"username": "alice", "email": "alice@example.com"}` + padding
+	w := performRequest(router, "POST", "/users", strings.NewReader(body))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var response struct {
+		Success bool `json:"success"`
+		Error   struct {
+			ErrorCode string `json:"error_code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, bodylimit.ErrorCode, response.Error.ErrorCode)
+	assert.Empty(t, repo.users)
+}
+
+func TestUploadAvatar_AllowsLargerBodyThanDefault(t *testing.T) {
+	router := SetupRouter(NewUserHandler(NewUserService(NewMockUserRepository())))
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("avatar", "big.jpg")
+	require.NoError(t, err)
+	_, err = part.Write(bytes.Repeat([]byte("x"), 2<<20))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	w := performRequestWithHeaders(router, "POST", "/users/1/avatar", body, map[string]string{
+		"Content-Type":  writer.FormDataContentType(),
+		"Authorization": "Bearer valid-token",
+	})
+
+	// 2MB 파일은 전역 제한보다 크지만 업로드 라우트 제한 안이라 통과
+	assert.NotEqual(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, http.StatusBadRequest, w.Code) // Content-Type이 image/*가 아님
+}
+
+func avatarUpload(t *testing.T, data []byte, checksum string) (*bytes.Buffer, map[string]string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="me.png"`)
+	header.Set("Content-Type", "image/png")
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	headers := map[string]string{
+		"Content-Type":  writer.FormDataContentType(),
+		"Authorization": "Bearer valid-token",
+	}
+	if checksum != "" {
+		headers[checksumHeader] = checksum
+	}
+	return body, headers
+}
+
+func TestUploadAvatar_ChecksumVerification(t *testing.T) {
+	handler := NewUserHandler(NewUserService(NewMockUserRepository()))
+	handler.uploadDir = t.TempDir()
+	router := SetupRouter(handler)
+
+	data := bytes.Repeat([]byte("png-bytes"), 1000)
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	t.Run("matching checksum", func(t *testing.T) {
+		body, headers := avatarUpload(t, data, strings.ToUpper(checksum))
+		w := performRequestWithHeaders(router, "POST", "/users/1/avatar", body, headers)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, checksum, response["sha256"])
+		assert.EqualValues(t, len(data), response["size"])
+
+		stored, err := os.ReadFile(filepath.Join(handler.uploadDir, "user-1.png"))
+		require.NoError(t, err)
+		assert.Equal(t, data, stored)
+	})
+
+	t.Run("mismatched checksum", func(t *testing.T) {
+		wrong := sha256.Sum256([]byte("something else"))
+		body, headers := avatarUpload(t, data, hex.EncodeToString(wrong[:]))
+		w := performRequestWithHeaders(router, "POST", "/users/2/avatar", body, headers)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		// 검증에 실패한 파일은 남지 않음
+		entries, err := os.ReadDir(handler.uploadDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "user-1.png", entries[0].Name())
+	})
+
+	t.Run("malformed checksum header", func(t *testing.T) {
+		body, headers := avatarUpload(t, data, "not-hex")
+		w := performRequestWithHeaders(router, "POST", "/users/3/avatar", body, headers)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("no checksum header", func(t *testing.T) {
+		body, headers := avatarUpload(t, data, "")
+		w := performRequestWithHeaders(router, "POST", "/users/4/avatar", body, headers)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestUploadAvatar_StreamedFileTooLarge(t *testing.T) {
+	handler := NewUserHandler(NewUserService(NewMockUserRepository()))
+	handler.uploadDir = t.TempDir()
+	router := SetupRouter(handler)
+
+	body, headers := avatarUpload(t, bytes.Repeat([]byte("x"), maxAvatarSize+1), "")
+	w := performRequestWithHeaders(router, "POST", "/users/1/avatar", body, headers)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	entries, err := os.ReadDir(handler.uploadDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCreateUser_BindErrorEnvelope(t *testing.T) {
+	repo := NewMockUserRepository()
+	router := SetupRouter(NewUserHandler(NewUserService(repo)))
+
+	type envelope struct {
+		Success bool `json:"success"`
+		Error   struct {
+			ErrorCode string                  `json:"error_code"`
+			Details   []bindingerr.FieldError `json:"details"`
+		} `json:"error"`
+	}
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		w := performRequest(router, "POST", "/users", strings.NewReader(`{"username": "alice",`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response envelope
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.False(t, response.Success)
+		assert.Equal(t, bindingerr.ErrorCodeBadRequest, response.Error.ErrorCode)
+		assert.Empty(t, response.Error.Details)
+	})
+
+	t.Run("invalid but parseable JSON", func(t *testing.T) {
+		w := performRequest(router, "POST", "/users", strings.NewReader(`{"username": "ab", "email": "not-an-email"}`))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response envelope
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, bindingerr.ErrorCodeValidation, response.Error.ErrorCode)
+		require.Len(t, response.Error.Details, 2)
+		assert.Equal(t, "username", response.Error.Details[0].Field)
+		assert.Equal(t, "email", response.Error.Details[1].Field)
+		assert.NotContains(t, w.Body.String(), "Key: 'User.")
+	})
+
+	assert.Empty(t, repo.users)
+}
@@ -3,21 +3,29 @@ package main
 import (
 	"bytes"
 	"context"
-	"database/sql"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/mail"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"example.com/gin-playground/pkg/compress"
+	"example.com/gin-playground/pkg/jwtauth"
 	"github.com/gin-gonic/gin"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -26,41 +34,47 @@ import (
 // ========== Models ==========
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Username  string    `json:"username" gorm:"unique;not null"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Posts     []Post    `json:"posts,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Username  string         `json:"username" gorm:"unique;not null"`
+	Email     string         `json:"email" gorm:"unique;not null"`
+	Password  string         `json:"-" gorm:"not null"`
+	Role      string         `json:"role" gorm:"not null;default:user"` // user or admin
+	Posts     []Post         `json:"posts,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // soft delete
 }
 
 type Post struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Title     string    `json:"title" gorm:"not null"`
-	Content   string    `json:"content"`
-	UserID    uint      `json:"user_id"`
-	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Comments  []Comment `json:"comments,omitempty" gorm:"foreignKey:PostID"`
-	Tags      []Tag     `json:"tags,omitempty" gorm:"many2many:post_tags;"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Title     string         `json:"title" gorm:"not null"`
+	Content   string         `json:"content"`
+	UserID    uint           `json:"user_id"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID"`
+	Tags      []Tag          `json:"tags,omitempty" gorm:"many2many:post_tags;"`
+	Version   uint           `json:"version" gorm:"not null;default:1"` // optimistic locking
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"` // soft delete
 }
 
 type Comment struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Content   string    `json:"content" gorm:"not null"`
-	PostID    uint      `json:"post_id"`
-	Post      *Post     `json:"post,omitempty" gorm:"foreignKey:PostID"`
-	UserID    uint      `json:"user_id"`
-	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Content   string         `json:"content" gorm:"not null"`
+	PostID    uint           `json:"post_id"`
+	Post      *Post          `json:"post,omitempty" gorm:"foreignKey:PostID"`
+	UserID    uint           `json:"user_id"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // soft delete
 }
 
 type Tag struct {
-	ID    uint   `json:"id" gorm:"primaryKey"`
-	Name  string `json:"name" gorm:"unique;not null"`
-	Posts []Post `json:"posts,omitempty" gorm:"many2many:post_tags;"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"unique;not null"`
+	Posts     []Post         `json:"posts,omitempty" gorm:"many2many:post_tags;"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // soft delete
 }
 
 // ========== Database ==========
@@ -139,28 +153,107 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(user *User) error {
-	return r.db.Create(user).Error
+func (r *UserRepository) Create(ctx context.Context, user *User) error {
+	return translateDuplicate(r.db.WithContext(ctx).Create(user).Error)
 }
 
-func (r *UserRepository) FindByID(id uint) (*User, error) {
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*User, error) {
 	var user User
-	err := r.db.Preload("Posts").First(&user, id).Error
+	err := r.db.WithContext(ctx).Preload("Posts").First(&user, id).Error
 	return &user, err
 }
 
-func (r *UserRepository) FindByEmail(email string) (*User, error) {
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	return &user, err
 }
 
-func (r *UserRepository) Update(user *User) error {
-	return r.db.Save(user).Error
+func (r *UserRepository) Update(ctx context.Context, user *User) error {
+	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&User{}, id).Error
+var ErrDuplicateUser = errors.New("already in use")
+
+// DuplicateFieldError names the unique column a write collided with; it
+// matches ErrDuplicateUser with errors.Is
+type DuplicateFieldError struct {
+	Field string
+}
+
+func (e *DuplicateFieldError) Error() string {
+	return e.Field + " " + ErrDuplicateUser.Error()
+}
+
+func (e *DuplicateFieldError) Unwrap() error {
+	return ErrDuplicateUser
+}
+
+// translateDuplicate turns a SQLite unique violation such as
+// "UNIQUE constraint failed: users.email" into a DuplicateFieldError and
+// returns any other error unchanged
+func translateDuplicate(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return err
+	}
+	_, columns, _ := strings.Cut(sqliteErr.Error(), "failed: ")
+	column := strings.TrimSpace(strings.Split(columns, ",")[0])
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		column = column[i+1:]
+	}
+	if column == "" {
+		return err
+	}
+	return &DuplicateFieldError{Field: column}
+}
+
+// UpdateFields writes only the given columns; columns missing from fields are
+// left untouched, unlike Update which saves the whole struct
+func (r *UserRepository) UpdateFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Soft-deleted rows still hold the unique index, so check them too
+		for _, column := range []string{"username", "email"} {
+			value, ok := fields[column]
+			if !ok {
+				continue
+			}
+			var n int64
+			if err := tx.Unscoped().Model(&User{}).Where(column+" = ? AND id <> ?", value, id).Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				return &DuplicateFieldError{Field: column}
+			}
+		}
+		// The constraint still catches a concurrent insert that slipped past the check
+		return translateDuplicate(tx.Model(&User{ID: id}).Updates(fields).Error)
+	})
+}
+
+// UserStats holds the counters shown on a profile page
+type UserStats struct {
+	UserID       uint  `json:"user_id"`
+	PostCount    int64 `json:"post_count"`
+	CommentCount int64 `json:"comment_count"`
+}
+
+// CountsFor counts the user's posts and comments without loading them.
+// Soft-deleted rows are excluded, and a user with no activity gets zeros.
+func (r *UserRepository) CountsFor(ctx context.Context, userID uint) (*UserStats, error) {
+	stats := &UserStats{UserID: userID}
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&Post{}).Where("user_id = ?", userID).Count(&stats.PostCount).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&Comment{}).Where("user_id = ?", userID).Count(&stats.CommentCount).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&User{}, id).Error
 }
 
 type PostRepository struct {
@@ -171,32 +264,95 @@ func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{db: db}
 }
 
-func (r *PostRepository) Create(post *Post) error {
-	return r.db.Create(post).Error
+func (r *PostRepository) Create(ctx context.Context, post *Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
+}
+
+// PostIncludes selects which associations FindByIDWith preloads
+type PostIncludes struct {
+	User     bool
+	Comments bool // also loads each comment's author
+	Tags     bool
 }
 
-func (r *PostRepository) FindByID(id uint) (*Post, error) {
+// AllPostIncludes is the full set FindByID has always loaded
+var AllPostIncludes = PostIncludes{User: true, Comments: true, Tags: true}
+
+func (r *PostRepository) FindByID(ctx context.Context, id uint) (*Post, error) {
+	return r.FindByIDWith(ctx, id, AllPostIncludes)
+}
+
+// FindByIDWith loads a post with only the requested associations
+func (r *PostRepository) FindByIDWith(ctx context.Context, id uint, opts PostIncludes) (*Post, error) {
 	var post Post
-	err := r.db.Preload("User").Preload("Comments.User").Preload("Tags").
-		First(&post, id).Error
+	db := r.db.WithContext(ctx)
+	if opts.User {
+		db = db.Preload("User")
+	}
+	if opts.Comments {
+		db = db.Preload("Comments.User")
+	}
+	if opts.Tags {
+		db = db.Preload("Tags")
+	}
+	err := db.First(&post, id).Error
 	return &post, err
 }
 
-func (r *PostRepository) List(limit, offset int) ([]Post, error) {
+// List returns a page of posts; includeDeleted also returns soft-deleted ones
+func (r *PostRepository) List(ctx context.Context, limit, offset int, includeDeleted bool) ([]Post, error) {
 	var posts []Post
-	err := r.db.Preload("User").Preload("Tags").
+	db := r.db.WithContext(ctx)
+	if includeDeleted {
+		db = db.Unscoped()
+	}
+	err := db.Preload("User").Preload("Tags").
 		Limit(limit).Offset(offset).
 		Order("created_at DESC").
 		Find(&posts).Error
 	return posts, err
 }
 
-func (r *PostRepository) Update(post *Post) error {
-	return r.db.Save(post).Error
+// ErrStaleObject is returned by Update when the post was modified after the
+// caller read it, i.e. its version no longer matches.
+var ErrStaleObject = errors.New("stale object: post was modified by another request")
+
+// Update writes the post only if its version still matches post.Version and
+// bumps the version, so concurrent edits can't silently overwrite each other.
+func (r *PostRepository) Update(ctx context.Context, post *Post) error {
+	result := r.db.WithContext(ctx).Model(&Post{}).
+		Where("id = ? AND version = ?", post.ID, post.Version).
+		Updates(map[string]interface{}{
+			"title":   post.Title,
+			"content": post.Content,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleObject
+	}
+
+	post.Version++
+	return nil
+}
+
+// Delete soft-deletes the post; ordinary queries stop returning it
+func (r *PostRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&Post{}, id).Error
+}
+
+// FindWithDeleted loads a post whether or not it is soft-deleted
+func (r *PostRepository) FindWithDeleted(ctx context.Context, id uint) (*Post, error) {
+	var post Post
+	err := r.db.WithContext(ctx).Unscoped().First(&post, id).Error
+	return &post, err
 }
 
-func (r *PostRepository) Delete(id uint) error {
-	return r.db.Delete(&Post{}, id).Error
+// Restore clears DeletedAt so the post is visible again
+func (r *PostRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&Post{}).Where("id = ?", id).Update("deleted_at", nil).Error
 }
 
 // ========== Services ==========
@@ -215,24 +371,30 @@ func NewBlogService(db *gorm.DB) *BlogService {
 	}
 }
 
-func (s *BlogService) CreateUserWithPost(username, email, password, title, content string) (*User, error) {
+func (s *BlogService) CreateUserWithPost(ctx context.Context, username, email, password, title, content string) (*User, error) {
 	// Use transaction
-	tx := s.db.Begin()
+	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		}
 	}()
 
+	hash, err := hashPassword(password)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	user := &User{
 		Username: username,
 		Email:    email,
-		Password: password,
+		Password: hash,
 	}
 
 	if err := tx.Create(user).Error; err != nil {
 		tx.Rollback()
-		return nil, err
+		return nil, translateDuplicate(err)
 	}
 
 	post := &Post{
@@ -251,10 +413,98 @@ func (s *BlogService) CreateUserWithPost(username, email, password, title, conte
 	}
 
 	// Reload with associations
-	s.userRepo.FindByID(user.ID)
+	s.userRepo.FindByID(ctx, user.ID)
 	return user, nil
 }
 
+// ========== Auth ==========
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Same issuer/audience as the gin/19 JWT example, so its tokens verify here
+// when both run with the same JWT_SECRET. main refuses to start without it
+// rather than fall back to a well-known key anyone could sign with.
+var authConfig = jwtauth.Config{
+	SecretKey: os.Getenv("JWT_SECRET"),
+	Algorithm: jwtauth.AlgorithmHS256,
+	Issuer:    "gin-jwt-example",
+	Audience:  []string{"gin-api"},
+}
+
+const accessTokenExpiry = 15 * time.Minute
+
+func issueToken(user *User) (string, error) {
+	now := time.Now()
+	return authConfig.Sign(jwtauth.Claims{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    authConfig.Issuer,
+			Subject:   fmt.Sprintf("%d", user.ID),
+			Audience:  authConfig.Audience,
+		},
+	})
+}
+
+// AuthMiddleware requires a valid bearer token and stores its claims
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := jwtauth.BearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
+			return
+		}
+
+		claims, err := authConfig.ValidateAccessToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware stores claims when a valid token is sent but never rejects
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString, ok := jwtauth.BearerToken(c.GetHeader("Authorization")); ok {
+			if claims, err := authConfig.ValidateAccessToken(tokenString); err == nil {
+				c.Set("claims", claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+func currentClaims(c *gin.Context) *jwtauth.Claims {
+	return c.MustGet("claims").(*jwtauth.Claims)
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// checkPassword compares against a bcrypt hash. Anything else stored in the
+// column, such as a plain-text row, never authenticates.
+func checkPassword(stored, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
+
+// canModifyPost lets authors edit their own posts and admins edit any post
+func canModifyPost(claims *jwtauth.Claims, post *Post) bool {
+	return claims.Role == RoleAdmin || post.UserID == claims.UserID
+}
+
 // ========== Handlers ==========
 
 type BlogHandler struct {
@@ -277,13 +527,22 @@ func (h *BlogHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
 	user := &User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: req.Password, // Should be hashed in production
+		Password: hash,
 	}
 
-	if err := h.service.userRepo.Create(user); err != nil {
+	if err := h.service.userRepo.Create(c.Request.Context(), user); err != nil {
+		if respondDuplicate(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -291,6 +550,48 @@ func (h *BlogHandler) CreateUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, user)
 }
 
+// respondDuplicate writes a 409 naming the colliding field and reports
+// whether err was a duplicate
+func respondDuplicate(c *gin.Context, err error) bool {
+	var dup *DuplicateFieldError
+	if !errors.As(err, &dup) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": dup.Error(), "field": dup.Field})
+	return true
+}
+
+// Login exchanges email/password for an access token
+func (h *BlogHandler) Login(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.userRepo.FindByEmail(c.Request.Context(), req.Email)
+	if err != nil || !checkPassword(user.Password, req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	token, err := issueToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(accessTokenExpiry.Seconds()),
+	})
+}
+
 func (h *BlogHandler) GetUser(c *gin.Context) {
 	var id uint
 	if err := c.ShouldBindUri(&struct {
@@ -300,7 +601,7 @@ func (h *BlogHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.userRepo.FindByID(id)
+	user, err := h.service.userRepo.FindByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -309,11 +610,150 @@ func (h *BlogHandler) GetUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+var (
+	ErrFieldNotPatchable   = errors.New("cannot be changed")
+	ErrRoleChangeForbidden = errors.New("only admins can change roles")
+)
+
+// patchableUserFields lists what PATCH may change; id and timestamps are not here
+var patchableUserFields = map[string]bool{"username": true, "email": true, "password": true, "role": true}
+
+// userPatch validates a partial update body. Null and empty values are skipped
+// so clients can send a form as-is without clearing fields.
+func userPatch(body map[string]interface{}, isAdmin bool) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	for field, value := range body {
+		if !patchableUserFields[field] {
+			return nil, fmt.Errorf("%s %w", field, ErrFieldNotPatchable)
+		}
+		if value == nil {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a string", field)
+		}
+		if s == "" {
+			continue
+		}
+
+		switch field {
+		case "email":
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return nil, errors.New("email must be a valid email address")
+			}
+		case "password":
+			if len(s) < 6 {
+				return nil, errors.New("password must be at least 6 characters")
+			}
+		case "role":
+			if !isAdmin {
+				return nil, ErrRoleChangeForbidden
+			}
+			if s != RoleUser && s != RoleAdmin {
+				return nil, fmt.Errorf("role must be %q or %q", RoleUser, RoleAdmin)
+			}
+		}
+		updates[field] = s
+	}
+	return updates, nil
+}
+
+// PatchUser updates only the fields present in the body. Users may patch
+// themselves, admins anyone; the password is only touched when sent.
+func (h *BlogHandler) PatchUser(c *gin.Context) {
+	var uri struct {
+		ID uint `uri:"id" binding:"required"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims.Role != RoleAdmin && claims.UserID != uri.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own account"})
+		return
+	}
+
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates, err := userPatch(body, claims.Role == RoleAdmin)
+	if errors.Is(err, ErrRoleChangeForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.service.userRepo.FindByID(ctx, uri.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if password, ok := updates["password"].(string); ok {
+		hash, err := hashPassword(password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+		updates["password"] = hash
+	}
+
+	if len(updates) > 0 {
+		err := h.service.userRepo.UpdateFields(ctx, uri.ID, updates)
+		if respondDuplicate(c, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+	}
+
+	user, err := h.service.userRepo.FindByID(ctx, uri.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// GetUserStats returns post and comment counts for a profile page
+func (h *BlogHandler) GetUserStats(c *gin.Context) {
+	var uri struct {
+		ID uint `uri:"id" binding:"required"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.service.db.WithContext(ctx).Select("id").First(&User{}, uri.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	stats, err := h.service.userRepo.CountsFor(ctx, uri.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user stats"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
 func (h *BlogHandler) CreatePost(c *gin.Context) {
 	var req struct {
 		Title   string   `json:"title" binding:"required"`
 		Content string   `json:"content"`
-		UserID  uint     `json:"user_id" binding:"required"`
 		Tags    []string `json:"tags"`
 	}
 
@@ -322,24 +762,27 @@ func (h *BlogHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
+	// The author is always the token's user, never a user_id from the body
 	post := &Post{
 		Title:   req.Title,
 		Content: req.Content,
-		UserID:  req.UserID,
+		UserID:  currentClaims(c).UserID,
 	}
 
+	ctx := c.Request.Context()
+
 	// Handle tags
 	if len(req.Tags) > 0 {
 		var tags []Tag
 		for _, tagName := range req.Tags {
 			var tag Tag
-			h.service.db.FirstOrCreate(&tag, Tag{Name: tagName})
+			h.service.db.WithContext(ctx).FirstOrCreate(&tag, Tag{Name: tagName})
 			tags = append(tags, tag)
 		}
 		post.Tags = tags
 	}
 
-	if err := h.service.postRepo.Create(post); err != nil {
+	if err := h.service.postRepo.Create(ctx, post); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
@@ -348,19 +791,204 @@ func (h *BlogHandler) CreatePost(c *gin.Context) {
 }
 
 func (h *BlogHandler) GetPost(c *gin.Context) {
-	var id uint
-	if err := c.ShouldBindUri(&struct {
+	var uri struct {
 		ID uint `uri:"id" binding:"required"`
-	}{ID: id}); err != nil {
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	includes, err := parsePostIncludes(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	post, err := h.service.postRepo.FindByIDWith(c.Request.Context(), uri.ID, includes)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	// Conditional GET: skip the body when the client's copy is still current
+	etag := postETag(post, includes)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, post)
+}
+
+// parsePostIncludes reads ?include=user,comments,tags. Without the parameter
+// every association is loaded; an empty value loads none.
+func parsePostIncludes(c *gin.Context) (PostIncludes, error) {
+	raw, ok := c.GetQuery("include")
+	if !ok {
+		return AllPostIncludes, nil
+	}
+
+	var includes PostIncludes
+	for _, name := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "user":
+			includes.User = true
+		case "comments":
+			includes.Comments = true
+		case "tags":
+			includes.Tags = true
+		default:
+			return includes, fmt.Errorf("unknown include %q (want user, comments or tags)", name)
+		}
+	}
+	return includes, nil
+}
+
+// postETag builds a weak validator from the post's ID and UpdatedAt plus its
+// comments and tags, which are returned in the body but don't touch the post row.
+// The include set is part of it since each selection is a different representation.
+func postETag(post *Post, includes PostIncludes) string {
+	parts := []string{fmt.Sprintf("post:%d:%d:%+v", post.ID, post.UpdatedAt.UnixNano(), includes)}
+	for _, comment := range post.Comments {
+		parts = append(parts, fmt.Sprintf("comment:%d:%d", comment.ID, comment.CreatedAt.UnixNano()))
+	}
+	for _, tag := range post.Tags {
+		parts = append(parts, fmt.Sprintf("tag:%d:%s", tag.ID, tag.Name))
+	}
+	sort.Strings(parts[1:]) // association order is not guaranteed
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return fmt.Sprintf(`W/"%x"`, sum[:8])
+}
+
+// etagMatches implements the weak comparison used for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *BlogHandler) UpdatePost(c *gin.Context) {
+	var uri struct {
+		ID uint `uri:"id" binding:"required"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Version is the version the client last read
+	var req struct {
+		Title   string `json:"title" binding:"required"`
+		Content string `json:"content"`
+		Version uint   `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var existing Post
+	if err := h.service.db.WithContext(ctx).First(&existing, uri.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if !canModifyPost(currentClaims(c), &existing) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own posts"})
+		return
+	}
+
+	post := &Post{ID: uri.ID, Title: req.Title, Content: req.Content, Version: req.Version}
+	if err := h.service.postRepo.Update(ctx, post); err != nil {
+		if errors.Is(err, ErrStaleObject) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Post was modified by another request"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+
+	post, err := h.service.postRepo.FindByID(ctx, uri.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, post)
+}
+
+func (h *BlogHandler) DeletePost(c *gin.Context) {
+	var uri struct {
+		ID uint `uri:"id" binding:"required"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var post Post
+	if err := h.service.db.WithContext(ctx).First(&post, uri.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if !canModifyPost(currentClaims(c), &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own posts"})
+		return
+	}
+
+	if err := h.service.postRepo.Delete(ctx, post.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *BlogHandler) RestorePost(c *gin.Context) {
+	var uri struct {
+		ID uint `uri:"id" binding:"required"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	post, err := h.service.postRepo.FindByID(id)
+	ctx := c.Request.Context()
+	post, err := h.service.postRepo.FindWithDeleted(ctx, uri.ID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
+	if !canModifyPost(currentClaims(c), post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own posts"})
+		return
+	}
+
+	// Restoring a post that isn't deleted is a no-op
+	if post.DeletedAt.Valid {
+		if err := h.service.postRepo.Restore(ctx, post.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore post"})
+			return
+		}
+	}
+
+	post, err = h.service.postRepo.FindByID(ctx, uri.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load post"})
+		return
+	}
 
 	c.JSON(http.StatusOK, post)
 }
@@ -376,7 +1004,17 @@ func (h *BlogHandler) ListPosts(c *gin.Context) {
 		fmt.Sscanf(o, "%d", &offset)
 	}
 
-	posts, err := h.service.postRepo.List(limit, offset)
+	// Soft-deleted posts are only listed for admins who ask for them
+	includeDeleted := c.Query("include_deleted") == "true"
+	if includeDeleted {
+		claims, ok := c.Get("claims")
+		if !ok || claims.(*jwtauth.Claims).Role != RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "include_deleted requires an admin token"})
+			return
+		}
+	}
+
+	posts, err := h.service.postRepo.List(c.Request.Context(), limit, offset, includeDeleted)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list posts"})
 		return
@@ -407,7 +1045,7 @@ func (h *BlogHandler) CreateComment(c *gin.Context) {
 		UserID:  req.UserID,
 	}
 
-	if err := h.service.db.Create(comment).Error; err != nil {
+	if err := h.service.db.WithContext(c.Request.Context()).Create(comment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
@@ -456,12 +1094,22 @@ func SetupRouter(handler *BlogHandler) *gin.Engine {
 		// Users
 		v1.POST("/users", handler.CreateUser)
 		v1.GET("/users/:id", handler.GetUser)
+		v1.GET("/users/:id/stats", handler.GetUserStats)
+		v1.POST("/login", handler.Login)
 
 		// Posts
-		v1.POST("/posts", handler.CreatePost)
-		v1.GET("/posts", handler.ListPosts)
+		// Only the list is gzipped; single posts stay uncompressed so their ETag stays valid
+		v1.GET("/posts", compress.New(), OptionalAuthMiddleware(), handler.ListPosts)
 		v1.GET("/posts/:id", handler.GetPost)
 
+		// Writes require a token; users modify their own account and posts, admins any
+		authed := v1.Group("", AuthMiddleware())
+		authed.PATCH("/users/:id", handler.PatchUser)
+		authed.POST("/posts", handler.CreatePost)
+		authed.PUT("/posts/:id", handler.UpdatePost)
+		authed.DELETE("/posts/:id", handler.DeletePost)
+		authed.POST("/posts/:id/restore", handler.RestorePost)
+
 		// Comments
 		v1.POST("/comments", handler.CreateComment)
 	}
@@ -498,6 +1146,15 @@ func NewTestServer() (*TestServer, error) {
 	}, nil
 }
 
+// BearerFor returns an Authorization header value for user
+func (ts *TestServer) BearerFor(user *User) string {
+	token, err := issueToken(user)
+	if err != nil {
+		panic(err)
+	}
+	return "Bearer " + token
+}
+
 func (ts *TestServer) Cleanup() {
 	if ts.DB != nil {
 		sqlDB, _ := ts.DB.DB.DB()
@@ -605,17 +1262,17 @@ func TestCreatePost_WithTags_Integration(t *testing.T) {
 	}
 	server.DB.Create(user)
 
-	// Create post with tags
+	// Create post with tags; the author comes from the token
 	post := map[string]interface{}{
 		"title":   "Test Post",
 		"content": "Test Content",
-		"user_id": user.ID,
 		"tags":    []string{"test", "integration", "golang"},
 	}
 	jsonBody, _ := json.Marshal(post)
 
 	req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", server.BearerFor(user))
 	w := httptest.NewRecorder()
 	server.Router.ServeHTTP(w, req)
 
@@ -625,6 +1282,7 @@ func TestCreatePost_WithTags_Integration(t *testing.T) {
 	err = json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 	assert.Equal(t, "Test Post", response.Title)
+	assert.Equal(t, user.ID, response.UserID)
 	assert.Len(t, response.Tags, 3)
 
 	// Verify tags in database
@@ -661,12 +1319,12 @@ func TestUserPostFlow_Integration(t *testing.T) {
 	post := map[string]interface{}{
 		"title":   "Flow Test Post",
 		"content": "Flow test content",
-		"user_id": createdUser.ID,
 	}
 	jsonBody, _ = json.Marshal(post)
 
 	req, _ = http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", server.BearerFor(&createdUser))
 	w = httptest.NewRecorder()
 	server.Router.ServeHTTP(w, req)
 
@@ -853,7 +1511,7 @@ type TestFixtures struct {
 func LoadTestFixtures(db *gorm.DB) *TestFixtures {
 	fixtures := &TestFixtures{
 		Users: []User{
-			{Username: "admin", Email: "admin@example.com", Password: "admin123"},
+			{Username: "admin", Email: "admin@example.com", Password: "admin123", Role: RoleAdmin},
 			{Username: "editor", Email: "editor@example.com", Password: "editor123"},
 			{Username: "viewer", Email: "viewer@example.com", Password: "viewer123"},
 		},
@@ -873,8 +1531,14 @@ func LoadTestFixtures(db *gorm.DB) *TestFixtures {
 		},
 	}
 
-	// Load fixtures into database
+	// Load fixtures into database, storing only bcrypt hashes so the
+	// seeded accounts go through the same login path as registered ones
 	for _, user := range fixtures.Users {
+		hash, err := hashPassword(user.Password)
+		if err != nil {
+			log.Fatal("Failed to hash fixture password:", err)
+		}
+		user.Password = hash
 		db.Create(&user)
 	}
 	for _, post := range fixtures.Posts {
@@ -948,6 +1612,10 @@ func main() {
 		dbFile = os.Getenv("DB_FILE")
 	}
 
+	if authConfig.SecretKey == "" {
+		log.Fatal("JWT_SECRET is required to sign and verify tokens")
+	}
+
 	db, err := NewDatabase(dbFile, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
@@ -976,9 +1644,15 @@ func main() {
 	fmt.Println("  GET    /health")
 	fmt.Println("  POST   /api/v1/users")
 	fmt.Println("  GET    /api/v1/users/:id")
-	fmt.Println("  POST   /api/v1/posts")
+	fmt.Println("  PATCH  /api/v1/users/:id   (auth)")
+	fmt.Println("  GET    /api/v1/users/:id/stats")
+	fmt.Println("  POST   /api/v1/login")
+	fmt.Println("  POST   /api/v1/posts       (auth)")
 	fmt.Println("  GET    /api/v1/posts")
 	fmt.Println("  GET    /api/v1/posts/:id")
+	fmt.Println("  PUT    /api/v1/posts/:id   (auth)")
+	fmt.Println("  DELETE /api/v1/posts/:id   (auth)")
+	fmt.Println("  POST   /api/v1/posts/:id/restore (auth)")
 	fmt.Println("  POST   /api/v1/comments")
 	fmt.Println("\nRun with 'test' argument to see test instructions")
 
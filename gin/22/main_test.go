@@ -0,0 +1,697 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// main refuses to start without JWT_SECRET; tests sign with their own key
+	authConfig.SecretKey = "test-secret"
+	os.Exit(m.Run())
+}
+
+func getPost(server *TestServer, id uint, ifNoneMatch string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/posts/%d", id), nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetPost_ConditionalGet_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	user := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(user).Error)
+	post := &Post{Title: "Cached Post", Content: "Body", UserID: user.ID}
+	require.NoError(t, server.DB.Create(post).Error)
+
+	// Step 1: Initial GET returns the body and a weak ETag
+	w := getPost(server, post.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]+"$`, etag)
+	body := w.Body.String()
+
+	var fetched Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(t, "Cached Post", fetched.Title)
+
+	// Step 2: Conditional GET with the same ETag returns 304 without a body
+	w = getPost(server, post.ID, etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	// A non-matching validator is a cache miss with the usual body
+	w = getPost(server, post.ID, `W/"stale", "other"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+
+	// Step 3: Editing the post changes the ETag
+	require.NoError(t, server.DB.Model(&Post{}).Where("id = ?", post.ID).Update("title", "Edited Post").Error)
+
+	w = getPost(server, post.ID, etag)
+	require.Equal(t, http.StatusOK, w.Code)
+	editedETag := w.Header().Get("ETag")
+	assert.NotEqual(t, etag, editedETag)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(t, "Edited Post", fetched.Title)
+
+	// Step 4: Adding a comment changes the ETag even though the post row is untouched
+	comment, _ := json.Marshal(map[string]interface{}{
+		"content": "Nice!",
+		"post_id": post.ID,
+		"user_id": user.ID,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/comments", bytes.NewBuffer(comment))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = getPost(server, post.ID, editedETag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, editedETag, w.Header().Get("ETag"))
+}
+
+func TestPostETag_ChangesWithTags(t *testing.T) {
+	post := &Post{ID: 1, Tags: []Tag{{ID: 1, Name: "go"}, {ID: 2, Name: "gin"}}}
+	reordered := &Post{ID: 1, Tags: []Tag{{ID: 2, Name: "gin"}, {ID: 1, Name: "go"}}}
+	retagged := &Post{ID: 1, Tags: []Tag{{ID: 1, Name: "go"}}}
+
+	assert.Equal(t, postETag(post, AllPostIncludes), postETag(reordered, AllPostIncludes))
+	assert.NotEqual(t, postETag(post, AllPostIncludes), postETag(retagged, AllPostIncludes))
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`"x", W/"abc"`, true},
+		{"*", true},
+		{`W/"abd"`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, etagMatches(tt.header, etag), "If-None-Match: %s", tt.header)
+	}
+}
+
+func putPost(server *TestServer, as *User, id uint, body map[string]interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/posts/%d", id), bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if as != nil {
+		req.Header.Set("Authorization", server.BearerFor(as))
+	}
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdatePost_ConcurrentEdits_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	user := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(user).Error)
+	post := &Post{Title: "Draft", Content: "Body", UserID: user.ID}
+	require.NoError(t, server.DB.Create(post).Error)
+	require.Equal(t, uint(1), post.Version)
+
+	// Both editors read version 1 before either saves
+	w := putPost(server, user, post.ID, map[string]interface{}{"title": "Editor A", "content": "Body", "version": 1})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var updated Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "Editor A", updated.Title)
+	assert.Equal(t, uint(2), updated.Version)
+
+	w = putPost(server, user, post.ID, map[string]interface{}{"title": "Editor B", "content": "Body", "version": 1})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// The losing edit must not clobber the winner
+	var stored Post
+	require.NoError(t, server.DB.First(&stored, post.ID).Error)
+	assert.Equal(t, "Editor A", stored.Title)
+	assert.Equal(t, uint(2), stored.Version)
+
+	// Retrying with the fresh version succeeds
+	w = putPost(server, user, post.ID, map[string]interface{}{"title": "Editor B", "content": "Body", "version": 2})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPostRepository_UpdateStaleObject(t *testing.T) {
+	db, err := NewTestDatabase()
+	require.NoError(t, err)
+
+	repo := NewPostRepository(db.GetDB())
+	post := &Post{Title: "Draft", Content: "Body"}
+	require.NoError(t, repo.Create(context.Background(), post))
+
+	stale := *post
+	post.Title = "Fresh"
+	require.NoError(t, repo.Update(context.Background(), post))
+	assert.Equal(t, uint(2), post.Version)
+
+	stale.Title = "Stale"
+	assert.ErrorIs(t, repo.Update(context.Background(), &stale), ErrStaleObject)
+}
+
+func TestPostAuthorization_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	author := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	other := &User{Username: "other", Email: "other@example.com", Password: "password123"}
+	admin := &User{Username: "admin", Email: "admin@example.com", Password: "password123", Role: RoleAdmin}
+	for _, u := range []*User{author, other, admin} {
+		require.NoError(t, server.DB.Create(u).Error)
+	}
+	post := &Post{Title: "Draft", Content: "Body", UserID: author.ID}
+	require.NoError(t, server.DB.Create(post).Error)
+
+	// No token
+	w := putPost(server, nil, post.ID, map[string]interface{}{"title": "Anon", "version": 1})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Author edits own post
+	w = putPost(server, author, post.ID, map[string]interface{}{"title": "By author", "version": 1})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// User edits someone else's post
+	w = putPost(server, other, post.ID, map[string]interface{}{"title": "By other", "version": 2})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var stored Post
+	require.NoError(t, server.DB.First(&stored, post.ID).Error)
+	assert.Equal(t, "By author", stored.Title)
+
+	// Admin edits any post
+	w = putPost(server, admin, post.ID, map[string]interface{}{"title": "By admin", "version": 2})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Delete follows the same rule
+	deletePost := func(as *User) int {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/v1/posts/%d", post.ID), nil)
+		req.Header.Set("Authorization", server.BearerFor(as))
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, deletePost(other))
+	assert.Equal(t, http.StatusNoContent, deletePost(author))
+	assert.Equal(t, http.StatusNotFound, deletePost(admin))
+}
+
+func TestCreatePost_IgnoresBodyUserID_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	hash, err := hashPassword("password123")
+	require.NoError(t, err)
+	author := &User{Username: "author", Email: "author@example.com", Password: hash}
+	victim := &User{Username: "victim", Email: "victim@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(author).Error)
+	require.NoError(t, server.DB.Create(victim).Error)
+
+	// Log in to get a token rather than minting one directly
+	creds, _ := json.Marshal(map[string]string{"email": "author@example.com", "password": "password123"})
+	req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(creds))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+
+	body, _ := json.Marshal(map[string]interface{}{"title": "Spoofed", "user_id": victim.ID})
+	req, _ = http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+login.AccessToken)
+	w = httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created Post
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, author.ID, created.UserID)
+}
+
+func listPostIDs(t *testing.T, server *TestServer, query string, as *User) (int, []uint) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/api/v1/posts"+query, nil)
+	if as != nil {
+		req.Header.Set("Authorization", server.BearerFor(as))
+	}
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+
+	var resp struct {
+		Posts []Post `json:"posts"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	var ids []uint
+	for _, post := range resp.Posts {
+		ids = append(ids, post.ID)
+	}
+	return w.Code, ids
+}
+
+func TestSoftDeleteAndRestorePost_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	author := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	admin := &User{Username: "admin", Email: "admin@example.com", Password: "password123", Role: RoleAdmin}
+	require.NoError(t, server.DB.Create(author).Error)
+	require.NoError(t, server.DB.Create(admin).Error)
+	post := &Post{Title: "Oops", Content: "Body", UserID: author.ID}
+	require.NoError(t, server.DB.Create(post).Error)
+
+	send := func(method, path string, as *User) int {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", server.BearerFor(as))
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		return w.Code
+	}
+	postPath := fmt.Sprintf("/api/v1/posts/%d", post.ID)
+
+	require.Equal(t, http.StatusNoContent, send("DELETE", postPath, author))
+
+	// Hidden from ordinary queries but the row is still there
+	_, ids := listPostIDs(t, server, "", nil)
+	assert.NotContains(t, ids, post.ID)
+	assert.Equal(t, http.StatusNotFound, getPost(server, post.ID, "").Code)
+
+	var stored Post
+	require.NoError(t, server.DB.Unscoped().First(&stored, post.ID).Error)
+	assert.True(t, stored.DeletedAt.Valid)
+
+	// include_deleted is admin only
+	code, _ := listPostIDs(t, server, "?include_deleted=true", author)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, ids = listPostIDs(t, server, "?include_deleted=true", admin)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, ids, post.ID)
+
+	// Restore brings it back
+	assert.Equal(t, http.StatusOK, send("POST", postPath+"/restore", author))
+	_, ids = listPostIDs(t, server, "", nil)
+	assert.Contains(t, ids, post.ID)
+	assert.Equal(t, http.StatusOK, getPost(server, post.ID, "").Code)
+
+	assert.Equal(t, http.StatusNotFound, send("POST", "/api/v1/posts/999/restore", admin))
+}
+
+func TestPostRepository_QueryCanceledWithContext(t *testing.T) {
+	db, err := NewTestDatabase()
+	require.NoError(t, err)
+
+	repo := NewPostRepository(db.GetDB())
+	post := &Post{Title: "Draft", Content: "Body"}
+	require.NoError(t, repo.Create(context.Background(), post))
+
+	// Simulate a slow query that only returns early when its context ends
+	err = db.GetDB().Callback().Query().Before("gorm:query").Register("test:slow_query", func(tx *gorm.DB) {
+		select {
+		case <-tx.Statement.Context.Done():
+		case <-time.After(time.Second):
+		}
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = repo.FindByID(ctx, post.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "query was not aborted by cancellation")
+}
+
+func patchUser(server *TestServer, as *User, id uint, body map[string]interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/users/%d", id), bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", server.BearerFor(as))
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPatchUser_OnlyProvidedFields_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	hash, err := hashPassword("password123")
+	require.NoError(t, err)
+	user := &User{Username: "patcher", Email: "old@example.com", Password: hash}
+	require.NoError(t, server.DB.Create(user).Error)
+
+	w := patchUser(server, user, user.ID, map[string]interface{}{"email": "new@example.com"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stored User
+	require.NoError(t, server.DB.First(&stored, user.ID).Error)
+	assert.Equal(t, "new@example.com", stored.Email)
+	assert.Equal(t, "patcher", stored.Username)
+	assert.Equal(t, hash, stored.Password)
+
+	// Empty values are skipped rather than clearing the column
+	w = patchUser(server, user, user.ID, map[string]interface{}{"username": "", "password": nil})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, server.DB.First(&stored, user.ID).Error)
+	assert.Equal(t, "patcher", stored.Username)
+	assert.Equal(t, hash, stored.Password)
+}
+
+func TestPatchUser_Password_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	user := &User{Username: "patcher", Email: "patcher@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(user).Error)
+
+	w := patchUser(server, user, user.ID, map[string]interface{}{"password": "new-secret"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stored User
+	require.NoError(t, server.DB.First(&stored, user.ID).Error)
+	assert.NotEqual(t, "new-secret", stored.Password)
+	assert.True(t, checkPassword(stored.Password, "new-secret"))
+	assert.False(t, checkPassword(stored.Password, "password123"))
+}
+
+func TestLogin_RejectsPlaintextPassword_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	// A row that bypassed hashPassword must not log in with its stored value
+	user := &User{Username: "legacy", Email: "legacy@example.com", Password: "admin123"}
+	require.NoError(t, server.DB.Create(user).Error)
+
+	creds, _ := json.Marshal(map[string]string{"email": "legacy@example.com", "password": "admin123"})
+	req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(creds))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+func TestLoadTestFixtures_HashesPasswords_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	fixtures := LoadTestFixtures(server.DB.DB)
+
+	var admin User
+	require.NoError(t, server.DB.Where("email = ?", "admin@example.com").First(&admin).Error)
+	assert.NotEqual(t, fixtures.Users[0].Password, admin.Password)
+	assert.True(t, checkPassword(admin.Password, fixtures.Users[0].Password))
+}
+
+func TestPatchUser_Rejections_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	user := &User{Username: "patcher", Email: "patcher@example.com", Password: "password123"}
+	other := &User{Username: "other", Email: "other@example.com", Password: "password123"}
+	admin := &User{Username: "admin", Email: "admin@example.com", Password: "password123", Role: RoleAdmin}
+	require.NoError(t, server.DB.Create(user).Error)
+	require.NoError(t, server.DB.Create(other).Error)
+	require.NoError(t, server.DB.Create(admin).Error)
+
+	assert.Equal(t, http.StatusBadRequest, patchUser(server, user, user.ID, map[string]interface{}{"id": 99}).Code)
+	assert.Equal(t, http.StatusBadRequest, patchUser(server, user, user.ID, map[string]interface{}{"created_at": "2020-01-01T00:00:00Z"}).Code)
+	assert.Equal(t, http.StatusBadRequest, patchUser(server, user, user.ID, map[string]interface{}{"email": "not-an-email"}).Code)
+	assert.Equal(t, http.StatusForbidden, patchUser(server, user, other.ID, map[string]interface{}{"email": "x@example.com"}).Code)
+	assert.Equal(t, http.StatusForbidden, patchUser(server, user, user.ID, map[string]interface{}{"role": RoleAdmin}).Code)
+	assert.Equal(t, http.StatusConflict, patchUser(server, user, user.ID, map[string]interface{}{"email": "other@example.com"}).Code)
+	assert.Equal(t, http.StatusNotFound, patchUser(server, admin, 999, map[string]interface{}{"email": "x@example.com"}).Code)
+
+	w := patchUser(server, admin, other.ID, map[string]interface{}{"role": RoleAdmin})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stored User
+	require.NoError(t, server.DB.First(&stored, other.ID).Error)
+	assert.Equal(t, RoleAdmin, stored.Role)
+	assert.Equal(t, "other@example.com", stored.Email)
+}
+
+func getUserStats(t *testing.T, server *TestServer, id uint) (int, UserStats) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%d/stats", id), nil)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	var stats UserStats
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	}
+	return w.Code, stats
+}
+
+func TestGetUserStats_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	author := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	reader := &User{Username: "reader", Email: "reader@example.com", Password: "password123"}
+	lurker := &User{Username: "lurker", Email: "lurker@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(author).Error)
+	require.NoError(t, server.DB.Create(reader).Error)
+	require.NoError(t, server.DB.Create(lurker).Error)
+
+	var posts []*Post
+	for i := 0; i < 3; i++ {
+		post := &Post{Title: fmt.Sprintf("Post %d", i), UserID: author.ID}
+		require.NoError(t, server.DB.Create(post).Error)
+		posts = append(posts, post)
+	}
+	for _, post := range posts {
+		require.NoError(t, server.DB.Create(&Comment{Content: "Nice", PostID: post.ID, UserID: reader.ID}).Error)
+	}
+	require.NoError(t, server.DB.Create(&Comment{Content: "Thanks", PostID: posts[0].ID, UserID: author.ID}).Error)
+
+	// Soft-deleted posts are not counted
+	require.NoError(t, server.DB.Delete(posts[2]).Error)
+
+	code, stats := getUserStats(t, server, author.ID)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, UserStats{UserID: author.ID, PostCount: 2, CommentCount: 1}, stats)
+
+	code, stats = getUserStats(t, server, reader.ID)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, UserStats{UserID: reader.ID, PostCount: 0, CommentCount: 3}, stats)
+
+	// A user with no activity gets zeros rather than an error
+	code, stats = getUserStats(t, server, lurker.ID)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, UserStats{UserID: lurker.ID}, stats)
+
+	code, _ = getUserStats(t, server, 999)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestGetPost_IncludeSelectsPreloads_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	author := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(author).Error)
+	post := &Post{Title: "Tagged", UserID: author.ID, Tags: []Tag{{Name: "go"}}}
+	require.NoError(t, server.DB.Create(post).Error)
+	require.NoError(t, server.DB.Create(&Comment{Content: "Hi", PostID: post.ID, UserID: author.ID}).Error)
+
+	// Record the table behind every query, preloads included
+	var tables []string
+	err = server.DB.Callback().Query().After("gorm:query").Register("test:record_tables", func(tx *gorm.DB) {
+		tables = append(tables, tx.Statement.Table)
+	})
+	require.NoError(t, err)
+
+	fetch := func(query string) map[string]interface{} {
+		tables = nil
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/posts/%d?%s", post.ID, query), nil)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	body := fetch("include=tags")
+	assert.NotContains(t, tables, "comments")
+	assert.NotContains(t, tables, "users")
+	assert.Contains(t, tables, "tags")
+	assert.Contains(t, body, "tags")
+	assert.NotContains(t, body, "comments")
+	assert.NotContains(t, body, "user")
+
+	// Without the parameter the full set is loaded as before
+	body = fetch("")
+	assert.Contains(t, tables, "comments")
+	assert.Contains(t, tables, "users")
+	assert.Contains(t, body, "comments")
+	assert.Contains(t, body, "user")
+	assert.Contains(t, body, "tags")
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/posts/%d?include=likes", post.ID), nil)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListPosts_GzipCompression_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	user := &User{Username: "author", Email: "author@example.com", Password: "password123"}
+	require.NoError(t, server.DB.Create(user).Error)
+	for i := 0; i < 10; i++ {
+		post := &Post{Title: fmt.Sprintf("Post %d", i), Content: strings.Repeat("lorem ipsum ", 20), UserID: user.ID}
+		require.NoError(t, server.DB.Create(post).Error)
+	}
+
+	list := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/posts", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := list("gzip")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	gz, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	var response struct {
+		Posts []Post `json:"posts"`
+	}
+	require.NoError(t, json.NewDecoder(gz).Decode(&response))
+	assert.Len(t, response.Posts, 10)
+
+	// Clients without gzip get plain JSON
+	w = list("identity")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.True(t, json.Valid(w.Body.Bytes()))
+
+	// Single posts are never compressed so their ETag describes the bytes on the wire
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/posts/%d", 1), nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func createUser(server *TestServer, username, email string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"username": username, "email": email, "password": "password123"})
+	req, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateUser_DuplicateFieldConflict_Integration(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	w := createUser(server, "first", "dup@example.com")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	for name, tc := range map[string]struct {
+		username, email, field string
+	}{
+		"email":    {"second", "dup@example.com", "email"},
+		"username": {"first", "other@example.com", "username"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := createUser(server, tc.username, tc.email)
+			require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+			var resp map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.field, resp["field"])
+			assert.Equal(t, tc.field+" already in use", resp["error"])
+		})
+	}
+
+	// Soft-deleted users still own their email
+	var first User
+	require.NoError(t, server.DB.Where("username = ?", "first").First(&first).Error)
+	require.NoError(t, server.DB.Delete(&first).Error)
+	w = createUser(server, "third", "dup@example.com")
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	var count int64
+	server.DB.Unscoped().Model(&User{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestTranslateDuplicate(t *testing.T) {
+	server, err := NewTestServer()
+	require.NoError(t, err)
+	defer server.Cleanup()
+
+	ctx := context.Background()
+	require.NoError(t, server.Service.userRepo.Create(ctx, &User{Username: "a", Email: "a@example.com", Password: "x"}))
+
+	err = server.Service.userRepo.Create(ctx, &User{Username: "b", Email: "a@example.com", Password: "x"})
+	var dup *DuplicateFieldError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "email", dup.Field)
+	assert.ErrorIs(t, err, ErrDuplicateUser)
+
+	_, err = server.Service.CreateUserWithPost(ctx, "a", "c@example.com", "password123", "title", "content")
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "username", dup.Field)
+
+	assert.Nil(t, translateDuplicate(nil))
+	other := fmt.Errorf("boom")
+	assert.Equal(t, other, translateDuplicate(other))
+}
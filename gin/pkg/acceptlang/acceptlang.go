@@ -0,0 +1,47 @@
+// Package acceptlang parses the Accept-Language request header so examples
+// can pick a locale for localized messages.
+package acceptlang
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parse returns the language tags in header ordered by descending q value.
+// Tags with equal weight keep their header order. Empty tags and the "*"
+// wildcard are skipped, and a malformed q parameter counts as q=1.
+func Parse(header string) []string {
+	type langQ struct {
+		tag string
+		q   float64
+	}
+
+	var langs []langQ
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		langs = append(langs, langQ{tag: tag, q: q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	tags := make([]string, 0, len(langs))
+	for _, l := range langs {
+		tags = append(tags, l.tag)
+	}
+	return tags
+}
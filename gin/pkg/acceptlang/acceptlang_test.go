@@ -0,0 +1,26 @@
+package acceptlang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"ko", []string{"ko"}},
+		{"en;q=0.5, ko-KR, ko;q=0.9", []string{"ko-KR", "ko", "en"}},
+		{"fr, de", []string{"fr", "de"}},
+		{"*, en;q=0.1", []string{"en"}},
+		{"ja;q=bogus, en;q=0.8", []string{"ja", "en"}},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package bindingerr turns the errors returned by gin's ShouldBind* methods
+// into the response envelope the error handling lesson (09) uses, instead of
+// echoing err.Error() back to the client.
+//
+//   - validator.ValidationErrors become 422 with one entry per field, named
+//     by its JSON tag ("email", "items[2].quantity") rather than the Go field.
+//   - A well-formed body with a value of the wrong JSON type becomes the same
+//     422 with a single field entry.
+//   - Anything else (malformed JSON, empty body) is a plain 400.
+//
+// BindQuery does the same for query strings, but always answers 400: a bad
+// query parameter is a malformed request, not an unprocessable entity. Fields
+// are named by their form tag ("page_size").
+//
+// Errors from reading past a bodylimit cap are attached with c.Error and left
+// unanswered so the bodylimit middleware can reply with 413.
+package bindingerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Error codes used in the envelope, matching lesson 09's registry.
+const (
+	ErrorCodeBadRequest = "BAD_REQUEST"
+	ErrorCodeValidation = "VALIDATION_ERROR"
+)
+
+// FieldError describes one invalid field in a 422 response.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Value   string `json:"value,omitempty"`
+}
+
+// BindJSON binds the request body into obj. On failure it writes the error
+// response and returns false, so handlers can simply return:
+//
+//	if !bindingerr.BindJSON(c, &req) {
+//		return
+//	}
+func BindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		Respond(c, err, obj)
+		return false
+	}
+	return true
+}
+
+// BindQuery binds the query string into obj, answering 400 on failure.
+//
+//	var q ListQuery
+//	if !bindingerr.BindQuery(c, &q) {
+//		return
+//	}
+func BindQuery(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		RespondQuery(c, err, obj)
+		return false
+	}
+	return true
+}
+
+// Invalid writes the 422 validation response for checks made after binding,
+// such as rules that span several fields, so they share the envelope above.
+func Invalid(c *gin.Context, fields []FieldError) {
+	abort(c, http.StatusUnprocessableEntity, ErrorCodeValidation, "Validation failed", fields)
+}
+
+// RespondQuery writes the 400 response for a query bind error. obj is used
+// to report fields by their form names, as in Respond.
+func RespondQuery(c *gin.Context, err error, obj interface{}) {
+	var root reflect.Type
+	if obj != nil {
+		root = reflect.TypeOf(obj)
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, e := range validationErrs {
+			fields = append(fields, fieldErrorTagged(e, root, "form"))
+		}
+		abort(c, http.StatusBadRequest, ErrorCodeBadRequest, "Invalid query parameters", fields)
+		return
+	}
+
+	// gin reports unparsable numbers and booleans as the bare strconv error,
+	// so find the parameter that carried the rejected value.
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) && root != nil {
+		var fields []FieldError
+		for name, kind := range queryFields(root) {
+			if values, ok := c.GetQueryArray(name); ok && contains(values, numErr.Num) {
+				fields = append(fields, FieldError{Field: name, Message: kindMessage(kind), Value: numErr.Num})
+			}
+		}
+		if len(fields) > 0 {
+			abort(c, http.StatusBadRequest, ErrorCodeBadRequest, "Invalid query parameters", fields)
+			return
+		}
+	}
+
+	abort(c, http.StatusBadRequest, ErrorCodeBadRequest, "Invalid query parameters", nil)
+}
+
+// queryFields maps each form-tagged field of root, including those of
+// embedded structs, to the kind it is parsed as.
+func queryFields(root reflect.Type) map[string]reflect.Kind {
+	for root.Kind() == reflect.Ptr {
+		root = root.Elem()
+	}
+	fields := make(map[string]reflect.Kind)
+	if root.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < root.NumField(); i++ {
+		field := root.Field(i)
+		kind := field.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = field.Type.Elem().Kind()
+		}
+		if field.Anonymous && field.Tag.Get("form") == "" && kind == reflect.Struct {
+			for name, k := range queryFields(field.Type) {
+				fields[name] = k
+			}
+			continue
+		}
+		fields[tagName(field, "form")] = kind
+	}
+	return fields
+}
+
+func kindMessage(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Must be an integer"
+	case reflect.Float32, reflect.Float64:
+		return "Must be a number"
+	case reflect.Bool:
+		return "Must be true or false"
+	default:
+		return "Invalid value"
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Respond writes the response for a bind error. obj is the value that was
+// being bound and is used to report fields by their JSON names; nil falls
+// back to the Go field names.
+func Respond(c *gin.Context, err error, obj interface{}) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		var root reflect.Type
+		if obj != nil {
+			root = reflect.TypeOf(obj)
+		}
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, e := range validationErrs {
+			fields = append(fields, fieldError(e, root))
+		}
+		abort(c, http.StatusUnprocessableEntity, ErrorCodeValidation, "Validation failed", fields)
+		return
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		abort(c, http.StatusUnprocessableEntity, ErrorCodeValidation, "Validation failed", []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("Must be of type %s", typeErr.Type),
+		}})
+		return
+	}
+
+	abort(c, http.StatusBadRequest, ErrorCodeBadRequest, badRequestMessage(err), nil)
+}
+
+func badRequestMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("Malformed JSON at offset %d", syntaxErr.Offset)
+	case errors.Is(err, io.EOF):
+		return "Request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "Malformed JSON: unexpected end of input"
+	default:
+		return "Invalid request body"
+	}
+}
+
+func fieldError(e validator.FieldError, root reflect.Type) FieldError {
+	return fieldErrorTagged(e, root, "json")
+}
+
+func fieldErrorTagged(e validator.FieldError, root reflect.Type, tag string) FieldError {
+	field := fieldPath(e, root, tag)
+	out := FieldError{Field: field, Message: message(e)}
+
+	// Never echo secrets such as passwords back to the client
+	if e.Tag() != "required" && !strings.Contains(strings.ToLower(field), "password") {
+		if v := fmt.Sprint(e.Value()); v != "" {
+			out.Value = v
+		}
+	}
+	return out
+}
+
+// message maps a validator tag to a client-facing sentence.
+func message(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "This field is required"
+	case "email":
+		return "Invalid email format"
+	case "min":
+		if e.Kind() == reflect.String {
+			return fmt.Sprintf("Must be at least %s characters", e.Param())
+		}
+		return fmt.Sprintf("Must be at least %s", e.Param())
+	case "max":
+		if e.Kind() == reflect.String {
+			return fmt.Sprintf("Must be at most %s characters", e.Param())
+		}
+		return fmt.Sprintf("Must be at most %s", e.Param())
+	case "oneof":
+		return fmt.Sprintf("Must be one of: %s", e.Param())
+	default:
+		return "Invalid value"
+	}
+}
+
+// fieldPath converts a StructNamespace ("User.Items[2].Quantity") into the
+// tagged path ("items[2].quantity"), falling back to e.Field() when the path
+// cannot be resolved against root. Untagged embedded structs are flattened.
+func fieldPath(e validator.FieldError, root reflect.Type, tag string) string {
+	segments := strings.Split(e.StructNamespace(), ".")
+	if root == nil || len(segments) < 2 {
+		return e.Field()
+	}
+
+	current := root
+	path := make([]string, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		for current.Kind() == reflect.Ptr {
+			current = current.Elem()
+		}
+		if current.Kind() != reflect.Struct {
+			return e.Field()
+		}
+
+		fieldName, indexes := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 {
+			fieldName, indexes = segment[:i], segment[i:]
+		}
+
+		field, ok := current.FieldByName(fieldName)
+		if !ok {
+			return e.Field()
+		}
+		if !field.Anonymous || field.Tag.Get(tag) != "" {
+			path = append(path, tagName(field, tag)+indexes)
+		}
+
+		current = field.Type
+		for n := strings.Count(indexes, "["); n > 0; n-- {
+			for current.Kind() == reflect.Ptr {
+				current = current.Elem()
+			}
+			switch current.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				current = current.Elem()
+			default:
+				return e.Field()
+			}
+		}
+	}
+
+	return strings.Join(path, ".")
+}
+
+func tagName(field reflect.StructField, tag string) string {
+	if name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+type errorResponse struct {
+	Success bool      `json:"success"`
+	Error   errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      int          `json:"code"`
+	Message   string       `json:"message"`
+	ErrorCode string       `json:"error_code"`
+	Details   []FieldError `json:"details,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+	Path      string       `json:"path"`
+	RequestID string       `json:"request_id"`
+}
+
+func abort(c *gin.Context, status int, code, message string, details []FieldError) {
+	c.AbortWithStatusJSON(status, errorResponse{
+		Success: false,
+		Error: errorBody{
+			Code:      status,
+			Message:   message,
+			ErrorCode: code,
+			Details:   details,
+			Timestamp: time.Now(),
+			Path:      c.Request.URL.Path,
+			RequestID: requestID(c),
+		},
+	})
+}
+
+// requestID returns the ID set by the request ID middleware, which the
+// examples store under either "request_id" or "RequestID".
+func requestID(c *gin.Context) string {
+	if id := c.GetString("request_id"); id != "" {
+		return id
+	}
+	return c.GetString("RequestID")
+}
@@ -0,0 +1,225 @@
+package bindingerr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type item struct {
+	SKU      string `json:"sku" binding:"required"`
+	Quantity int    `json:"quantity" binding:"min=1"`
+}
+
+type signup struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+	Age      int    `json:"age"`
+	Items    []item `json:"items" binding:"dive"`
+}
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+	})
+	r.POST("/signup", func(c *gin.Context) {
+		var req signup
+		if !BindJSON(c, &req) {
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+	return r
+}
+
+func post(r http.Handler, body string) (*httptest.ResponseRecorder, errorResponse) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/signup", strings.NewReader(body)))
+
+	var resp errorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestMalformedJSONIsBadRequest(t *testing.T) {
+	r := newTestRouter()
+
+	for name, body := range map[string]string{
+		"syntax error": `{"email": "a@example.com",}`,
+		"truncated":    `{"email": "a@example.com"`,
+		"empty body":   ``,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, resp := post(r, body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			if resp.Error.ErrorCode != ErrorCodeBadRequest {
+				t.Errorf("expected error_code %q, got %q", ErrorCodeBadRequest, resp.Error.ErrorCode)
+			}
+			if resp.Success || resp.Error.RequestID != "req-1" || resp.Error.Path != "/signup" {
+				t.Errorf("unexpected envelope: %+v", resp)
+			}
+			if len(resp.Error.Details) != 0 {
+				t.Errorf("expected no field details, got %+v", resp.Error.Details)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsAreUnprocessable(t *testing.T) {
+	r := newTestRouter()
+
+	w, resp := post(r, `{"email": "not-an-email", "password": "short", "items": [{"sku": "a", "quantity": 1}, {"quantity": 0}]}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Error.ErrorCode != ErrorCodeValidation {
+		t.Errorf("expected error_code %q, got %q", ErrorCodeValidation, resp.Error.ErrorCode)
+	}
+
+	got := map[string]FieldError{}
+	for _, f := range resp.Error.Details {
+		got[f.Field] = f
+	}
+	want := map[string]string{
+		"email":             "Invalid email format",
+		"password":          "Must be at least 8 characters",
+		"items[1].sku":      "This field is required",
+		"items[1].quantity": "Must be at least 1",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), resp.Error.Details)
+	}
+	for field, msg := range want {
+		if got[field].Message != msg {
+			t.Errorf("%s: expected message %q, got %q", field, msg, got[field].Message)
+		}
+	}
+
+	if got["email"].Value != "not-an-email" {
+		t.Errorf("expected rejected email to be echoed, got %q", got["email"].Value)
+	}
+	if got["password"].Value != "" {
+		t.Error("password value leaked into the response")
+	}
+	if strings.Contains(w.Body.String(), "Key:") {
+		t.Error("raw validator message leaked into the response")
+	}
+}
+
+func TestWrongJSONTypeIsUnprocessable(t *testing.T) {
+	r := newTestRouter()
+
+	w, resp := post(r, `{"email": "a@example.com", "password": "longenough", "age": "ten"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Error.Details) != 1 || resp.Error.Details[0].Field != "age" {
+		t.Errorf("expected a single age error, got %+v", resp.Error.Details)
+	}
+}
+
+func TestValidBodyPassesThrough(t *testing.T) {
+	r := newTestRouter()
+
+	w, _ := post(r, `{"email": "a@example.com", "password": "longenough"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestInvalidUsesValidationEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-2")
+	})
+	r.POST("/transfer", func(c *gin.Context) {
+		Invalid(c, []FieldError{{Field: "to_account_id", Message: "Must differ from from_account_id", Value: "1"}})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/transfer", nil))
+	var resp errorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if w.Code != http.StatusUnprocessableEntity || resp.Error.ErrorCode != ErrorCodeValidation {
+		t.Fatalf("expected 422 %s, got %d: %s", ErrorCodeValidation, w.Code, w.Body.String())
+	}
+	if resp.Error.RequestID != "req-2" || resp.Error.Path != "/transfer" {
+		t.Errorf("unexpected envelope: %+v", resp)
+	}
+	if len(resp.Error.Details) != 1 || resp.Error.Details[0].Field != "to_account_id" || resp.Error.Details[0].Value != "1" {
+		t.Errorf("expected the to_account_id detail, got %+v", resp.Error.Details)
+	}
+}
+
+type pageQuery struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=10" binding:"min=1,max=100"`
+}
+
+type postQuery struct {
+	pageQuery
+	Published *bool `form:"published"`
+}
+
+func getQuery(query string) (*httptest.ResponseRecorder, errorResponse) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/posts", func(c *gin.Context) {
+		var q postQuery
+		if !BindQuery(c, &q) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"page": q.Page, "page_size": q.PageSize})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/posts?"+query, nil))
+
+	var resp errorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestBindQueryReportsFormNames(t *testing.T) {
+	tests := []struct {
+		query   string
+		field   string
+		message string
+	}{
+		{"page=abc", "page", "Must be an integer"},
+		{"published=maybe", "published", "Must be true or false"},
+		{"page=0", "page", "Must be at least 1"},
+		{"page_size=500", "page_size", "Must be at most 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w, resp := getQuery(tt.query)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			if resp.Error.ErrorCode != ErrorCodeBadRequest {
+				t.Errorf("expected error_code %q, got %q", ErrorCodeBadRequest, resp.Error.ErrorCode)
+			}
+			if len(resp.Error.Details) != 1 || resp.Error.Details[0].Field != tt.field || resp.Error.Details[0].Message != tt.message {
+				t.Errorf("expected %s: %q, got %+v", tt.field, tt.message, resp.Error.Details)
+			}
+		})
+	}
+}
+
+func TestBindQueryAppliesDefaults(t *testing.T) {
+	w, _ := getQuery("")
+	if w.Code != http.StatusOK || w.Body.String() != `{"page":1,"page_size":10}` {
+		t.Errorf("expected defaults, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,129 @@
+// Package bodylimit provides a gin middleware that caps the request body size
+// so oversized uploads and JSON payloads are rejected with 413 Request Entity
+// Too Large before they are buffered in memory.
+//
+// Requests that announce a Content-Length over the limit are rejected before
+// the handler runs. Bodies without a length (chunked) are wrapped with
+// http.MaxBytesReader, so reading past the limit fails; handlers that attach
+// that error with c.Error(err) and return without writing get the same 413.
+package bodylimit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode is the error_code used in the default 413 response.
+const ErrorCode = "REQUEST_TOO_LARGE"
+
+// DefaultLimit is a sensible global limit for JSON APIs.
+const DefaultLimit int64 = 1 << 20 // 1MB
+
+// Config configures the body limit middleware.
+type Config struct {
+	// Limit is the maximum body size in bytes.
+	Limit int64
+	// Routes overrides Limit per route, keyed by the route pattern as
+	// returned by c.FullPath() (e.g. "/users/:id/avatar" for uploads).
+	Routes map[string]int64
+	// OnExceeded writes the response when the body is over the limit.
+	// Defaults to DefaultResponse.
+	OnExceeded gin.HandlerFunc
+}
+
+// MaxBodyBytes returns the middleware with limit n and the default response.
+func MaxBodyBytes(n int64) gin.HandlerFunc {
+	return NewWithConfig(Config{Limit: n})
+}
+
+// NewWithConfig returns the middleware for cfg.
+func NewWithConfig(cfg Config) gin.HandlerFunc {
+	onExceeded := cfg.OnExceeded
+	if onExceeded == nil {
+		onExceeded = DefaultResponse
+	}
+
+	return func(c *gin.Context) {
+		limit := cfg.Limit
+		if n, ok := cfg.Routes[c.FullPath()]; ok {
+			limit = n
+		}
+
+		c.Set(limitKey, limit)
+
+		if c.Request.ContentLength > limit {
+			onExceeded(c)
+			c.Abort()
+			return
+		}
+
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+
+		if !c.Writer.Written() && exceededReported(c) {
+			onExceeded(c)
+		}
+	}
+}
+
+const limitKey = "bodylimit.limit"
+
+// Exceeded reports whether err came from reading past the body limit.
+func Exceeded(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+func exceededReported(c *gin.Context) bool {
+	for _, err := range c.Errors {
+		if Exceeded(err.Err) {
+			return true
+		}
+	}
+	return false
+}
+
+type errorResponse struct {
+	Success bool      `json:"success"`
+	Error   errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      int       `json:"code"`
+	Message   string    `json:"message"`
+	ErrorCode string    `json:"error_code"`
+	Timestamp time.Time `json:"timestamp"`
+	Path      string    `json:"path"`
+	RequestID string    `json:"request_id"`
+}
+
+// DefaultResponse writes a 413 in the same envelope the error handling
+// lesson (09) uses for every error.
+func DefaultResponse(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse{
+		Success: false,
+		Error: errorBody{
+			Code:      http.StatusRequestEntityTooLarge,
+			Message:   fmt.Sprintf("Request body exceeds %d bytes", c.GetInt64(limitKey)),
+			ErrorCode: ErrorCode,
+			Timestamp: time.Now(),
+			Path:      c.Request.URL.Path,
+			RequestID: requestID(c),
+		},
+	})
+}
+
+// requestID returns the ID set by the request ID middleware, which the
+// examples store under either "request_id" or "RequestID".
+func requestID(c *gin.Context) string {
+	if id := c.GetString("request_id"); id != "" {
+		return id
+	}
+	return c.GetString("RequestID")
+}
@@ -0,0 +1,96 @@
+package bodylimit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(cfg Config) (*gin.Engine, *bool) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+	})
+	r.Use(NewWithConfig(cfg))
+
+	called := false
+	echo := func(c *gin.Context) {
+		called = true
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"size": len(data)})
+	}
+	r.POST("/echo", echo)
+	r.POST("/upload", echo)
+	return r, &called
+}
+
+func post(r http.Handler, path string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", path, body))
+	return w
+}
+
+// chunked hides the length so the request has no Content-Length.
+type chunked struct{ io.Reader }
+
+func TestOversizedBodyRejectedBeforeHandler(t *testing.T) {
+	r, called := newTestRouter(Config{Limit: 10})
+
+	w := post(r, "/echo", strings.NewReader(strings.Repeat("x", 11)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", w.Code)
+	}
+	if *called {
+		t.Error("handler ran for an oversized body")
+	}
+
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Success || resp.Error.ErrorCode != ErrorCode || resp.Error.RequestID != "req-1" || resp.Error.Path != "/echo" {
+		t.Errorf("unexpected envelope: %+v", resp)
+	}
+}
+
+func TestBodyWithinLimitPasses(t *testing.T) {
+	r, _ := newTestRouter(Config{Limit: 10})
+
+	if w := post(r, "/echo", strings.NewReader(strings.Repeat("x", 10))); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestChunkedBodyOverLimit(t *testing.T) {
+	r, called := newTestRouter(Config{Limit: 10})
+
+	w := post(r, "/echo", chunked{strings.NewReader(strings.Repeat("x", 100))})
+	if !*called {
+		t.Fatal("expected handler to run when the length is unknown")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", w.Code)
+	}
+}
+
+func TestRouteOverride(t *testing.T) {
+	r, _ := newTestRouter(Config{Limit: 10, Routes: map[string]int64{"/upload": 100}})
+
+	body := strings.Repeat("x", 50)
+	if w := post(r, "/upload", strings.NewReader(body)); w.Code != http.StatusOK {
+		t.Errorf("expected override to allow 50 bytes, got %d", w.Code)
+	}
+	if w := post(r, "/echo", strings.NewReader(body)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected default limit on other routes, got %d", w.Code)
+	}
+}
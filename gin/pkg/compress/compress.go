@@ -0,0 +1,252 @@
+// Package compress provides an opt-in gin middleware that gzips responses
+// for clients that send Accept-Encoding: gzip.
+//
+// Small bodies are not worth the CPU and the gzip header overhead, so the
+// middleware buffers the response until it reaches MinSize. Responses that
+// finish below the threshold are sent as-is; larger ones switch to a gzip
+// stream with Content-Encoding: gzip and no Content-Length. Content types
+// that are already compressed (images, archives, video) and responses that
+// already carry a Content-Encoding are never re-encoded.
+//
+// Register it before middleware that inspects the response body (such as
+// loggers) so they see the uncompressed bytes.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMinSize is the smallest body that gets compressed.
+const DefaultMinSize = 1024
+
+// Config configures the gzip middleware.
+type Config struct {
+	// MinSize is the body size in bytes at which compression starts.
+	// Defaults to DefaultMinSize.
+	MinSize int
+	// Level is the gzip level. Defaults to gzip.DefaultCompression.
+	Level int
+	// SkipContentTypes lists extra media types to send uncompressed, in
+	// addition to the built-in already-compressed types.
+	SkipContentTypes []string
+}
+
+// New returns the middleware with the default threshold and level.
+func New() gin.HandlerFunc {
+	return NewWithConfig(Config{})
+}
+
+// NewWithConfig returns the middleware for cfg.
+func NewWithConfig(cfg Config) gin.HandlerFunc {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultMinSize
+	}
+	if cfg.Level == 0 {
+		cfg.Level = gzip.DefaultCompression
+	}
+	skip := make(map[string]bool, len(cfg.SkipContentTypes))
+	for _, t := range cfg.SkipContentTypes {
+		skip[strings.ToLower(t)] = true
+	}
+
+	return func(c *gin.Context) {
+		// The body now depends on Accept-Encoding, so caches must key on it.
+		c.Header("Vary", "Accept-Encoding")
+
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, cfg: cfg, skip: skip}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip,
+// honouring q=0 as an explicit refusal.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compressedTypes are media types whose payload is already compressed.
+var compressedTypes = map[string]bool{
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/pdf":              true,
+	"application/octet-stream":     true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+	"text/event-stream":            true, // streamed; buffering would delay events
+}
+
+func (w *gzipWriter) compressible() bool {
+	switch w.Status() {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	mediaType = strings.ToLower(mediaType)
+	if compressedTypes[mediaType] || w.skip[mediaType] {
+		return false
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	for _, prefix := range []string{"image/", "video/", "audio/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// gzipWriter buffers the body until it knows whether to compress, then
+// either streams through a gzip.Writer or passes the bytes on unchanged.
+type gzipWriter struct {
+	gin.ResponseWriter
+	cfg  Config
+	skip map[string]bool
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow is used for bodiless responses (c.AbortWithStatus), so
+// there is nothing to compress.
+func (w *gzipWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *gzipWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *gzipWriter) Written() bool {
+	return w.decided || w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	n, _ := w.buf.Write(b)
+	if w.buf.Len() >= w.cfg.MinSize {
+		if err := w.decide(w.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush commits to a decision so streaming handlers are not held back.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(w.compressible() && w.buf.Len() >= w.cfg.MinSize)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide sends the status line and the buffered bytes, compressed or not.
+func (w *gzipWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.cfg.Level)
+		if err != nil {
+			return err
+		}
+		w.gz = gz
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// finish flushes whatever the handler left behind once it returns.
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		// Never reached MinSize: send as-is.
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,148 @@
+package compress
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(cfg Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewWithConfig(cfg))
+
+	r.GET("/large", func(c *gin.Context) {
+		items := make([]gin.H, 500)
+		for i := range items {
+			items[i] = gin.H{"id": i, "value": "item"}
+		}
+		c.JSON(http.StatusOK, items)
+	})
+	r.GET("/tiny", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	r.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", make([]byte, 4096))
+	})
+	r.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/json", make([]byte, 4096))
+	})
+	r.GET("/unauthorized", func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	return r
+}
+
+func get(r http.Handler, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLargeJSONIsGzipped(t *testing.T) {
+	r := newTestRouter(Config{})
+
+	w := get(r, "/large", "gzip, deflate")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected Content-Encoding gzip, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+	}
+	if w.Header().Get("Content-Length") != "" {
+		t.Error("Content-Length must not be set on a gzip stream")
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("expected the JSON content type to be kept, got %q", w.Header().Get("Content-Type"))
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(body, &items); err != nil {
+		t.Fatalf("decompressed body is not JSON: %v", err)
+	}
+	if len(items) != 500 {
+		t.Errorf("expected 500 items, got %d", len(items))
+	}
+	if w.Body.Len() >= len(body) {
+		t.Errorf("expected compressed body (%d bytes) to be smaller than %d", w.Body.Len(), len(body))
+	}
+}
+
+func TestTinyJSONIsNotGzipped(t *testing.T) {
+	r := newTestRouter(Config{})
+
+	w := get(r, "/tiny", "gzip")
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding below the threshold, got %q", got)
+	}
+	if w.Body.String() != `{"ok":true}` {
+		t.Errorf("expected plain body, got %q", w.Body.String())
+	}
+}
+
+func TestNoCompressionWithoutAcceptEncoding(t *testing.T) {
+	r := newTestRouter(Config{})
+
+	for _, accept := range []string{"", "br", "gzip;q=0"} {
+		w := get(r, "/large", accept)
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Accept-Encoding %q: expected identity, got %q", accept, got)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Errorf("Accept-Encoding %q: expected plain JSON body", accept)
+		}
+	}
+}
+
+func TestSkipsAlreadyEncodedContent(t *testing.T) {
+	r := newTestRouter(Config{})
+
+	if w := get(r, "/image", "gzip"); w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 4096 {
+		t.Errorf("expected image to pass through, got encoding %q and %d bytes", w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+	if w := get(r, "/encoded", "gzip"); w.Header().Get("Content-Encoding") != "br" || w.Body.Len() != 4096 {
+		t.Errorf("expected existing encoding to be kept, got %q and %d bytes", w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+}
+
+func TestSkipContentTypesConfig(t *testing.T) {
+	r := newTestRouter(Config{SkipContentTypes: []string{"application/json"}})
+
+	if w := get(r, "/large", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Error("expected configured content type to be skipped")
+	}
+}
+
+func TestBodilessStatusIsPreserved(t *testing.T) {
+	r := newTestRouter(Config{})
+
+	w := get(r, "/unauthorized", "gzip")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Error("expected an empty, unencoded body")
+	}
+}
@@ -0,0 +1,290 @@
+// Package jwtauth signs and validates the access tokens shared by the JWT
+// examples, so a token issued by one example is accepted by another
+// configured with the same key, issuer and audience.
+//
+// A Config with a KeySet rotates keys without logging everyone out: tokens
+// carry a kid header naming the key that signed them, new tokens are signed
+// with the current key, and older keys keep verifying until retired.
+package jwtauth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// Claims is the access token payload
+type Claims struct {
+	UserID      uint     `json:"user_id"`
+	Email       string   `json:"email"`
+	Username    string   `json:"username"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions,omitempty"` // scopes such as "users:delete"
+	jwt.RegisteredClaims
+}
+
+// Config holds the signing keys and the issuer/audience every token must carry
+type Config struct {
+	SecretKey string
+	Issuer    string
+	Audience  []string
+
+	// Algorithm is HS256 (shared secret, local dev) or RS256 (private key signs, public key verifies)
+	Algorithm  string
+	PrivateKey *rsa.PrivateKey // RS256 signing key; services that only verify tokens can leave it nil
+	PublicKey  *rsa.PublicKey  // RS256 verification key
+
+	// Keys, when set, is used instead of SecretKey/PrivateKey/PublicKey and
+	// tokens without a known kid are rejected
+	Keys *KeySet
+}
+
+// LoadRSAKeys loads PEM encoded RSA keys for RS256. Either path may be empty.
+func (cfg *Config) LoadRSAKeys(privateKeyPath, publicKeyPath string) error {
+	if privateKeyPath != "" {
+		data, err := os.ReadFile(privateKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read private key: %w", err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse private key: %w", err)
+		}
+		cfg.PrivateKey = key
+		cfg.PublicKey = &key.PublicKey
+	}
+
+	if publicKeyPath != "" {
+		data, err := os.ReadFile(publicKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse public key: %w", err)
+		}
+		cfg.PublicKey = key
+	}
+
+	if cfg.Algorithm == AlgorithmRS256 && cfg.PublicKey == nil {
+		return errors.New("RS256 requires a public key")
+	}
+	return nil
+}
+
+// Sign signs claims with the configured algorithm, using the current key
+// and setting the kid header when a KeySet is configured
+func (cfg *Config) Sign(claims jwt.Claims) (string, error) {
+	var method jwt.SigningMethod
+	switch cfg.Algorithm {
+	case AlgorithmRS256:
+		method = jwt.SigningMethodRS256
+	case AlgorithmHS256:
+		method = jwt.SigningMethodHS256
+	default:
+		return "", fmt.Errorf("unsupported signing algorithm: %s", cfg.Algorithm)
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	secret, privateKey := []byte(cfg.SecretKey), cfg.PrivateKey
+	if cfg.Keys != nil {
+		key := cfg.Keys.Current()
+		token.Header["kid"] = key.ID
+		secret, privateKey = key.Secret, key.PrivateKey
+	}
+
+	if method == jwt.SigningMethodRS256 {
+		if privateKey == nil {
+			return "", errors.New("RS256 private key not configured")
+		}
+		return token.SignedString(privateKey)
+	}
+	return token.SignedString(secret)
+}
+
+// Parse verifies the signature, only accepting the configured algorithm
+// so an attacker can't swap alg (e.g. RS256 -> HS256 signed with the public key, or "none")
+func (cfg *Config) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		secret, publicKey := []byte(cfg.SecretKey), cfg.PublicKey
+		if cfg.Keys != nil {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := cfg.Keys.Lookup(kid)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+			}
+			secret, publicKey = key.Secret, key.PublicKey
+		}
+
+		switch cfg.Algorithm {
+		case AlgorithmRS256:
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return publicKey, nil
+		default:
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return secret, nil
+		}
+	}, jwt.WithValidMethods([]string{cfg.Algorithm}))
+}
+
+// ValidateAccessToken parses an access token and checks its issuer and audience.
+// Revocation is left to the caller since each example stores it differently.
+func (cfg *Config) ValidateAccessToken(tokenString string) (*Claims, error) {
+	token, err := cfg.Parse(tokenString, &Claims{})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.Issuer != cfg.Issuer {
+		return nil, errors.New("invalid issuer")
+	}
+
+	validAudience := false
+	for _, aud := range claims.Audience {
+		if slices.Contains(cfg.Audience, aud) {
+			validAudience = true
+			break
+		}
+	}
+	if !validAudience {
+		return nil, errors.New("invalid audience")
+	}
+
+	return claims, nil
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>" header
+func BearerToken(header string) (string, bool) {
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+var (
+	// ErrUnknownKeyID is returned for tokens whose kid is missing or not in the KeySet
+	ErrUnknownKeyID = errors.New("unknown signing key id")
+	// ErrDuplicateKeyID is returned when rotating to a kid that is already in the KeySet
+	ErrDuplicateKeyID = errors.New("signing key id already exists")
+	// ErrCurrentKey is returned when retiring the key that is still signing tokens
+	ErrCurrentKey = errors.New("cannot retire the current signing key")
+)
+
+// SigningKey is one key of a KeySet, named by the kid header of the tokens it signs
+type SigningKey struct {
+	ID         string
+	Secret     []byte          // HS256
+	PrivateKey *rsa.PrivateKey // RS256; nil on services that only verify
+	PublicKey  *rsa.PublicKey  // RS256
+	CreatedAt  time.Time
+	RotatedAt  time.Time // when another key took over signing; zero for the current key
+}
+
+// KeySet is the set of keys tokens may be verified with. It is safe for
+// concurrent use, so keys can be rotated while requests are being served.
+type KeySet struct {
+	mu      sync.RWMutex
+	keys    map[string]*SigningKey
+	current string
+}
+
+// NewKeySet returns a KeySet that signs with current
+func NewKeySet(current SigningKey) *KeySet {
+	if current.CreatedAt.IsZero() {
+		current.CreatedAt = time.Now()
+	}
+	current.RotatedAt = time.Time{}
+	return &KeySet{
+		keys:    map[string]*SigningKey{current.ID: &current},
+		current: current.ID,
+	}
+}
+
+// Current returns the key new tokens are signed with
+func (s *KeySet) Current() SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return *s.keys[s.current]
+}
+
+// Lookup returns the key with the given kid
+func (s *KeySet) Lookup(id string) (SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return SigningKey{}, false
+	}
+	return *key, true
+}
+
+// Rotate makes next the signing key. The previous key keeps verifying the
+// tokens it signed until it is retired.
+func (s *KeySet) Rotate(next SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.keys[next.ID]; exists {
+		return fmt.Errorf("%w: %q", ErrDuplicateKeyID, next.ID)
+	}
+	now := time.Now()
+	if next.CreatedAt.IsZero() {
+		next.CreatedAt = now
+	}
+	next.RotatedAt = time.Time{}
+
+	s.keys[s.current].RotatedAt = now
+	s.keys[next.ID] = &next
+	s.current = next.ID
+	return nil
+}
+
+// Retire removes a previous key; tokens it signed stop verifying immediately
+func (s *KeySet) Retire(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.keys[id]; !exists {
+		return fmt.Errorf("%w: %q", ErrUnknownKeyID, id)
+	}
+	if id == s.current {
+		return ErrCurrentKey
+	}
+	delete(s.keys, id)
+	return nil
+}
+
+// Keys returns every key, oldest first
+func (s *KeySet) Keys() []SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]SigningKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	slices.SortFunc(keys, func(a, b SigningKey) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return keys
+}
@@ -0,0 +1,125 @@
+package jwtauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testConfig() *Config {
+	return &Config{
+		SecretKey: "test-secret",
+		Algorithm: AlgorithmHS256,
+		Issuer:    "test-issuer",
+		Audience:  []string{"test-api"},
+	}
+}
+
+func signed(t *testing.T, cfg *Config, issuer string, audience ...string) string {
+	t.Helper()
+	token, err := cfg.Sign(Claims{
+		UserID: 7,
+		Role:   "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			Issuer:    issuer,
+			Audience:  audience,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	return token
+}
+
+func TestValidateAccessToken(t *testing.T) {
+	cfg := testConfig()
+
+	claims, err := cfg.ValidateAccessToken(signed(t, cfg, "test-issuer", "other", "test-api"))
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if claims.UserID != 7 || claims.Role != "admin" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := cfg.ValidateAccessToken(signed(t, cfg, "someone-else", "test-api")); err == nil {
+		t.Error("expected wrong issuer to be rejected")
+	}
+	if _, err := cfg.ValidateAccessToken(signed(t, cfg, "test-issuer", "other")); err == nil {
+		t.Error("expected wrong audience to be rejected")
+	}
+
+	other := testConfig()
+	other.SecretKey = "different-secret"
+	if _, err := cfg.ValidateAccessToken(signed(t, other, "test-issuer", "test-api")); err == nil {
+		t.Error("expected token signed with another key to be rejected")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"", "", false},
+		{"Bearer", "", false},
+		{"Basic abc", "", false},
+		{"Bearer a b", "", false},
+	}
+	for _, tt := range tests {
+		token, ok := BearerToken(tt.header)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("BearerToken(%q) = %q, %v; want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	cfg := testConfig()
+	cfg.Keys = NewKeySet(SigningKey{ID: "k1", Secret: []byte("first-secret")})
+
+	old := signed(t, cfg, "test-issuer", "test-api")
+	if err := cfg.Keys.Rotate(SigningKey{ID: "k2", Secret: []byte("second-secret")}); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	current := signed(t, cfg, "test-issuer", "test-api")
+
+	token, _ := cfg.Parse(current, &Claims{})
+	if token.Header["kid"] != "k2" {
+		t.Errorf("expected new tokens to carry kid k2, got %v", token.Header["kid"])
+	}
+	if _, err := cfg.ValidateAccessToken(old); err != nil {
+		t.Errorf("expected token signed with the previous key to verify, got %v", err)
+	}
+
+	if err := cfg.Keys.Retire("k2"); !errors.Is(err, ErrCurrentKey) {
+		t.Errorf("expected ErrCurrentKey, got %v", err)
+	}
+	if err := cfg.Keys.Rotate(SigningKey{ID: "k1"}); !errors.Is(err, ErrDuplicateKeyID) {
+		t.Errorf("expected ErrDuplicateKeyID, got %v", err)
+	}
+	if err := cfg.Keys.Retire("k1"); err != nil {
+		t.Fatalf("Retire failed: %v", err)
+	}
+	if _, err := cfg.ValidateAccessToken(old); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("expected retired key to be rejected with ErrUnknownKeyID, got %v", err)
+	}
+	if _, err := cfg.ValidateAccessToken(current); err != nil {
+		t.Errorf("expected current token to verify, got %v", err)
+	}
+}
+
+func TestKeySetRejectsMissingKid(t *testing.T) {
+	cfg := testConfig()
+	legacy := signed(t, cfg, "test-issuer", "test-api") // no kid header
+
+	cfg.Keys = NewKeySet(SigningKey{ID: "k1", Secret: []byte(cfg.SecretKey)})
+	if _, err := cfg.ValidateAccessToken(legacy); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("expected token without kid to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,380 @@
+// Package metrics provides an opt-in gin middleware that records per-route
+// request counts, status classes and latency histograms, and serves them at
+// /metrics in Prometheus text format (or JSON with ?format=json).
+//
+// Applications can register their own labelled counters with
+// Registry.NewCounter; they are served alongside the request metrics.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnmatchedRoute is the route label used for requests that matched no route,
+// so that random 404 paths cannot blow up the number of series.
+const UnmatchedRoute = "unmatched"
+
+// DefaultBuckets are the latency histogram upper bounds in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type routeStats struct {
+	count    uint64
+	statuses map[string]uint64 // "2xx", "4xx", ...
+	buckets  []uint64          // non-cumulative count per bucket, last one is +Inf
+	sum      float64
+}
+
+// RouteMetrics is a point-in-time copy of one route's metrics.
+type RouteMetrics struct {
+	Method   string            `json:"method"`
+	Route    string            `json:"route"`
+	Count    uint64            `json:"count"`
+	Statuses map[string]uint64 `json:"statuses"`
+	// Buckets maps the upper bound ("0.005", ..., "+Inf") to the cumulative count.
+	Buckets    map[string]uint64 `json:"buckets"`
+	SumSeconds float64           `json:"sum_seconds"`
+}
+
+// Registry collects request metrics. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	bounds   []float64
+	routes   map[routeKey]*routeStats
+	counters []*Counter
+	now      func() time.Time
+}
+
+// NewRegistry creates a registry with the given latency buckets (seconds),
+// falling back to DefaultBuckets.
+func NewRegistry(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+
+	return &Registry{
+		bounds: bounds,
+		routes: make(map[routeKey]*routeStats),
+		now:    time.Now,
+	}
+}
+
+// Mount installs the middleware and the /metrics endpoint on router.
+// Call it before registering other routes so they are all measured.
+func (r *Registry) Mount(router gin.IRouter) {
+	router.Use(r.Middleware())
+	router.GET("/metrics", r.Handler())
+}
+
+// Middleware records every request under its route template (c.FullPath()),
+// e.g. /users/:id rather than /users/42.
+func (r *Registry) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := r.now()
+		c.Next()
+		elapsed := r.now().Sub(start)
+
+		route := c.FullPath()
+		if route == "" {
+			route = UnmatchedRoute
+		}
+		r.observe(c.Request.Method, route, c.Writer.Status(), elapsed)
+	}
+}
+
+func (r *Registry) observe(method, route string, status int, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := routeKey{method: method, route: route}
+	stats, ok := r.routes[key]
+	if !ok {
+		stats = &routeStats{
+			statuses: make(map[string]uint64),
+			buckets:  make([]uint64, len(r.bounds)+1),
+		}
+		r.routes[key] = stats
+	}
+
+	seconds := elapsed.Seconds()
+	stats.count++
+	stats.statuses[statusClass(status)]++
+	stats.buckets[sort.SearchFloat64s(r.bounds, seconds)]++
+	stats.sum += seconds
+}
+
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// Snapshot returns a copy of all route metrics sorted by route and method.
+func (r *Registry) Snapshot() []RouteMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make([]RouteMetrics, 0, len(r.routes))
+	for key, stats := range r.routes {
+		m := RouteMetrics{
+			Method:     key.method,
+			Route:      key.route,
+			Count:      stats.count,
+			Statuses:   make(map[string]uint64, len(stats.statuses)),
+			Buckets:    make(map[string]uint64, len(stats.buckets)),
+			SumSeconds: stats.sum,
+		}
+		for class, n := range stats.statuses {
+			m.Statuses[class] = n
+		}
+		var cumulative uint64
+		for i, n := range stats.buckets {
+			cumulative += n
+			m.Buckets[r.bucketLabel(i)] = cumulative
+		}
+		snapshot = append(snapshot, m)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Route != snapshot[j].Route {
+			return snapshot[i].Route < snapshot[j].Route
+		}
+		return snapshot[i].Method < snapshot[j].Method
+	})
+	return snapshot
+}
+
+func (r *Registry) bucketLabel(i int) string {
+	if i == len(r.bounds) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(r.bounds[i], 'g', -1, 64)
+}
+
+// Counter is a monotonically increasing count split by label values. Keep
+// the label values bounded (status classes, buckets) rather than raw IDs.
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries // keyed by the joined label values
+}
+
+type counterSeries struct {
+	values []string
+	count  uint64
+}
+
+// CounterMetrics is a point-in-time copy of one counter.
+type CounterMetrics struct {
+	Name   string          `json:"name"`
+	Help   string          `json:"help"`
+	Series []CounterSeries `json:"series"`
+}
+
+// CounterSeries is the value of a counter for one set of label values.
+type CounterSeries struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  uint64            `json:"value"`
+}
+
+// NewCounter registers a counter with the given label names. It panics if a
+// counter with the same name is already registered.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.counters {
+		if c.name == name {
+			panic(fmt.Sprintf("metrics: counter %q already registered", name))
+		}
+	}
+	c := &Counter{
+		name:   name,
+		help:   help,
+		labels: append([]string(nil), labels...),
+		series: make(map[string]*counterSeries),
+	}
+	r.counters = append(r.counters, c)
+	return c
+}
+
+// Inc adds one to the series for the given label values, which must match
+// the label names the counter was registered with.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds n to the series for the given label values.
+func (c *Counter) Add(n uint64, values ...string) {
+	key := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.count += n
+}
+
+// Value returns the current count for the given label values.
+func (c *Counter) Value(values ...string) uint64 {
+	key := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[key]; ok {
+		return s.count
+	}
+	return 0
+}
+
+// Total returns the sum over all label values.
+func (c *Counter) Total() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total uint64
+	for _, s := range c.series {
+		total += s.count
+	}
+	return total
+}
+
+func (c *Counter) key(values []string) string {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: counter %q expects %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (c *Counter) snapshot() CounterMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := CounterMetrics{Name: c.name, Help: c.help, Series: make([]CounterSeries, 0, len(c.series))}
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := c.series[key]
+		series := CounterSeries{Value: s.count}
+		if len(c.labels) > 0 {
+			series.Labels = make(map[string]string, len(c.labels))
+			for i, label := range c.labels {
+				series.Labels[label] = s.values[i]
+			}
+		}
+		m.Series = append(m.Series, series)
+	}
+	return m
+}
+
+// Counters returns a copy of all registered counters in registration order.
+func (r *Registry) Counters() []CounterMetrics {
+	r.mu.Lock()
+	counters := append([]*Counter(nil), r.counters...)
+	r.mu.Unlock()
+
+	snapshot := make([]CounterMetrics, 0, len(counters))
+	for _, c := range counters {
+		snapshot = append(snapshot, c.snapshot())
+	}
+	return snapshot
+}
+
+// Handler serves the metrics in Prometheus text format, or as JSON when
+// called with ?format=json.
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		snapshot, counters := r.Snapshot(), r.Counters()
+		if c.Query("format") == "json" {
+			body := gin.H{"routes": snapshot}
+			if len(counters) > 0 {
+				body["counters"] = counters
+			}
+			c.JSON(http.StatusOK, body)
+			return
+		}
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(r.prometheusText(snapshot, counters)))
+	}
+}
+
+func (r *Registry) prometheusText(snapshot []RouteMetrics, counters []CounterMetrics) string {
+	var b strings.Builder
+
+	b.WriteString("# HELP http_requests_total Total HTTP requests by route and status class.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, m := range snapshot {
+		classes := make([]string, 0, len(m.Statuses))
+		for class := range m.Statuses {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(&b, "http_requests_total{method=%q,route=%q,status=%q} %d\n", m.Method, m.Route, class, m.Statuses[class])
+		}
+	}
+
+	b.WriteString("# HELP http_request_duration_seconds HTTP request latency by route.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, m := range snapshot {
+		for i := 0; i <= len(r.bounds); i++ {
+			le := r.bucketLabel(i)
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{method=%q,route=%q,le=%q} %d\n", m.Method, m.Route, le, m.Buckets[le])
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{method=%q,route=%q} %g\n", m.Method, m.Route, m.SumSeconds)
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{method=%q,route=%q} %d\n", m.Method, m.Route, m.Count)
+	}
+
+	for _, m := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.Name, m.Help)
+		fmt.Fprintf(&b, "# TYPE %s counter\n", m.Name)
+		for _, s := range m.Series {
+			fmt.Fprintf(&b, "%s%s %d\n", m.Name, labelText(s.Labels), s.Value)
+		}
+	}
+
+	b.WriteString("# HELP go_goroutines Number of goroutines.\n")
+	b.WriteString("# TYPE go_goroutines gauge\n")
+	fmt.Fprintf(&b, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	return b.String()
+}
+
+// labelText formats labels as {a="1",b="2"} sorted by name, or "" if empty.
+func labelText(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter returns a router whose handlers take the latency encoded in
+// the "ms" query parameter, measured by a fake clock.
+func newTestRouter() (*gin.Engine, *Registry) {
+	gin.SetMode(gin.TestMode)
+
+	registry := NewRegistry(0.01, 0.1, 1)
+	now := time.Unix(0, 0)
+	registry.now = func() time.Time { return now }
+
+	router := gin.New()
+	registry.Mount(router)
+	router.Use(func(c *gin.Context) {
+		if d, err := time.ParseDuration(c.Query("ms") + "ms"); err == nil {
+			now = now.Add(d)
+		}
+	})
+	router.GET("/users/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return router, registry
+}
+
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestMiddlewareGroupsByRouteTemplate(t *testing.T) {
+	router, registry := newTestRouter()
+
+	get(router, "/users/1?ms=5")
+	get(router, "/users/2?ms=50")
+	get(router, "/users/3?ms=500")
+	get(router, "/users/0?ms=5")
+	get(router, "/nope/1")
+	get(router, "/nope/2")
+
+	snapshot := registry.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("expected 2 series (route template + unmatched), got %+v", snapshot)
+	}
+
+	unmatched, users := snapshot[1], snapshot[0]
+	if unmatched.Route != UnmatchedRoute || unmatched.Count != 2 || unmatched.Statuses["4xx"] != 2 {
+		t.Errorf("unexpected unmatched series: %+v", unmatched)
+	}
+
+	if users.Route != "/users/:id" || users.Method != "GET" || users.Count != 4 {
+		t.Fatalf("unexpected route series: %+v", users)
+	}
+	if users.Statuses["2xx"] != 3 || users.Statuses["4xx"] != 1 {
+		t.Errorf("unexpected status classes: %v", users.Statuses)
+	}
+
+	wantBuckets := map[string]uint64{"0.01": 2, "0.1": 3, "1": 4, "+Inf": 4}
+	for le, want := range wantBuckets {
+		if got := users.Buckets[le]; got != want {
+			t.Errorf("bucket le=%s: expected %d, got %d", le, want, got)
+		}
+	}
+	if users.SumSeconds < 0.559 || users.SumSeconds > 0.561 {
+		t.Errorf("expected latency sum 0.56s, got %v", users.SumSeconds)
+	}
+}
+
+func TestHandlerPrometheusText(t *testing.T) {
+	router, _ := newTestRouter()
+	get(router, "/users/1?ms=5")
+	get(router, "/users/2?ms=5")
+
+	w := get(router, "/metrics")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/users/:id",status="2xx"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="0.01"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/users/:id"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "/users/1") {
+		t.Error("raw paths must not appear as labels")
+	}
+}
+
+func TestHandlerJSON(t *testing.T) {
+	router, _ := newTestRouter()
+	get(router, "/users/1")
+
+	w := get(router, "/metrics?format=json")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"route":"/users/:id"`) {
+		t.Errorf("unexpected JSON metrics: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCounterServedWithRequestMetrics(t *testing.T) {
+	router, registry := newTestRouter()
+	retries := registry.NewCounter("stock_retries_total", "Retries by bucket.", "bucket")
+	retries.Inc("1")
+	retries.Add(2, "0")
+	retries.Inc("1")
+
+	if got := retries.Value("1"); got != 2 {
+		t.Errorf("expected 2 for bucket 1, got %d", got)
+	}
+	if got := retries.Total(); got != 4 {
+		t.Errorf("expected total 4, got %d", got)
+	}
+
+	body := get(router, "/metrics").Body.String()
+	for _, line := range []string{
+		"# TYPE stock_retries_total counter",
+		`stock_retries_total{bucket="0"} 2`,
+		`stock_retries_total{bucket="1"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in:\n%s", line, body)
+		}
+	}
+
+	w := get(router, "/metrics?format=json")
+	if !strings.Contains(w.Body.String(), `"counters":[{"name":"stock_retries_total"`) {
+		t.Errorf("expected counters in JSON metrics, got %s", w.Body.String())
+	}
+}
+
+func TestCounterPanicsOnLabelMismatch(t *testing.T) {
+	counter := NewRegistry().NewCounter("jobs_total", "Jobs.", "queue")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for missing label values")
+		}
+	}()
+	counter.Inc()
+}
@@ -0,0 +1,108 @@
+// Package timeout provides a gin middleware that gives every request a
+// deadline-bound context and answers 504 Gateway Timeout when it expires.
+//
+// The middleware is cooperative: it never interrupts a running handler.
+// Handlers pass c.Request.Context() down to the database and other slow work,
+// and when that work fails with context.DeadlineExceeded they attach the error
+// with c.Error(err) and return without writing. The middleware then renders
+// the 504 response.
+package timeout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode is the error_code used in the default 504 response.
+const ErrorCode = "GATEWAY_TIMEOUT"
+
+// Config configures the timeout middleware.
+type Config struct {
+	// Timeout is the per-request deadline.
+	Timeout time.Duration
+	// OnTimeout writes the response when the deadline passed before the
+	// handler wrote one. Defaults to DefaultResponse.
+	OnTimeout gin.HandlerFunc
+}
+
+// New returns the middleware with the given deadline and the default response.
+func New(timeout time.Duration) gin.HandlerFunc {
+	return NewWithConfig(Config{Timeout: timeout})
+}
+
+// NewWithConfig returns the middleware for cfg.
+func NewWithConfig(cfg Config) gin.HandlerFunc {
+	onTimeout := cfg.OnTimeout
+	if onTimeout == nil {
+		onTimeout = DefaultResponse
+	}
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if c.Writer.Written() {
+			return
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) || deadlineReported(c) {
+			onTimeout(c)
+		}
+	}
+}
+
+// deadlineReported reports whether a handler attached a deadline error, which
+// also covers handlers that narrowed the deadline further themselves.
+func deadlineReported(c *gin.Context) bool {
+	for _, err := range c.Errors {
+		if errors.Is(err.Err, context.DeadlineExceeded) {
+			return true
+		}
+	}
+	return false
+}
+
+type errorResponse struct {
+	Success bool      `json:"success"`
+	Error   errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      int       `json:"code"`
+	Message   string    `json:"message"`
+	ErrorCode string    `json:"error_code"`
+	Timestamp time.Time `json:"timestamp"`
+	Path      string    `json:"path"`
+	RequestID string    `json:"request_id"`
+}
+
+// DefaultResponse writes a 504 in the same envelope the error handling
+// lesson (09) uses for every error.
+func DefaultResponse(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusGatewayTimeout, errorResponse{
+		Success: false,
+		Error: errorBody{
+			Code:      http.StatusGatewayTimeout,
+			Message:   "The request took too long to process",
+			ErrorCode: ErrorCode,
+			Timestamp: time.Now(),
+			Path:      c.Request.URL.Path,
+			RequestID: requestID(c),
+		},
+	})
+}
+
+// requestID returns the ID set by the request ID middleware, which the
+// examples store under either "request_id" or "RequestID".
+func requestID(c *gin.Context) string {
+	if id := c.GetString("request_id"); id != "" {
+		return id
+	}
+	return c.GetString("RequestID")
+}
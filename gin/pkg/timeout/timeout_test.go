@@ -0,0 +1,96 @@
+package timeout
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slowHandler waits for d or until the request context is done.
+func slowHandler(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case <-time.After(d):
+			c.JSON(http.StatusOK, gin.H{"done": true})
+		case <-c.Request.Context().Done():
+			c.Error(c.Request.Context().Err())
+		}
+	}
+}
+
+func newTestRouter(cfg Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+	})
+	r.Use(NewWithConfig(cfg))
+	r.GET("/slow", slowHandler(time.Second))
+	r.GET("/fast", slowHandler(0))
+	r.GET("/narrowed", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		c.Error(ctx.Err())
+	})
+	return r
+}
+
+func get(r http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestSlowHandlerGetsGatewayTimeout(t *testing.T) {
+	r := newTestRouter(Config{Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	w := get(r, "/slow")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("handler did not observe cancellation, took %v", elapsed)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", w.Code)
+	}
+
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode %q: %v", w.Body.String(), err)
+	}
+	if resp.Success || resp.Error.ErrorCode != ErrorCode || resp.Error.Code != http.StatusGatewayTimeout ||
+		resp.Error.Path != "/slow" || resp.Error.RequestID != "req-1" {
+		t.Errorf("unexpected envelope: %+v", resp)
+	}
+}
+
+func TestFastHandlerIsUntouched(t *testing.T) {
+	r := newTestRouter(Config{Timeout: time.Second})
+	if w := get(r, "/fast"); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestHandlerNarrowedDeadline(t *testing.T) {
+	r := newTestRouter(Config{Timeout: time.Second})
+	if w := get(r, "/narrowed"); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504 for a reported deadline error, got %d", w.Code)
+	}
+}
+
+func TestCustomOnTimeout(t *testing.T) {
+	r := newTestRouter(Config{
+		Timeout: 10 * time.Millisecond,
+		OnTimeout: func(c *gin.Context) {
+			c.String(http.StatusServiceUnavailable, "busy")
+		},
+	})
+	if w := get(r, "/slow"); w.Code != http.StatusServiceUnavailable || w.Body.String() != "busy" {
+		t.Errorf("expected custom response, got %d %q", w.Code, w.Body.String())
+	}
+}